@@ -12,6 +12,7 @@ require (
 	github.com/aws/aws-sdk-go-v2/config v1.1.1
 	github.com/aws/aws-sdk-go-v2/credentials v1.1.1
 	github.com/aws/aws-sdk-go-v2/internal/ini v1.0.0 // indirect
+	github.com/aws/aws-sdk-go-v2/service/cloudwatch v1.4.1
 	github.com/aws/aws-sdk-go-v2/service/ecs v1.1.1
 	github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2 v1.3.1
 	github.com/aws/aws-sdk-go-v2/service/lambda v1.1.1
@@ -208,6 +208,7 @@ func convert(results []*model.PlanPreviewCommandResult) ReadableResult {
 			out.Applications = append(out.Applications, ApplicationResult{
 				ApplicationInfo: appInfo,
 				SyncStrategy:    a.SyncStrategy.String(),
+				PipelineStages:  a.PipelineStages,
 				PlanSummary:     string(a.PlanSummary),
 				PlanDetails:     string(a.PlanDetails),
 				NoChange:        a.NoChange,
@@ -226,10 +227,11 @@ type ReadableResult struct {
 
 type ApplicationResult struct {
 	ApplicationInfo
-	SyncStrategy string // QUICK_SYNC, PIPELINE
-	PlanSummary  string
-	PlanDetails  string
-	NoChange     bool
+	SyncStrategy   string // QUICK_SYNC, PIPELINE
+	PipelineStages []string
+	PlanSummary    string
+	PlanDetails    string
+	NoChange       bool
 }
 
 type FailurePiped struct {
@@ -275,6 +277,9 @@ func (r ReadableResult) String() string {
 		for i, app := range r.Applications {
 			fmt.Fprintf(&b, "\n%d. app: %s, env: %s, kind: %s\n", i+1, app.ApplicationName, app.EnvName, app.ApplicationKind)
 			fmt.Fprintf(&b, "  sync strategy: %s\n", app.SyncStrategy)
+			if len(app.PipelineStages) > 0 {
+				fmt.Fprintf(&b, "  pipeline: %s\n", strings.Join(app.PipelineStages, " -> "))
+			}
 			fmt.Fprintf(&b, "  summary: %s\n", app.PlanSummary)
 			fmt.Fprintf(&b, "  details:\n\n  ---DETAILS_BEGIN---\n%s\n  ---DETAILS_END---\n", app.PlanDetails)
 		}
@@ -65,6 +65,42 @@ Here are plan-preview for 1 application:
   summary: 2 manifests will be added, 1 manifest will be deleted and 5 manifests will be changed
   details:
 
+  ---DETAILS_BEGIN---
+changes-1
+  ---DETAILS_END---
+`,
+		},
+		{
+			name: "there is a pipeline application",
+			results: []*model.PlanPreviewCommandResult{
+				{
+					CommandId: "command-2",
+					PipedId:   "piped-2",
+					PipedUrl:  "https://pipecd.dev/piped-2",
+					Results: []*model.ApplicationPlanPreviewResult{
+						{
+							ApplicationId:   "app-1",
+							ApplicationName: "app-1",
+							ApplicationUrl:  "https://pipecd.dev/app-1",
+							ApplicationKind: model.ApplicationKind_KUBERNETES,
+							EnvName:         "env-1",
+							SyncStrategy:    model.SyncStrategy_PIPELINE,
+							PipelineStages:  []string{"K8S_CANARY_ROLLOUT", "ANALYSIS", "K8S_PRIMARY_ROLLOUT", "K8S_CANARY_CLEAN"},
+							PlanSummary:     []byte("1 manifest will be changed"),
+							PlanDetails:     []byte("changes-1"),
+						},
+					},
+				},
+			},
+			expected: `
+Here are plan-preview for 1 application:
+
+1. app: app-1, env: env-1, kind: KUBERNETES
+  sync strategy: PIPELINE
+  pipeline: K8S_CANARY_ROLLOUT -> ANALYSIS -> K8S_PRIMARY_ROLLOUT -> K8S_CANARY_CLEAN
+  summary: 1 manifest will be changed
+  details:
+
   ---DETAILS_BEGIN---
 changes-1
   ---DETAILS_END---
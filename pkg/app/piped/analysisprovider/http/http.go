@@ -17,17 +17,28 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
 	"time"
 
+	"k8s.io/client-go/util/jsonpath"
+
 	"github.com/pipe-cd/pipecd/pkg/config"
 )
 
 const (
 	ProviderType   = "HTTP"
 	defaultTimeout = 30 * time.Second
+	// The maximum size of the response body to be evaluated.
+	maxResponseBodySize = 10 << 20
 )
 
 type Provider struct {
@@ -54,21 +65,37 @@ func (p *Provider) Run(ctx context.Context, cfg *config.AnalysisHTTP) (bool, str
 		return false, "", err
 	}
 
+	start := time.Now()
 	res, err := p.client.Do(req)
 	if err != nil {
 		return false, "", err
 	}
 	defer res.Body.Close()
 
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxResponseBodySize))
+	if err != nil {
+		return false, "", fmt.Errorf("failed to read the response body: %w", err)
+	}
+	latency := time.Since(start)
+
 	if res.StatusCode != cfg.ExpectedCode {
 		return false, "", fmt.Errorf("unexpected status code %d", res.StatusCode)
 	}
-	// TODO: Decide how to check if the body is expected one.
-	return true, "", nil
+	if maxLatency := cfg.MaxLatency.Duration(); maxLatency > 0 && latency > maxLatency {
+		return false, fmt.Sprintf("the response took %v which exceeds the max latency %v", latency, maxLatency), nil
+	}
+	if ok, reason := evaluateBody(body, cfg); !ok {
+		return false, reason, nil
+	}
+	return true, fmt.Sprintf("status code %d returned in %v", res.StatusCode, latency), nil
 }
 
 func (p *Provider) makeRequest(ctx context.Context, cfg *config.AnalysisHTTP) (*http.Request, error) {
-	req, err := http.NewRequestWithContext(ctx, cfg.Method, cfg.URL, nil)
+	var body io.Reader
+	if cfg.Body != "" {
+		body = strings.NewReader(cfg.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, cfg.URL, body)
 	if err != nil {
 		return nil, err
 	}
@@ -76,5 +103,114 @@ func (p *Provider) makeRequest(ctx context.Context, cfg *config.AnalysisHTTP) (*
 	for _, h := range cfg.Headers {
 		req.Header.Set(h.Key, h.Value)
 	}
+
+	switch {
+	case cfg.BasicAuth != nil:
+		username, err := readSecretFile(cfg.BasicAuth.UsernameFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the username file: %w", err)
+		}
+		password, err := readSecretFile(cfg.BasicAuth.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the password file: %w", err)
+		}
+		req.SetBasicAuth(username, password)
+	case cfg.BearerTokenFile != "":
+		token, err := readSecretFile(cfg.BearerTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the bearer token file: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 	return req, nil
 }
+
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// evaluateBody checks if the given response body satisfies all of the configured expectations.
+// The reason is returned when any of them was not satisfied.
+func evaluateBody(body []byte, cfg *config.AnalysisHTTP) (bool, string) {
+	if cfg.ExpectedResponse != "" && !bytes.Equal(body, []byte(cfg.ExpectedResponse)) {
+		return false, fmt.Sprintf("the response body is not equal to %q", cfg.ExpectedResponse)
+	}
+	if cfg.ExpectedResponseRegex != "" {
+		re, err := regexp.Compile(cfg.ExpectedResponseRegex)
+		if err != nil {
+			return false, fmt.Sprintf("invalid regular expression %q: %v", cfg.ExpectedResponseRegex, err)
+		}
+		if !re.Match(body) {
+			return false, fmt.Sprintf("the response body does not match %q", cfg.ExpectedResponseRegex)
+		}
+	}
+	if len(cfg.ExpectedJSON) == 0 {
+		return true, ""
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return false, fmt.Sprintf("the response body is not a valid JSON: %v", err)
+	}
+	for _, a := range cfg.ExpectedJSON {
+		if ok, reason := evaluateJSON(data, a); !ok {
+			return false, reason
+		}
+	}
+	return true, ""
+}
+
+func evaluateJSON(data interface{}, assertion config.AnalysisHTTPJSONAssertion) (bool, string) {
+	path := assertion.Path
+	if !strings.HasPrefix(path, "{") {
+		path = "{" + path + "}"
+	}
+	jp := jsonpath.New("expectedJSON")
+	if err := jp.Parse(path); err != nil {
+		return false, fmt.Sprintf("invalid JSONPath %q: %v", assertion.Path, err)
+	}
+	results, err := jp.FindResults(data)
+	if err != nil {
+		return false, fmt.Sprintf("failed to find %s in the response body: %v", assertion.Path, err)
+	}
+	if len(results) == 0 || len(results[0]) == 0 {
+		return false, fmt.Sprintf("%s was not found in the response body", assertion.Path)
+	}
+	value := results[0][0].Interface()
+
+	if assertion.Equal != nil && !equalJSONValue(value, assertion.Equal) {
+		return false, fmt.Sprintf("%s is %v but expected to be equal to %v", assertion.Path, value, assertion.Equal)
+	}
+	if assertion.Min == nil && assertion.Max == nil {
+		return true, ""
+	}
+	number, ok := value.(float64)
+	if !ok {
+		return false, fmt.Sprintf("%s is %v but expected to be a number", assertion.Path, value)
+	}
+	if assertion.Min != nil && number < *assertion.Min {
+		return false, fmt.Sprintf("%s is %g but expected to be greater than or equal to %g", assertion.Path, number, *assertion.Min)
+	}
+	if assertion.Max != nil && number > *assertion.Max {
+		return false, fmt.Sprintf("%s is %g but expected to be less than or equal to %g", assertion.Path, number, *assertion.Max)
+	}
+	return true, ""
+}
+
+// equalJSONValue reports whether the given values are equal after normalizing
+// them as the values decoded from JSON, e.g. all numbers are treated as float64.
+func equalJSONValue(got, want interface{}) bool {
+	data, err := json.Marshal(want)
+	if err != nil {
+		return false
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(got, normalized)
+}
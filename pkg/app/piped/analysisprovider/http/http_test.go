@@ -0,0 +1,185 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+)
+
+func TestProviderRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/text":
+			w.Write([]byte("ok"))
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status":"healthy","checks":{"db":{"latency":12.5}},"items":[{"name":"foo"}]}`))
+		case "/echo":
+			username, password, _ := r.BasicAuth()
+			body, _ := io.ReadAll(r.Body)
+			w.Write([]byte(username + ":" + password + ":" + r.Header.Get("X-Test") + ":" + string(body)))
+		case "/bearer":
+			w.Write([]byte(r.Header.Get("Authorization")))
+		case "/slow":
+			time.Sleep(50 * time.Millisecond)
+			w.Write([]byte("ok"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeFile := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(data+"\n"), 0600))
+		return path
+	}
+	usernameFile := writeFile("username", "user")
+	passwordFile := writeFile("password", "pass")
+	tokenFile := writeFile("token", "secret-token")
+
+	float64Pointer := func(f float64) *float64 { return &f }
+
+	testcases := []struct {
+		name    string
+		cfg     config.AnalysisHTTP
+		want    bool
+		wantErr bool
+	}{
+		{
+			name: "unexpected status code",
+			cfg: config.AnalysisHTTP{
+				URL:          server.URL + "/not-found",
+				ExpectedCode: http.StatusOK,
+			},
+			wantErr: true,
+		},
+		{
+			name: "exact body matched",
+			cfg: config.AnalysisHTTP{
+				URL:              server.URL + "/text",
+				ExpectedCode:     http.StatusOK,
+				ExpectedResponse: "ok",
+			},
+			want: true,
+		},
+		{
+			name: "exact body unmatched",
+			cfg: config.AnalysisHTTP{
+				URL:              server.URL + "/text",
+				ExpectedCode:     http.StatusOK,
+				ExpectedResponse: "ng",
+			},
+			want: false,
+		},
+		{
+			name: "regex unmatched",
+			cfg: config.AnalysisHTTP{
+				URL:                   server.URL + "/json",
+				ExpectedCode:          http.StatusOK,
+				ExpectedResponseRegex: `"status":"unhealthy"`,
+			},
+			want: false,
+		},
+		{
+			name: "json assertions satisfied",
+			cfg: config.AnalysisHTTP{
+				URL:          server.URL + "/json",
+				ExpectedCode: http.StatusOK,
+				ExpectedJSON: []config.AnalysisHTTPJSONAssertion{
+					{Path: "$.status", Equal: "healthy"},
+					{Path: "$.checks.db.latency", Min: float64Pointer(0), Max: float64Pointer(100)},
+					{Path: "{.items[0].name}", Equal: "foo"},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "json value out of range",
+			cfg: config.AnalysisHTTP{
+				URL:          server.URL + "/json",
+				ExpectedCode: http.StatusOK,
+				ExpectedJSON: []config.AnalysisHTTPJSONAssertion{
+					{Path: "$.checks.db.latency", Max: float64Pointer(10)},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "json value not found",
+			cfg: config.AnalysisHTTP{
+				URL:          server.URL + "/json",
+				ExpectedCode: http.StatusOK,
+				ExpectedJSON: []config.AnalysisHTTPJSONAssertion{
+					{Path: "$.missing", Equal: "foo"},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "request body and basic auth sent",
+			cfg: config.AnalysisHTTP{
+				URL:              server.URL + "/echo",
+				Method:           http.MethodPost,
+				Headers:          []config.AnalysisHTTPHeader{{Key: "X-Test", Value: "header"}},
+				Body:             "body",
+				BasicAuth:        &config.AnalysisHTTPBasicAuth{UsernameFile: usernameFile, PasswordFile: passwordFile},
+				ExpectedCode:     http.StatusOK,
+				ExpectedResponse: "user:pass:header:body",
+			},
+			want: true,
+		},
+		{
+			name: "bearer token sent",
+			cfg: config.AnalysisHTTP{
+				URL:              server.URL + "/bearer",
+				BearerTokenFile:  tokenFile,
+				ExpectedCode:     http.StatusOK,
+				ExpectedResponse: "Bearer secret-token",
+			},
+			want: true,
+		},
+		{
+			name: "latency exceeded",
+			cfg: config.AnalysisHTTP{
+				URL:          server.URL + "/slow",
+				ExpectedCode: http.StatusOK,
+				MaxLatency:   config.Duration(time.Millisecond),
+			},
+			want: false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewProvider(time.Second)
+			got, _, err := p.Run(context.Background(), &tc.cfg)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
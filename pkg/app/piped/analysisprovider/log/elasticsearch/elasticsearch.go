@@ -0,0 +1,194 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/log"
+)
+
+const (
+	ProviderType          = "Elasticsearch"
+	defaultTimeout        = 30 * time.Second
+	defaultTimestampField = "@timestamp"
+)
+
+// Provider works as an HTTP client for Elasticsearch.
+// Because it uses only the Count API, OpenSearch is also available.
+type Provider struct {
+	client *http.Client
+
+	address        string
+	index          string
+	timestampField string
+	username       string
+	password       string
+	apiKey         string
+	timeout        time.Duration
+	logger         *zap.Logger
+}
+
+func NewProvider(address, index string, opts ...Option) (*Provider, error) {
+	if address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+	if index == "" {
+		return nil, fmt.Errorf("index is required")
+	}
+
+	p := &Provider{
+		client:         &http.Client{},
+		address:        strings.TrimSuffix(address, "/"),
+		index:          index,
+		timestampField: defaultTimestampField,
+		timeout:        defaultTimeout,
+		logger:         zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+type Option func(*Provider)
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		if timeout > 0 {
+			p.timeout = timeout
+		}
+	}
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger.Named("elasticsearch-provider")
+	}
+}
+
+func WithBasicAuth(username, password string) Option {
+	return func(p *Provider) {
+		p.username = username
+		p.password = password
+	}
+}
+
+func WithAPIKey(apiKey string) Option {
+	return func(p *Provider) {
+		p.apiKey = apiKey
+	}
+}
+
+// WithTimestampField sets the name of the field used to filter the documents by time.
+func WithTimestampField(field string) Option {
+	return func(p *Provider) {
+		if field != "" {
+			p.timestampField = field
+		}
+	}
+}
+
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+type countResponse struct {
+	Count int `json:"count"`
+}
+
+// Evaluate counts the documents matching the given query string within the given range
+// and considers the result as unexpected if any document was found.
+// The query is expected to be a Lucene query string that matches error logs only.
+func (p *Provider) Evaluate(ctx context.Context, query string, queryRange log.QueryRange) (bool, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	if err := queryRange.Validate(); err != nil {
+		return false, "", err
+	}
+
+	body, err := p.buildCountRequestBody(query, queryRange)
+	if err != nil {
+		return false, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/%s/_count", p.address, p.index), bytes.NewReader(body))
+	if err != nil {
+		return false, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	switch {
+	case p.apiKey != "":
+		req.Header.Set("Authorization", "ApiKey "+p.apiKey)
+	case p.username != "" && p.password != "":
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	p.logger.Info("run query", zap.String("query", query))
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to run query for %s: %w", ProviderType, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, "", fmt.Errorf("unexpected HTTP status code from %s: %d", req.URL.Path, resp.StatusCode)
+	}
+
+	var out countResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, "", fmt.Errorf("failed to decode the response from %s: %w", ProviderType, err)
+	}
+	if out.Count == 0 {
+		return true, fmt.Sprintf("no log found within the range (%s)", queryRange.String()), nil
+	}
+	return false, fmt.Sprintf("found %d logs within the range (%s)", out.Count, queryRange.String()), nil
+}
+
+func (p *Provider) buildCountRequestBody(query string, queryRange log.QueryRange) ([]byte, error) {
+	body := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": []interface{}{
+					map[string]interface{}{
+						"query_string": map[string]interface{}{
+							"query": query,
+						},
+					},
+				},
+				"filter": []interface{}{
+					map[string]interface{}{
+						"range": map[string]interface{}{
+							p.timestampField: map[string]interface{}{
+								"gte":    queryRange.From.UTC().Format(time.RFC3339),
+								"lte":    queryRange.To.UTC().Format(time.RFC3339),
+								"format": "strict_date_optional_time",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	return json.Marshal(body)
+}
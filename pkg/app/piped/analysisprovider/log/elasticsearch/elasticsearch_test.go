@@ -0,0 +1,94 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/log"
+)
+
+func TestProviderEvaluate(t *testing.T) {
+	queryRange := log.QueryRange{
+		From: time.Date(2009, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2009, time.January, 1, 0, 5, 0, 0, time.UTC),
+	}
+	testcases := []struct {
+		name       string
+		httpStatus int
+		response   string
+		want       bool
+		wantErr    bool
+	}{
+		{
+			name:       "no log found",
+			httpStatus: http.StatusOK,
+			response:   `{"count":0}`,
+			want:       true,
+		},
+		{
+			name:       "error logs found",
+			httpStatus: http.StatusOK,
+			response:   `{"count":3}`,
+			want:       false,
+		},
+		{
+			name:       "unexpected HTTP status given",
+			httpStatus: http.StatusNotFound,
+			response:   `{"error":"index_not_found_exception"}`,
+			wantErr:    true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "/logs-app/_count", r.URL.Path)
+				username, password, ok := r.BasicAuth()
+				assert.True(t, ok)
+				assert.Equal(t, "user", username)
+				assert.Equal(t, "pass", password)
+
+				var body map[string]interface{}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				query := body["query"].(map[string]interface{})["bool"].(map[string]interface{})
+				queryString := query["must"].([]interface{})[0].(map[string]interface{})["query_string"].(map[string]interface{})
+				assert.Equal(t, "level:error", queryString["query"])
+				timeRange := query["filter"].([]interface{})[0].(map[string]interface{})["range"].(map[string]interface{})["@timestamp"].(map[string]interface{})
+				assert.Equal(t, "2009-01-01T00:00:00Z", timeRange["gte"])
+				assert.Equal(t, "2009-01-01T00:05:00Z", timeRange["lte"])
+
+				w.WriteHeader(tc.httpStatus)
+				w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			p, err := NewProvider(server.URL, "logs-app", WithBasicAuth("user", "pass"))
+			require.NoError(t, err)
+
+			got, _, err := p.Evaluate(context.Background(), "level:error", queryRange)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
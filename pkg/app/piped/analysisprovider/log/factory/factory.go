@@ -17,17 +17,20 @@ package factory
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"go.uber.org/zap"
 
 	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/log"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/log/elasticsearch"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/log/loki"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/log/stackdriver"
 	"github.com/pipe-cd/pipecd/pkg/config"
 	"github.com/pipe-cd/pipecd/pkg/model"
 )
 
 // NewProvider generates an appropriate provider according to analysis provider config.
-func NewProvider(providerCfg *config.PipedAnalysisProvider, logger *zap.Logger) (provider log.Provider, err error) {
+func NewProvider(analysisLogCfg *config.AnalysisLog, providerCfg *config.PipedAnalysisProvider, logger *zap.Logger) (provider log.Provider, err error) {
 	switch providerCfg.Type {
 	case model.AnalysisProviderStackdriver:
 		cfg := providerCfg.StackdriverConfig
@@ -40,8 +43,65 @@ func NewProvider(providerCfg *config.PipedAnalysisProvider, logger *zap.Logger)
 			return nil, err
 		}
 
+	case model.AnalysisProviderLoki:
+		cfg := providerCfg.LokiConfig
+		options := []loki.Option{
+			loki.WithLogger(logger),
+			loki.WithTimeout(analysisLogCfg.Timeout.Duration()),
+			loki.WithTenantID(cfg.TenantID),
+		}
+		if cfg.UsernameFile != "" && cfg.PasswordFile != "" {
+			username, password, err := readBasicAuth(cfg.UsernameFile, cfg.PasswordFile)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, loki.WithBasicAuth(username, password))
+		}
+		provider, err = loki.NewProvider(cfg.Address, options...)
+		if err != nil {
+			return nil, err
+		}
+
+	case model.AnalysisProviderElasticsearch:
+		cfg := providerCfg.ElasticsearchConfig
+		options := []elasticsearch.Option{
+			elasticsearch.WithLogger(logger),
+			elasticsearch.WithTimeout(analysisLogCfg.Timeout.Duration()),
+			elasticsearch.WithTimestampField(cfg.TimestampField),
+		}
+		if cfg.APIKeyFile != "" {
+			apiKey, err := os.ReadFile(cfg.APIKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the api-key file: %w", err)
+			}
+			options = append(options, elasticsearch.WithAPIKey(strings.TrimSpace(string(apiKey))))
+		}
+		if cfg.UsernameFile != "" && cfg.PasswordFile != "" {
+			username, password, err := readBasicAuth(cfg.UsernameFile, cfg.PasswordFile)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, elasticsearch.WithBasicAuth(username, password))
+		}
+		provider, err = elasticsearch.NewProvider(cfg.Address, cfg.Index, options...)
+		if err != nil {
+			return nil, err
+		}
+
 	default:
 		return nil, fmt.Errorf("any of providers config not found")
 	}
 	return provider, nil
 }
+
+func readBasicAuth(usernameFile, passwordFile string) (username, password string, err error) {
+	u, err := os.ReadFile(usernameFile)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read the username file: %w", err)
+	}
+	p, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read the password file: %w", err)
+	}
+	return strings.TrimSpace(string(u)), strings.TrimSpace(string(p)), nil
+}
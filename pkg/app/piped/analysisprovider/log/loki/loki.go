@@ -0,0 +1,179 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/log"
+)
+
+const (
+	ProviderType   = "Loki"
+	defaultTimeout = 30 * time.Second
+	// The maximum number of log entries to be returned by a query.
+	// This is enough to decide whether there is any error log.
+	queryLimit = 100
+
+	queryRangePath = "/loki/api/v1/query_range"
+	tenantIDHeader = "X-Scope-OrgID"
+)
+
+// Provider works as an HTTP client for Grafana Loki.
+type Provider struct {
+	client *http.Client
+
+	address  string
+	tenantID string
+	username string
+	password string
+	timeout  time.Duration
+	logger   *zap.Logger
+}
+
+func NewProvider(address string, opts ...Option) (*Provider, error) {
+	if address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+
+	p := &Provider{
+		client:  &http.Client{},
+		address: strings.TrimSuffix(address, "/"),
+		timeout: defaultTimeout,
+		logger:  zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+type Option func(*Provider)
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		if timeout > 0 {
+			p.timeout = timeout
+		}
+	}
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger.Named("loki-provider")
+	}
+}
+
+func WithBasicAuth(username, password string) Option {
+	return func(p *Provider) {
+		p.username = username
+		p.password = password
+	}
+}
+
+// WithTenantID sets the tenant ID to be sent to a multi-tenant Loki.
+func WithTenantID(tenantID string) Option {
+	return func(p *Provider) {
+		p.tenantID = tenantID
+	}
+}
+
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+type queryRangeResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][]string        `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Evaluate runs the given LogQL query within the given range
+// and considers the result as unexpected if any log line was found.
+// The query is expected to be a log query that selects error logs only.
+func (p *Provider) Evaluate(ctx context.Context, query string, queryRange log.QueryRange) (bool, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	if err := queryRange.Validate(); err != nil {
+		return false, "", err
+	}
+
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(queryRange.From.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(queryRange.To.UnixNano(), 10))
+	params.Set("limit", strconv.Itoa(queryLimit))
+	params.Set("direction", "backward")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.address+queryRangePath+"?"+params.Encode(), nil)
+	if err != nil {
+		return false, "", err
+	}
+	if p.tenantID != "" {
+		req.Header.Set(tenantIDHeader, p.tenantID)
+	}
+	if p.username != "" && p.password != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	p.logger.Info("run query", zap.String("query", query))
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to run query for %s: %w", ProviderType, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, "", fmt.Errorf("unexpected HTTP status code from %s: %d", req.URL.Path, resp.StatusCode)
+	}
+
+	var out queryRangeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return false, "", fmt.Errorf("failed to decode the response from %s: %w", ProviderType, err)
+	}
+	if out.Status != "success" {
+		return false, "", fmt.Errorf("unexpected query status %q returned", out.Status)
+	}
+	if out.Data.ResultType != "streams" {
+		return false, "", fmt.Errorf("unsupported result type %q returned, only log queries are supported", out.Data.ResultType)
+	}
+
+	var count int
+	for _, r := range out.Data.Result {
+		count += len(r.Values)
+	}
+	if count == 0 {
+		return true, fmt.Sprintf("no log found within the range (%s)", queryRange.String()), nil
+	}
+	if count >= queryLimit {
+		return false, fmt.Sprintf("found %d or more logs within the range (%s)", queryLimit, queryRange.String()), nil
+	}
+	return false, fmt.Sprintf("found %d logs within the range (%s)", count, queryRange.String()), nil
+}
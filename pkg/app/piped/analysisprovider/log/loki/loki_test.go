@@ -0,0 +1,88 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loki
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/log"
+)
+
+func TestProviderEvaluate(t *testing.T) {
+	queryRange := log.QueryRange{
+		From: time.Date(2009, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2009, time.January, 1, 0, 5, 0, 0, time.UTC),
+	}
+	testcases := []struct {
+		name       string
+		httpStatus int
+		response   string
+		want       bool
+		wantErr    bool
+	}{
+		{
+			name:       "no log found",
+			httpStatus: http.StatusOK,
+			response:   `{"status":"success","data":{"resultType":"streams","result":[]}}`,
+			want:       true,
+		},
+		{
+			name:       "error logs found",
+			httpStatus: http.StatusOK,
+			response:   `{"status":"success","data":{"resultType":"streams","result":[{"stream":{"app":"foo"},"values":[["1230768000000000000","error"],["1230768001000000000","error"]]}]}}`,
+			want:       false,
+		},
+		{
+			name:       "metric query is not supported",
+			httpStatus: http.StatusOK,
+			response:   `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			wantErr:    true,
+		},
+		{
+			name:       "unexpected HTTP status given",
+			httpStatus: http.StatusBadRequest,
+			response:   `parse error`,
+			wantErr:    true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, queryRangePath, r.URL.Path)
+				assert.Equal(t, `{app="foo"} |= "error"`, r.URL.Query().Get("query"))
+				assert.Equal(t, "1230768000000000000", r.URL.Query().Get("start"))
+				assert.Equal(t, "1230768300000000000", r.URL.Query().Get("end"))
+				assert.Equal(t, "tenant", r.Header.Get(tenantIDHeader))
+				w.WriteHeader(tc.httpStatus)
+				w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			p, err := NewProvider(server.URL, WithTenantID("tenant"))
+			require.NoError(t, err)
+
+			got, _, err := p.Evaluate(context.Background(), `{app="foo"} |= "error"`, queryRange)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -16,13 +16,19 @@ package log
 
 import (
 	"context"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/metrics"
 )
 
 // Provider represents a client for log provider which provides logs for analysis.
 type Provider interface {
 	Type() string
-	// Evaluate runs the given query against the log provider,
+	// Evaluate runs the given query against the log provider within the given range,
 	// and then checks if there is at least one error log.
 	// Returns the result reason if non-error occurred.
-	Evaluate(ctx context.Context, query string) (result bool, reason string, err error)
+	Evaluate(ctx context.Context, query string, queryRange QueryRange) (result bool, reason string, err error)
 }
+
+// QueryRange represents a sliced time range.
+// It is shared with the metrics providers.
+type QueryRange = metrics.QueryRange
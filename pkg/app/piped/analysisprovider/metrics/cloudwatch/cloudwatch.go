@@ -0,0 +1,162 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/metrics"
+)
+
+const (
+	ProviderType   = "CloudWatch"
+	defaultTimeout = 30 * time.Second
+	// The granularity of the returned data points.
+	// CloudWatch requires it to be a multiple of 60 seconds for regular metrics.
+	period  = 60
+	queryID = "pipecd"
+)
+
+type client interface {
+	GetMetricData(ctx context.Context, params *cloudwatch.GetMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error)
+}
+
+// Provider works as a client for Amazon CloudWatch.
+type Provider struct {
+	client client
+
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+// NewProvider creates a CloudWatch provider with the same credential options as ECS and Lambda cloud providers.
+func NewProvider(region, profile, credentialsFile, roleARN, tokenPath string, opts ...Option) (*Provider, error) {
+	if region == "" {
+		return nil, fmt.Errorf("region is required")
+	}
+
+	p := &Provider{
+		timeout: defaultTimeout,
+		logger:  zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	optFns := []func(*config.LoadOptions) error{config.WithRegion(region)}
+	if credentialsFile != "" {
+		optFns = append(optFns, config.WithSharedCredentialsFiles([]string{credentialsFile}))
+	}
+	if profile != "" {
+		optFns = append(optFns, config.WithSharedConfigProfile(profile))
+	}
+	if tokenPath != "" && roleARN != "" {
+		optFns = append(optFns, config.WithWebIdentityRoleCredentialOptions(func(v *stscreds.WebIdentityRoleOptions) {
+			v.RoleARN = roleARN
+			v.TokenRetriever = stscreds.IdentityTokenFile(tokenPath)
+		}))
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config to create cloudwatch client: %w", err)
+	}
+	p.client = cloudwatch.NewFromConfig(cfg)
+	return p, nil
+}
+
+type Option func(*Provider)
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.timeout = timeout
+	}
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger.Named("cloudwatch-provider")
+	}
+}
+
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+// QueryPoints runs the given metric math expression or Metrics Insights query
+// by using GetMetricData API and gives back data points within the given range.
+func (p *Provider) QueryPoints(ctx context.Context, query string, queryRange metrics.QueryRange) ([]metrics.DataPoint, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	if err := queryRange.Validate(); err != nil {
+		return nil, err
+	}
+
+	input := &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(queryRange.From),
+		EndTime:   aws.Time(queryRange.To),
+		MetricDataQueries: []types.MetricDataQuery{
+			{
+				Id:         aws.String(queryID),
+				Expression: aws.String(query),
+				Period:     aws.Int32(period),
+				ReturnData: aws.Bool(true),
+			},
+		},
+		ScanBy: types.ScanByTimestampAscending,
+	}
+
+	p.logger.Info("run query", zap.String("query", query))
+	var points []metrics.DataPoint
+	for {
+		out, err := p.client.GetMetricData(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run query for %s: %w", ProviderType, err)
+		}
+		for _, r := range out.MetricDataResults {
+			if r.StatusCode == types.StatusCodeInternalError {
+				return nil, fmt.Errorf("internal error occurred while running query for %s", ProviderType)
+			}
+			if len(r.Timestamps) != len(r.Values) {
+				return nil, fmt.Errorf("invalid response: the number of timestamps and values are mismatched")
+			}
+			for i := range r.Values {
+				points = append(points, metrics.DataPoint{
+					Timestamp: r.Timestamps[i].Unix(),
+					Value:     r.Values[i],
+				})
+			}
+		}
+		if out.NextToken == nil {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no data points found within the queried range: %w", metrics.ErrNoDataFound)
+	}
+	return points, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/metrics"
+)
+
+type fakeClient struct {
+	outputs []*cloudwatch.GetMetricDataOutput
+	err     error
+	calls   int
+}
+
+func (f *fakeClient) GetMetricData(_ context.Context, params *cloudwatch.GetMetricDataInput, _ ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	out := f.outputs[f.calls]
+	f.calls++
+	return out, nil
+}
+
+func TestProviderQueryPoints(t *testing.T) {
+	t1 := time.Date(2009, time.January, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2009, time.January, 1, 0, 1, 0, 0, time.UTC)
+	queryRange := metrics.QueryRange{
+		From: t1,
+		To:   time.Date(2009, time.January, 1, 0, 5, 0, 0, time.UTC),
+	}
+	testcases := []struct {
+		name    string
+		client  *fakeClient
+		want    []metrics.DataPoint
+		wantErr bool
+	}{
+		{
+			name: "query failed",
+			client: &fakeClient{
+				err: fmt.Errorf("query failed"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "no data points given",
+			client: &fakeClient{
+				outputs: []*cloudwatch.GetMetricDataOutput{
+					{
+						MetricDataResults: []types.MetricDataResult{
+							{Id: aws.String(queryID), StatusCode: types.StatusCodeComplete},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "multiple pages given",
+			client: &fakeClient{
+				outputs: []*cloudwatch.GetMetricDataOutput{
+					{
+						MetricDataResults: []types.MetricDataResult{
+							{
+								Id:         aws.String(queryID),
+								StatusCode: types.StatusCodePartialData,
+								Timestamps: []time.Time{t1},
+								Values:     []float64{0.1},
+							},
+						},
+						NextToken: aws.String("next"),
+					},
+					{
+						MetricDataResults: []types.MetricDataResult{
+							{
+								Id:         aws.String(queryID),
+								StatusCode: types.StatusCodeComplete,
+								Timestamps: []time.Time{t2},
+								Values:     []float64{0.2},
+							},
+						},
+					},
+				},
+			},
+			want: []metrics.DataPoint{
+				{Timestamp: t1.Unix(), Value: 0.1},
+				{Timestamp: t2.Unix(), Value: 0.2},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Provider{
+				client:  tc.client,
+				timeout: defaultTimeout,
+				logger:  zap.NewNop(),
+			}
+			got, err := p.QueryPoints(context.Background(), "SELECT AVG(CPUUtilization) FROM \"AWS/ECS\"", queryRange)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package newrelic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/metrics"
+)
+
+const (
+	ProviderType   = "NewRelic"
+	defaultAddress = "https://api.newrelic.com/graphql"
+	defaultTimeout = 30 * time.Second
+
+	apiKeyHeader = "API-Key"
+
+	nrqlQuery = `query($accountID: Int!, $nrql: Nrql!) { actor { account(id: $accountID) { nrql(query: $nrql) { results } } } }`
+)
+
+// Provider works as an HTTP client for New Relic NerdGraph API.
+type Provider struct {
+	client *http.Client
+
+	address   string
+	accountID int
+	apiKey    string
+	timeout   time.Duration
+	logger    *zap.Logger
+}
+
+func NewProvider(accountID int, apiKey string, opts ...Option) (*Provider, error) {
+	if accountID <= 0 {
+		return nil, fmt.Errorf("account-id is required")
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("api-key is required")
+	}
+
+	p := &Provider{
+		client:    &http.Client{},
+		address:   defaultAddress,
+		accountID: accountID,
+		apiKey:    apiKey,
+		timeout:   defaultTimeout,
+		logger:    zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+type Option func(*Provider)
+
+// WithAddress sets the NerdGraph endpoint. Use "https://api.eu.newrelic.com/graphql" for EU region accounts.
+func WithAddress(address string) Option {
+	return func(p *Provider) {
+		p.address = address
+	}
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		if timeout > 0 {
+			p.timeout = timeout
+		}
+	}
+}
+
+func WithLogger(logger *zap.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger.Named("newrelic-provider")
+	}
+}
+
+func (p *Provider) Type() string {
+	return ProviderType
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphQLResponse struct {
+	Data struct {
+		Actor struct {
+			Account struct {
+				NRQL *struct {
+					Results []map[string]interface{} `json:"results"`
+				} `json:"nrql"`
+			} `json:"account"`
+		} `json:"actor"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// QueryPoints runs the given NRQL query within the given range and gives back data points.
+// The range is appended to the query as SINCE and UNTIL clauses, so the query must not contain them.
+// Every result row must contain exactly one numeric field except for the time window ones,
+// for example "SELECT average(duration) FROM Transaction TIMESERIES".
+func (p *Provider) QueryPoints(ctx context.Context, query string, queryRange metrics.QueryRange) ([]metrics.DataPoint, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	if err := queryRange.Validate(); err != nil {
+		return nil, err
+	}
+
+	nrql := fmt.Sprintf("%s SINCE %d UNTIL %d", strings.TrimSpace(query), queryRange.From.UnixNano()/int64(time.Millisecond), queryRange.To.UnixNano()/int64(time.Millisecond))
+	body, err := json.Marshal(graphQLRequest{
+		Query: nrqlQuery,
+		Variables: map[string]interface{}{
+			"accountID": p.accountID,
+			"nrql":      nrql,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.address, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(apiKeyHeader, p.apiKey)
+
+	p.logger.Info("run query", zap.String("query", nrql))
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run query for %s: %w", ProviderType, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status code from %s: %d", req.URL, resp.StatusCode)
+	}
+
+	var out graphQLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode the response from %s: %w", ProviderType, err)
+	}
+	if len(out.Errors) > 0 {
+		return nil, fmt.Errorf("failed to run query for %s: %s", ProviderType, out.Errors[0].Message)
+	}
+	nrqlResult := out.Data.Actor.Account.NRQL
+	if nrqlResult == nil || len(nrqlResult.Results) == 0 {
+		return nil, fmt.Errorf("no query result found: %w", metrics.ErrNoDataFound)
+	}
+
+	points := make([]metrics.DataPoint, 0, len(nrqlResult.Results))
+	for _, r := range nrqlResult.Results {
+		point, err := toDataPoint(r, queryRange)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// toDataPoint converts a result row into a data point.
+// Rows given by a non-TIMESERIES query have no time window, so the end of the range is used as the timestamp.
+func toDataPoint(row map[string]interface{}, queryRange metrics.QueryRange) (metrics.DataPoint, error) {
+	point := metrics.DataPoint{
+		Timestamp: queryRange.To.Unix(),
+	}
+	if v, ok := row["beginTimeSeconds"].(float64); ok {
+		point.Timestamp = int64(v)
+	}
+
+	var found bool
+	for k, v := range row {
+		if k == "beginTimeSeconds" || k == "endTimeSeconds" {
+			continue
+		}
+		value, ok := v.(float64)
+		if !ok {
+			continue
+		}
+		if found {
+			return metrics.DataPoint{}, fmt.Errorf("invalid response: multiple numeric values found in a result row, the query should select only one value")
+		}
+		point.Value = value
+		found = true
+	}
+	if !found {
+		return metrics.DataPoint{}, fmt.Errorf("invalid response: no numeric value found in a result row: %w", metrics.ErrNoDataFound)
+	}
+	return point, nil
+}
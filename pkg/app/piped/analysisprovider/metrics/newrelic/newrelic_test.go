@@ -0,0 +1,105 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package newrelic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/metrics"
+)
+
+func TestProviderQueryPoints(t *testing.T) {
+	queryRange := metrics.QueryRange{
+		From: time.Date(2009, time.January, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2009, time.January, 1, 0, 5, 0, 0, time.UTC),
+	}
+	testcases := []struct {
+		name       string
+		statusCode int
+		response   string
+		want       []metrics.DataPoint
+		wantErr    bool
+	}{
+		{
+			name:       "unexpected status code",
+			statusCode: http.StatusUnauthorized,
+			response:   `{}`,
+			wantErr:    true,
+		},
+		{
+			name:       "graphql error given",
+			statusCode: http.StatusOK,
+			response:   `{"errors":[{"message":"NRQL Syntax Error"}]}`,
+			wantErr:    true,
+		},
+		{
+			name:       "no result given",
+			statusCode: http.StatusOK,
+			response:   `{"data":{"actor":{"account":{"nrql":{"results":[]}}}}}`,
+			wantErr:    true,
+		},
+		{
+			name:       "multiple values in a row",
+			statusCode: http.StatusOK,
+			response:   `{"data":{"actor":{"account":{"nrql":{"results":[{"average.duration":0.1,"count":3}]}}}}}`,
+			wantErr:    true,
+		},
+		{
+			name:       "timeseries given",
+			statusCode: http.StatusOK,
+			response:   `{"data":{"actor":{"account":{"nrql":{"results":[{"beginTimeSeconds":1230768000,"endTimeSeconds":1230768060,"average.duration":0.1},{"beginTimeSeconds":1230768060,"endTimeSeconds":1230768120,"average.duration":0.2}]}}}}}`,
+			want: []metrics.DataPoint{
+				{Timestamp: 1230768000, Value: 0.1},
+				{Timestamp: 1230768060, Value: 0.2},
+			},
+		},
+		{
+			name:       "single value given",
+			statusCode: http.StatusOK,
+			response:   `{"data":{"actor":{"account":{"nrql":{"results":[{"percentile.duration":{"95":0.3},"average.duration":0.1}]}}}}}`,
+			want: []metrics.DataPoint{
+				{Timestamp: queryRange.To.Unix(), Value: 0.1},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "api-key", r.Header.Get(apiKeyHeader))
+				var req graphQLRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				assert.Equal(t, "SELECT average(duration) FROM Transaction TIMESERIES SINCE 1230768000000 UNTIL 1230768300000", req.Variables["nrql"])
+				assert.Equal(t, float64(1), req.Variables["accountID"])
+				w.WriteHeader(tc.statusCode)
+				w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			p, err := NewProvider(1, "api-key", WithAddress(server.URL))
+			require.NoError(t, err)
+			got, err := p.QueryPoints(context.Background(), "SELECT average(duration) FROM Transaction TIMESERIES", queryRange)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -124,9 +124,9 @@ func (s *store) sync(ctx context.Context) error {
 		switch cmd.Type {
 		case model.Command_SYNC_APPLICATION, model.Command_UPDATE_APPLICATION_CONFIG, model.Command_CHAIN_SYNC_APPLICATION:
 			applicationCommands = append(applicationCommands, s.makeReportableCommand(cmd))
-		case model.Command_CANCEL_DEPLOYMENT:
+		case model.Command_CANCEL_DEPLOYMENT, model.Command_OVERRIDE_DEPLOY_WINDOW:
 			deploymentCommands = append(deploymentCommands, s.makeReportableCommand(cmd))
-		case model.Command_APPROVE_STAGE:
+		case model.Command_APPROVE_STAGE, model.Command_REJECT_STAGE, model.Command_RETRY_STAGE, model.Command_SKIP_STAGE:
 			stageCommands = append(stageCommands, s.makeReportableCommand(cmd))
 		case model.Command_BUILD_PLAN_PREVIEW:
 			planPreviewCommands = append(planPreviewCommands, s.makeReportableCommand(cmd))
@@ -0,0 +1,128 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploywindowstore
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/pipe-cd/pipecd/pkg/app/server/service/pipedservice"
+	"github.com/pipe-cd/pipecd/pkg/config"
+)
+
+// Lister helps get the deploy windows configured at the project level.
+// All objects returned here must be treated as read-only.
+type Lister interface {
+	// Get returns the deploy windows of the project.
+	// Nil is returned when no window was configured.
+	Get() *config.DeployWindows
+}
+
+type apiClient interface {
+	GetProjectDeployWindows(ctx context.Context, in *pipedservice.GetProjectDeployWindowsRequest, opts ...grpc.CallOption) (*pipedservice.GetProjectDeployWindowsResponse, error)
+}
+
+type Store interface {
+	// Run starts syncing the deploy windows with the control-plane.
+	Run(ctx context.Context) error
+	// Lister returns a lister for retrieving the deploy windows.
+	Lister() Lister
+}
+
+// windows wraps the deploy windows to be stored into atomic.Value
+// since it does not accept a nil value.
+type windows struct {
+	value *config.DeployWindows
+}
+
+type store struct {
+	apiClient    apiClient
+	windows      atomic.Value
+	syncInterval time.Duration
+	logger       *zap.Logger
+}
+
+var (
+	defaultSyncInterval = time.Minute
+)
+
+// NewStore creates a new deploy window store instance.
+// This syncs with the control plane to keep the deploy windows of the project up-to-date.
+func NewStore(apiClient apiClient, logger *zap.Logger) Store {
+	return &store{
+		apiClient:    apiClient,
+		syncInterval: defaultSyncInterval,
+		logger:       logger.Named("deploy-window-store"),
+	}
+}
+
+// Run starts syncing the deploy windows with the control-plane.
+func (s *store) Run(ctx context.Context) error {
+	s.logger.Info("start running deploy window store")
+
+	syncTicker := time.NewTicker(s.syncInterval)
+	defer syncTicker.Stop()
+
+	// Do first sync without waiting the first ticker.
+	s.sync(ctx)
+
+	for {
+		select {
+		case <-syncTicker.C:
+			s.sync(ctx)
+
+		case <-ctx.Done():
+			s.logger.Info("deploy window store has been stopped")
+			return nil
+		}
+	}
+}
+
+// Lister returns a lister for retrieving the deploy windows.
+func (s *store) Lister() Lister {
+	return s
+}
+
+func (s *store) sync(ctx context.Context) error {
+	resp, err := s.apiClient.GetProjectDeployWindows(ctx, &pipedservice.GetProjectDeployWindowsRequest{})
+	if err != nil {
+		s.logger.Error("failed to get project deploy windows", zap.Error(err))
+		return err
+	}
+
+	// Keep using the last valid windows when the received ones are invalid.
+	w, err := config.NewDeployWindowsFromProject(resp.DeployWindows)
+	if err != nil {
+		s.logger.Error("received invalid project deploy windows", zap.Error(err))
+		return err
+	}
+
+	s.windows.Store(windows{value: w})
+	return nil
+}
+
+// Get returns the deploy windows of the project.
+// Nil is returned when no window was configured.
+func (s *store) Get() *config.DeployWindows {
+	w := s.windows.Load()
+	if w == nil {
+		return nil
+	}
+	return w.(windows).value
+}
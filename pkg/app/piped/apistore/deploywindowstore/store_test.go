@@ -0,0 +1,87 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploywindowstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/pipe-cd/pipecd/pkg/app/server/service/pipedservice"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type fakeAPIClient struct {
+	resp *pipedservice.GetProjectDeployWindowsResponse
+	err  error
+}
+
+func (c *fakeAPIClient) GetProjectDeployWindows(_ context.Context, _ *pipedservice.GetProjectDeployWindowsRequest, _ ...grpc.CallOption) (*pipedservice.GetProjectDeployWindowsResponse, error) {
+	return c.resp, c.err
+}
+
+func TestSync(t *testing.T) {
+	freeze := &model.ProjectDeployWindows{
+		Deny: []*model.ProjectDeployWindow{
+			{Schedule: "0 18 * * 5", Duration: "62h"},
+		},
+	}
+	expected := &config.DeployWindows{
+		Deny: []config.DeployWindow{
+			{Schedule: "0 18 * * 5", Duration: config.Duration(62 * time.Hour)},
+		},
+	}
+
+	client := &fakeAPIClient{}
+	s := NewStore(client, zap.NewNop()).(*store)
+	assert.Nil(t, s.Get())
+
+	// Not configured.
+	client.resp = &pipedservice.GetProjectDeployWindowsResponse{}
+	require.NoError(t, s.sync(context.Background()))
+	assert.Nil(t, s.Get())
+
+	// Configured.
+	client.resp = &pipedservice.GetProjectDeployWindowsResponse{DeployWindows: freeze}
+	require.NoError(t, s.sync(context.Background()))
+	assert.Equal(t, expected, s.Get())
+
+	// The last valid windows are kept while failing to get the new ones.
+	client.resp, client.err = nil, errors.New("unavailable")
+	require.Error(t, s.sync(context.Background()))
+	assert.Equal(t, expected, s.Get())
+
+	// The last valid windows are kept when the received ones are invalid.
+	client.err = nil
+	client.resp = &pipedservice.GetProjectDeployWindowsResponse{
+		DeployWindows: &model.ProjectDeployWindows{
+			Timezone: "Mars/Olympus",
+		},
+	}
+	require.Error(t, s.sync(context.Background()))
+	assert.Equal(t, expected, s.Get())
+
+	// Removed.
+	client.resp = &pipedservice.GetProjectDeployWindowsResponse{}
+	require.NoError(t, s.sync(context.Background()))
+	assert.Nil(t, s.Get())
+}
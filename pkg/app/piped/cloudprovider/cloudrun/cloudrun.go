@@ -16,6 +16,7 @@ package cloudrun
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"path/filepath"
 	"sync"
@@ -41,6 +42,16 @@ type (
 	Revision run.Revision
 )
 
+// ServiceManifest returns the manifest of the running service
+// to be compared with the one defined in Git.
+func (s *Service) ServiceManifest() (ServiceManifest, error) {
+	data, err := json.Marshal((*run.Service)(s))
+	if err != nil {
+		return ServiceManifest{}, err
+	}
+	return ParseServiceManifest(data)
+}
+
 const (
 	LabelManagedBy   = "pipecd-dev-managed-by"  // Always be piped.
 	LabelPiped       = "pipecd-dev-piped"       // The id of piped handling this application.
@@ -21,6 +21,8 @@ import (
 	"os/exec"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
 	"github.com/pipe-cd/pipecd/pkg/diff"
 )
 
@@ -35,11 +37,19 @@ type DiffResult struct {
 }
 
 func (d *DiffResult) NoChange() bool {
-	return len(d.Diff.Nodes()) == 0
+	return d.Diff == nil || len(d.Diff.Nodes()) == 0
 }
 
 func Diff(old, new ServiceManifest, opts ...diff.Option) (*DiffResult, error) {
-	d, err := diff.DiffUnstructureds(*old.u, *new.u, opts...)
+	var oldU, newU unstructured.Unstructured
+	// An empty manifest is used when there is no previous deployment.
+	if old.u != nil {
+		oldU = *old.u
+	}
+	if new.u != nil {
+		newU = *new.u
+	}
+	d, err := diff.DiffUnstructureds(oldU, newU, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -39,6 +39,11 @@ func TestDiff(t *testing.T) {
 	got, err = Diff(old, old)
 	require.NoError(t, err)
 	require.Empty(t, got)
+
+	// Compare with an empty manifest.
+	got, err = Diff(ServiceManifest{}, new)
+	require.NoError(t, err)
+	require.False(t, got.NoChange())
 }
 
 func TestDiffResult_NoChange(t *testing.T) {
@@ -55,6 +60,12 @@ func TestDiffResult_NoChange(t *testing.T) {
 
 	got := result.NoChange()
 	require.False(t, got)
+
+	result, err = Diff(old, old)
+	require.NoError(t, err)
+
+	got = result.NoChange()
+	require.True(t, got)
 }
 
 func TestDiffResult_Render(t *testing.T) {
@@ -61,9 +61,16 @@ func (m ServiceManifest) UpdateAllTraffic(revision string) error {
 }
 
 func (m ServiceManifest) YamlBytes() ([]byte, error) {
+	if m.u == nil {
+		return nil, nil
+	}
 	return yaml.Marshal(m.u)
 }
 
+func (m ServiceManifest) Labels() map[string]string {
+	return m.u.GetLabels()
+}
+
 func (m ServiceManifest) AddLabels(labels map[string]string) {
 	if len(labels) == 0 {
 		return
@@ -120,6 +120,19 @@ func (c *client) UpdateService(ctx context.Context, service types.Service) (*typ
 		return nil, fmt.Errorf("failed to update ECS service %s: %w", *service.ServiceName, err)
 	}
 
+	// The tags of an existing service can not be updated by UpdateService,
+	// so they are applied separately to keep the builtin tags up-to-date.
+	if len(service.Tags) > 0 {
+		tagInput := &ecs.TagResourceInput{
+			ResourceArn: output.Service.ServiceArn,
+			Tags:        service.Tags,
+		}
+		if _, err := c.ecsClient.TagResource(ctx, tagInput); err != nil {
+			return nil, fmt.Errorf("failed to update tags of ECS service %s: %w", *service.ServiceName, err)
+		}
+		output.Service.Tags = service.Tags
+	}
+
 	// Hack: Since we use EXTERNAL deployment controller, the below configurations are not allowed to be passed
 	// in UpdateService step, but it required in further step (CreateTaskSet step). We reassign those values
 	// as part of service definition for that purpose.
@@ -242,6 +255,84 @@ func (c *client) ServiceExists(ctx context.Context, clusterName string, serviceN
 	return false, nil
 }
 
+func (c *client) ListClusters(ctx context.Context) ([]string, error) {
+	var (
+		clusters  []string
+		nextToken *string
+	)
+	for {
+		input := &ecs.ListClustersInput{
+			NextToken: nextToken,
+		}
+		output, err := c.ecsClient.ListClusters(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list ECS clusters: %w", err)
+		}
+		clusters = append(clusters, output.ClusterArns...)
+		if output.NextToken == nil {
+			return clusters, nil
+		}
+		nextToken = output.NextToken
+	}
+}
+
+// GetServices returns all services placed in the given cluster along with their tags.
+func (c *client) GetServices(ctx context.Context, clusterName string) ([]*types.Service, error) {
+	var (
+		serviceArns []string
+		nextToken   *string
+	)
+	for {
+		input := &ecs.ListServicesInput{
+			Cluster:   aws.String(clusterName),
+			NextToken: nextToken,
+		}
+		output, err := c.ecsClient.ListServices(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services of ECS cluster %s: %w", clusterName, err)
+		}
+		serviceArns = append(serviceArns, output.ServiceArns...)
+		if output.NextToken == nil {
+			break
+		}
+		nextToken = output.NextToken
+	}
+
+	services := make([]*types.Service, 0, len(serviceArns))
+	// DescribeServices accepts up to 10 services at a time.
+	const maxDescribeServices = 10
+	for i := 0; i < len(serviceArns); i += maxDescribeServices {
+		end := i + maxDescribeServices
+		if end > len(serviceArns) {
+			end = len(serviceArns)
+		}
+		input := &ecs.DescribeServicesInput{
+			Cluster:  aws.String(clusterName),
+			Services: serviceArns[i:end],
+			Include:  []types.ServiceField{types.ServiceFieldTags},
+		}
+		output, err := c.ecsClient.DescribeServices(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe services of ECS cluster %s: %w", clusterName, err)
+		}
+		for j := range output.Services {
+			services = append(services, &output.Services[j])
+		}
+	}
+	return services, nil
+}
+
+func (c *client) GetTaskDefinition(ctx context.Context, taskDefinitionArn string) (*types.TaskDefinition, error) {
+	input := &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: aws.String(taskDefinitionArn),
+	}
+	output, err := c.ecsClient.DescribeTaskDefinition(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ECS task definition %s: %w", taskDefinitionArn, err)
+	}
+	return output.TaskDefinition, nil
+}
+
 func (c *client) GetListener(ctx context.Context, targetGroup types.LoadBalancer) (string, error) {
 	loadBalancerArn, err := c.getLoadBalancerArn(ctx, *targetGroup.TargetGroupArn)
 	if err != nil {
@@ -0,0 +1,250 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/pipe-cd/pipecd/pkg/diff"
+)
+
+const (
+	diffCommand = "diff"
+)
+
+type DiffResult struct {
+	Diff *diff.Result
+	Old  unstructured.Unstructured
+	New  unstructured.Unstructured
+}
+
+func (d *DiffResult) NoChange() bool {
+	return d.Diff == nil || len(d.Diff.Nodes()) == 0
+}
+
+// DiffServiceDefinitions calculates the diff between two service definitions.
+func DiffServiceDefinitions(old, new types.Service, opts ...diff.Option) (*DiffResult, error) {
+	return diffDefinitions(old, new, opts...)
+}
+
+// DiffTaskDefinitions calculates the diff between two task definitions.
+func DiffTaskDefinitions(old, new types.TaskDefinition, opts ...diff.Option) (*DiffResult, error) {
+	return diffDefinitions(old, new, opts...)
+}
+
+func diffDefinitions(old, new interface{}, opts ...diff.Option) (*DiffResult, error) {
+	oldU := toUnstructured(old)
+	newU := toUnstructured(new)
+
+	d, err := diff.DiffUnstructureds(oldU, newU, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if !d.HasDiff() {
+		return &DiffResult{}, nil
+	}
+	ret := &DiffResult{
+		Old:  oldU,
+		New:  newU,
+		Diff: d,
+	}
+	return ret, nil
+}
+
+type DiffRenderOptions struct {
+	// If true, use "diff" command to render.
+	UseDiffCommand bool
+}
+
+func (d *DiffResult) Render(opt DiffRenderOptions) string {
+	var b strings.Builder
+	opts := []diff.RenderOption{
+		diff.WithLeftPadding(1),
+	}
+	renderer := diff.NewRenderer(opts...)
+	if !opt.UseDiffCommand {
+		b.WriteString(renderer.Render(d.Diff.Nodes()))
+	} else {
+		d, err := diffByCommand(diffCommand, d.Old, d.New)
+		if err != nil {
+			b.WriteString(fmt.Sprintf("An error occurred while rendering diff (%v)", err))
+		} else {
+			b.Write(d)
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// toUnstructured converts the given ECS definition into an unstructured object
+// whose keys are the lower camel case field names used in the definition files.
+// Unset fields are dropped so that only the specified ones are compared.
+func toUnstructured(v interface{}) unstructured.Unstructured {
+	obj, _ := toUnstructuredValue(reflect.ValueOf(v)).(map[string]interface{})
+	return unstructured.Unstructured{Object: obj}
+}
+
+func toUnstructuredValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		// The pointer to a zero value is kept because it was explicitly set.
+		if e := v.Elem(); e.Kind() != reflect.Struct && e.Kind() != reflect.Ptr && e.Kind() != reflect.Interface {
+			return toScalar(e)
+		}
+		return toUnstructuredValue(v.Elem())
+
+	case reflect.Struct:
+		if t, ok := v.Interface().(time.Time); ok {
+			return t.Format(time.RFC3339)
+		}
+		obj := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			fv := toUnstructuredValue(v.Field(i))
+			if fv == nil {
+				continue
+			}
+			obj[lowerFirst(f.Name)] = fv
+		}
+		if len(obj) == 0 {
+			return nil
+		}
+		return obj
+
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil
+		}
+		obj := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			obj[fmt.Sprint(iter.Key().Interface())] = toUnstructuredValue(iter.Value())
+		}
+		return obj
+
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return nil
+		}
+		list := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			list = append(list, toUnstructuredValue(v.Index(i)))
+		}
+		return list
+
+	default:
+		if v.IsZero() {
+			return nil
+		}
+		return toScalar(v)
+	}
+}
+
+// toScalar converts a scalar value into one of the types used in unstructured objects.
+func toScalar(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+func diffByCommand(command string, old, new unstructured.Unstructured) ([]byte, error) {
+	oldBytes, err := yaml.Marshal(old.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	newBytes, err := yaml.Marshal(new.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	oldFile, err := os.CreateTemp("", "old")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(oldFile.Name())
+	if _, err := oldFile.Write(oldBytes); err != nil {
+		return nil, err
+	}
+
+	newFile, err := os.CreateTemp("", "new")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(newFile.Name())
+	if _, err := newFile.Write(newBytes); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(command, "-u", "-N", oldFile.Name(), newFile.Name())
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if stdout.Len() > 0 {
+		// diff exits with a non-zero status when the files don't match.
+		// Ignore that failure as long as we get output.
+		err = nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run diff, err = %w, %s", err, stderr.String())
+	}
+
+	// Remove two-line header from output.
+	data := bytes.TrimSpace(stdout.Bytes())
+	rows := bytes.SplitN(data, []byte("\n"), 3)
+	if len(rows) == 3 {
+		return rows[2], nil
+	}
+	return data, nil
+}
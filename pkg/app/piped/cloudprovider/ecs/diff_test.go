@@ -0,0 +1,122 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipecd/pkg/diff"
+)
+
+func TestDiffTaskDefinitions(t *testing.T) {
+	old := types.TaskDefinition{
+		Family:                  aws.String("nginx-service-fam"),
+		NetworkMode:             types.NetworkModeAwsvpc,
+		RequiresCompatibilities: []types.Compatibility{types.CompatibilityFargate},
+		Cpu:                     aws.String("256"),
+		Memory:                  aws.String("512"),
+		ContainerDefinitions: []types.ContainerDefinition{
+			{
+				Name:      aws.String("web"),
+				Image:     aws.String("gcr.io/pipecd/helloworld:v0.1.0"),
+				Essential: aws.Bool(true),
+			},
+		},
+	}
+	new := old
+	new.Memory = aws.String("1024")
+	new.ContainerDefinitions = []types.ContainerDefinition{
+		{
+			Name:      aws.String("web"),
+			Image:     aws.String("gcr.io/pipecd/helloworld:v0.2.0"),
+			Essential: aws.Bool(true),
+		},
+	}
+
+	// Have diff.
+	got, err := DiffTaskDefinitions(old, new, diff.WithEquateEmpty())
+	require.NoError(t, err)
+	require.False(t, got.NoChange())
+
+	rendered := got.Render(DiffRenderOptions{})
+	assert.Contains(t, rendered, "memory")
+	assert.Contains(t, rendered, "containerDefinitions.0.image")
+	assert.NotContains(t, rendered, "family")
+
+	// Don't have diff.
+	got, err = DiffTaskDefinitions(old, old, diff.WithEquateEmpty())
+	require.NoError(t, err)
+	assert.True(t, got.NoChange())
+}
+
+func TestDiffServiceDefinitions(t *testing.T) {
+	old := types.Service{
+		ClusterArn:   aws.String("arn:aws:ecs:ap-northeast-1:XXXX:cluster/YYYY"),
+		ServiceName:  aws.String("nginx-service"),
+		DesiredCount: 2,
+		DeploymentConfiguration: &types.DeploymentConfiguration{
+			MaximumPercent:        aws.Int32(200),
+			MinimumHealthyPercent: aws.Int32(0),
+		},
+	}
+	// The fields set by ECS are ignored since they are not specified in the old one.
+	live := old
+	live.ServiceArn = aws.String("arn:aws:ecs:ap-northeast-1:XXXX:service/YYYY/nginx-service")
+	live.Status = aws.String("ACTIVE")
+	live.RunningCount = 2
+	live.PlatformVersion = aws.String("LATEST")
+
+	got, err := DiffServiceDefinitions(old, live, diff.WithEquateEmpty(), diff.WithIgnoreAddingMapKeys())
+	require.NoError(t, err)
+	assert.True(t, got.NoChange())
+
+	live.DesiredCount = 3
+	live.DeploymentConfiguration = &types.DeploymentConfiguration{
+		MaximumPercent:        aws.Int32(200),
+		MinimumHealthyPercent: aws.Int32(50),
+	}
+	got, err = DiffServiceDefinitions(old, live, diff.WithEquateEmpty(), diff.WithIgnoreAddingMapKeys())
+	require.NoError(t, err)
+	require.False(t, got.NoChange())
+
+	rendered := got.Render(DiffRenderOptions{})
+	assert.Contains(t, rendered, "desiredCount")
+	assert.Contains(t, rendered, "deploymentConfiguration.minimumHealthyPercent")
+}
+
+func TestToUnstructured(t *testing.T) {
+	got := toUnstructured(types.Service{
+		ServiceName:  aws.String("nginx-service"),
+		DesiredCount: 2,
+		Tags: []types.Tag{
+			{Key: aws.String("app"), Value: aws.String("nginx")},
+		},
+		HealthCheckGracePeriodSeconds: aws.Int32(0),
+	})
+	expected := map[string]interface{}{
+		"serviceName":  "nginx-service",
+		"desiredCount": int64(2),
+		"tags": []interface{}{
+			map[string]interface{}{"key": "app", "value": "nginx"},
+		},
+		"healthCheckGracePeriodSeconds": int64(0),
+	}
+	assert.Equal(t, expected, got.Object)
+}
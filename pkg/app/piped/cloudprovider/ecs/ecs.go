@@ -26,6 +26,14 @@ import (
 	"github.com/pipe-cd/pipecd/pkg/config"
 )
 
+const (
+	LabelManagedBy   = "pipecd-dev-managed-by"  // Always be piped.
+	LabelPiped       = "pipecd-dev-piped"       // The id of piped handling this application.
+	LabelApplication = "pipecd-dev-application" // The application this resource belongs to.
+	LabelCommitHash  = "pipecd-dev-commit-hash" // Hash value of the deployed commit.
+	ManagedByPiped   = "piped"
+)
+
 // Client is wrapper of ECS client.
 type Client interface {
 	ECS
@@ -41,6 +49,9 @@ type ECS interface {
 	CreateTaskSet(ctx context.Context, service types.Service, taskDefinition types.TaskDefinition, targetGroup types.LoadBalancer, scale int) (*types.TaskSet, error)
 	DeleteTaskSet(ctx context.Context, service types.Service, taskSetArn string) error
 	UpdateServicePrimaryTaskSet(ctx context.Context, service types.Service, taskSet types.TaskSet) (*types.TaskSet, error)
+	ListClusters(ctx context.Context) ([]string, error)
+	GetServices(ctx context.Context, clusterName string) ([]*types.Service, error)
+	GetTaskDefinition(ctx context.Context, taskDefinitionArn string) (*types.TaskDefinition, error)
 }
 
 type ELB interface {
@@ -70,6 +81,15 @@ func LoadTargetGroups(targetGroups config.ECSTargetGroups) (*types.LoadBalancer,
 	return loadTargetGroups(targetGroups)
 }
 
+// IsBuiltinTag reports whether the given tag key is one of the tags added by piped.
+func IsBuiltinTag(key string) bool {
+	switch key {
+	case LabelManagedBy, LabelPiped, LabelApplication, LabelCommitHash:
+		return true
+	}
+	return false
+}
+
 type registry struct {
 	clients  map[string]Client
 	mu       sync.RWMutex
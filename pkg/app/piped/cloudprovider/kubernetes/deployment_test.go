@@ -352,3 +352,236 @@ spec:
 		})
 	}
 }
+
+func TestAnnotateConfigHash(t *testing.T) {
+	testcases := []struct {
+		name          string
+		manifests     string
+		expected      string
+		expectedError error
+	}{
+		{
+			name: "empty list",
+		},
+		{
+			name: "one config",
+			manifests: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: canary-by-config-change
+  labels:
+    app: canary-by-config-change
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: canary-by-config-change
+      pipecd.dev/variant: primary
+  template:
+    metadata:
+      labels:
+        app: canary-by-config-change
+        pipecd.dev/variant: primary
+    spec:
+      containers:
+        - name: helloworld
+          image: gcr.io/pipecd/helloworld:v0.5.0
+          args:
+            - server
+          ports:
+            - containerPort: 9085
+          volumeMounts:
+            - name: config
+              mountPath: /etc/pipecd-config
+              readOnly: true
+      volumes:
+        - name: config
+          configMap:
+            name: canary-by-config-change
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: canary-by-config-change
+data:
+  two: "2"
+			`,
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: canary-by-config-change
+  labels:
+    app: canary-by-config-change
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: canary-by-config-change
+      pipecd.dev/variant: primary
+  template:
+    metadata:
+      labels:
+        app: canary-by-config-change
+        pipecd.dev/variant: primary
+      annotations:
+        pipecd.dev/config-hash: 75c9m2btb6
+    spec:
+      containers:
+        - name: helloworld
+          image: gcr.io/pipecd/helloworld:v0.5.0
+          args:
+            - server
+          ports:
+            - containerPort: 9085
+          volumeMounts:
+            - name: config
+              mountPath: /etc/pipecd-config
+              readOnly: true
+      volumes:
+        - name: config
+          configMap:
+            name: canary-by-config-change
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: canary-by-config-change
+data:
+  two: "2"
+			`,
+		},
+		{
+			name: "multiple configs",
+			manifests: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: canary-by-config-change
+  labels:
+    app: canary-by-config-change
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: canary-by-config-change
+      pipecd.dev/variant: primary
+  template:
+    metadata:
+      labels:
+        app: canary-by-config-change
+        pipecd.dev/variant: primary
+    spec:
+      containers:
+        - name: helloworld
+          image: gcr.io/pipecd/helloworld:v0.5.0
+          args:
+            - server
+          ports:
+            - containerPort: 9085
+          volumeMounts:
+            - name: config
+              mountPath: /etc/pipecd-config
+              readOnly: true
+      volumes:
+        - name: config
+          configMap:
+            name: canary-by-config-change
+        - name: secret
+          secret:
+            secretName: secret-1
+        - name: unmanaged-config
+          configMap:
+            name: unmanaged-config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: canary-by-config-change
+data:
+  two: "2"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: secret-1
+type: my-type
+data:
+  "one": "Mg=="
+			`,
+			expected: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: canary-by-config-change
+  labels:
+    app: canary-by-config-change
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: canary-by-config-change
+      pipecd.dev/variant: primary
+  template:
+    metadata:
+      labels:
+        app: canary-by-config-change
+        pipecd.dev/variant: primary
+      annotations:
+        pipecd.dev/config-hash: t7dtkdm455
+    spec:
+      containers:
+        - name: helloworld
+          image: gcr.io/pipecd/helloworld:v0.5.0
+          args:
+            - server
+          ports:
+            - containerPort: 9085
+          volumeMounts:
+            - name: config
+              mountPath: /etc/pipecd-config
+              readOnly: true
+      volumes:
+        - name: config
+          configMap:
+            name: canary-by-config-change
+        - name: secret
+          secret:
+            secretName: secret-1
+        - name: unmanaged-config
+          configMap:
+            name: unmanaged-config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: canary-by-config-change
+data:
+  two: "2"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: secret-1
+type: my-type
+data:
+  "one": "Mg=="
+			`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := ParseManifests(tc.manifests)
+			require.NoError(t, err)
+
+			expected, err := ParseManifests(tc.expected)
+			require.NoError(t, err)
+
+			err = AnnotateConfigHash(manifests)
+			assert.Equal(t, expected, manifests)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
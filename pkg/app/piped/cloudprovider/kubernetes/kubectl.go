@@ -18,14 +18,20 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
+	"time"
 
 	"k8s.io/client-go/rest"
 
 	"github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/kubernetes/kubernetesmetrics"
 )
 
+// logsPodRunningTimeout is the maximum duration to wait
+// until at least one pod of the given resource is running before streaming its logs.
+const logsPodRunningTimeout = 5 * time.Minute
+
 type Kubectl struct {
 	version  string
 	execPath string
@@ -96,3 +102,105 @@ func (c *Kubectl) Delete(ctx context.Context, namespace string, r ResourceKey) (
 	}
 	return nil
 }
+
+// Get returns the live manifest of the given resource.
+func (c *Kubectl) Get(ctx context.Context, namespace string, r ResourceKey) (m Manifest, err error) {
+	defer func() {
+		kubernetesmetrics.IncKubectlCallsCounter(
+			c.version,
+			kubernetesmetrics.LabelGetCommand,
+			err == nil,
+		)
+	}()
+
+	args := make([]string, 0, 7)
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	args = append(args, "get", r.Kind, r.Name, "-o", "yaml")
+
+	cmd := exec.CommandContext(ctx, c.execPath, args...)
+	out, err := cmd.CombinedOutput()
+
+	if strings.Contains(string(out), "(NotFound)") {
+		return Manifest{}, fmt.Errorf("failed to get: %s, (%w), %v", string(out), ErrNotFound, err)
+	}
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to get: %s, %v", string(out), err)
+	}
+
+	manifests, err := ParseManifests(string(out))
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to parse the output of get: %v", err)
+	}
+	if len(manifests) != 1 {
+		return Manifest{}, fmt.Errorf("unexpected number of resources were returned: %d", len(manifests))
+	}
+	return manifests[0], nil
+}
+
+// GetPodNames returns the names of the pods matching the given label selector
+// in the order of their creation.
+func (c *Kubectl) GetPodNames(ctx context.Context, namespace, selector string) (names []string, err error) {
+	defer func() {
+		kubernetesmetrics.IncKubectlCallsCounter(
+			c.version,
+			kubernetesmetrics.LabelGetCommand,
+			err == nil,
+		)
+	}()
+
+	args := make([]string, 0, 9)
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	args = append(args,
+		"get", "pods",
+		"-l", selector,
+		"--sort-by=.metadata.creationTimestamp",
+		"-o", "jsonpath={.items[*].metadata.name}",
+	)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.execPath, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pods: %s (%v)", stderr.String(), err)
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// Logs streams the logs of all containers of the given resource's pod into the writer.
+// It blocks until the containers terminated or the context was cancelled.
+func (c *Kubectl) Logs(ctx context.Context, namespace string, r ResourceKey, w io.Writer) (err error) {
+	defer func() {
+		kubernetesmetrics.IncKubectlCallsCounter(
+			c.version,
+			kubernetesmetrics.LabelLogsCommand,
+			err == nil,
+		)
+	}()
+
+	args := make([]string, 0, 8)
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	args = append(args,
+		"logs",
+		"--follow",
+		"--all-containers",
+		fmt.Sprintf("--pod-running-timeout=%s", logsPodRunningTimeout),
+		fmt.Sprintf("%s/%s", strings.ToLower(r.Kind), r.Name),
+	)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.execPath, args...)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to get logs: %s (%v)", stderr.String(), err)
+	}
+	return nil
+}
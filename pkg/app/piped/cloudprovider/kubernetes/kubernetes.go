@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -59,6 +60,7 @@ const (
 type Provider interface {
 	ManifestLoader
 	Applier
+	LiveResourceReader
 }
 
 type ManifestLoader interface {
@@ -75,6 +77,15 @@ type Applier interface {
 	Delete(ctx context.Context, key ResourceKey) error
 }
 
+type LiveResourceReader interface {
+	// GetLiveManifest returns the manifest of the given resource running in Kubernetes cluster.
+	GetLiveManifest(ctx context.Context, key ResourceKey) (Manifest, error)
+	// StreamLogs writes the logs of the given resource's pod into the writer until it terminated.
+	StreamLogs(ctx context.Context, key ResourceKey, w io.Writer) error
+	// GetJobPodNames returns the names of all pods created by the given Job in the order of their creation.
+	GetJobPodNames(ctx context.Context, key ResourceKey) ([]string, error)
+}
+
 type gitClient interface {
 	Clone(ctx context.Context, repoID, remote, branch, destination string) (git.Repo, error)
 }
@@ -240,7 +251,39 @@ func (p *provider) Delete(ctx context.Context, k ResourceKey) (err error) {
 	return p.kubectl.Delete(ctx, p.getNamespaceToRun(k), k)
 }
 
-// getNamespaceToRun returns namespace used on kubectl apply/delete commands.
+// GetLiveManifest returns the manifest of the given resource running in Kubernetes cluster.
+func (p *provider) GetLiveManifest(ctx context.Context, k ResourceKey) (Manifest, error) {
+	p.initOnce.Do(func() { p.init(ctx) })
+	if p.initErr != nil {
+		return Manifest{}, p.initErr
+	}
+
+	return p.kubectl.Get(ctx, p.getNamespaceToRun(k), k)
+}
+
+// StreamLogs writes the logs of the given resource's pod into the writer until it terminated.
+func (p *provider) StreamLogs(ctx context.Context, k ResourceKey, w io.Writer) error {
+	p.initOnce.Do(func() { p.init(ctx) })
+	if p.initErr != nil {
+		return p.initErr
+	}
+
+	return p.kubectl.Logs(ctx, p.getNamespaceToRun(k), k, w)
+}
+
+// GetJobPodNames returns the names of all pods created by the given Job in the order of their creation.
+func (p *provider) GetJobPodNames(ctx context.Context, k ResourceKey) ([]string, error) {
+	p.initOnce.Do(func() { p.init(ctx) })
+	if p.initErr != nil {
+		return nil, p.initErr
+	}
+
+	// Kubernetes adds this label to all pods created by a Job.
+	selector := fmt.Sprintf("job-name=%s", k.Name)
+	return p.kubectl.GetPodNames(ctx, p.getNamespaceToRun(k), selector)
+}
+
+// getNamespaceToRun returns namespace used on kubectl commands.
 // priority: config.KubernetesDeploymentInput > kubernetes.ResourceKey
 func (p *provider) getNamespaceToRun(k ResourceKey) string {
 	if p.input.Namespace != "" {
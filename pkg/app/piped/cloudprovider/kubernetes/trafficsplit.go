@@ -0,0 +1,66 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	KindTrafficSplit = "TrafficSplit"
+)
+
+// TrafficSplitBackend represents a backend service of an SMI TrafficSplit
+// and the weight of traffic it should receive.
+type TrafficSplitBackend struct {
+	Service string
+	Weight  int
+}
+
+// MakeTrafficSplitManifest generates an SMI TrafficSplit manifest that splits
+// the traffic sent to the given root service into the given backends.
+// The backends with zero weight are omitted.
+func MakeTrafficSplitManifest(apiVersion, name, namespace, rootService string, backends []TrafficSplitBackend) Manifest {
+	items := make([]interface{}, 0, len(backends))
+	for _, b := range backends {
+		if b.Weight <= 0 {
+			continue
+		}
+		items = append(items, map[string]interface{}{
+			"service": b.Service,
+			"weight":  int64(b.Weight),
+		})
+	}
+
+	metadata := map[string]interface{}{
+		"name": name,
+	}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       KindTrafficSplit,
+			"metadata":   metadata,
+			"spec": map[string]interface{}{
+				"service":  rootService,
+				"backends": items,
+			},
+		},
+	}
+	return MakeManifest(MakeResourceKey(obj), obj)
+}
@@ -108,6 +108,53 @@ func (c *client) IsFunctionExist(ctx context.Context, name string) (bool, error)
 	return true, nil
 }
 
+func (c *client) ListFunctions(ctx context.Context) ([]types.FunctionConfiguration, error) {
+	var (
+		functions []types.FunctionConfiguration
+		marker    *string
+	)
+	for {
+		input := &lambda.ListFunctionsInput{
+			Marker: marker,
+		}
+		output, err := c.client.ListFunctions(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Lambda functions: %w", err)
+		}
+		functions = append(functions, output.Functions...)
+		if output.NextMarker == nil {
+			return functions, nil
+		}
+		marker = output.NextMarker
+	}
+}
+
+// GetFunction returns lambda provider.ErrNotFound in case the function is not existed.
+func (c *client) GetFunction(ctx context.Context, name string) (*Function, error) {
+	input := &lambda.GetFunctionInput{
+		FunctionName: aws.String(name),
+	}
+	output, err := c.client.GetFunction(ctx, input)
+	if err != nil {
+		var nfe *types.ResourceNotFoundException
+		if errors.As(err, &nfe) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get Lambda function %s: %w", name, err)
+	}
+
+	f := &Function{
+		Tags: output.Tags,
+	}
+	if output.Configuration != nil {
+		f.Configuration = *output.Configuration
+	}
+	if output.Code != nil {
+		f.ImageURI = aws.ToString(output.Code.ImageUri)
+	}
+	return f, nil
+}
+
 func (c *client) CreateFunction(ctx context.Context, fm FunctionManifest) error {
 	input := &lambda.CreateFunctionInput{
 		FunctionName: aws.String(fm.Spec.Name),
@@ -0,0 +1,55 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lambda
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipecd/pkg/diff"
+)
+
+func TestDiff(t *testing.T) {
+	old := FunctionManifest{
+		Kind:       "LambdaFunction",
+		APIVersion: "pipecd.dev/v1beta1",
+		Spec: FunctionManifestSpec{
+			Name:     "SimpleFunction",
+			Role:     "arn:aws:iam::xxxxx:role/lambda-role",
+			Memory:   128,
+			Timeout:  5,
+			ImageURI: "ecr.region.amazonaws.com/lambda-simple-function:v0.0.1",
+		},
+	}
+	new := old
+	new.Spec.Memory = 512
+	new.Spec.Environments = map[string]string{"FOO": "bar"}
+
+	// Have diff.
+	got, err := Diff(old, new, diff.WithEquateEmpty())
+	require.NoError(t, err)
+	require.False(t, got.NoChange())
+
+	rendered := got.Render(DiffRenderOptions{})
+	assert.Contains(t, rendered, "spec.memory")
+	assert.Contains(t, rendered, "spec.environments")
+
+	// Don't have diff.
+	got, err = Diff(old, old, diff.WithEquateEmpty())
+	require.NoError(t, err)
+	assert.True(t, got.NoChange())
+}
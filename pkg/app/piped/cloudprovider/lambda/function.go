@@ -19,6 +19,8 @@ import (
 	"os"
 	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"sigs.k8s.io/yaml"
 )
 
@@ -97,6 +99,19 @@ func (fmp FunctionManifestSpec) validate() error {
 	return nil
 }
 
+// AddTags adds the given tags to the function manifest.
+func (fm *FunctionManifest) AddTags(tags map[string]string) {
+	if len(tags) == 0 {
+		return
+	}
+	if fm.Spec.Tags == nil {
+		fm.Spec.Tags = make(map[string]string, len(tags))
+	}
+	for k, v := range tags {
+		fm.Spec.Tags[k] = v
+	}
+}
+
 type SourceCode struct {
 	Git  string `json:"git"`
 	Ref  string `json:"ref"`
@@ -164,3 +179,57 @@ func parseContainerImage(image string) (name, tag string) {
 	name = paths[len(paths)-1]
 	return
 }
+
+// Function represents the live state of a Lambda function running on AWS.
+type Function struct {
+	Configuration types.FunctionConfiguration
+	// The URI of the container image in case the function was packaged as a container image.
+	ImageURI string
+	Tags     map[string]string
+}
+
+// FunctionManifest returns the manifest representing the live configuration of the function.
+// Since the location of the deployment package cannot be retrieved from AWS,
+// the S3 and source code fields are left empty.
+// The builtin tags added by piped are excluded as well.
+func (f Function) FunctionManifest() FunctionManifest {
+	var tags map[string]string
+	for k, v := range f.Tags {
+		if isBuiltinTag(k) {
+			continue
+		}
+		if tags == nil {
+			tags = make(map[string]string, len(f.Tags))
+		}
+		tags[k] = v
+	}
+
+	var envs map[string]string
+	if f.Configuration.Environment != nil {
+		envs = f.Configuration.Environment.Variables
+	}
+
+	return FunctionManifest{
+		Kind:       functionManifestKind,
+		APIVersion: versionV1Beta1,
+		Spec: FunctionManifestSpec{
+			Name:         aws.ToString(f.Configuration.FunctionName),
+			Role:         aws.ToString(f.Configuration.Role),
+			ImageURI:     f.ImageURI,
+			Handler:      aws.ToString(f.Configuration.Handler),
+			Runtime:      string(f.Configuration.Runtime),
+			Memory:       aws.ToInt32(f.Configuration.MemorySize),
+			Timeout:      aws.ToInt32(f.Configuration.Timeout),
+			Tags:         tags,
+			Environments: envs,
+		},
+	}
+}
+
+func isBuiltinTag(key string) bool {
+	switch key {
+	case LabelManagedBy, LabelPiped, LabelApplication, LabelCommitHash:
+		return true
+	}
+	return false
+}
@@ -17,6 +17,8 @@ package lambda
 import (
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -172,3 +174,38 @@ func TestparseFunctionManifest(t *testing.T) {
 		})
 	}
 }
+
+func TestFunctionManifest(t *testing.T) {
+	f := Function{
+		Configuration: types.FunctionConfiguration{
+			FunctionName: aws.String("SimpleFunction"),
+			Role:         aws.String("arn:aws:iam::xxxxx:role/lambda-role"),
+			MemorySize:   aws.Int32(128),
+			Timeout:      aws.Int32(5),
+			Environment: &types.EnvironmentResponse{
+				Variables: map[string]string{"FOO": "bar"},
+			},
+		},
+		ImageURI: "ecr.region.amazonaws.com/lambda-simple-function:v0.0.1",
+		Tags: map[string]string{
+			"app":            "simple",
+			LabelManagedBy:   ManagedByPiped,
+			LabelApplication: "app-id",
+			LabelCommitHash:  "0123456789",
+		},
+	}
+	want := FunctionManifest{
+		Kind:       "LambdaFunction",
+		APIVersion: "pipecd.dev/v1beta1",
+		Spec: FunctionManifestSpec{
+			Name:         "SimpleFunction",
+			Role:         "arn:aws:iam::xxxxx:role/lambda-role",
+			Memory:       128,
+			Timeout:      5,
+			ImageURI:     "ecr.region.amazonaws.com/lambda-simple-function:v0.0.1",
+			Tags:         map[string]string{"app": "simple"},
+			Environments: map[string]string{"FOO": "bar"},
+		},
+	}
+	assert.Equal(t, want, f.FunctionManifest())
+}
@@ -20,15 +20,26 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"go.uber.org/zap"
 	"golang.org/x/sync/singleflight"
 
 	"github.com/pipe-cd/pipecd/pkg/config"
 )
 
+const (
+	LabelManagedBy   = "pipecd-dev-managed-by"  // Always be piped.
+	LabelPiped       = "pipecd-dev-piped"       // The id of piped handling this application.
+	LabelApplication = "pipecd-dev-application" // The application this resource belongs to.
+	LabelCommitHash  = "pipecd-dev-commit-hash" // Hash value of the deployed commit.
+	ManagedByPiped   = "piped"
+)
+
 // Client is wrapper of AWS client.
 type Client interface {
 	IsFunctionExist(ctx context.Context, name string) (bool, error)
+	ListFunctions(ctx context.Context) ([]types.FunctionConfiguration, error)
+	GetFunction(ctx context.Context, name string) (*Function, error)
 	CreateFunction(ctx context.Context, fm FunctionManifest) error
 	CreateFunctionFromSource(ctx context.Context, fm FunctionManifest, zip io.Reader) error
 	UpdateFunction(ctx context.Context, fm FunctionManifest) error
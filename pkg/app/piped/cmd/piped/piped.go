@@ -42,6 +42,7 @@ import (
 	"github.com/pipe-cd/pipecd/pkg/app/piped/apistore/applicationstore"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/apistore/commandstore"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/apistore/deploymentstore"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/apistore/deploywindowstore"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/apistore/environmentstore"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/apistore/eventstore"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/appconfigreporter"
@@ -290,6 +291,16 @@ func (p *piped) run(ctx context.Context, input cli.Input) (runErr error) {
 		commandLister = store.Lister()
 	}
 
+	// Start running deploy window store.
+	var deployWindowLister deploywindowstore.Lister
+	{
+		store := deploywindowstore.NewStore(apiClient, input.Logger)
+		group.Go(func() error {
+			return store.Run(ctx)
+		})
+		deployWindowLister = store.Lister()
+	}
+
 	// Start running event store.
 	var eventGetter eventstore.Getter
 	{
@@ -335,6 +346,7 @@ func (p *piped) run(ctx context.Context, input cli.Input) (runErr error) {
 			applicationLister,
 			gitClient,
 			liveStateGetter,
+			deployWindowLister,
 			apiClient,
 			appManifestsCache,
 			cfg,
@@ -355,6 +367,7 @@ func (p *piped) run(ctx context.Context, input cli.Input) (runErr error) {
 			commandLister,
 			applicationLister,
 			environmentStore,
+			deployWindowLister,
 			livestatestore.LiveResourceLister{Getter: liveStateGetter},
 			analysisResultStore,
 			notifier,
@@ -379,6 +392,7 @@ func (p *piped) run(ctx context.Context, input cli.Input) (runErr error) {
 			applicationLister,
 			commandLister,
 			environmentStore,
+			deployWindowLister,
 			notifier,
 			cfg,
 			p.gracePeriod,
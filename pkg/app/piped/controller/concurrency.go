@@ -0,0 +1,120 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+// concurrencyLimiter keeps track of the number of deployments being handled
+// to decide whether a new one can be started without exceeding
+// the concurrency limits configured in piped.
+type concurrencyLimiter struct {
+	cfg *config.PipedDeploymentConcurrency
+
+	total          int
+	cloudProviders map[string]int
+	labels         []int
+}
+
+// newConcurrencyLimiter creates a limiter that already counts the given active deployments.
+func newConcurrencyLimiter(cfg *config.PipedDeploymentConcurrency, actives []*model.Deployment) *concurrencyLimiter {
+	l := &concurrencyLimiter{
+		cfg:            cfg,
+		cloudProviders: make(map[string]int),
+	}
+	if cfg != nil {
+		l.labels = make([]int, len(cfg.Labels))
+	}
+	for _, d := range actives {
+		l.add(d)
+	}
+	return l
+}
+
+// acquire counts the given deployment in when it does not exceed any limit.
+// Otherwise, it returns false with the description of the reached limit.
+func (l *concurrencyLimiter) acquire(d *model.Deployment) (bool, string) {
+	if reason := l.reachedLimit(d); reason != "" {
+		return false, reason
+	}
+	l.add(d)
+	return true, ""
+}
+
+func (l *concurrencyLimiter) reachedLimit(d *model.Deployment) string {
+	if l.cfg == nil {
+		return ""
+	}
+	if l.cfg.MaxDeployments > 0 && l.total >= l.cfg.MaxDeployments {
+		return fmt.Sprintf("the maximum number of concurrent deployments of piped (%d) was reached", l.cfg.MaxDeployments)
+	}
+	for _, cp := range l.cfg.CloudProviders {
+		if cp.Name == d.CloudProvider && l.cloudProviders[cp.Name] >= cp.MaxDeployments {
+			return fmt.Sprintf("the maximum number of concurrent deployments to cloud provider %s (%d) was reached", cp.Name, cp.MaxDeployments)
+		}
+	}
+	for i, s := range l.cfg.Labels {
+		if s.Matches(d.Labels) && l.labels[i] >= s.MaxDeployments {
+			return fmt.Sprintf("the maximum number of concurrent deployments of applications labeled %s (%d) was reached", formatSelector(s.Selector), s.MaxDeployments)
+		}
+	}
+	return ""
+}
+
+func (l *concurrencyLimiter) add(d *model.Deployment) {
+	l.total++
+	l.cloudProviders[d.CloudProvider]++
+	if l.cfg == nil {
+		return
+	}
+	for i, s := range l.cfg.Labels {
+		if s.Matches(d.Labels) {
+			l.labels[i]++
+		}
+	}
+}
+
+// sortByTriggeredTime sorts the given deployments to make the ones triggered earlier come first.
+func sortByTriggeredTime(ds []*model.Deployment) {
+	sort.Slice(ds, func(i, j int) bool {
+		if ds[i].Trigger.Timestamp != ds[j].Trigger.Timestamp {
+			return ds[i].Trigger.Timestamp < ds[j].Trigger.Timestamp
+		}
+		if ds[i].CreatedAt != ds[j].CreatedAt {
+			return ds[i].CreatedAt < ds[j].CreatedAt
+		}
+		return ds[i].Id < ds[j].Id
+	})
+}
+
+func formatSelector(selector map[string]string) string {
+	keys := make([]string, 0, len(selector))
+	for k := range selector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+selector[k])
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	newDeployment := func(id, cloudProvider string, labels map[string]string) *model.Deployment {
+		return &model.Deployment{
+			Id:            id,
+			CloudProvider: cloudProvider,
+			Labels:        labels,
+		}
+	}
+
+	testcases := []struct {
+		name     string
+		cfg      *config.PipedDeploymentConcurrency
+		actives  []*model.Deployment
+		pendings []*model.Deployment
+		expected []bool
+	}{
+		{
+			name: "no limit",
+			actives: []*model.Deployment{
+				newDeployment("d-1", "kubernetes", nil),
+			},
+			pendings: []*model.Deployment{
+				newDeployment("d-2", "kubernetes", nil),
+				newDeployment("d-3", "kubernetes", nil),
+			},
+			expected: []bool{true, true},
+		},
+		{
+			name: "piped limit",
+			cfg: &config.PipedDeploymentConcurrency{
+				MaxDeployments: 2,
+			},
+			actives: []*model.Deployment{
+				newDeployment("d-1", "kubernetes", nil),
+			},
+			pendings: []*model.Deployment{
+				newDeployment("d-2", "kubernetes", nil),
+				newDeployment("d-3", "terraform", nil),
+			},
+			expected: []bool{true, false},
+		},
+		{
+			name: "cloud provider limit",
+			cfg: &config.PipedDeploymentConcurrency{
+				CloudProviders: []config.CloudProviderDeploymentConcurrency{
+					{
+						Name:           "kubernetes",
+						MaxDeployments: 1,
+					},
+				},
+			},
+			actives: []*model.Deployment{
+				newDeployment("d-1", "kubernetes", nil),
+			},
+			pendings: []*model.Deployment{
+				newDeployment("d-2", "kubernetes", nil),
+				newDeployment("d-3", "terraform", nil),
+			},
+			expected: []bool{false, true},
+		},
+		{
+			name: "label limit",
+			cfg: &config.PipedDeploymentConcurrency{
+				Labels: []config.LabelDeploymentConcurrency{
+					{
+						Selector:       map[string]string{"team": "payment"},
+						MaxDeployments: 1,
+					},
+				},
+			},
+			pendings: []*model.Deployment{
+				newDeployment("d-1", "kubernetes", map[string]string{"team": "payment", "env": "prod"}),
+				newDeployment("d-2", "kubernetes", map[string]string{"team": "payment"}),
+				newDeployment("d-3", "kubernetes", map[string]string{"team": "search"}),
+			},
+			expected: []bool{true, false, true},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newConcurrencyLimiter(tc.cfg, tc.actives)
+			got := make([]bool, 0, len(tc.pendings))
+			for _, d := range tc.pendings {
+				ok, reason := l.acquire(d)
+				assert.Equal(t, ok, reason == "")
+				got = append(got, ok)
+			}
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestSortByTriggeredTime(t *testing.T) {
+	ds := []*model.Deployment{
+		{
+			Id:        "d-3",
+			Trigger:   &model.DeploymentTrigger{Timestamp: 2},
+			CreatedAt: 3,
+		},
+		{
+			Id:        "d-2",
+			Trigger:   &model.DeploymentTrigger{Timestamp: 1},
+			CreatedAt: 3,
+		},
+		{
+			Id:        "d-1",
+			Trigger:   &model.DeploymentTrigger{Timestamp: 1},
+			CreatedAt: 2,
+		},
+	}
+	sortByTriggeredTime(ds)
+
+	ids := make([]string, 0, len(ds))
+	for _, d := range ds {
+		ids = append(ids, d.Id)
+	}
+	assert.Equal(t, []string{"d-1", "d-2", "d-3"}, ids)
+}
@@ -22,6 +22,7 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"sync"
@@ -81,6 +82,10 @@ type environmentLister interface {
 	Get(ctx context.Context, id string) (*model.Environment, error)
 }
 
+type deployWindowLister interface {
+	Get() *config.DeployWindows
+}
+
 type liveResourceLister interface {
 	ListKubernetesAppLiveResources(cloudProvider, appID string) ([]provider.Manifest, bool)
 }
@@ -114,6 +119,7 @@ type controller struct {
 	commandLister       commandLister
 	applicationLister   applicationLister
 	environmentLister   environmentLister
+	deployWindowLister  deployWindowLister
 	liveResourceLister  liveResourceLister
 	analysisResultStore analysisResultStore
 	notifier            notifier
@@ -138,6 +144,16 @@ type controller struct {
 	doneSchedulers map[string]time.Time
 	// Map from application ID to its most recently successful commit hash.
 	mostRecentlySuccessfulCommits map[string]string
+	// Map from deployment ID to the reason why that PENDING deployment
+	// is being blocked by the deploy windows.
+	blockedDeployments map[string]string
+	// Set of PENDING deployments whose deploy windows were overridden by command.
+	// This is used until the saved override appears in the deployment metadata
+	// since the deployment lister may return not fresh data.
+	overriddenDeployments map[string]struct{}
+	// Map from deployment ID to the reported status reason of that PENDING deployment
+	// while it is being queued because of the concurrency limits.
+	queuedDeployments map[string]string
 	// WaitGroup for waiting the completions of all planners, schedulers.
 	wg sync.WaitGroup
 
@@ -155,6 +171,7 @@ func NewController(
 	commandLister commandLister,
 	applicationLister applicationLister,
 	environmentLister environmentLister,
+	deployWindowLister deployWindowLister,
 	liveResourceLister liveResourceLister,
 	analysisResultStore analysisResultStore,
 	notifier notifier,
@@ -176,6 +193,7 @@ func NewController(
 		commandLister:       commandLister,
 		applicationLister:   applicationLister,
 		environmentLister:   environmentLister,
+		deployWindowLister:  deployWindowLister,
 		liveResourceLister:  liveResourceLister,
 		analysisResultStore: analysisResultStore,
 		notifier:            notifier,
@@ -189,6 +207,9 @@ func NewController(
 		schedulers:                    make(map[string]*scheduler),
 		doneSchedulers:                make(map[string]time.Time),
 		mostRecentlySuccessfulCommits: make(map[string]string),
+		blockedDeployments:            make(map[string]string),
+		overriddenDeployments:         make(map[string]struct{}),
+		queuedDeployments:             make(map[string]string),
 
 		syncInternal: 10 * time.Second,
 		gracePeriod:  gracePeriod,
@@ -326,6 +347,28 @@ func (c *controller) syncPlanners(ctx context.Context) error {
 
 	// Add missing planners.
 	pendings := c.deploymentLister.ListPendings()
+
+	// Forget the deployments those are no longer PENDING.
+	pendingIDs := make(map[string]struct{}, len(pendings))
+	for _, d := range pendings {
+		pendingIDs[d.Id] = struct{}{}
+	}
+	for id := range c.blockedDeployments {
+		if _, ok := pendingIDs[id]; !ok {
+			delete(c.blockedDeployments, id)
+		}
+	}
+	for id := range c.overriddenDeployments {
+		if _, ok := pendingIDs[id]; !ok {
+			delete(c.overriddenDeployments, id)
+		}
+	}
+	for id := range c.queuedDeployments {
+		if _, ok := pendingIDs[id]; !ok {
+			delete(c.queuedDeployments, id)
+		}
+	}
+
 	if len(pendings) == 0 {
 		return nil
 	}
@@ -370,7 +413,21 @@ func (c *controller) syncPlanners(ctx context.Context) error {
 		pendingByApp[appID] = d
 	}
 
-	for appID, d := range pendingByApp {
+	// Deployments are started in the order of their triggered time
+	// to keep the queue fair when the concurrency limits were reached.
+	candidates := make([]*model.Deployment, 0, len(pendingByApp))
+	for _, d := range pendingByApp {
+		candidates = append(candidates, d)
+	}
+	sortByTriggeredTime(candidates)
+
+	var (
+		limiter       = newConcurrencyLimiter(c.pipedConfig.DeploymentConcurrency, c.listActiveDeployments())
+		queuePosition uint32
+	)
+
+	for _, d := range candidates {
+		appID := d.ApplicationId
 		plannable, cancel, cancelReason, err := c.shouldStartPlanningDeployment(ctx, d)
 		if err != nil {
 			c.logger.Error("failed to check deployment plannability",
@@ -393,7 +450,13 @@ func (c *controller) syncPlanners(ctx context.Context) error {
 		}
 
 		if !plannable {
-			if d.IsInChainDeployment() {
+			if reason, ok := c.blockedDeployments[d.Id]; ok {
+				c.logger.Info("unable to start planning deployment because it is blocked by deploy windows",
+					zap.String("deployment", d.Id),
+					zap.String("app", d.ApplicationId),
+					zap.String("reason", reason),
+				)
+			} else if d.IsInChainDeployment() {
 				c.logger.Info("unable to start planning deployment, probably locked by the previous block in its deployment chain",
 					zap.String("deployment_chain", d.DeploymentChainId),
 					zap.String("deployment", d.Id),
@@ -408,6 +471,13 @@ func (c *controller) syncPlanners(ctx context.Context) error {
 			continue
 		}
 
+		if ok, reason := limiter.acquire(d); !ok {
+			queuePosition++
+			c.queueDeployment(ctx, d, queuePosition, reason)
+			continue
+		}
+		delete(c.queuedDeployments, d.Id)
+
 		planner, err := c.startNewPlanner(ctx, d)
 		if err != nil {
 			c.logger.Error("failed to start a new planner",
@@ -432,6 +502,69 @@ func (c *controller) syncPlanners(ctx context.Context) error {
 	return nil
 }
 
+// listActiveDeployments returns all deployments being handled by planners and schedulers.
+// Because the done planners are removed after syncing schedulers, the PLANNED deployments
+// whose schedulers have not been started yet are included as well as the RUNNING ones.
+func (c *controller) listActiveDeployments() []*model.Deployment {
+	var (
+		planneds = c.deploymentLister.ListPlanneds()
+		runnings = c.deploymentLister.ListRunnings()
+		size     = len(c.planners) + len(c.schedulers) + len(planneds) + len(runnings)
+		actives  = make([]*model.Deployment, 0, size)
+		seen     = make(map[string]struct{}, size)
+	)
+	add := func(d *model.Deployment) {
+		if _, ok := seen[d.Id]; ok {
+			return
+		}
+		seen[d.Id] = struct{}{}
+		actives = append(actives, d)
+	}
+
+	for _, p := range c.planners {
+		add(p.deployment)
+	}
+	for _, s := range c.schedulers {
+		add(s.deployment)
+	}
+	for _, ds := range [][]*model.Deployment{planneds, runnings} {
+		for _, d := range ds {
+			// The deployment lister may return not fresh data
+			// so the completed ones must be ignored.
+			if _, ok := c.doneSchedulers[d.Id]; ok {
+				continue
+			}
+			add(d)
+		}
+	}
+	return actives
+}
+
+// queueDeployment reports the position of the given deployment in the queue
+// and why it is being queued when they were changed from the previous report.
+func (c *controller) queueDeployment(ctx context.Context, d *model.Deployment, position uint32, reason string) {
+	statusReason := fmt.Sprintf("Waiting in the queue at position %d because %s", position, reason)
+	if c.queuedDeployments[d.Id] == statusReason {
+		return
+	}
+	c.logger.Info("queued deployment because concurrency limits were reached",
+		zap.String("deployment", d.Id),
+		zap.String("app", d.ApplicationId),
+		zap.Uint32("position", position),
+		zap.String("reason", reason),
+	)
+
+	if err := c.reportPendingDeploymentStatus(ctx, d, statusReason, position); err != nil {
+		c.logger.Error("failed to report the queue position of deployment",
+			zap.String("deployment", d.Id),
+			zap.String("app", d.ApplicationId),
+			zap.Error(err),
+		)
+		return
+	}
+	c.queuedDeployments[d.Id] = statusReason
+}
+
 func (c *controller) startNewPlanner(ctx context.Context, d *model.Deployment) (*planner, error) {
 	logger := c.logger.With(
 		zap.String("deployment", d.Id),
@@ -691,6 +824,12 @@ func (c *controller) getMostRecentlySuccessfulDeployment(ctx context.Context, ap
 }
 
 func (c *controller) shouldStartPlanningDeployment(ctx context.Context, d *model.Deployment) (plannable, cancel bool, cancelReason string, err error) {
+	var blocked bool
+	blocked, cancel, cancelReason, err = c.checkDeployWindows(ctx, d)
+	if err != nil || blocked || cancel {
+		return
+	}
+
 	if !d.IsInChainDeployment() {
 		plannable = true
 		return
@@ -709,6 +848,211 @@ func (c *controller) shouldStartPlanningDeployment(ctx context.Context, d *model
 	return
 }
 
+// checkDeployWindows reports whether the given PENDING deployment is blocked by the deploy windows
+// configured in the project, the piped and the application configuration.
+// A blocked deployment can be forced through by an OVERRIDE_DEPLOY_WINDOW command
+// or be cancelled by a CANCEL_DEPLOYMENT command.
+func (c *controller) checkDeployWindows(ctx context.Context, d *model.Deployment) (blocked, cancel bool, cancelReason string, err error) {
+	var appWindows *config.DeployWindows
+	if v, ok := d.Metadata[model.MetadataKeyDeployWindows]; ok {
+		appWindows = &config.DeployWindows{}
+		if err = json.Unmarshal([]byte(v), appWindows); err != nil {
+			err = fmt.Errorf("failed to extract deploy windows config: %w", err)
+			return
+		}
+	}
+	projectWindows := c.deployWindowLister.Get()
+	if projectWindows == nil && c.pipedConfig.DeployWindows == nil && appWindows == nil {
+		return
+	}
+	if _, ok := d.Metadata[model.MetadataKeyDeployWindowOverride]; ok {
+		return
+	}
+	if _, ok := c.overriddenDeployments[d.Id]; ok {
+		return
+	}
+
+	var cancelCmd *model.ReportableCommand
+	for _, cmd := range c.commandLister.ListDeploymentCommands() {
+		if cmd.DeploymentId != d.Id {
+			continue
+		}
+		if cmd.GetOverrideDeployWindow() != nil && c.overrideDeployWindows(ctx, d, cmd) {
+			return
+		}
+		if cmd.GetCancelDeployment() != nil {
+			cmd := cmd
+			cancelCmd = &cmd
+		}
+	}
+
+	allowed, reason, err := config.CheckDeployWindows(time.Now(), projectWindows, c.pipedConfig.DeployWindows, appWindows)
+	if err != nil {
+		return
+	}
+	if allowed {
+		delete(c.blockedDeployments, d.Id)
+		return
+	}
+	blocked = true
+
+	// Because no planner is running for the blocked deployment,
+	// its cancel command must be handled here.
+	if cancelCmd != nil {
+		if err := cancelCmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil, nil); err != nil {
+			c.logger.Error("failed to report command status", zap.Error(err))
+		}
+		return false, true, fmt.Sprintf("Cancelled by %s while being blocked by deploy windows", cancelCmd.Commander), nil
+	}
+
+	// Deployments blocked by the deploy windows are not counted in the queue.
+	delete(c.queuedDeployments, d.Id)
+
+	prev, ok := c.blockedDeployments[d.Id]
+	if ok && prev == reason {
+		return
+	}
+	c.blockedDeployments[d.Id] = reason
+
+	if err := c.reportPendingDeploymentStatus(ctx, d, reason, 0); err != nil {
+		c.logger.Error("failed to report the reason why deployment is blocked",
+			zap.String("deployment", d.Id),
+			zap.String("app", d.ApplicationId),
+			zap.Error(err),
+		)
+	}
+	if !ok {
+		c.notifyDeploymentBlocked(ctx, d, reason)
+	}
+	return
+}
+
+func (c *controller) notifyDeploymentBlocked(ctx context.Context, d *model.Deployment, reason string) {
+	accounts, err := getMentionedAccounts(d.Metadata[model.MetadataKeyDeploymentNotification], model.NotificationEventType_EVENT_DEPLOYMENT_BLOCKED)
+	if err != nil {
+		c.logger.Error("failed to get the list of accounts", zap.Error(err))
+	}
+	c.notifier.Notify(model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPLOYMENT_BLOCKED,
+		Metadata: &model.NotificationEventDeploymentBlocked{
+			Deployment:        d,
+			EnvName:           c.getEnvName(ctx, d),
+			Reason:            reason,
+			MentionedAccounts: accounts,
+		},
+	})
+}
+
+// overrideDeployWindows marks the given deployment as no longer restricted by the deploy windows
+// by recording who did that and why into the deployment metadata for auditing.
+// The deployment stays restricted when that record could not be saved.
+func (c *controller) overrideDeployWindows(ctx context.Context, d *model.Deployment, cmd model.ReportableCommand) bool {
+	reason := cmd.GetOverrideDeployWindow().Reason
+	value, err := json.Marshal(map[string]interface{}{
+		"commander": cmd.Commander,
+		"reason":    reason,
+		"commandId": cmd.Id,
+		"timestamp": time.Now().Unix(),
+	})
+	if err == nil {
+		_, err = c.apiClient.SaveDeploymentMetadata(ctx, &pipedservice.SaveDeploymentMetadataRequest{
+			DeploymentId: d.Id,
+			Metadata: map[string]string{
+				model.MetadataKeyDeployWindowOverride: string(value),
+			},
+		})
+	}
+	if err != nil {
+		c.logger.Error("failed to save the deploy windows override into deployment metadata",
+			zap.String("deployment", d.Id),
+			zap.Error(err),
+		)
+		if err := cmd.Report(ctx, model.CommandStatus_COMMAND_FAILED, nil, []byte(err.Error())); err != nil {
+			c.logger.Error("failed to report command status", zap.Error(err))
+		}
+		return false
+	}
+
+	c.logger.Info("deploy windows were overridden by command",
+		zap.String("deployment", d.Id),
+		zap.String("app", d.ApplicationId),
+		zap.String("commander", cmd.Commander),
+		zap.String("reason", reason),
+	)
+	c.overriddenDeployments[d.Id] = struct{}{}
+	delete(c.blockedDeployments, d.Id)
+
+	if err := cmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil, nil); err != nil {
+		c.logger.Error("failed to report command status", zap.Error(err))
+	}
+
+	accounts, err := getMentionedAccounts(d.Metadata[model.MetadataKeyDeploymentNotification], model.NotificationEventType_EVENT_DEPLOYMENT_DEPLOY_WINDOW_OVERRIDDEN)
+	if err != nil {
+		c.logger.Error("failed to get the list of accounts", zap.Error(err))
+	}
+	c.notifier.Notify(model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPLOYMENT_DEPLOY_WINDOW_OVERRIDDEN,
+		Metadata: &model.NotificationEventDeploymentDeployWindowOverridden{
+			Deployment:        d,
+			EnvName:           c.getEnvName(ctx, d),
+			Commander:         cmd.Commander,
+			Reason:            reason,
+			MentionedAccounts: accounts,
+		},
+	})
+	return true
+}
+
+func (c *controller) reportPendingDeploymentStatus(ctx context.Context, d *model.Deployment, reason string, queuePosition uint32) error {
+	var (
+		err error
+		req = &pipedservice.ReportDeploymentStatusChangedRequest{
+			DeploymentId:              d.Id,
+			Status:                    model.DeploymentStatus_DEPLOYMENT_PENDING,
+			StatusReason:              reason,
+			DeploymentChainId:         d.DeploymentChainId,
+			DeploymentChainBlockIndex: d.DeploymentChainBlockIndex,
+			QueuePosition:             queuePosition,
+		}
+		retry = pipedservice.NewRetry(10)
+	)
+
+	for retry.WaitNext(ctx) {
+		if _, err = c.apiClient.ReportDeploymentStatusChanged(ctx, req); err == nil {
+			return nil
+		}
+		err = fmt.Errorf("failed to report deployment status to control-plane: %v", err)
+	}
+	return err
+}
+
+func (c *controller) getEnvName(ctx context.Context, d *model.Deployment) string {
+	if d.EnvId == "" {
+		return ""
+	}
+	env, err := c.environmentLister.Get(ctx, d.EnvId)
+	if err != nil {
+		c.logger.Warn("failed to get environment", zap.String("env", d.EnvId), zap.Error(err))
+		return ""
+	}
+	return env.Name
+}
+
+// getMentionedAccounts returns the accounts to be mentioned in the notification of the given event
+// by using the notification configuration stored in the deployment metadata.
+func getMentionedAccounts(notificationMetadata string, event model.NotificationEventType) ([]string, error) {
+	if notificationMetadata == "" {
+		return []string{}, nil
+	}
+
+	var notification config.DeploymentNotification
+	if err := json.Unmarshal([]byte(notificationMetadata), &notification); err != nil {
+		return nil, fmt.Errorf("could not extract mentions config: %w", err)
+	}
+
+	return notification.FindSlackAccounts(event), nil
+}
+
 func (c *controller) cancelDeployment(ctx context.Context, d *model.Deployment, reason string) error {
 	var (
 		err error
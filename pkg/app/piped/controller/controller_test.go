@@ -13,3 +13,231 @@
 // limitations under the License.
 
 package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/pipe-cd/pipecd/pkg/app/server/service/pipedservice"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type fakeDeployWindowAPIClient struct {
+	apiClient
+	savedMetadata map[string]string
+}
+
+func (c *fakeDeployWindowAPIClient) SaveDeploymentMetadata(_ context.Context, req *pipedservice.SaveDeploymentMetadataRequest, _ ...grpc.CallOption) (*pipedservice.SaveDeploymentMetadataResponse, error) {
+	c.savedMetadata = req.Metadata
+	return &pipedservice.SaveDeploymentMetadataResponse{}, nil
+}
+
+func (c *fakeDeployWindowAPIClient) ReportDeploymentStatusChanged(_ context.Context, _ *pipedservice.ReportDeploymentStatusChangedRequest, _ ...grpc.CallOption) (*pipedservice.ReportDeploymentStatusChangedResponse, error) {
+	return &pipedservice.ReportDeploymentStatusChangedResponse{}, nil
+}
+
+type fakeCommandLister struct {
+	commandLister
+	commands []model.ReportableCommand
+}
+
+func (l *fakeCommandLister) ListDeploymentCommands() []model.ReportableCommand {
+	return l.commands
+}
+
+type fakeDeployWindowLister struct {
+	windows *config.DeployWindows
+}
+
+func (l *fakeDeployWindowLister) Get() *config.DeployWindows {
+	return l.windows
+}
+
+type fakeNotifier struct {
+	events []model.NotificationEvent
+}
+
+func (n *fakeNotifier) Notify(event model.NotificationEvent) {
+	n.events = append(n.events, event)
+}
+
+func TestCheckDeployWindows(t *testing.T) {
+	// A deny window which is always open.
+	freeze := &config.DeployWindows{
+		Deny: []config.DeployWindow{
+			{Schedule: "* * * * *", Duration: config.Duration(time.Hour), Description: "Freeze"},
+		},
+	}
+
+	testcases := []struct {
+		name              string
+		projectWindows    *config.DeployWindows
+		pipedWindows      *config.DeployWindows
+		metadata          map[string]string
+		overrideCommand   bool
+		expectedBlocked   bool
+		expectedEvents    []model.NotificationEventType
+		expectedSavedKeys []string
+	}{
+		{
+			name: "no deploy window",
+		},
+		{
+			name:            "blocked by project deploy windows",
+			projectWindows:  freeze,
+			expectedBlocked: true,
+			expectedEvents:  []model.NotificationEventType{model.NotificationEventType_EVENT_DEPLOYMENT_BLOCKED},
+		},
+		{
+			name:            "blocked by piped deploy windows",
+			pipedWindows:    freeze,
+			expectedBlocked: true,
+			expectedEvents:  []model.NotificationEventType{model.NotificationEventType_EVENT_DEPLOYMENT_BLOCKED},
+		},
+		{
+			name:           "overridden by the saved metadata",
+			projectWindows: freeze,
+			metadata: map[string]string{
+				model.MetadataKeyDeployWindowOverride: `{"commander":"admin","reason":"hotfix"}`,
+			},
+		},
+		{
+			name:              "overridden by command",
+			projectWindows:    freeze,
+			overrideCommand:   true,
+			expectedEvents:    []model.NotificationEventType{model.NotificationEventType_EVENT_DEPLOYMENT_DEPLOY_WINDOW_OVERRIDDEN},
+			expectedSavedKeys: []string{model.MetadataKeyDeployWindowOverride},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				ac       = &fakeDeployWindowAPIClient{}
+				cl       = &fakeCommandLister{}
+				n        = &fakeNotifier{}
+				reported model.CommandStatus
+			)
+			if tc.overrideCommand {
+				cl.commands = []model.ReportableCommand{
+					{
+						Command: &model.Command{
+							Id:           "command-1",
+							DeploymentId: "deployment-1",
+							Commander:    "admin",
+							OverrideDeployWindow: &model.Command_OverrideDeployWindow{
+								DeploymentId: "deployment-1",
+								Reason:       "hotfix",
+							},
+						},
+						Report: func(_ context.Context, status model.CommandStatus, _ map[string]string, _ []byte) error {
+							reported = status
+							return nil
+						},
+					},
+				}
+			}
+			c := &controller{
+				apiClient:             ac,
+				commandLister:         cl,
+				deployWindowLister:    &fakeDeployWindowLister{windows: tc.projectWindows},
+				notifier:              n,
+				pipedConfig:           &config.PipedSpec{DeployWindows: tc.pipedWindows},
+				blockedDeployments:    make(map[string]string),
+				overriddenDeployments: make(map[string]struct{}),
+				queuedDeployments:     make(map[string]string),
+				logger:                zap.NewNop(),
+			}
+			d := &model.Deployment{
+				Id:       "deployment-1",
+				Metadata: tc.metadata,
+			}
+
+			blocked, cancel, _, err := c.checkDeployWindows(context.Background(), d)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedBlocked, blocked)
+			assert.False(t, cancel)
+
+			var events []model.NotificationEventType
+			for _, e := range n.events {
+				events = append(events, e.Type)
+			}
+			assert.Equal(t, tc.expectedEvents, events)
+
+			var savedKeys []string
+			for k := range ac.savedMetadata {
+				savedKeys = append(savedKeys, k)
+			}
+			assert.Equal(t, tc.expectedSavedKeys, savedKeys)
+
+			if tc.overrideCommand {
+				assert.Equal(t, model.CommandStatus_COMMAND_SUCCEEDED, reported)
+
+				// The override is kept even before the saved metadata is delivered.
+				cl.commands = nil
+				blocked, _, _, err := c.checkDeployWindows(context.Background(), d)
+				require.NoError(t, err)
+				assert.False(t, blocked)
+			}
+		})
+	}
+}
+
+type fakeDeploymentLister struct {
+	deploymentLister
+	planneds []*model.Deployment
+	runnings []*model.Deployment
+}
+
+func (l *fakeDeploymentLister) ListPlanneds() []*model.Deployment {
+	return l.planneds
+}
+
+func (l *fakeDeploymentLister) ListRunnings() []*model.Deployment {
+	return l.runnings
+}
+
+func TestListActiveDeployments(t *testing.T) {
+	var (
+		planning  = &model.Deployment{Id: "planning", ApplicationId: "app-1"}
+		planned   = &model.Deployment{Id: "planned", ApplicationId: "app-2"}
+		running   = &model.Deployment{Id: "running", ApplicationId: "app-3"}
+		scheduled = &model.Deployment{Id: "scheduled", ApplicationId: "app-4"}
+		completed = &model.Deployment{Id: "completed", ApplicationId: "app-5"}
+		cfg       = &config.PipedDeploymentConcurrency{MaxDeployments: 4}
+	)
+	c := &controller{
+		deploymentLister: &fakeDeploymentLister{
+			planneds: []*model.Deployment{planned},
+			runnings: []*model.Deployment{running, scheduled, completed},
+		},
+		planners: map[string]*planner{
+			planning.ApplicationId: {deployment: planning},
+		},
+		schedulers: map[string]*scheduler{
+			scheduled.ApplicationId: {deployment: scheduled},
+		},
+		doneSchedulers: map[string]time.Time{
+			completed.Id: time.Now(),
+		},
+	}
+
+	actives := c.listActiveDeployments()
+	ids := make([]string, 0, len(actives))
+	for _, d := range actives {
+		ids = append(ids, d.Id)
+	}
+	assert.ElementsMatch(t, []string{"planning", "planned", "running", "scheduled"}, ids)
+
+	// The planned deployment whose scheduler has not been started yet must be counted
+	// to avoid exceeding the limits.
+	l := newConcurrencyLimiter(cfg, actives)
+	ok, _ := l.acquire(&model.Deployment{Id: "pending", ApplicationId: "app-6"})
+	assert.False(t, ok)
+}
@@ -16,8 +16,8 @@ package controller
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"path/filepath"
 	"time"
 
@@ -211,10 +211,63 @@ func (p *planner) Run(ctx context.Context) error {
 		return p.reportDeploymentFailed(ctx, fmt.Sprintf("Unable to plan the deployment (%v)", err))
 	}
 
+	// Stages are executed by following their requirements,
+	// so a pipeline containing a cyclic requirement would never be completed.
+	if err := pln.ValidateStageGraph(out.Stages); err != nil {
+		p.doneDeploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
+		return p.reportDeploymentFailed(ctx, fmt.Sprintf("Invalid pipeline (%v)", err))
+	}
+
+	// Skip the stages whose conditions are not satisfied.
+	// The conditions referring to the results of other stages are evaluated by the scheduler.
+	if err := p.skipUnsatisfiedStages(ctx, in.TargetDSP, out.Stages); err != nil {
+		p.doneDeploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
+		return p.reportDeploymentFailed(ctx, fmt.Sprintf("Unable to evaluate the conditions of stages (%v)", err))
+	}
+
 	p.doneDeploymentStatus = model.DeploymentStatus_DEPLOYMENT_PLANNED
 	return p.reportDeploymentPlanned(ctx, p.lastSuccessfulCommitHash, out)
 }
 
+// skipUnsatisfiedStages marks the stages whose conditions are evaluated to false as skipped.
+func (p *planner) skipUnsatisfiedStages(ctx context.Context, dsp deploysource.Provider, stages []*model.PipelineStage) error {
+	// The predefined stages such as the ones of quick sync have no condition.
+	configured := make([]*model.PipelineStage, 0, len(stages))
+	for _, s := range stages {
+		if s.Visible && !s.Predefined {
+			configured = append(configured, s)
+		}
+	}
+	if len(configured) == 0 {
+		return nil
+	}
+
+	ds, err := dsp.GetReadOnly(ctx, io.Discard)
+	if err != nil {
+		return err
+	}
+
+	lister := newChangedFilesLister(p.gitClient, p.deployment, p.lastSuccessfulCommitHash, p.workingDir)
+	for _, s := range configured {
+		cfg, ok := ds.GenericApplicationConfig.GetStage(s.Index)
+		if !ok || cfg.When == "" || usesStageResults(cfg) {
+			continue
+		}
+		ok, err := evaluateStageCondition(cfg, p.deployment, nil, lister.Func(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to evaluate the condition of stage %s: %w", s.Id, err)
+		}
+		if ok {
+			continue
+		}
+		p.logger.Info("stage will be skipped since its condition was not satisfied", zap.String("stage-id", s.Id))
+		s.Status = model.StageStatus_STAGE_SKIPPED
+		s.StatusReason = makeStageSkippedReason(cfg)
+		s.CompletedAt = p.nowFunc().Unix()
+	}
+	return nil
+}
+
 func (p *planner) reportDeploymentPlanned(ctx context.Context, runningCommitHash string, out pln.Output) error {
 	var (
 		err   error
@@ -354,15 +407,6 @@ func (p *planner) reportDeploymentCancelled(ctx context.Context, commander, reas
 }
 
 func (p *planner) getMentionedAccounts(event model.NotificationEventType) ([]string, error) {
-	n, ok := p.metadataStore.Shared().Get(model.MetadataKeyDeploymentNotification)
-	if !ok {
-		return []string{}, nil
-	}
-
-	var notification config.DeploymentNotification
-	if err := json.Unmarshal([]byte(n), &notification); err != nil {
-		return nil, fmt.Errorf("could not extract mentions config: %w", err)
-	}
-
-	return notification.FindSlackAccounts(event), nil
+	n, _ := p.metadataStore.Shared().Get(model.MetadataKeyDeploymentNotification)
+	return getMentionedAccounts(n, event)
 }
@@ -16,10 +16,10 @@ package controller
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"go.uber.org/atomic"
@@ -37,6 +37,9 @@ import (
 	"github.com/pipe-cd/pipecd/pkg/model"
 )
 
+// The interval to check whether a RETRY_STAGE or SKIP_STAGE command was issued for the failed stages.
+const stageRecoveryCheckInterval = 10 * time.Second
+
 // scheduler is a dedicated object for a specific deployment of a single application.
 type scheduler struct {
 	// Readonly deployment model.
@@ -60,13 +63,16 @@ type scheduler struct {
 
 	targetDSP  deploysource.Provider
 	runningDSP deploysource.Provider
+	// Used to evaluate the conditions of stages.
+	changedFilesLister *changedFilesLister
 
-	// Current status of each stages.
-	// We stores their current statuses into this field
+	// Current status and the number of retries of each stages.
+	// We stores their current statuses into these fields
 	// because the deployment model is readonly to avoid data race.
-	// We may need a mutex for this field in the future
-	// when the stages can be executed concurrently.
+	// The mutex is required since the stages can be executed concurrently.
 	stageStatuses            map[string]model.StageStatus
+	stageRetriedCounts       map[string]int32
+	stageStatusesMu          sync.Mutex
 	genericApplicationConfig config.GenericApplicationSpec
 
 	done                 atomic.Bool
@@ -130,8 +136,10 @@ func newScheduler(
 
 	// Initialize the map of current status of all stages.
 	s.stageStatuses = make(map[string]model.StageStatus, len(d.Stages))
+	s.stageRetriedCounts = make(map[string]int32, len(d.Stages))
 	for _, stage := range d.Stages {
 		s.stageStatuses[stage.Id] = stage.Status
+		s.stageRetriedCounts[stage.Id] = stage.RetriedCount
 	}
 
 	return s
@@ -230,6 +238,8 @@ func (s *scheduler) Run(ctx context.Context) error {
 		s.secretDecrypter,
 	)
 
+	s.changedFilesLister = newChangedFilesLister(s.gitClient, s.deployment, s.deployment.RunningCommitHash, s.workingDir)
+
 	if s.deployment.RunningCommitHash != "" {
 		s.runningDSP = deploysource.NewProvider(
 			filepath.Join(s.workingDir, "running-deploysource"),
@@ -261,96 +271,281 @@ func (s *scheduler) Run(ctx context.Context) error {
 	timer := time.NewTimer(s.genericApplicationConfig.Timeout.Duration())
 	defer timer.Stop()
 
-	// Iterate all the stages and execute the uncompleted ones.
-	for i, ps := range s.deployment.Stages {
-		lastStage = s.deployment.Stages[i]
-
-		if ps.Status == model.StageStatus_STAGE_SUCCESS {
-			continue
-		}
+	// Collect the stages to be executed.
+	stages := make([]*model.PipelineStage, 0, len(s.deployment.Stages))
+	for _, ps := range s.deployment.Stages {
 		if !ps.Visible || ps.Name == model.StageRollback.String() {
 			continue
 		}
+		stages = append(stages, ps)
+	}
 
-		// This stage is already completed by a previous scheduler.
+	// The failed stages which were still in the rollback grace period
+	// when the previous scheduler stopped can be retried or skipped yet.
+	gracePeriod := s.genericApplicationConfig.RollbackGracePeriod.Duration()
+	resumedAwaiting := findAwaitingStages(stages, gracePeriod, s.nowFunc())
+
+	// Check whether the deployment was already completed by a previous scheduler.
+	for _, ps := range stages {
 		if ps.Status == model.StageStatus_STAGE_CANCELLED {
+			lastStage = ps
 			deploymentStatus = model.DeploymentStatus_DEPLOYMENT_CANCELLED
 			statusReason = fmt.Sprintf("Deployment was cancelled while executing stage %s", ps.Id)
 			break
 		}
 		if ps.Status == model.StageStatus_STAGE_FAILURE {
+			if _, ok := resumedAwaiting[ps.Id]; ok {
+				continue
+			}
+			lastStage = ps
 			deploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
 			statusReason = fmt.Sprintf("Failed while executing stage %s", ps.Id)
 			break
 		}
+	}
 
+	if deploymentStatus == model.DeploymentStatus_DEPLOYMENT_SUCCESS {
 		var (
-			result       model.StageStatus
-			sig, handler = executor.NewStopSignal()
-			doneCh       = make(chan struct{})
+			terminated bool
+			// The stages which are being executed.
+			running = make(map[string]executor.StopSignalHandler)
+			// The running stages which have already been sent a stop signal.
+			// A stop signal can be sent only once to each stage.
+			stopping = make(map[string]struct{})
+			// The current status of all stages.
+			statuses = make(map[string]model.StageStatus, len(stages))
+			resultCh = make(chan stageResult, len(stages))
+			// Those channels are set to nil once they were received
+			// to avoid handling the same signal multiple times.
+			doneCh      = ctx.Done()
+			timerCh     = timer.C
+			cancelledCh = s.cancelledCh
+			// The failed stages which are waiting for a RETRY_STAGE or SKIP_STAGE command
+			// until the rollback grace period is over.
+			awaiting   = resumedAwaiting
+			recoveryCh <-chan time.Time
 		)
+		for _, ps := range stages {
+			statuses[ps.Id] = ps.Status
+		}
+		if gracePeriod > 0 {
+			ticker := time.NewTicker(stageRecoveryCheckInterval)
+			defer ticker.Stop()
+			recoveryCh = ticker.C
+		}
 
-		go func() {
-			result = s.executeStage(sig, *ps, func(in executor.Input) (executor.Executor, bool) {
-				return s.executorRegistry.Executor(model.Stage(ps.Name), in)
-			})
-			close(doneCh)
-		}()
-
-		select {
-		case <-ctx.Done():
-			handler.Terminate()
-			<-doneCh
-
-		case <-timer.C:
-			handler.Timeout()
-			<-doneCh
-
-		case cmd := <-s.cancelledCh:
-			if cmd != nil {
-				cancelCommand = cmd
-				cancelCommander = cmd.Commander
-				handler.Cancel()
-				<-doneCh
+		// No new stage will be started after the deployment was completed or stopped.
+		stopped := func() bool {
+			return terminated || timerCh == nil || cancelCommand != nil || deploymentStatus != model.DeploymentStatus_DEPLOYMENT_SUCCESS
+		}
+		stopRunningStages := func(stop func(executor.StopSignalHandler)) {
+			for id, handler := range running {
+				if _, ok := stopping[id]; ok {
+					continue
+				}
+				stopping[id] = struct{}{}
+				stop(handler)
 			}
-
-		case <-doneCh:
-			break
 		}
-
-		// If all operations of the stage were completed successfully
-		// handle the next stage.
-		if result == model.StageStatus_STAGE_SUCCESS {
-			continue
+		// Give up waiting for the decision on the failed stages.
+		giveUpAwaitingStages := func() {
+			for id, a := range awaiting {
+				delete(awaiting, id)
+				if deploymentStatus != model.DeploymentStatus_DEPLOYMENT_SUCCESS {
+					continue
+				}
+				lastStage = a.stage
+				if cancelCommand != nil {
+					deploymentStatus = model.DeploymentStatus_DEPLOYMENT_CANCELLED
+					statusReason = fmt.Sprintf("Cancelled by %s while waiting for the failed stage %s to be retried or skipped", cancelCommander, id)
+					continue
+				}
+				deploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
+				statusReason = a.reason
+			}
 		}
 
-		// The deployment was cancelled by a web user.
-		if result == model.StageStatus_STAGE_CANCELLED {
-			deploymentStatus = model.DeploymentStatus_DEPLOYMENT_CANCELLED
-			statusReason = fmt.Sprintf("Cancelled by %s while executing stage %s", cancelCommander, ps.Id)
-			break
-		}
+		for {
+			// Start all stages whose required stages have been completed successfully.
+			// Independent stages are executed concurrently.
+			if !stopped() {
+				for _, ps := range findReadyStages(stages, statuses, running) {
+					ps := ps
+					sig, handler := executor.NewStopSignal()
+					running[ps.Id] = handler
+
+					go func() {
+						result, signal := s.executeStage(sig, *ps, func(in executor.Input) (executor.Executor, bool) {
+							return s.executorRegistry.Executor(model.Stage(ps.Name), in)
+						})
+						resultCh <- stageResult{
+							stage:  ps,
+							status: result,
+							signal: signal,
+						}
+					}()
+				}
+			}
 
-		if result == model.StageStatus_STAGE_FAILURE {
-			deploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
-			// The stage was failed because of timing out.
-			if sig.Signal() == executor.StopSignalTimeout {
-				statusReason = fmt.Sprintf("Timed out while executing stage %s", ps.Id)
-			} else {
-				statusReason = fmt.Sprintf("Failed while executing stage %s", ps.Id)
+			// All stages have been completed or no more stage can be started.
+			if len(running) == 0 && len(awaiting) == 0 {
+				break
+			}
+
+			select {
+			case <-doneCh:
+				doneCh = nil
+				terminated = true
+				// The failed stages will be handled by the next scheduler.
+				awaiting = make(map[string]awaitingStage)
+				stopRunningStages(executor.StopSignalHandler.Terminate)
+
+			case <-timerCh:
+				timerCh = nil
+				giveUpAwaitingStages()
+				stopRunningStages(executor.StopSignalHandler.Timeout)
+
+			case cmd := <-cancelledCh:
+				cancelledCh = nil
+				if cmd != nil {
+					cancelCommand = cmd
+					cancelCommander = cmd.Commander
+					giveUpAwaitingStages()
+					stopRunningStages(executor.StopSignalHandler.Cancel)
+				}
+
+			case <-recoveryCh:
+				for id, a := range awaiting {
+					cmd, ok := s.findStageRecoveryCommand(id)
+					if !ok {
+						if s.nowFunc().Before(a.deadline) {
+							continue
+						}
+						delete(awaiting, id)
+						if deploymentStatus == model.DeploymentStatus_DEPLOYMENT_SUCCESS {
+							lastStage = a.stage
+							deploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
+							statusReason = a.reason
+						}
+						continue
+					}
+
+					status, err := s.recoverStage(ctx, a.stage, cmd)
+					if err != nil {
+						s.logger.Error("failed to handle stage recovery command", zap.String("stage-id", id), zap.Error(err))
+						if err := cmd.Report(ctx, model.CommandStatus_COMMAND_FAILED, nil, []byte(err.Error())); err != nil {
+							s.logger.Error("failed to report command status", zap.Error(err))
+						}
+						continue
+					}
+					delete(awaiting, id)
+					statuses[id] = status
+
+					if err := cmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil, nil); err != nil {
+						s.logger.Error("failed to report command status", zap.Error(err))
+					}
+				}
+
+				// Stop the stages running concurrently since the deployment can not be succeeded anymore.
+				if deploymentStatus != model.DeploymentStatus_DEPLOYMENT_SUCCESS {
+					stopRunningStages(executor.StopSignalHandler.Cancel)
+				}
+
+			case r := <-resultCh:
+				delete(running, r.stage.Id)
+				delete(stopping, r.stage.Id)
+				statuses[r.stage.Id] = r.status
+
+				// If all operations of the stage were completed successfully
+				// or the stage was skipped because of its condition
+				// handle the next stages.
+				if isSatisfiedStage(r.status) {
+					break
+				}
+
+				// The deployment was already decided to be completed by another stage.
+				// We just wait for the remaining stages to be stopped.
+				if deploymentStatus != model.DeploymentStatus_DEPLOYMENT_SUCCESS {
+					break
+				}
+
+				switch {
+				// The deployment was cancelled by a web user.
+				case r.status == model.StageStatus_STAGE_CANCELLED:
+					lastStage = r.stage
+					deploymentStatus = model.DeploymentStatus_DEPLOYMENT_CANCELLED
+					statusReason = fmt.Sprintf("Cancelled by %s while executing stage %s", cancelCommander, r.stage.Id)
+
+				case r.status == model.StageStatus_STAGE_FAILURE:
+					reason := fmt.Sprintf("Failed while executing stage %s", r.stage.Id)
+					// The stage was failed because of timing out.
+					if r.signal == executor.StopSignalTimeout {
+						reason = fmt.Sprintf("Timed out while executing stage %s", r.stage.Id)
+					}
+
+					// Wait for a human to decide whether the failed stage should be retried or skipped
+					// before completing the deployment and starting the rollback.
+					if gracePeriod > 0 && !stopped() {
+						awaiting[r.stage.Id] = awaitingStage{
+							stage:    r.stage,
+							reason:   reason,
+							deadline: s.nowFunc().Add(gracePeriod),
+						}
+						desc := fmt.Sprintf("Stage %s was failed, waiting %v for it to be retried or skipped", r.stage.Id, gracePeriod)
+						if err := s.reportDeploymentStatusChanged(ctx, model.DeploymentStatus_DEPLOYMENT_RUNNING, desc); err != nil {
+							s.logger.Error("failed to report deployment status", zap.Error(err))
+						}
+						break
+					}
+
+					lastStage = r.stage
+					deploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
+					statusReason = reason
+
+				// The deployment was cancelled at the previous stage and this stage was terminated before run.
+				case r.status == model.StageStatus_STAGE_NOT_STARTED_YET && cancelCommand != nil:
+					lastStage = r.stage
+					deploymentStatus = model.DeploymentStatus_DEPLOYMENT_CANCELLED
+					statusReason = fmt.Sprintf("Cancelled by %s while executing the previous stage of %s", cancelCommander, r.stage.Id)
+
+				default:
+					terminated = true
+				}
+
+				// Stop the stages running concurrently since the deployment can not be succeeded anymore.
+				if deploymentStatus != model.DeploymentStatus_DEPLOYMENT_SUCCESS {
+					stopRunningStages(executor.StopSignalHandler.Cancel)
+				}
 			}
-			break
 		}
 
-		// The deployment was cancelled at the previous stage and this stage was terminated before run.
-		if result == model.StageStatus_STAGE_NOT_STARTED_YET && cancelCommand != nil {
-			deploymentStatus = model.DeploymentStatus_DEPLOYMENT_CANCELLED
-			statusReason = fmt.Sprintf("Cancelled by %s while executing the previous stage of %s", cancelCommander, ps.Id)
-			break
+		if terminated {
+			s.logger.Info("stop scheduler because of temination signal")
+			return nil
 		}
 
-		s.logger.Info("stop scheduler because of temination signal", zap.String("stage-id", ps.Id))
-		return nil
+		// All stages must be completed successfully when the deployment was succeeded.
+		// Otherwise, some stages could not be started because of their requirements.
+		if deploymentStatus == model.DeploymentStatus_DEPLOYMENT_SUCCESS {
+			for _, ps := range stages {
+				if isSatisfiedStage(statuses[ps.Id]) {
+					continue
+				}
+				lastStage = ps
+				switch {
+				case cancelCommand != nil:
+					deploymentStatus = model.DeploymentStatus_DEPLOYMENT_CANCELLED
+					statusReason = fmt.Sprintf("Cancelled by %s while executing the previous stage of %s", cancelCommander, ps.Id)
+				case timerCh == nil:
+					deploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
+					statusReason = fmt.Sprintf("Timed out before executing stage %s", ps.Id)
+				default:
+					deploymentStatus = model.DeploymentStatus_DEPLOYMENT_FAILURE
+					statusReason = fmt.Sprintf("Unable to execute stage %s because its required stages were not completed", ps.Id)
+				}
+				break
+			}
+		}
 	}
 
 	// When the deployment has completed but not successful,
@@ -405,8 +600,77 @@ func (s *scheduler) Run(ctx context.Context) error {
 	return nil
 }
 
+type stageResult struct {
+	stage  *model.PipelineStage
+	status model.StageStatus
+	signal executor.StopSignalType
+}
+
+type awaitingStage struct {
+	stage    *model.PipelineStage
+	reason   string
+	deadline time.Time
+}
+
+// findAwaitingStages returns the failed stages whose rollback grace period,
+// counted from the time they were completed, has not been over yet.
+func findAwaitingStages(stages []*model.PipelineStage, gracePeriod time.Duration, now time.Time) map[string]awaitingStage {
+	awaiting := make(map[string]awaitingStage)
+	if gracePeriod <= 0 {
+		return awaiting
+	}
+	for _, ps := range stages {
+		if ps.Status != model.StageStatus_STAGE_FAILURE {
+			continue
+		}
+		deadline := time.Unix(ps.CompletedAt, 0).Add(gracePeriod)
+		if !now.Before(deadline) {
+			continue
+		}
+		awaiting[ps.Id] = awaitingStage{
+			stage:    ps,
+			reason:   fmt.Sprintf("Failed while executing stage %s", ps.Id),
+			deadline: deadline,
+		}
+	}
+	return awaiting
+}
+
+// isSatisfiedStage reports whether the stages requiring a stage in the given status can be started.
+func isSatisfiedStage(status model.StageStatus) bool {
+	return status == model.StageStatus_STAGE_SUCCESS || status == model.StageStatus_STAGE_SKIPPED
+}
+
+// findReadyStages returns the uncompleted stages which are not running
+// and all of whose required stages have been completed successfully or skipped.
+// The required stages not included in the given statuses (e.g. invisible ones) are considered as satisfied.
+func findReadyStages(stages []*model.PipelineStage, statuses map[string]model.StageStatus, running map[string]executor.StopSignalHandler) []*model.PipelineStage {
+	ready := make([]*model.PipelineStage, 0)
+	for _, ps := range stages {
+		if _, ok := running[ps.Id]; ok {
+			continue
+		}
+		if model.IsCompletedStage(statuses[ps.Id]) {
+			continue
+		}
+		satisfied := true
+		for _, r := range ps.Requires {
+			if status, ok := statuses[r]; ok && !isSatisfiedStage(status) {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			ready = append(ready, ps)
+		}
+	}
+	return ready
+}
+
 // executeStage finds the executor for the given stage and execute.
-func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage, executorFactory func(executor.Input) (executor.Executor, bool)) (finalStatus model.StageStatus) {
+// The stage is executed again while it is failing and its retry configuration allows.
+// The returned signal is the stop signal received by the last attempt.
+func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage, executorFactory func(executor.Input) (executor.Executor, bool)) (finalStatus model.StageStatus, finalSignal executor.StopSignalType) {
 	var (
 		ctx            = sig.Context()
 		originalStatus = ps.Status
@@ -421,10 +685,30 @@ func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage
 		lp.Complete(time.Minute)
 	}()
 
+	// Skip the stage without executing when its condition is not satisfied.
+	// The condition is evaluated only before the stage was started for the first time.
+	if ps.Status == model.StageStatus_STAGE_NOT_STARTED_YET {
+		reason, err := s.checkStageCondition(ctx, &ps)
+		if err != nil {
+			lp.Errorf("Unable to evaluate the condition of the stage (%v)", err)
+			if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, ps.Requires); err != nil {
+				s.logger.Error("failed to report stage status", zap.Error(err))
+			}
+			return model.StageStatus_STAGE_FAILURE, sig.Signal()
+		}
+		if reason != "" {
+			lp.Info(reason)
+			if err := s.reportStageStatusWithReason(ctx, ps.Id, model.StageStatus_STAGE_SKIPPED, reason, ps.Requires); err != nil {
+				return model.StageStatus_STAGE_FAILURE, sig.Signal()
+			}
+			return model.StageStatus_STAGE_SKIPPED, sig.Signal()
+		}
+	}
+
 	// Update stage status to RUNNING if needed.
 	if model.CanUpdateStageStatus(ps.Status, model.StageStatus_STAGE_RUNNING) {
 		if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_RUNNING, ps.Requires); err != nil {
-			return model.StageStatus_STAGE_FAILURE
+			return model.StageStatus_STAGE_FAILURE, sig.Signal()
 		}
 		originalStatus = model.StageStatus_STAGE_RUNNING
 	}
@@ -435,31 +719,24 @@ func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage
 		if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, ps.Requires); err != nil {
 			s.logger.Error("failed to report stage status", zap.Error(err))
 		}
-		return model.StageStatus_STAGE_FAILURE
+		return model.StageStatus_STAGE_FAILURE, sig.Signal()
 	}
 
 	// Load the stage configuration.
-	var stageConfig config.PipelineStage
-	var stageConfigFound bool
-	if ps.Predefined {
-		stageConfig, stageConfigFound = pln.GetPredefinedStage(ps.Id)
-	} else {
-		stageConfig, stageConfigFound = s.genericApplicationConfig.GetStage(ps.Index)
-	}
-
+	stageConfig, stageConfigFound := s.getStageConfig(&ps)
 	if !stageConfigFound {
 		lp.Error("Unable to find the stage configuration")
 		if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, ps.Requires); err != nil {
 			s.logger.Error("failed to report stage status", zap.Error(err))
 		}
-		return model.StageStatus_STAGE_FAILURE
+		return model.StageStatus_STAGE_FAILURE, sig.Signal()
 	}
 
 	app, ok := s.applicationLister.Get(s.deployment.ApplicationId)
 	if !ok {
 		lp.Errorf("Application %s for this deployment was not found (Maybe it was disabled).", s.deployment.ApplicationId)
 		s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, ps.Requires)
-		return model.StageStatus_STAGE_FAILURE
+		return model.StageStatus_STAGE_FAILURE, sig.Signal()
 	}
 
 	cmdLister := stageCommandLister{
@@ -496,17 +773,66 @@ func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage
 		EnvName:               s.envName,
 	}
 
-	// Find the executor for this stage.
-	ex, ok := executorFactory(input)
-	if !ok {
-		err := fmt.Errorf("no registered executor for stage %s", ps.Name)
-		lp.Error(err.Error())
-		s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, ps.Requires)
-		return model.StageStatus_STAGE_FAILURE
-	}
+	var (
+		status       model.StageStatus
+		signal       executor.StopSignalType
+		retriedCount = int(s.getStageRetriedCount(ps.Id))
+	)
+	for {
+		switch {
+		case retriedCount > stageConfig.Retries:
+			// The stage was retried by a RETRY_STAGE command after all its attempts were failed.
+			lp.Infof("Start retrying the stage by command (attempt %d)", retriedCount+1)
+		case retriedCount > 0:
+			lp.Infof("Start retrying the stage (attempt %d/%d)", retriedCount+1, stageConfig.Retries+1)
+		}
 
-	// Start running executor.
-	status := ex.Execute(sig)
+		// Find the executor for this stage.
+		// A new executor is created for each attempt since it may hold the state of the previous one.
+		ex, ok := executorFactory(input)
+		if !ok {
+			err := fmt.Errorf("no registered executor for stage %s", ps.Name)
+			lp.Error(err.Error())
+			s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_FAILURE, ps.Requires)
+			return model.StageStatus_STAGE_FAILURE, sig.Signal()
+		}
+
+		// Start running executor.
+		status, signal = runExecutor(sig, ex, stageTimeout(stageConfig))
+		if status != model.StageStatus_STAGE_FAILURE || sig.Signal() != executor.StopSignalNone {
+			break
+		}
+
+		timedOut := signal == executor.StopSignalTimeout
+		if timedOut {
+			lp.Errorf("The stage was timed out after %v", stageTimeout(stageConfig))
+		}
+		if !stageConfig.ShouldRetry(retriedCount, timedOut) {
+			break
+		}
+
+		interval := stageConfig.RetryInterval.Duration()
+		lp.Infof("Attempt %d/%d of the stage was failed, it will be retried in %v", retriedCount+1, stageConfig.Retries+1, interval)
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+		}
+		// The stage was stopped while waiting for the next attempt.
+		if sig.Signal() != executor.StopSignalNone {
+			signal = sig.Signal()
+			if signal == executor.StopSignalCancel {
+				status = model.StageStatus_STAGE_CANCELLED
+			}
+			break
+		}
+
+		retriedCount++
+		s.setStageRetriedCount(ps.Id, int32(retriedCount))
+		if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_RUNNING, ps.Requires); err != nil {
+			s.logger.Error("failed to report stage status", zap.Error(err))
+		}
+	}
 
 	// Commit deployment state status in the following cases:
 	// - Apply state successfully.
@@ -517,15 +843,141 @@ func (s *scheduler) executeStage(sig executor.StopSignal, ps model.PipelineStage
 		(status == model.StageStatus_STAGE_FAILURE && !sig.Terminated()) {
 
 		s.reportStageStatus(ctx, ps.Id, status, ps.Requires)
-		return status
+		return status, signal
 	}
 
 	// In case piped process got killed (Terminated signal occurred)
 	// the original state status will be returned.
-	return originalStatus
+	return originalStatus, signal
+}
+
+func (s *scheduler) getStageConfig(ps *model.PipelineStage) (config.PipelineStage, bool) {
+	if ps.Predefined {
+		return pln.GetPredefinedStage(ps.Id)
+	}
+	return s.genericApplicationConfig.GetStage(ps.Index)
+}
+
+// checkStageCondition evaluates the condition of the given stage
+// with the current statuses of the other stages.
+// The returned reason is not empty when the stage should be skipped.
+func (s *scheduler) checkStageCondition(ctx context.Context, ps *model.PipelineStage) (string, error) {
+	cfg, ok := s.getStageConfig(ps)
+	if !ok || cfg.When == "" {
+		return "", nil
+	}
+
+	s.stageStatusesMu.Lock()
+	statuses := make(map[string]model.StageStatus, len(s.stageStatuses))
+	for id, status := range s.stageStatuses {
+		statuses[id] = status
+	}
+	s.stageStatusesMu.Unlock()
+
+	var changedFiles func() ([]string, error)
+	if s.changedFilesLister != nil {
+		changedFiles = s.changedFilesLister.Func(ctx)
+	}
+	ok, err := evaluateStageCondition(cfg, s.deployment, statuses, changedFiles)
+	if err != nil || ok {
+		return "", err
+	}
+	return makeStageSkippedReason(cfg), nil
+}
+
+// findStageRecoveryCommand returns the first RETRY_STAGE or SKIP_STAGE command issued for the given stage.
+func (s *scheduler) findStageRecoveryCommand(stageID string) (model.ReportableCommand, bool) {
+	for _, cmd := range s.commandLister.ListStageCommands(s.deployment.Id, stageID) {
+		if cmd.GetRetryStage() != nil || cmd.GetSkipStage() != nil {
+			return cmd, true
+		}
+	}
+	return model.ReportableCommand{}, false
+}
+
+// recoverStage handles the given RETRY_STAGE or SKIP_STAGE command for the failed stage.
+// The returned status is the new status of the stage to be used for deciding the next stages.
+func (s *scheduler) recoverStage(ctx context.Context, ps *model.PipelineStage, cmd model.ReportableCommand) (model.StageStatus, error) {
+	if cmd.GetSkipStage() != nil {
+		cfg, ok := s.getStageConfig(ps)
+		if !ok {
+			return model.StageStatus_STAGE_FAILURE, fmt.Errorf("unable to find the configuration of stage %s", ps.Id)
+		}
+		if !cfg.Skippable {
+			return model.StageStatus_STAGE_FAILURE, fmt.Errorf("stage %s can not be skipped because it is not configured as skippable", ps.Id)
+		}
+		if err := s.reportStageStatus(ctx, ps.Id, model.StageStatus_STAGE_SKIPPED, ps.Requires); err != nil {
+			return model.StageStatus_STAGE_FAILURE, err
+		}
+		desc := fmt.Sprintf("Stage %s was skipped by %s", ps.Id, cmd.Commander)
+		if err := s.reportDeploymentStatusChanged(ctx, model.DeploymentStatus_DEPLOYMENT_RUNNING, desc); err != nil {
+			s.logger.Error("failed to report deployment status", zap.Error(err))
+		}
+		return model.StageStatus_STAGE_SKIPPED, nil
+	}
+
+	// The stage will be started again as a new attempt.
+	s.setStageRetriedCount(ps.Id, s.getStageRetriedCount(ps.Id)+1)
+	desc := fmt.Sprintf("Stage %s was retried by %s", ps.Id, cmd.Commander)
+	if err := s.reportDeploymentStatusChanged(ctx, model.DeploymentStatus_DEPLOYMENT_RUNNING, desc); err != nil {
+		s.logger.Error("failed to report deployment status", zap.Error(err))
+	}
+	return model.StageStatus_STAGE_NOT_STARTED_YET, nil
+}
+
+// stageTimeout returns the timeout enforced on each attempt of the given stage.
+// Zero means no timeout is enforced, so the stages without an explicitly configured timeout
+// can run as long as they did before the stage timeout was enforced.
+// WAIT_APPROVAL stage is never timed out by this since it is limited by its own timeout option.
+func stageTimeout(cfg config.PipelineStage) time.Duration {
+	if cfg.Name == model.StageWaitApproval {
+		return 0
+	}
+	return cfg.Timeout.Duration()
+}
+
+// runExecutor runs the given executor with a dedicated stop signal for this attempt.
+// The signal is stopped when the given timeout was reached or the given stage signal was stopped.
+func runExecutor(sig executor.StopSignal, ex executor.Executor, timeout time.Duration) (model.StageStatus, executor.StopSignalType) {
+	var (
+		attemptSig, handler = executor.NewStopSignal()
+		doneCh              = make(chan struct{})
+		timeoutCh           <-chan time.Time
+	)
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
+	go func() {
+		select {
+		case <-sig.Context().Done():
+			switch sig.Signal() {
+			case executor.StopSignalCancel:
+				handler.Cancel()
+			case executor.StopSignalTimeout:
+				handler.Timeout()
+			case executor.StopSignalTerminate:
+				handler.Terminate()
+			}
+		case <-timeoutCh:
+			handler.Timeout()
+		case <-doneCh:
+		}
+	}()
+
+	status := ex.Execute(attemptSig)
+	close(doneCh)
+
+	return status, attemptSig.Signal()
 }
 
 func (s *scheduler) reportStageStatus(ctx context.Context, stageID string, status model.StageStatus, requires []string) error {
+	return s.reportStageStatusWithReason(ctx, stageID, status, "", requires)
+}
+
+func (s *scheduler) reportStageStatusWithReason(ctx context.Context, stageID string, status model.StageStatus, reason string, requires []string) error {
 	var (
 		err error
 		now = s.nowFunc()
@@ -533,15 +985,19 @@ func (s *scheduler) reportStageStatus(ctx context.Context, stageID string, statu
 			DeploymentId: s.deployment.Id,
 			StageId:      stageID,
 			Status:       status,
+			StatusReason: reason,
 			Requires:     requires,
 			Visible:      true,
+			RetriedCount: s.getStageRetriedCount(stageID),
 			CompletedAt:  now.Unix(),
 		}
 		retry = pipedservice.NewRetry(10)
 	)
 
 	// Update stage status at local.
+	s.stageStatusesMu.Lock()
 	s.stageStatuses[stageID] = status
+	s.stageStatusesMu.Unlock()
 
 	// Update stage status on the remote.
 	for retry.WaitNext(ctx) {
@@ -555,6 +1011,18 @@ func (s *scheduler) reportStageStatus(ctx context.Context, stageID string, statu
 	return err
 }
 
+func (s *scheduler) getStageRetriedCount(stageID string) int32 {
+	s.stageStatusesMu.Lock()
+	defer s.stageStatusesMu.Unlock()
+	return s.stageRetriedCounts[stageID]
+}
+
+func (s *scheduler) setStageRetriedCount(stageID string, count int32) {
+	s.stageStatusesMu.Lock()
+	defer s.stageStatusesMu.Unlock()
+	s.stageRetriedCounts[stageID] = count
+}
+
 func (s *scheduler) reportDeploymentStatusChanged(ctx context.Context, status model.DeploymentStatus, desc string) error {
 	var (
 		err   error
@@ -655,17 +1123,8 @@ func (s *scheduler) reportDeploymentCompleted(ctx context.Context, status model.
 }
 
 func (s *scheduler) getMentionedAccounts(event model.NotificationEventType) ([]string, error) {
-	n, ok := s.metadataStore.Shared().Get(model.MetadataKeyDeploymentNotification)
-	if !ok {
-		return []string{}, nil
-	}
-
-	var notification config.DeploymentNotification
-	if err := json.Unmarshal([]byte(n), &notification); err != nil {
-		return nil, fmt.Errorf("could not extract mentions config: %w", err)
-	}
-
-	return notification.FindSlackAccounts(event), nil
+	n, _ := s.metadataStore.Shared().Get(model.MetadataKeyDeploymentNotification)
+	return getMentionedAccounts(n, event)
 }
 
 func (s *scheduler) reportMostRecentlySuccessfulDeployment(ctx context.Context) error {
@@ -0,0 +1,261 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+func TestFindReadyStages(t *testing.T) {
+	// a -> (b, c) -> d
+	stages := []*model.PipelineStage{
+		{Id: "a"},
+		{Id: "b", Requires: []string{"a"}},
+		{Id: "c", Requires: []string{"a"}},
+		{Id: "d", Requires: []string{"b", "c"}},
+	}
+
+	testcases := []struct {
+		name     string
+		statuses map[string]model.StageStatus
+		running  []string
+		want     []string
+	}{
+		{
+			name: "only the first stage is ready",
+			statuses: map[string]model.StageStatus{
+				"a": model.StageStatus_STAGE_NOT_STARTED_YET,
+				"b": model.StageStatus_STAGE_NOT_STARTED_YET,
+				"c": model.StageStatus_STAGE_NOT_STARTED_YET,
+				"d": model.StageStatus_STAGE_NOT_STARTED_YET,
+			},
+			want: []string{"a"},
+		},
+		{
+			name: "independent stages are ready at the same time",
+			statuses: map[string]model.StageStatus{
+				"a": model.StageStatus_STAGE_SUCCESS,
+				"b": model.StageStatus_STAGE_NOT_STARTED_YET,
+				"c": model.StageStatus_STAGE_NOT_STARTED_YET,
+				"d": model.StageStatus_STAGE_NOT_STARTED_YET,
+			},
+			want: []string{"b", "c"},
+		},
+		{
+			name: "running stages are not returned",
+			statuses: map[string]model.StageStatus{
+				"a": model.StageStatus_STAGE_SUCCESS,
+				"b": model.StageStatus_STAGE_RUNNING,
+				"c": model.StageStatus_STAGE_NOT_STARTED_YET,
+				"d": model.StageStatus_STAGE_NOT_STARTED_YET,
+			},
+			running: []string{"b"},
+			want:    []string{"c"},
+		},
+		{
+			name: "running stage from the previous scheduler is resumed",
+			statuses: map[string]model.StageStatus{
+				"a": model.StageStatus_STAGE_SUCCESS,
+				"b": model.StageStatus_STAGE_RUNNING,
+				"c": model.StageStatus_STAGE_SUCCESS,
+				"d": model.StageStatus_STAGE_NOT_STARTED_YET,
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "stage is not ready until all required stages succeeded",
+			statuses: map[string]model.StageStatus{
+				"a": model.StageStatus_STAGE_SUCCESS,
+				"b": model.StageStatus_STAGE_SUCCESS,
+				"c": model.StageStatus_STAGE_FAILURE,
+				"d": model.StageStatus_STAGE_NOT_STARTED_YET,
+			},
+			want: []string{},
+		},
+		{
+			name: "skipped stage satisfies the requirement",
+			statuses: map[string]model.StageStatus{
+				"a": model.StageStatus_STAGE_SUCCESS,
+				"b": model.StageStatus_STAGE_SUCCESS,
+				"c": model.StageStatus_STAGE_SKIPPED,
+				"d": model.StageStatus_STAGE_NOT_STARTED_YET,
+			},
+			want: []string{"d"},
+		},
+		{
+			name: "last stage is ready",
+			statuses: map[string]model.StageStatus{
+				"a": model.StageStatus_STAGE_SUCCESS,
+				"b": model.StageStatus_STAGE_SUCCESS,
+				"c": model.StageStatus_STAGE_SUCCESS,
+				"d": model.StageStatus_STAGE_NOT_STARTED_YET,
+			},
+			want: []string{"d"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			running := make(map[string]executor.StopSignalHandler, len(tc.running))
+			for _, id := range tc.running {
+				_, handler := executor.NewStopSignal()
+				running[id] = handler
+			}
+			got := findReadyStages(stages, tc.statuses, running)
+			ids := make([]string, 0, len(got))
+			for _, s := range got {
+				ids = append(ids, s.Id)
+			}
+			assert.Equal(t, tc.want, ids)
+		})
+	}
+}
+
+type fakeExecutor struct {
+	status model.StageStatus
+	delay  time.Duration
+}
+
+func (e *fakeExecutor) Execute(sig executor.StopSignal) model.StageStatus {
+	select {
+	case <-time.After(e.delay):
+		return e.status
+	case s := <-sig.Ch():
+		switch s {
+		case executor.StopSignalCancel:
+			return model.StageStatus_STAGE_CANCELLED
+		case executor.StopSignalTerminate:
+			return model.StageStatus_STAGE_RUNNING
+		default:
+			return model.StageStatus_STAGE_FAILURE
+		}
+	}
+}
+
+func TestRunExecutor(t *testing.T) {
+	t.Run("completed before timeout", func(t *testing.T) {
+		sig, _ := executor.NewStopSignal()
+		status, signal := runExecutor(sig, &fakeExecutor{status: model.StageStatus_STAGE_SUCCESS}, time.Second)
+		assert.Equal(t, model.StageStatus_STAGE_SUCCESS, status)
+		assert.Equal(t, executor.StopSignalNone, signal)
+	})
+
+	t.Run("stage timeout is reached", func(t *testing.T) {
+		sig, _ := executor.NewStopSignal()
+		status, signal := runExecutor(sig, &fakeExecutor{status: model.StageStatus_STAGE_SUCCESS, delay: time.Minute}, 10*time.Millisecond)
+		assert.Equal(t, model.StageStatus_STAGE_FAILURE, status)
+		assert.Equal(t, executor.StopSignalTimeout, signal)
+		assert.Equal(t, executor.StopSignalNone, sig.Signal())
+	})
+
+	t.Run("stage signal is forwarded", func(t *testing.T) {
+		sig, handler := executor.NewStopSignal()
+		time.AfterFunc(10*time.Millisecond, handler.Cancel)
+		status, signal := runExecutor(sig, &fakeExecutor{status: model.StageStatus_STAGE_SUCCESS, delay: time.Minute}, 0)
+		assert.Equal(t, model.StageStatus_STAGE_CANCELLED, status)
+		assert.Equal(t, executor.StopSignalCancel, signal)
+	})
+}
+
+func TestStageTimeout(t *testing.T) {
+	testcases := []struct {
+		name     string
+		stage    config.PipelineStage
+		expected time.Duration
+	}{
+		{
+			name: "no timeout is enforced when not configured",
+			stage: config.PipelineStage{
+				Name: model.StageAnalysis,
+			},
+			expected: 0,
+		},
+		{
+			name: "configured timeout is enforced",
+			stage: config.PipelineStage{
+				Name:    model.StageK8sSync,
+				Timeout: config.Duration(10 * time.Minute),
+			},
+			expected: 10 * time.Minute,
+		},
+		{
+			name: "no timeout is enforced on WAIT_APPROVAL stage",
+			stage: config.PipelineStage{
+				Name:    model.StageWaitApproval,
+				Timeout: config.Duration(10 * time.Minute),
+			},
+			expected: 0,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, stageTimeout(tc.stage))
+		})
+	}
+}
+
+func TestFindAwaitingStages(t *testing.T) {
+	now := time.Unix(1000, 0)
+	stages := []*model.PipelineStage{
+		{Id: "succeeded", Status: model.StageStatus_STAGE_SUCCESS, CompletedAt: 990},
+		{Id: "failed-recently", Status: model.StageStatus_STAGE_FAILURE, CompletedAt: 990},
+		{Id: "failed-long-ago", Status: model.StageStatus_STAGE_FAILURE, CompletedAt: 900},
+		{Id: "failed-at-deadline", Status: model.StageStatus_STAGE_FAILURE, CompletedAt: 940},
+	}
+	testcases := []struct {
+		name        string
+		gracePeriod time.Duration
+		want        map[string]time.Time
+	}{
+		{
+			name:        "no grace period",
+			gracePeriod: 0,
+			want:        map[string]time.Time{},
+		},
+		{
+			name:        "only stages in grace period",
+			gracePeriod: time.Minute,
+			want: map[string]time.Time{
+				"failed-recently": time.Unix(1050, 0),
+			},
+		},
+		{
+			name:        "long grace period",
+			gracePeriod: 10 * time.Minute,
+			want: map[string]time.Time{
+				"failed-recently":    time.Unix(1590, 0),
+				"failed-long-ago":    time.Unix(1500, 0),
+				"failed-at-deadline": time.Unix(1540, 0),
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := findAwaitingStages(stages, tc.gracePeriod, now)
+			deadlines := make(map[string]time.Time, len(got))
+			for id, a := range got {
+				assert.Equal(t, id, a.stage.Id)
+				deadlines[id] = a.deadline
+			}
+			assert.Equal(t, tc.want, deadlines)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pipe-cd/pipecd/pkg/condition"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+// evaluateStageCondition reports whether the stage of the given configuration should be executed.
+// It always returns true when the stage has no condition.
+func evaluateStageCondition(cfg config.PipelineStage, d *model.Deployment, statuses map[string]model.StageStatus, changedFiles func() ([]string, error)) (bool, error) {
+	cond, err := cfg.Condition()
+	if err != nil || cond == nil {
+		return true, err
+	}
+	return cond.Evaluate(makeStageConditionEnv(d, statuses, changedFiles))
+}
+
+// usesStageResults reports whether the condition of the given stage refers to the results of other stages.
+// Such a condition can be evaluated only while executing the pipeline.
+func usesStageResults(cfg config.PipelineStage) bool {
+	cond, err := cfg.Condition()
+	if err != nil || cond == nil {
+		return false
+	}
+	return len(cond.StageIDs()) > 0
+}
+
+func makeStageSkippedReason(cfg config.PipelineStage) string {
+	return fmt.Sprintf("Skipped because the condition `%s` was not satisfied", cfg.When)
+}
+
+func makeStageConditionEnv(d *model.Deployment, statuses map[string]model.StageStatus, changedFiles func() ([]string, error)) condition.Env {
+	vars := make(map[string]string, len(d.Labels)+len(statuses)+6)
+	if t := d.Trigger; t != nil {
+		vars["trigger.kind"] = t.Kind.String()
+		vars["trigger.commander"] = t.Commander
+		if c := t.Commit; c != nil {
+			vars["commit.hash"] = c.Hash
+			vars["commit.message"] = c.Message
+			vars["commit.branch"] = c.Branch
+			vars["commit.author"] = c.Author
+		}
+	}
+	for k, v := range d.Labels {
+		vars["labels."+k] = v
+	}
+	for id, s := range statuses {
+		vars["stages."+id+".status"] = strings.TrimPrefix(s.String(), "STAGE_")
+	}
+	return condition.Env{
+		Variables:    vars,
+		ChangedFiles: changedFiles,
+	}
+}
+
+// changedFilesLister lists the files changed from the running commit to the target commit of a deployment.
+// The repository is cloned only at the first call and the result is reused after that
+// since the same list is used by the conditions of all stages.
+type changedFilesLister struct {
+	gitClient  gitClient
+	repoCfg    config.PipedRepository
+	workingDir string
+	from       string
+	to         string
+
+	once  sync.Once
+	files []string
+	err   error
+}
+
+func newChangedFilesLister(gc gitClient, d *model.Deployment, runningCommitHash, workingDir string) *changedFilesLister {
+	return &changedFilesLister{
+		gitClient: gc,
+		repoCfg: config.PipedRepository{
+			RepoID: d.GitPath.Repo.Id,
+			Remote: d.GitPath.Repo.Remote,
+			Branch: d.GitPath.Repo.Branch,
+		},
+		workingDir: workingDir,
+		from:       runningCommitHash,
+		to:         d.Trigger.Commit.Hash,
+	}
+}
+
+// Func returns the function to be used as condition.Env.ChangedFiles.
+// Nil is returned when there is no running commit (e.g. the first deployment)
+// so that all files are considered as changed.
+func (l *changedFilesLister) Func(ctx context.Context) func() ([]string, error) {
+	if l.from == "" {
+		return nil
+	}
+	return func() ([]string, error) {
+		l.once.Do(func() {
+			repo, err := l.gitClient.Clone(ctx, l.repoCfg.RepoID, l.repoCfg.Remote, l.repoCfg.Branch, filepath.Join(l.workingDir, "changed-files"))
+			if err != nil {
+				l.err = fmt.Errorf("failed to clone repository %s: %w", l.repoCfg.RepoID, err)
+				return
+			}
+			l.files, l.err = repo.ChangedFiles(ctx, l.from, l.to)
+		})
+		return l.files, l.err
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+func TestEvaluateStageCondition(t *testing.T) {
+	deployment := &model.Deployment{
+		Trigger: &model.DeploymentTrigger{
+			Kind:      model.TriggerKind_ON_COMMAND,
+			Commander: "user",
+			Commit: &model.Commit{
+				Hash:    "abc",
+				Message: "Update config",
+				Branch:  "main",
+			},
+		},
+		Labels: map[string]string{
+			"env": "prod",
+		},
+	}
+	statuses := map[string]model.StageStatus{
+		"canary":   model.StageStatus_STAGE_SUCCESS,
+		"approval": model.StageStatus_STAGE_SKIPPED,
+	}
+	changedFiles := func() ([]string, error) {
+		return []string{"apps/web/config.yaml"}, nil
+	}
+
+	testcases := []struct {
+		name     string
+		when     string
+		expected bool
+	}{
+		{
+			name:     "no condition",
+			when:     "",
+			expected: true,
+		},
+		{
+			name:     "trigger kind",
+			when:     `trigger.kind == "ON_COMMAND" && trigger.commander == "user"`,
+			expected: true,
+		},
+		{
+			name:     "commit",
+			when:     `commit.branch == "main" && commit.message =~ "^Update"`,
+			expected: true,
+		},
+		{
+			name:     "labels",
+			when:     `labels.env == "dev"`,
+			expected: false,
+		},
+		{
+			name:     "stage results",
+			when:     `stages.canary.status == "SUCCESS" && stages.approval.status == "SKIPPED"`,
+			expected: true,
+		},
+		{
+			name:     "changed files",
+			when:     `changed("apps/web/**/*.go")`,
+			expected: false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.PipelineStage{Name: model.StageWaitApproval, When: tc.when}
+			got, err := evaluateStageCondition(cfg, deployment, statuses, changedFiles)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestUsesStageResults(t *testing.T) {
+	assert.False(t, usesStageResults(config.PipelineStage{}))
+	assert.False(t, usesStageResults(config.PipelineStage{When: `changed("apps/web")`}))
+	assert.True(t, usesStageResults(config.PipelineStage{When: `stages.canary.status == "SUCCESS"`}))
+}
@@ -171,6 +171,17 @@ func (p *provider) prepare(ctx context.Context, lw io.Writer) (*DeploySource, er
 	}
 	fmt.Fprintln(lw, "Successfully loaded the application configuration file")
 
+	// Expand the pipeline template if the application is using one.
+	// Since the pipeline is shared with the loaded config, all later
+	// consumers such as planner and scheduler will see the expanded stages.
+	if pl := gac.Pipeline; pl != nil && pl.UseTemplate != "" {
+		if err := pl.ExpandTemplate(repoDir); err != nil {
+			fmt.Fprintf(lw, "Unable to expand the pipeline template %s (%v)\n", pl.UseTemplate, err)
+			return nil, err
+		}
+		fmt.Fprintf(lw, "Successfully expanded the pipeline template %s\n", pl.UseTemplate)
+	}
+
 	// Decrypt the sealed secrets if needed.
 	if gac.Encryption != nil && p.secretDecrypter != nil && len(gac.Encryption.DecryptionTargets) > 0 {
 		if err := sourcedecrypter.DecryptSecrets(appDir, *gac.Encryption, p.secretDecrypter); err != nil {
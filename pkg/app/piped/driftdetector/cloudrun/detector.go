@@ -13,3 +13,328 @@
 // limitations under the License.
 
 package cloudrun
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/cloudrun"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/livestatestore/cloudrun"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/sourcedecrypter"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/diff"
+	"github.com/pipe-cd/pipecd/pkg/git"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type applicationLister interface {
+	ListByCloudProvider(name string) []*model.Application
+}
+
+type gitClient interface {
+	Clone(ctx context.Context, repoID, remote, branch, destination string) (git.Repo, error)
+}
+
+type secretDecrypter interface {
+	Decrypt(string) (string, error)
+}
+
+type reporter interface {
+	ReportApplicationSyncState(ctx context.Context, appID string, state model.ApplicationSyncState) error
+}
+
+type detector struct {
+	provider        config.PipedCloudProvider
+	appLister       applicationLister
+	gitClient       gitClient
+	stateGetter     cloudrun.Getter
+	reporter        reporter
+	interval        time.Duration
+	config          *config.PipedSpec
+	secretDecrypter secretDecrypter
+	logger          *zap.Logger
+
+	gitRepos map[string]git.Repo
+}
+
+func NewDetector(
+	cp config.PipedCloudProvider,
+	appLister applicationLister,
+	gitClient gitClient,
+	stateGetter cloudrun.Getter,
+	reporter reporter,
+	cfg *config.PipedSpec,
+	sd secretDecrypter,
+	logger *zap.Logger,
+) *detector {
+
+	logger = logger.Named("cloudrun-detector").With(
+		zap.String("cloud-provider", cp.Name),
+	)
+	return &detector{
+		provider:        cp,
+		appLister:       appLister,
+		gitClient:       gitClient,
+		stateGetter:     stateGetter,
+		reporter:        reporter,
+		interval:        time.Minute,
+		config:          cfg,
+		secretDecrypter: sd,
+		gitRepos:        make(map[string]git.Repo),
+		logger:          logger,
+	}
+}
+
+func (d *detector) Run(ctx context.Context) error {
+	d.logger.Info("start running drift detector for cloudrun applications")
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+L:
+	for {
+		select {
+		case <-ticker.C:
+			d.check(ctx)
+
+		case <-ctx.Done():
+			break L
+		}
+	}
+
+	d.logger.Info("drift detector for cloudrun applications has been stopped")
+	return nil
+}
+
+func (d *detector) check(ctx context.Context) error {
+	appsByRepo := d.listGroupedApplication()
+
+	for repoID, apps := range appsByRepo {
+		gitRepo, ok := d.gitRepos[repoID]
+		if !ok {
+			// Clone repository for the first time.
+			repoCfg, ok := d.config.GetRepository(repoID)
+			if !ok {
+				d.logger.Error(fmt.Sprintf("repository %s was not found in piped configuration", repoID))
+				continue
+			}
+			gr, err := d.gitClient.Clone(ctx, repoID, repoCfg.Remote, repoCfg.Branch, "")
+			if err != nil {
+				d.logger.Error("failed to clone repository",
+					zap.String("repo-id", repoID),
+					zap.Error(err),
+				)
+				continue
+			}
+			gitRepo = gr
+			d.gitRepos[repoID] = gitRepo
+		}
+
+		// Fetch the latest commit to compare the states.
+		branch := gitRepo.GetClonedBranch()
+		if err := gitRepo.Pull(ctx, branch); err != nil {
+			d.logger.Error("failed to update repository branch",
+				zap.String("repo-id", repoID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		// Get the head commit of the repository.
+		headCommit, err := gitRepo.GetLatestCommit(ctx)
+		if err != nil {
+			d.logger.Error("failed to get head commit hash",
+				zap.String("repo-id", repoID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		// Start checking all applications in this repository.
+		for _, app := range apps {
+			if err := d.checkApplication(ctx, app, gitRepo, headCommit); err != nil {
+				d.logger.Error(fmt.Sprintf("failed to check application: %s", app.Id), zap.Error(err))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *detector) checkApplication(ctx context.Context, app *model.Application, repo git.Repo, headCommit git.Commit) error {
+	liveManifest, ok := d.stateGetter.GetServiceManifest(app.Id)
+	if !ok {
+		d.logger.Info(fmt.Sprintf("no live state of cloudrun application %s to compare", app.Id))
+		return nil
+	}
+
+	headManifest, err := d.loadHeadServiceManifest(app, repo)
+	if err != nil {
+		return err
+	}
+	d.logger.Info(fmt.Sprintf("application %s has a service manifest at commit %s", app.Id, headCommit.Hash))
+
+	headManifest, err = makeExpectedManifest(headManifest, liveManifest)
+	if err != nil {
+		return err
+	}
+
+	result, err := provider.Diff(
+		headManifest,
+		liveManifest,
+		diff.WithEquateEmpty(),
+		diff.WithIgnoreAddingMapKeys(),
+		diff.WithCompareNumberAndNumericString(),
+	)
+	if err != nil {
+		return err
+	}
+
+	state := makeSyncState(result, headCommit.Hash)
+
+	return d.reporter.ReportApplicationSyncState(ctx, app.Id, state)
+}
+
+func (d *detector) loadHeadServiceManifest(app *model.Application, repo git.Repo) (provider.ServiceManifest, error) {
+	var (
+		repoDir = repo.GetPath()
+		appDir  = filepath.Join(repoDir, app.GitPath.Path)
+	)
+
+	cfg, err := d.loadApplicationConfiguration(repoDir, app)
+	if err != nil {
+		return provider.ServiceManifest{}, fmt.Errorf("failed to load application configuration: %w", err)
+	}
+
+	appCfg := cfg.CloudRunApplicationSpec
+	if appCfg == nil {
+		return provider.ServiceManifest{}, fmt.Errorf("missing CloudRun spec field in application configuration")
+	}
+
+	if d.secretDecrypter != nil && appCfg.Encryption != nil {
+		// We have to copy repository into another directory because
+		// decrypting the sealed secrets might change the git repository.
+		dir, err := os.MkdirTemp("", "detector-git-decrypt")
+		if err != nil {
+			return provider.ServiceManifest{}, fmt.Errorf("failed to prepare a temporary directory for git repository (%w)", err)
+		}
+		defer os.RemoveAll(dir)
+
+		repo, err = repo.Copy(filepath.Join(dir, "repo"))
+		if err != nil {
+			return provider.ServiceManifest{}, fmt.Errorf("failed to copy the cloned git repository (%w)", err)
+		}
+		repoDir = repo.GetPath()
+		appDir = filepath.Join(repoDir, app.GitPath.Path)
+
+		if err := sourcedecrypter.DecryptSecrets(appDir, *appCfg.Encryption, d.secretDecrypter); err != nil {
+			return provider.ServiceManifest{}, fmt.Errorf("failed to decrypt secrets (%w)", err)
+		}
+	}
+
+	sm, err := provider.LoadServiceManifest(appDir, appCfg.Input.ServiceManifestFile)
+	if err != nil {
+		return provider.ServiceManifest{}, fmt.Errorf("failed to load service manifest: %w", err)
+	}
+	return sm, nil
+}
+
+// listGroupedApplication retrieves all applications those should be handled by this director
+// and then groups them by repoID.
+func (d *detector) listGroupedApplication() map[string][]*model.Application {
+	var (
+		apps = d.appLister.ListByCloudProvider(d.provider.Name)
+		m    = make(map[string][]*model.Application)
+	)
+	for _, app := range apps {
+		repoID := app.GitPath.Repo.Id
+		if _, ok := m[repoID]; !ok {
+			m[repoID] = []*model.Application{app}
+		} else {
+			m[repoID] = append(m[repoID], app)
+		}
+	}
+	return m
+}
+
+func (d *detector) loadApplicationConfiguration(repoPath string, app *model.Application) (*config.Config, error) {
+	path := filepath.Join(repoPath, app.GitPath.GetApplicationConfigFilePath())
+	cfg, err := config.LoadFromYAML(path)
+	if err != nil {
+		return nil, err
+	}
+	if appKind, ok := config.ToApplicationKind(cfg.Kind); !ok || appKind != app.Kind {
+		return nil, fmt.Errorf("application in application configuration file is not match, got: %s, expected: %s", appKind, app.Kind)
+	}
+	return cfg, nil
+}
+
+func (d *detector) ProviderName() string {
+	return d.provider.Name
+}
+
+// makeExpectedManifest returns the manifest expected to be running
+// after the given head manifest was deployed by piped.
+// While deploying, piped names the revision by the image tag and the deployed commit
+// and routes all traffic to it, so those are applied to the head manifest as well.
+// The commit recorded in the live manifest is used to decide the revision name
+// because changing the commit alone does not change the running service.
+func makeExpectedManifest(head, live provider.ServiceManifest) (provider.ServiceManifest, error) {
+	commit := live.Labels()[provider.LabelCommitHash]
+	if commit == "" {
+		return head, nil
+	}
+
+	revision, err := provider.DecideRevisionName(head, commit)
+	if err != nil {
+		return provider.ServiceManifest{}, fmt.Errorf("failed to decide revision name: %w", err)
+	}
+	if err := head.SetRevision(revision); err != nil {
+		return provider.ServiceManifest{}, fmt.Errorf("failed to set revision name: %w", err)
+	}
+	if err := head.UpdateAllTraffic(revision); err != nil {
+		return provider.ServiceManifest{}, fmt.Errorf("failed to set traffic: %w", err)
+	}
+	return head, nil
+}
+
+func makeSyncState(r *provider.DiffResult, commit string) model.ApplicationSyncState {
+	if r.NoChange() {
+		return model.ApplicationSyncState{
+			Status:      model.ApplicationSyncStatus_SYNCED,
+			ShortReason: "",
+			Reason:      "",
+			Timestamp:   time.Now().Unix(),
+		}
+	}
+
+	shortReason := fmt.Sprintf("The service manifest is not synced (%d fields changed)", r.Diff.NumNodes())
+	if len(commit) >= 7 {
+		commit = commit[:7]
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Diff between the defined state in Git at commit %s and actual state in Cloud Run:\n\n", commit))
+	b.WriteString("--- Expected\n+++ Actual\n\n")
+
+	details := r.Render(provider.DiffRenderOptions{
+		// Currently, we do not use the diff command to render the result
+		// because Cloud Run adds a large number of default values to the
+		// running manifest that causes a wrong diff text.
+		UseDiffCommand: false,
+	})
+	b.WriteString(details)
+
+	return model.ApplicationSyncState{
+		Status:      model.ApplicationSyncStatus_OUT_OF_SYNC,
+		ShortReason: shortReason,
+		Reason:      b.String(),
+		Timestamp:   time.Now().Unix(),
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudrun
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/cloudrun"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/livestatestore/cloudrun"
+	"github.com/pipe-cd/pipecd/pkg/git"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+const headServiceManifest = `apiVersion: serving.knative.dev/v1
+kind: Service
+metadata:
+  name: helloworld
+spec:
+  template:
+    metadata:
+      annotations:
+        autoscaling.knative.dev/maxScale: '1'
+    spec:
+      containerConcurrency: 80
+      containers:
+      - image: gcr.io/pipecd/helloworld:v0.6.0
+        ports:
+        - containerPort: 9085
+        resources:
+          limits:
+            memory: 128Mi
+`
+
+const liveServiceManifest = `apiVersion: serving.knative.dev/v1
+kind: Service
+metadata:
+  name: helloworld
+  namespace: "123456789"
+  uid: 8fa4c1b2-6f2f-4a3a-9b60-6a5b8e3c9f00
+  labels:
+    cloud.googleapis.com/location: asia-northeast1
+    pipecd-dev-managed-by: piped
+    pipecd-dev-application: app-1
+    pipecd-dev-commit-hash: 0123456789abcdef
+  annotations:
+    run.googleapis.com/ingress: all
+spec:
+  template:
+    metadata:
+      name: helloworld-v060-0123456
+      annotations:
+        autoscaling.knative.dev/maxScale: '1'
+    spec:
+      containerConcurrency: 80
+      timeoutSeconds: 300
+      containers:
+      - image: gcr.io/pipecd/helloworld:v0.6.0
+        ports:
+        - name: http1
+          containerPort: 9085
+        resources:
+          limits:
+            cpu: 1000m
+            memory: %s
+  traffic:
+  - revisionName: helloworld-v060-0123456
+    percent: 100
+    latestRevision: false
+status:
+  latestReadyRevisionName: helloworld-v060-0123456
+`
+
+type fakeReporter struct {
+	states map[string]model.ApplicationSyncState
+}
+
+func (r *fakeReporter) ReportApplicationSyncState(_ context.Context, appID string, state model.ApplicationSyncState) error {
+	r.states[appID] = state
+	return nil
+}
+
+type fakeStateGetter struct {
+	cloudrun.Getter
+	manifests map[string]provider.ServiceManifest
+}
+
+func (g *fakeStateGetter) GetServiceManifest(appID string) (provider.ServiceManifest, bool) {
+	sm, ok := g.manifests[appID]
+	return sm, ok
+}
+
+func TestCheckApplication(t *testing.T) {
+	testcases := []struct {
+		name       string
+		liveMemory string
+		noLive     bool
+		wantStatus model.ApplicationSyncStatus
+		wantShort  string
+	}{
+		{
+			name:       "synced",
+			liveMemory: "128Mi",
+			wantStatus: model.ApplicationSyncStatus_SYNCED,
+		},
+		{
+			name:       "out of sync",
+			liveMemory: "512Mi",
+			wantStatus: model.ApplicationSyncStatus_OUT_OF_SYNC,
+			wantShort:  "The service manifest is not synced (1 fields changed)",
+		},
+		{
+			name:   "no live state",
+			noLive: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			repoDir := filepath.Join(t.TempDir(), "repo")
+			appDir := filepath.Join(repoDir, "app")
+			require.NoError(t, os.MkdirAll(appDir, 0755))
+			appCfg := `apiVersion: pipecd.dev/v1beta1
+kind: CloudRunApp
+spec:
+  input:
+    serviceManifestFile: service.yaml
+`
+			require.NoError(t, os.WriteFile(filepath.Join(appDir, "app.pipecd.yaml"), []byte(appCfg), 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(appDir, "service.yaml"), []byte(headServiceManifest), 0644))
+
+			getter := &fakeStateGetter{
+				manifests: make(map[string]provider.ServiceManifest),
+			}
+			if !tc.noLive {
+				live, err := provider.ParseServiceManifest([]byte(fmt.Sprintf(liveServiceManifest, tc.liveMemory)))
+				require.NoError(t, err)
+				getter.manifests["app-1"] = live
+			}
+			reporter := &fakeReporter{
+				states: make(map[string]model.ApplicationSyncState),
+			}
+			d := &detector{
+				stateGetter: getter,
+				reporter:    reporter,
+				logger:      zap.NewNop(),
+			}
+			app := &model.Application{
+				Id:   "app-1",
+				Kind: model.ApplicationKind_CLOUDRUN,
+				GitPath: &model.ApplicationGitPath{
+					Path:           "app",
+					ConfigFilename: "app.pipecd.yaml",
+				},
+			}
+			repo := git.NewRepo(repoDir, "", "", "master", nil)
+
+			err := d.checkApplication(context.Background(), app, repo, git.Commit{Hash: "fedcba9876543210"})
+			require.NoError(t, err)
+
+			state, ok := reporter.states["app-1"]
+			if tc.noLive {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tc.wantStatus, state.Status)
+			assert.Equal(t, tc.wantShort, state.ShortReason)
+		})
+	}
+}
+
+func TestMakeExpectedManifest(t *testing.T) {
+	head, err := provider.ParseServiceManifest([]byte(headServiceManifest))
+	require.NoError(t, err)
+	live, err := provider.ParseServiceManifest([]byte(fmt.Sprintf(liveServiceManifest, "128Mi")))
+	require.NoError(t, err)
+
+	got, err := makeExpectedManifest(head, live)
+	require.NoError(t, err)
+
+	data, err := got.YamlBytes()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "name: helloworld-v060-0123456")
+	assert.Contains(t, string(data), "revisionName: helloworld-v060-0123456")
+}
+
+func TestMakeSyncState(t *testing.T) {
+	head, err := provider.ParseServiceManifest([]byte(headServiceManifest))
+	require.NoError(t, err)
+
+	result, err := provider.Diff(head, head)
+	require.NoError(t, err)
+	state := makeSyncState(result, "0123456789abcdef")
+	assert.Equal(t, model.ApplicationSyncStatus_SYNCED, state.Status)
+	assert.NotZero(t, state.Timestamp)
+	assert.LessOrEqual(t, state.Timestamp, time.Now().Unix())
+}
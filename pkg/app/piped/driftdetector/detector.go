@@ -27,7 +27,11 @@ import (
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 
+	"github.com/pipe-cd/pipecd/pkg/app/piped/driftdetector/cloudrun"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/driftdetector/ecs"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/driftdetector/kubernetes"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/driftdetector/lambda"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/driftdetector/terraform"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/livestatestore"
 	"github.com/pipe-cd/pipecd/pkg/app/server/service/pipedservice"
 	"github.com/pipe-cd/pipecd/pkg/cache"
@@ -40,6 +44,10 @@ type applicationLister interface {
 	ListByCloudProvider(name string) []*model.Application
 }
 
+type deployWindowLister interface {
+	Get() *config.DeployWindows
+}
+
 type deploymentLister interface {
 	ListAppHeadDeployments() map[string]*model.Deployment
 }
@@ -50,6 +58,8 @@ type gitClient interface {
 
 type apiClient interface {
 	ReportApplicationSyncState(ctx context.Context, req *pipedservice.ReportApplicationSyncStateRequest, opts ...grpc.CallOption) (*pipedservice.ReportApplicationSyncStateResponse, error)
+	CreateDeployment(ctx context.Context, req *pipedservice.CreateDeploymentRequest, opts ...grpc.CallOption) (*pipedservice.CreateDeploymentResponse, error)
+	ReportApplicationMostRecentDeployment(ctx context.Context, req *pipedservice.ReportApplicationMostRecentDeploymentRequest, opts ...grpc.CallOption) (*pipedservice.ReportApplicationMostRecentDeploymentResponse, error)
 }
 
 type secretDecrypter interface {
@@ -77,6 +87,7 @@ func NewDetector(
 	appLister applicationLister,
 	gitClient gitClient,
 	stateGetter livestatestore.Getter,
+	deployWindowLister deployWindowLister,
 	apiClient apiClient,
 	appManifestsCache cache.Cache,
 	cfg *config.PipedSpec,
@@ -104,6 +115,7 @@ func NewDetector(
 				appLister,
 				gitClient,
 				sg,
+				deployWindowLister,
 				d,
 				appManifestsCache,
 				cfg,
@@ -111,6 +123,72 @@ func NewDetector(
 				logger,
 			))
 
+		case model.CloudProviderTerraform:
+			if cp.TerraformConfig.DriftDetection.Disabled {
+				d.logger.Info(fmt.Sprintf("drift detection is disabled for cloud provider: %s", cp.Name))
+				continue
+			}
+			d.detectors = append(d.detectors, terraform.NewDetector(
+				cp,
+				appLister,
+				gitClient,
+				d,
+				cfg,
+				sd,
+				logger,
+			))
+
+		case model.CloudProviderCloudRun:
+			sg, ok := stateGetter.CloudRunGetter(cp.Name)
+			if !ok {
+				d.logger.Error(fmt.Sprintf("unable to find live state getter for cloud provider: %s", cp.Name))
+				continue
+			}
+			d.detectors = append(d.detectors, cloudrun.NewDetector(
+				cp,
+				appLister,
+				gitClient,
+				sg,
+				d,
+				cfg,
+				sd,
+				logger,
+			))
+
+		case model.CloudProviderLambda:
+			sg, ok := stateGetter.LambdaGetter(cp.Name)
+			if !ok {
+				d.logger.Error(fmt.Sprintf("unable to find live state getter for cloud provider: %s", cp.Name))
+				continue
+			}
+			d.detectors = append(d.detectors, lambda.NewDetector(
+				cp,
+				appLister,
+				gitClient,
+				sg,
+				d,
+				cfg,
+				sd,
+				logger,
+			))
+
+		case model.CloudProviderECS:
+			sg, ok := stateGetter.ECSRunGetter(cp.Name)
+			if !ok {
+				d.logger.Error(fmt.Sprintf("unable to find live state getter for cloud provider: %s", cp.Name))
+				continue
+			}
+			d.detectors = append(d.detectors, ecs.NewDetector(
+				cp,
+				appLister,
+				gitClient,
+				sg,
+				d,
+				cfg,
+				sd,
+				logger,
+			))
+
 		default:
 		}
 	}
@@ -171,3 +249,54 @@ func (d *detector) ReportApplicationSyncState(ctx context.Context, appID string,
 
 	return nil
 }
+
+// ReportSelfHealDeployment registers the given completed deployment
+// that was made to revert the configuration drift of an application.
+// When it was successful, it is also reported as the most recent deployment
+// of the application since the running resources were changed by it.
+func (d *detector) ReportSelfHealDeployment(ctx context.Context, deployment *model.Deployment) error {
+	_, err := d.apiClient.CreateDeployment(ctx, &pipedservice.CreateDeploymentRequest{
+		Deployment: deployment,
+	})
+	if err != nil {
+		d.logger.Error("failed to register self-heal deployment",
+			zap.String("application-id", deployment.ApplicationId),
+			zap.String("deployment-id", deployment.Id),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if deployment.Status != model.DeploymentStatus_DEPLOYMENT_SUCCESS {
+		return nil
+	}
+
+	ref := &model.ApplicationDeploymentReference{
+		DeploymentId: deployment.Id,
+		Trigger:      deployment.Trigger,
+		Summary:      deployment.Summary,
+		Version:      deployment.Version,
+		StartedAt:    deployment.CreatedAt,
+		CompletedAt:  deployment.CompletedAt,
+	}
+	for _, status := range []model.DeploymentStatus{
+		model.DeploymentStatus_DEPLOYMENT_PENDING,
+		model.DeploymentStatus_DEPLOYMENT_SUCCESS,
+	} {
+		_, err := d.apiClient.ReportApplicationMostRecentDeployment(ctx, &pipedservice.ReportApplicationMostRecentDeploymentRequest{
+			ApplicationId: deployment.ApplicationId,
+			Status:        status,
+			Deployment:    ref,
+		})
+		if err != nil {
+			d.logger.Error("failed to report most recent deployment",
+				zap.String("application-id", deployment.ApplicationId),
+				zap.String("deployment-id", deployment.Id),
+				zap.Error(err),
+			)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,368 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"go.uber.org/zap"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/ecs"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/livestatestore/ecs"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/sourcedecrypter"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/diff"
+	"github.com/pipe-cd/pipecd/pkg/git"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type applicationLister interface {
+	ListByCloudProvider(name string) []*model.Application
+}
+
+type gitClient interface {
+	Clone(ctx context.Context, repoID, remote, branch, destination string) (git.Repo, error)
+}
+
+type secretDecrypter interface {
+	Decrypt(string) (string, error)
+}
+
+type reporter interface {
+	ReportApplicationSyncState(ctx context.Context, appID string, state model.ApplicationSyncState) error
+}
+
+type detector struct {
+	provider        config.PipedCloudProvider
+	appLister       applicationLister
+	gitClient       gitClient
+	stateGetter     ecs.Getter
+	reporter        reporter
+	interval        time.Duration
+	config          *config.PipedSpec
+	secretDecrypter secretDecrypter
+	logger          *zap.Logger
+
+	gitRepos map[string]git.Repo
+}
+
+func NewDetector(
+	cp config.PipedCloudProvider,
+	appLister applicationLister,
+	gitClient gitClient,
+	stateGetter ecs.Getter,
+	reporter reporter,
+	cfg *config.PipedSpec,
+	sd secretDecrypter,
+	logger *zap.Logger,
+) *detector {
+
+	logger = logger.Named("ecs-detector").With(
+		zap.String("cloud-provider", cp.Name),
+	)
+	return &detector{
+		provider:        cp,
+		appLister:       appLister,
+		gitClient:       gitClient,
+		stateGetter:     stateGetter,
+		reporter:        reporter,
+		interval:        time.Minute,
+		config:          cfg,
+		secretDecrypter: sd,
+		gitRepos:        make(map[string]git.Repo),
+		logger:          logger,
+	}
+}
+
+func (d *detector) Run(ctx context.Context) error {
+	d.logger.Info("start running drift detector for ecs applications")
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+L:
+	for {
+		select {
+		case <-ticker.C:
+			d.check(ctx)
+
+		case <-ctx.Done():
+			break L
+		}
+	}
+
+	d.logger.Info("drift detector for ecs applications has been stopped")
+	return nil
+}
+
+func (d *detector) check(ctx context.Context) error {
+	appsByRepo := d.listGroupedApplication()
+
+	for repoID, apps := range appsByRepo {
+		gitRepo, ok := d.gitRepos[repoID]
+		if !ok {
+			// Clone repository for the first time.
+			repoCfg, ok := d.config.GetRepository(repoID)
+			if !ok {
+				d.logger.Error(fmt.Sprintf("repository %s was not found in piped configuration", repoID))
+				continue
+			}
+			gr, err := d.gitClient.Clone(ctx, repoID, repoCfg.Remote, repoCfg.Branch, "")
+			if err != nil {
+				d.logger.Error("failed to clone repository",
+					zap.String("repo-id", repoID),
+					zap.Error(err),
+				)
+				continue
+			}
+			gitRepo = gr
+			d.gitRepos[repoID] = gitRepo
+		}
+
+		// Fetch the latest commit to compare the states.
+		branch := gitRepo.GetClonedBranch()
+		if err := gitRepo.Pull(ctx, branch); err != nil {
+			d.logger.Error("failed to update repository branch",
+				zap.String("repo-id", repoID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		// Get the head commit of the repository.
+		headCommit, err := gitRepo.GetLatestCommit(ctx)
+		if err != nil {
+			d.logger.Error("failed to get head commit hash",
+				zap.String("repo-id", repoID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		// Start checking all applications in this repository.
+		for _, app := range apps {
+			if err := d.checkApplication(ctx, app, gitRepo, headCommit); err != nil {
+				d.logger.Error(fmt.Sprintf("failed to check application: %s", app.Id), zap.Error(err))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *detector) checkApplication(ctx context.Context, app *model.Application, repo git.Repo, headCommit git.Commit) error {
+	liveService, ok := d.stateGetter.GetServiceDefinition(app.Id)
+	if !ok {
+		d.logger.Info(fmt.Sprintf("no live state of ecs application %s to compare", app.Id))
+		return nil
+	}
+
+	headService, headTaskDef, err := d.loadHeadDefinitions(app, repo)
+	if err != nil {
+		return err
+	}
+	d.logger.Info(fmt.Sprintf("application %s has service and task definitions at commit %s", app.Id, headCommit.Hash))
+
+	opts := []diff.Option{
+		diff.WithEquateEmpty(),
+		diff.WithIgnoreAddingMapKeys(),
+		diff.WithCompareNumberAndNumericString(),
+	}
+
+	liveService = ignoreUnretrievableFields(headService, liveService)
+	serviceResult, err := provider.DiffServiceDefinitions(headService, liveService, opts...)
+	if err != nil {
+		return err
+	}
+
+	// The task definition is compared only when the service has a PRIMARY task set.
+	taskDefResult := &provider.DiffResult{}
+	if liveTaskDef, ok := d.stateGetter.GetTaskDefinition(app.Id); ok {
+		taskDefResult, err = provider.DiffTaskDefinitions(headTaskDef, liveTaskDef, opts...)
+		if err != nil {
+			return err
+		}
+	} else {
+		d.logger.Info(fmt.Sprintf("no live task definition of ecs application %s to compare", app.Id))
+	}
+
+	state := makeSyncState(serviceResult, taskDefResult, headCommit.Hash)
+
+	return d.reporter.ReportApplicationSyncState(ctx, app.Id, state)
+}
+
+func (d *detector) loadHeadDefinitions(app *model.Application, repo git.Repo) (types.Service, types.TaskDefinition, error) {
+	var (
+		repoDir = repo.GetPath()
+		appDir  = filepath.Join(repoDir, app.GitPath.Path)
+	)
+
+	cfg, err := d.loadApplicationConfiguration(repoDir, app)
+	if err != nil {
+		return types.Service{}, types.TaskDefinition{}, fmt.Errorf("failed to load application configuration: %w", err)
+	}
+
+	appCfg := cfg.ECSApplicationSpec
+	if appCfg == nil {
+		return types.Service{}, types.TaskDefinition{}, fmt.Errorf("missing ECS spec field in application configuration")
+	}
+
+	if d.secretDecrypter != nil && appCfg.Encryption != nil {
+		// We have to copy repository into another directory because
+		// decrypting the sealed secrets might change the git repository.
+		dir, err := os.MkdirTemp("", "detector-git-decrypt")
+		if err != nil {
+			return types.Service{}, types.TaskDefinition{}, fmt.Errorf("failed to prepare a temporary directory for git repository (%w)", err)
+		}
+		defer os.RemoveAll(dir)
+
+		repo, err = repo.Copy(filepath.Join(dir, "repo"))
+		if err != nil {
+			return types.Service{}, types.TaskDefinition{}, fmt.Errorf("failed to copy the cloned git repository (%w)", err)
+		}
+		repoDir = repo.GetPath()
+		appDir = filepath.Join(repoDir, app.GitPath.Path)
+
+		if err := sourcedecrypter.DecryptSecrets(appDir, *appCfg.Encryption, d.secretDecrypter); err != nil {
+			return types.Service{}, types.TaskDefinition{}, fmt.Errorf("failed to decrypt secrets (%w)", err)
+		}
+	}
+
+	service, err := provider.LoadServiceDefinition(appDir, appCfg.Input.ServiceDefinitionFile)
+	if err != nil {
+		return types.Service{}, types.TaskDefinition{}, fmt.Errorf("failed to load service definition: %w", err)
+	}
+	taskDef, err := provider.LoadTaskDefinition(appDir, appCfg.Input.TaskDefinitionFile)
+	if err != nil {
+		return types.Service{}, types.TaskDefinition{}, fmt.Errorf("failed to load task definition: %w", err)
+	}
+	return service, taskDef, nil
+}
+
+// listGroupedApplication retrieves all applications those should be handled by this director
+// and then groups them by repoID.
+func (d *detector) listGroupedApplication() map[string][]*model.Application {
+	var (
+		apps = d.appLister.ListByCloudProvider(d.provider.Name)
+		m    = make(map[string][]*model.Application)
+	)
+	for _, app := range apps {
+		repoID := app.GitPath.Repo.Id
+		if _, ok := m[repoID]; !ok {
+			m[repoID] = []*model.Application{app}
+		} else {
+			m[repoID] = append(m[repoID], app)
+		}
+	}
+	return m
+}
+
+func (d *detector) loadApplicationConfiguration(repoPath string, app *model.Application) (*config.Config, error) {
+	path := filepath.Join(repoPath, app.GitPath.GetApplicationConfigFilePath())
+	cfg, err := config.LoadFromYAML(path)
+	if err != nil {
+		return nil, err
+	}
+	if appKind, ok := config.ToApplicationKind(cfg.Kind); !ok || appKind != app.Kind {
+		return nil, fmt.Errorf("application in application configuration file is not match, got: %s, expected: %s", appKind, app.Kind)
+	}
+	return cfg, nil
+}
+
+func (d *detector) ProviderName() string {
+	return d.provider.Name
+}
+
+// ignoreUnretrievableFields returns the live service definition whose fields
+// that are specified by name in Git but returned as ARN by AWS are replaced by the head ones.
+func ignoreUnretrievableFields(head, live types.Service) types.Service {
+	if matchesByName(aws.ToString(head.ClusterArn), aws.ToString(live.ClusterArn)) {
+		live.ClusterArn = head.ClusterArn
+	}
+	if matchesByName(aws.ToString(head.RoleArn), aws.ToString(live.RoleArn)) {
+		live.RoleArn = head.RoleArn
+	}
+	return live
+}
+
+// matchesByName reports whether the given name is the name part of the given ARN.
+func matchesByName(name, arn string) bool {
+	if name == "" || strings.HasPrefix(name, "arn:") {
+		return false
+	}
+	return strings.HasSuffix(arn, "/"+name)
+}
+
+func makeSyncState(serviceResult, taskDefResult *provider.DiffResult, commit string) model.ApplicationSyncState {
+	if serviceResult.NoChange() && taskDefResult.NoChange() {
+		return model.ApplicationSyncState{
+			Status:      model.ApplicationSyncStatus_SYNCED,
+			ShortReason: "",
+			Reason:      "",
+			Timestamp:   time.Now().Unix(),
+		}
+	}
+
+	var (
+		targets    []string
+		numChanges int
+	)
+	if !serviceResult.NoChange() {
+		targets = append(targets, "service definition")
+		numChanges += serviceResult.Diff.NumNodes()
+	}
+	if !taskDefResult.NoChange() {
+		targets = append(targets, "task definition")
+		numChanges += taskDefResult.Diff.NumNodes()
+	}
+
+	verb := "is"
+	if len(targets) > 1 {
+		verb = "are"
+	}
+	shortReason := fmt.Sprintf("The %s %s not synced (%d fields changed)", strings.Join(targets, " and "), verb, numChanges)
+	if len(commit) >= 7 {
+		commit = commit[:7]
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Diff between the defined state in Git at commit %s and actual state in ECS:\n\n", commit))
+	b.WriteString("--- Expected\n+++ Actual\n\n")
+
+	if !serviceResult.NoChange() {
+		b.WriteString("- Service definition\n\n")
+		b.WriteString(serviceResult.Render(provider.DiffRenderOptions{}))
+		b.WriteString("\n")
+	}
+	if !taskDefResult.NoChange() {
+		b.WriteString("- Task definition\n\n")
+		b.WriteString(taskDefResult.Render(provider.DiffRenderOptions{}))
+		b.WriteString("\n")
+	}
+
+	return model.ApplicationSyncState{
+		Status:      model.ApplicationSyncStatus_OUT_OF_SYNC,
+		ShortReason: shortReason,
+		Reason:      b.String(),
+		Timestamp:   time.Now().Unix(),
+	}
+}
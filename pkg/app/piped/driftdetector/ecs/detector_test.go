@@ -0,0 +1,238 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/livestatestore/ecs"
+	"github.com/pipe-cd/pipecd/pkg/git"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+const (
+	headServiceDefinition = `{
+  "cluster": "pipecd",
+  "serviceName": "nginx-service",
+  "desiredCount": 2,
+  "deploymentController": {
+    "type": "EXTERNAL"
+  },
+  "launchType": "FARGATE"
+}
+`
+	headTaskDefinition = `{
+  "family": "nginx-service-fam",
+  "networkMode": "awsvpc",
+  "cpu": "256",
+  "memory": "512",
+  "containerDefinitions": [
+    {
+      "name": "web",
+      "image": "gcr.io/pipecd/helloworld:v0.1.0",
+      "essential": true
+    }
+  ]
+}
+`
+)
+
+type fakeReporter struct {
+	states map[string]model.ApplicationSyncState
+}
+
+func (r *fakeReporter) ReportApplicationSyncState(_ context.Context, appID string, state model.ApplicationSyncState) error {
+	r.states[appID] = state
+	return nil
+}
+
+type fakeStateGetter struct {
+	ecs.Getter
+	services        map[string]types.Service
+	taskDefinitions map[string]types.TaskDefinition
+}
+
+func (g *fakeStateGetter) GetServiceDefinition(appID string) (types.Service, bool) {
+	svc, ok := g.services[appID]
+	return svc, ok
+}
+
+func (g *fakeStateGetter) GetTaskDefinition(appID string) (types.TaskDefinition, bool) {
+	td, ok := g.taskDefinitions[appID]
+	return td, ok
+}
+
+func makeLiveService(desiredCount int32) types.Service {
+	return types.Service{
+		ClusterArn:   aws.String("arn:aws:ecs:ap-northeast-1:123456789012:cluster/pipecd"),
+		ServiceArn:   aws.String("arn:aws:ecs:ap-northeast-1:123456789012:service/pipecd/nginx-service"),
+		ServiceName:  aws.String("nginx-service"),
+		Status:       aws.String("ACTIVE"),
+		DesiredCount: desiredCount,
+		RunningCount: desiredCount,
+		DeploymentController: &types.DeploymentController{
+			Type: types.DeploymentControllerTypeExternal,
+		},
+		LaunchType: types.LaunchTypeFargate,
+	}
+}
+
+func makeLiveTaskDefinition(image string) types.TaskDefinition {
+	return types.TaskDefinition{
+		TaskDefinitionArn: aws.String("arn:aws:ecs:ap-northeast-1:123456789012:task-definition/nginx-service-fam:3"),
+		Family:            aws.String("nginx-service-fam"),
+		Revision:          3,
+		Status:            types.TaskDefinitionStatusActive,
+		NetworkMode:       types.NetworkModeAwsvpc,
+		Cpu:               aws.String("256"),
+		Memory:            aws.String("512"),
+		ContainerDefinitions: []types.ContainerDefinition{
+			{
+				Name:      aws.String("web"),
+				Image:     aws.String(image),
+				Essential: aws.Bool(true),
+				Cpu:       0,
+			},
+		},
+	}
+}
+
+func TestCheckApplication(t *testing.T) {
+	testcases := []struct {
+		name string
+		// The desired count of the live service. No live service when it is zero.
+		liveDesiredCount int32
+		// The image of the live task definition. No live task definition when it is empty.
+		liveImage   string
+		wantStatus  model.ApplicationSyncStatus
+		wantShort   string
+		wantReasons []string
+	}{
+		{
+			name:             "synced",
+			liveDesiredCount: 2,
+			liveImage:        "gcr.io/pipecd/helloworld:v0.1.0",
+			wantStatus:       model.ApplicationSyncStatus_SYNCED,
+		},
+		{
+			name:             "task definition is out of sync",
+			liveDesiredCount: 2,
+			liveImage:        "gcr.io/pipecd/helloworld:v0.2.0",
+			wantStatus:       model.ApplicationSyncStatus_OUT_OF_SYNC,
+			wantShort:        "The task definition is not synced (1 fields changed)",
+			wantReasons:      []string{"- Task definition", "containerDefinitions.0.image"},
+		},
+		{
+			name:             "both are out of sync",
+			liveDesiredCount: 3,
+			liveImage:        "gcr.io/pipecd/helloworld:v0.2.0",
+			wantStatus:       model.ApplicationSyncStatus_OUT_OF_SYNC,
+			wantShort:        "The service definition and task definition are not synced (2 fields changed)",
+			wantReasons:      []string{"- Service definition", "desiredCount", "- Task definition"},
+		},
+		{
+			name:             "no primary task set",
+			liveDesiredCount: 2,
+			wantStatus:       model.ApplicationSyncStatus_SYNCED,
+		},
+		{
+			name: "no live state",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			repoDir := filepath.Join(t.TempDir(), "repo")
+			appDir := filepath.Join(repoDir, "app")
+			require.NoError(t, os.MkdirAll(appDir, 0755))
+			appCfg := `apiVersion: pipecd.dev/v1beta1
+kind: ECSApp
+spec:
+  input:
+    serviceDefinitionFile: service.json
+    taskDefinitionFile: taskdef.json
+`
+			require.NoError(t, os.WriteFile(filepath.Join(appDir, "app.pipecd.yaml"), []byte(appCfg), 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(appDir, "service.json"), []byte(headServiceDefinition), 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(appDir, "taskdef.json"), []byte(headTaskDefinition), 0644))
+
+			getter := &fakeStateGetter{
+				services:        make(map[string]types.Service),
+				taskDefinitions: make(map[string]types.TaskDefinition),
+			}
+			if tc.liveDesiredCount > 0 {
+				getter.services["app-1"] = makeLiveService(tc.liveDesiredCount)
+			}
+			if tc.liveImage != "" {
+				getter.taskDefinitions["app-1"] = makeLiveTaskDefinition(tc.liveImage)
+			}
+			reporter := &fakeReporter{
+				states: make(map[string]model.ApplicationSyncState),
+			}
+			d := &detector{
+				stateGetter: getter,
+				reporter:    reporter,
+				logger:      zap.NewNop(),
+			}
+			app := &model.Application{
+				Id:   "app-1",
+				Kind: model.ApplicationKind_ECS,
+				GitPath: &model.ApplicationGitPath{
+					Path:           "app",
+					ConfigFilename: "app.pipecd.yaml",
+				},
+			}
+			repo := git.NewRepo(repoDir, "", "", "master", nil)
+
+			err := d.checkApplication(context.Background(), app, repo, git.Commit{Hash: "0123456789abcdef"})
+			require.NoError(t, err)
+
+			state, ok := reporter.states["app-1"]
+			if tc.liveDesiredCount == 0 {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tc.wantStatus, state.Status)
+			assert.Equal(t, tc.wantShort, state.ShortReason)
+			for _, r := range tc.wantReasons {
+				assert.Contains(t, state.Reason, r)
+			}
+		})
+	}
+}
+
+func TestIgnoreUnretrievableFields(t *testing.T) {
+	head := types.Service{
+		ClusterArn: aws.String("pipecd"),
+		RoleArn:    aws.String("arn:aws:iam::123456789012:role/ecs-role"),
+	}
+	live := types.Service{
+		ClusterArn: aws.String("arn:aws:ecs:ap-northeast-1:123456789012:cluster/pipecd"),
+		RoleArn:    aws.String("arn:aws:iam::123456789012:role/another-role"),
+	}
+
+	got := ignoreUnretrievableFields(head, live)
+	assert.Equal(t, "pipecd", aws.ToString(got.ClusterArn))
+	assert.Equal(t, "arn:aws:iam::123456789012:role/another-role", aws.ToString(got.RoleArn))
+}
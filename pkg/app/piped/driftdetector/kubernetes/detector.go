@@ -38,6 +38,10 @@ type applicationLister interface {
 	ListByCloudProvider(name string) []*model.Application
 }
 
+type deployWindowLister interface {
+	Get() *config.DeployWindows
+}
+
 type gitClient interface {
 	Clone(ctx context.Context, repoID, remote, branch, destination string) (git.Repo, error)
 }
@@ -48,6 +52,7 @@ type secretDecrypter interface {
 
 type reporter interface {
 	ReportApplicationSyncState(ctx context.Context, appID string, state model.ApplicationSyncState) error
+	ReportSelfHealDeployment(ctx context.Context, deployment *model.Deployment) error
 }
 
 type detector struct {
@@ -55,6 +60,7 @@ type detector struct {
 	appLister         applicationLister
 	gitClient         gitClient
 	stateGetter       kubernetes.Getter
+	deployWindows     deployWindowLister
 	reporter          reporter
 	appManifestsCache cache.Cache
 	interval          time.Duration
@@ -64,6 +70,8 @@ type detector struct {
 
 	gitRepos   map[string]git.Repo
 	syncStates map[string]model.ApplicationSyncState
+	// The last time each application was self-healed.
+	healedAt map[string]time.Time
 }
 
 func NewDetector(
@@ -71,6 +79,7 @@ func NewDetector(
 	appLister applicationLister,
 	gitClient gitClient,
 	stateGetter kubernetes.Getter,
+	deployWindows deployWindowLister,
 	reporter reporter,
 	appManifestsCache cache.Cache,
 	cfg *config.PipedSpec,
@@ -86,6 +95,7 @@ func NewDetector(
 		appLister:         appLister,
 		gitClient:         gitClient,
 		stateGetter:       stateGetter,
+		deployWindows:     deployWindows,
 		reporter:          reporter,
 		appManifestsCache: appManifestsCache,
 		interval:          time.Minute,
@@ -93,6 +103,7 @@ func NewDetector(
 		secretDecrypter:   sd,
 		gitRepos:          make(map[string]git.Repo),
 		syncStates:        make(map[string]model.ApplicationSyncState),
+		healedAt:          make(map[string]time.Time),
 		logger:            logger,
 	}
 }
@@ -186,6 +197,10 @@ func (d *detector) checkApplication(ctx context.Context, app *model.Application,
 	liveManifests = filterIgnoringManifests(liveManifests)
 	d.logger.Info(fmt.Sprintf("application %s has %d live manifests", app.Id, len(liveManifests)))
 
+	// The TrafficSplits generated while deploying are not defined in Git
+	// so we add their expected states to the head manifests.
+	headManifests = appendGeneratedTrafficSplits(headManifests, liveManifests)
+
 	result, err := provider.DiffList(
 		headManifests,
 		liveManifests,
@@ -198,8 +213,14 @@ func (d *detector) checkApplication(ctx context.Context, app *model.Application,
 	}
 
 	state := makeSyncState(result, headCommit.Hash)
+	if err := d.reporter.ReportApplicationSyncState(ctx, app.Id, state); err != nil {
+		return err
+	}
 
-	return d.reporter.ReportApplicationSyncState(ctx, app.Id, state)
+	if result.NoChange() {
+		return nil
+	}
+	return d.selfHeal(ctx, app, repo, headCommit, headManifests, result)
 }
 
 func (d *detector) loadHeadManifests(ctx context.Context, app *model.Application, repo git.Repo, headCommit git.Commit, watchingResourceKinds []provider.APIVersionKind) ([]provider.Manifest, error) {
@@ -329,6 +350,47 @@ func filterIgnoringManifests(manifests []provider.Manifest) []provider.Manifest
 	return out
 }
 
+// appendGeneratedTrafficSplits appends the expected state of every TrafficSplit
+// that was generated by piped for SMI traffic routing.
+// After a completed deployment, all traffic should be routed to the PRIMARY variant.
+func appendGeneratedTrafficSplits(headManifests, liveManifests []provider.Manifest) []provider.Manifest {
+	for _, lm := range liveManifests {
+		if lm.Key.Kind != provider.KindTrafficSplit {
+			continue
+		}
+		if lm.GetAnnotations()[provider.LabelManagedBy] != provider.ManagedByPiped {
+			continue
+		}
+		spec, err := lm.GetNestedMap("spec")
+		if err != nil {
+			continue
+		}
+		rootService, _ := spec["service"].(string)
+		if rootService == "" {
+			continue
+		}
+		for _, hm := range headManifests {
+			if !hm.Key.IsService() || hm.Key.Name != rootService {
+				continue
+			}
+			var namespace string
+			if metadata, err := hm.GetNestedMap("metadata"); err == nil {
+				namespace, _ = metadata["namespace"].(string)
+			}
+			expected := provider.MakeTrafficSplitManifest(
+				lm.Key.APIVersion,
+				lm.Key.Name,
+				namespace,
+				rootService,
+				[]provider.TrafficSplitBackend{{Service: rootService, Weight: 100}},
+			)
+			headManifests = append(headManifests, expected)
+			break
+		}
+	}
+	return headManifests
+}
+
 func makeSyncState(r *provider.DiffListResult, commit string) model.ApplicationSyncState {
 	if r.NoChange() {
 		return model.ApplicationSyncState{
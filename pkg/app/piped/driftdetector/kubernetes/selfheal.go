@@ -0,0 +1,289 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/git"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+const (
+	variantLabel   = "pipecd.dev/variant"
+	primaryVariant = "primary"
+)
+
+// selfHeal re-applies the drifted resources of the given application
+// when its self-heal policy is enabled and allows doing that at this time.
+// Each self-heal is recorded as a completed deployment triggered by ON_OUT_OF_SYNC.
+func (d *detector) selfHeal(ctx context.Context, app *model.Application, repo git.Repo, headCommit git.Commit, headManifests []provider.Manifest, result *provider.DiffListResult) error {
+	repoDir := repo.GetPath()
+	cfg, err := d.loadApplicationConfiguration(repoDir, app)
+	if err != nil {
+		return fmt.Errorf("failed to load application configuration: %w", err)
+	}
+	if cfg.KubernetesApplicationSpec == nil {
+		return fmt.Errorf("unsupport application kind %s", cfg.Kind)
+	}
+
+	policy := cfg.KubernetesApplicationSpec.Trigger.OnOutOfSync.SelfHeal
+	if !policy.Enabled {
+		return nil
+	}
+
+	now := time.Now()
+	if ok, reason := checkSelfHealable(app, headCommit.Hash, policy.MinInterval.Duration(), d.healedAt[app.Id], now); !ok {
+		d.logger.Info(fmt.Sprintf("skip self-healing application %s: %s", app.Id, reason))
+		return nil
+	}
+
+	// Self-heal changes the running resources as same as a deployment does,
+	// so it must be blocked by the deploy windows of all levels.
+	allowed, reason, err := config.CheckDeployWindows(now, d.deployWindows.Get(), d.config.DeployWindows, cfg.KubernetesApplicationSpec.DeployWindows)
+	if err != nil {
+		return fmt.Errorf("failed to check deploy windows: %w", err)
+	}
+	if !allowed {
+		d.logger.Info(fmt.Sprintf("skip self-healing application %s: %s", app.Id, reason))
+		return nil
+	}
+
+	manifests, err := makeSelfHealManifests(headManifests, result, &policy, app, headCommit.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to make manifests for self-healing: %w", err)
+	}
+	if len(manifests) == 0 {
+		d.logger.Info(fmt.Sprintf("skip self-healing application %s: no drifted resource of the allowed kinds", app.Id))
+		return nil
+	}
+
+	d.logger.Info(fmt.Sprintf("start self-healing %d drifted resources of application %s", len(manifests), app.Id))
+	// Record the time before applying to avoid retrying too frequently when it was failed.
+	d.healedAt[app.Id] = now
+
+	var (
+		appDir   = filepath.Join(repoDir, app.GitPath.Path)
+		applier  = provider.NewProvider(app.Name, appDir, repoDir, app.GitPath.ConfigFilename, cfg.KubernetesApplicationSpec.Input, d.gitClient, d.logger)
+		reverted = make([]provider.ResourceKey, 0, len(manifests))
+		applyErr error
+	)
+	for _, m := range manifests {
+		if err := applier.ApplyManifest(ctx, m); err != nil {
+			applyErr = fmt.Errorf("failed to apply manifest %s: %w", m.Key.ReadableString(), err)
+			break
+		}
+		reverted = append(reverted, m.Key)
+	}
+
+	deployment := buildSelfHealDeployment(app, reverted, applyErr, now)
+	if err := d.reporter.ReportSelfHealDeployment(ctx, deployment); err != nil {
+		return err
+	}
+
+	if applyErr != nil {
+		return applyErr
+	}
+	d.logger.Info(fmt.Sprintf("successfully self-healed %d drifted resources of application %s", len(reverted), app.Id),
+		zap.String("deployment-id", deployment.Id),
+	)
+	return nil
+}
+
+// checkSelfHealable checks whether the drifted resources of the given application can be re-applied now.
+// The configuration drift is considered to be caused by changes made directly to the running resources
+// only when the head commit has already been deployed successfully and no other deployment was triggered after that.
+func checkSelfHealable(app *model.Application, headCommit string, minInterval time.Duration, lastHealedAt, now time.Time) (bool, string) {
+	successful := app.MostRecentlySuccessfulDeployment
+	if successful == nil {
+		return false, "no deployment has been completed successfully yet"
+	}
+	if successful.Trigger.GetCommit().GetHash() != headCommit {
+		return false, "the head commit has not been deployed yet"
+	}
+	if triggered := app.MostRecentlyTriggeredDeployment; triggered != nil && triggered.DeploymentId != successful.DeploymentId {
+		return false, "another deployment was triggered after the most recently successful one"
+	}
+
+	last := time.Unix(successful.CompletedAt, 0)
+	if lastHealedAt.After(last) {
+		last = lastHealedAt
+	}
+	if now.Sub(last) < minInterval {
+		return false, "the minimum interval has not elapsed since the last self-heal or deployment"
+	}
+	return true, ""
+}
+
+// makeSelfHealManifests returns the manifests defined in Git for the drifted resources
+// of the allowed kinds with the builtin annotations added.
+// As same as while deploying, the config hash annotation is added into the workloads
+// by using all of the ConfigMaps and Secrets defined in Git. The workloads referencing
+// the drifted ConfigMaps or Secrets are also returned so that they are applied
+// together with the restored configs.
+// The resources running in the cluster but not defined in Git are left as they are.
+func makeSelfHealManifests(headManifests []provider.Manifest, result *provider.DiffListResult, policy *config.SelfHeal, app *model.Application, commit string) ([]provider.Manifest, error) {
+	// Duplicate to avoid modifying the cached manifests.
+	heads := make(map[provider.ResourceKey]provider.Manifest, len(headManifests))
+	duplicated := make([]provider.Manifest, 0, len(headManifests))
+	for _, m := range headManifests {
+		m = m.Duplicate(m.Key.Name)
+		heads[m.Key] = m
+		duplicated = append(duplicated, m)
+	}
+	if err := provider.AnnotateConfigHash(duplicated); err != nil {
+		return nil, fmt.Errorf("failed to annotate config hash: %w", err)
+	}
+
+	var (
+		manifests  = make([]provider.Manifest, 0, len(result.Deletes)+len(result.Changes))
+		added      = make(map[provider.ResourceKey]struct{}, len(result.Deletes)+len(result.Changes))
+		configMaps = make(map[string]struct{})
+		secrets    = make(map[string]struct{})
+	)
+	add := func(key provider.ResourceKey) {
+		if _, ok := added[key]; ok || !policy.IsAllowedKind(key.Kind) {
+			return
+		}
+		m, ok := heads[key]
+		if !ok {
+			return
+		}
+		m.AddAnnotations(map[string]string{
+			provider.LabelManagedBy:          provider.ManagedByPiped,
+			provider.LabelPiped:              app.PipedId,
+			provider.LabelApplication:        app.Id,
+			variantLabel:                     primaryVariant,
+			provider.LabelOriginalAPIVersion: m.Key.APIVersion,
+			provider.LabelResourceKey:        m.Key.String(),
+			provider.LabelCommitHash:         commit,
+		})
+		manifests = append(manifests, m)
+		added[key] = struct{}{}
+		if key.IsConfigMap() {
+			configMaps[key.Name] = struct{}{}
+		}
+		if key.IsSecret() {
+			secrets[key.Name] = struct{}{}
+		}
+	}
+
+	// The head manifests were given as the old ones while calculating the diff,
+	// so the deleted ones are the resources missing in the cluster.
+	for _, m := range result.Deletes {
+		add(m.Key)
+	}
+	for _, c := range result.Changes {
+		add(c.Old.Key)
+	}
+
+	if len(configMaps)+len(secrets) == 0 {
+		return manifests, nil
+	}
+	for _, m := range duplicated {
+		ok, err := referencesConfigs(m, configMaps, secrets)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			add(m.Key)
+		}
+	}
+	return manifests, nil
+}
+
+// referencesConfigs reports whether the given workload manifest
+// references any of the given ConfigMaps or Secrets.
+func referencesConfigs(m provider.Manifest, configMaps, secrets map[string]struct{}) (bool, error) {
+	// TODO: Support other workload kinds such as DaemonSet, StatefulSet
+	// after their config hash got supported.
+	if !m.Key.IsDeployment() {
+		return false, nil
+	}
+	d := &appsv1.Deployment{}
+	if err := m.ConvertToStructuredObject(d); err != nil {
+		return false, err
+	}
+	for _, name := range provider.FindReferencingConfigMapsInDeployment(d) {
+		if _, ok := configMaps[name]; ok {
+			return true, nil
+		}
+	}
+	for _, name := range provider.FindReferencingSecretsInDeployment(d) {
+		if _, ok := secrets[name]; ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func buildSelfHealDeployment(app *model.Application, reverted []provider.ResourceKey, applyErr error, now time.Time) *model.Deployment {
+	names := make([]string, 0, len(reverted))
+	for _, k := range reverted {
+		names = append(names, fmt.Sprintf("%s/%s", k.Kind, k.Name))
+	}
+
+	var (
+		successful   = app.MostRecentlySuccessfulDeployment
+		summary      = fmt.Sprintf("Reverted the configuration drift of %d resources: %s", len(reverted), strings.Join(names, ", "))
+		status       = model.DeploymentStatus_DEPLOYMENT_SUCCESS
+		statusReason = "The drifted resources were re-applied successfully"
+	)
+	if len(reverted) == 0 {
+		summary = "Failed to revert the configuration drift"
+	}
+	if applyErr != nil {
+		status = model.DeploymentStatus_DEPLOYMENT_FAILURE
+		statusReason = applyErr.Error()
+	}
+
+	return &model.Deployment{
+		Id:              uuid.New().String(),
+		ApplicationId:   app.Id,
+		ApplicationName: app.Name,
+		EnvId:           app.EnvId,
+		PipedId:         app.PipedId,
+		ProjectId:       app.ProjectId,
+		Kind:            app.Kind,
+		Trigger: &model.DeploymentTrigger{
+			Commit:          successful.Trigger.Commit,
+			Kind:            model.TriggerKind_ON_OUT_OF_SYNC,
+			Timestamp:       now.Unix(),
+			SyncStrategy:    model.SyncStrategy_QUICK_SYNC,
+			StrategySummary: "Self-heal the configuration drift by re-applying the drifted resources",
+		},
+		GitPath:           app.GitPath,
+		CloudProvider:     app.CloudProvider,
+		Labels:            app.Labels,
+		RunningCommitHash: successful.Trigger.Commit.Hash,
+		Summary:           summary,
+		Version:           successful.Version,
+		Status:            status,
+		StatusReason:      statusReason,
+		CompletedAt:       now.Unix(),
+		CreatedAt:         now.Unix(),
+		UpdatedAt:         now.Unix(),
+	}
+}
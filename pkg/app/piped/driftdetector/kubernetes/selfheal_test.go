@@ -0,0 +1,367 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+func TestCheckSelfHealable(t *testing.T) {
+	now := time.Now()
+	successful := &model.ApplicationDeploymentReference{
+		DeploymentId: "deployment-1",
+		Trigger: &model.DeploymentTrigger{
+			Commit: &model.Commit{Hash: "head"},
+		},
+		CompletedAt: now.Add(-time.Hour).Unix(),
+	}
+
+	testcases := []struct {
+		name         string
+		app          *model.Application
+		lastHealedAt time.Time
+		expected     bool
+	}{
+		{
+			name:     "no successful deployment",
+			app:      &model.Application{},
+			expected: false,
+		},
+		{
+			name: "head commit has not been deployed yet",
+			app: &model.Application{
+				MostRecentlySuccessfulDeployment: &model.ApplicationDeploymentReference{
+					DeploymentId: "deployment-1",
+					Trigger: &model.DeploymentTrigger{
+						Commit: &model.Commit{Hash: "previous"},
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "another deployment was triggered",
+			app: &model.Application{
+				MostRecentlySuccessfulDeployment: successful,
+				MostRecentlyTriggeredDeployment: &model.ApplicationDeploymentReference{
+					DeploymentId: "deployment-2",
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "healed recently",
+			app: &model.Application{
+				MostRecentlySuccessfulDeployment: successful,
+				MostRecentlyTriggeredDeployment:  successful,
+			},
+			lastHealedAt: now.Add(-time.Minute),
+			expected:     false,
+		},
+		{
+			name: "deployed recently",
+			app: &model.Application{
+				MostRecentlySuccessfulDeployment: &model.ApplicationDeploymentReference{
+					DeploymentId: "deployment-1",
+					Trigger: &model.DeploymentTrigger{
+						Commit: &model.Commit{Hash: "head"},
+					},
+					CompletedAt: now.Add(-time.Minute).Unix(),
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "healable",
+			app: &model.Application{
+				MostRecentlySuccessfulDeployment: successful,
+				MostRecentlyTriggeredDeployment:  successful,
+			},
+			lastHealedAt: now.Add(-20 * time.Minute),
+			expected:     true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, reason := checkSelfHealable(tc.app, "head", 10*time.Minute, tc.lastHealedAt, now)
+			assert.Equal(t, tc.expected, got)
+			assert.Equal(t, tc.expected, reason == "")
+		})
+	}
+}
+
+func TestMakeSelfHealManifests(t *testing.T) {
+	heads, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: simple
+spec:
+  replicas: 2
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: simple
+data:
+  key: value
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: simple
+spec:
+  type: ClusterIP
+`)
+	require.NoError(t, err)
+	require.Len(t, heads, 3)
+
+	lives, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: simple
+spec:
+  replicas: 5
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: simple
+spec:
+  type: NodePort
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: added
+`)
+	require.NoError(t, err)
+
+	result, err := provider.DiffList(heads, lives)
+	require.NoError(t, err)
+	require.Len(t, result.Deletes, 1)
+	require.Len(t, result.Adds, 1)
+	require.Len(t, result.Changes, 2)
+
+	app := &model.Application{
+		Id:      "app-id",
+		PipedId: "piped-id",
+	}
+	policy := &config.SelfHeal{
+		Enabled: true,
+		Kinds:   []string{"Deployment", "ConfigMap", "Secret"},
+	}
+	got, err := makeSelfHealManifests(heads, result, policy, app, "commit-hash")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	assert.Equal(t, "ConfigMap", got[0].Key.Kind)
+	assert.Equal(t, "Deployment", got[1].Key.Kind)
+	replicas, err := got[1].GetNestedMap("spec")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), replicas["replicas"])
+
+	annotations := got[1].GetAnnotations()
+	assert.Equal(t, provider.ManagedByPiped, annotations[provider.LabelManagedBy])
+	assert.Equal(t, "piped-id", annotations[provider.LabelPiped])
+	assert.Equal(t, "app-id", annotations[provider.LabelApplication])
+	assert.Equal(t, primaryVariant, annotations[variantLabel])
+	assert.Equal(t, "commit-hash", annotations[provider.LabelCommitHash])
+
+	// The head manifests must not be modified.
+	for _, m := range heads {
+		assert.Empty(t, m.GetAnnotations())
+	}
+}
+
+func TestMakeSelfHealManifestsWithConfigHash(t *testing.T) {
+	heads, err := provider.ParseManifests(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: simple
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+        - name: helloworld
+          image: gcr.io/pipecd/helloworld:v0.1.0
+      volumes:
+        - name: config
+          configMap:
+            name: simple-config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: simple-config
+data:
+  key: value
+`)
+	require.NoError(t, err)
+	require.Len(t, heads, 2)
+
+	hash, err := provider.HashManifests(heads[1:])
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name          string
+		lives         string
+		expectedKinds []string
+	}{
+		{
+			name: "drifted workload keeps the config hash",
+			lives: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: simple
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+        - name: helloworld
+          image: gcr.io/pipecd/helloworld:v0.1.0
+      volumes:
+        - name: config
+          configMap:
+            name: simple-config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: simple-config
+data:
+  key: value
+`,
+			expectedKinds: []string{"Deployment"},
+		},
+		{
+			name: "workload referencing drifted config is re-applied with the config hash",
+			lives: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: simple
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+        - name: helloworld
+          image: gcr.io/pipecd/helloworld:v0.1.0
+      volumes:
+        - name: config
+          configMap:
+            name: simple-config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: simple-config
+data:
+  key: changed
+`,
+			expectedKinds: []string{"ConfigMap", "Deployment"},
+		},
+	}
+
+	app := &model.Application{
+		Id:      "app-id",
+		PipedId: "piped-id",
+	}
+	policy := &config.SelfHeal{
+		Enabled: true,
+		Kinds:   []string{"Deployment", "ConfigMap"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			lives, err := provider.ParseManifests(tc.lives)
+			require.NoError(t, err)
+
+			result, err := provider.DiffList(heads, lives)
+			require.NoError(t, err)
+
+			got, err := makeSelfHealManifests(heads, result, policy, app, "commit-hash")
+			require.NoError(t, err)
+
+			kinds := make([]string, 0, len(got))
+			for _, m := range got {
+				kinds = append(kinds, m.Key.Kind)
+			}
+			require.Equal(t, tc.expectedKinds, kinds)
+
+			deployment := got[len(got)-1]
+			annotations, err := deployment.GetNestedStringMap("spec", "template", "metadata", "annotations")
+			require.NoError(t, err)
+			assert.Equal(t, hash, annotations[provider.AnnotationConfigHash])
+
+			// The head manifests must not be modified.
+			annotations, err = heads[0].GetNestedStringMap("spec", "template", "metadata", "annotations")
+			require.NoError(t, err)
+			assert.Empty(t, annotations)
+		})
+	}
+}
+
+func TestBuildSelfHealDeployment(t *testing.T) {
+	now := time.Now()
+	app := &model.Application{
+		Id:            "app-id",
+		Name:          "app-name",
+		PipedId:       "piped-id",
+		ProjectId:     "project-id",
+		Kind:          model.ApplicationKind_KUBERNETES,
+		CloudProvider: "kubernetes-default",
+		MostRecentlySuccessfulDeployment: &model.ApplicationDeploymentReference{
+			DeploymentId: "deployment-1",
+			Trigger: &model.DeploymentTrigger{
+				Commit: &model.Commit{Hash: "commit-hash"},
+			},
+			Version: "v1.0.0",
+		},
+	}
+	reverted := []provider.ResourceKey{
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "simple"},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "simple"},
+	}
+
+	got := buildSelfHealDeployment(app, reverted, nil, now)
+	assert.NotEmpty(t, got.Id)
+	assert.Equal(t, model.TriggerKind_ON_OUT_OF_SYNC, got.Trigger.Kind)
+	assert.Equal(t, "commit-hash", got.Trigger.Commit.Hash)
+	assert.Equal(t, "commit-hash", got.RunningCommitHash)
+	assert.Equal(t, "v1.0.0", got.Version)
+	assert.Equal(t, model.DeploymentStatus_DEPLOYMENT_SUCCESS, got.Status)
+	assert.Equal(t, "Reverted the configuration drift of 2 resources: Deployment/simple, ConfigMap/simple", got.Summary)
+	assert.Equal(t, now.Unix(), got.CompletedAt)
+
+	got = buildSelfHealDeployment(app, reverted[:1], errors.New("failed to apply"), now)
+	assert.Equal(t, model.DeploymentStatus_DEPLOYMENT_FAILURE, got.Status)
+	assert.Equal(t, "failed to apply", got.StatusReason)
+	assert.Equal(t, "Reverted the configuration drift of 1 resources: Deployment/simple", got.Summary)
+}
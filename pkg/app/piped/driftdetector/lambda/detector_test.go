@@ -0,0 +1,160 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lambda
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/lambda"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/livestatestore/lambda"
+	"github.com/pipe-cd/pipecd/pkg/git"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+const headFunctionManifest = `apiVersion: pipecd.dev/v1beta1
+kind: LambdaFunction
+spec:
+  name: SimpleFunction
+  role: arn:aws:iam::123456789012:role/lambda-role
+  s3Bucket: pipecd-sample
+  s3Key: function-code
+  s3ObjectVersion: xyz
+  handler: app.handler
+  runtime: python3.9
+  memory: 128
+  timeout: 5
+  tags:
+    app: simple
+`
+
+type fakeReporter struct {
+	states map[string]model.ApplicationSyncState
+}
+
+func (r *fakeReporter) ReportApplicationSyncState(_ context.Context, appID string, state model.ApplicationSyncState) error {
+	r.states[appID] = state
+	return nil
+}
+
+type fakeStateGetter struct {
+	lambda.Getter
+	manifests map[string]provider.FunctionManifest
+}
+
+func (g *fakeStateGetter) GetFunctionManifest(appID string) (provider.FunctionManifest, bool) {
+	fm, ok := g.manifests[appID]
+	return fm, ok
+}
+
+func TestCheckApplication(t *testing.T) {
+	testcases := []struct {
+		name       string
+		liveMemory int32
+		noLive     bool
+		wantStatus model.ApplicationSyncStatus
+		wantShort  string
+	}{
+		{
+			name:       "synced",
+			liveMemory: 128,
+			wantStatus: model.ApplicationSyncStatus_SYNCED,
+		},
+		{
+			name:       "out of sync",
+			liveMemory: 512,
+			wantStatus: model.ApplicationSyncStatus_OUT_OF_SYNC,
+			wantShort:  "The function manifest is not synced (1 fields changed)",
+		},
+		{
+			name:   "no live state",
+			noLive: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			repoDir := filepath.Join(t.TempDir(), "repo")
+			appDir := filepath.Join(repoDir, "app")
+			require.NoError(t, os.MkdirAll(appDir, 0755))
+			appCfg := `apiVersion: pipecd.dev/v1beta1
+kind: LambdaApp
+spec:
+  input:
+    functionManifestFile: function.yaml
+`
+			require.NoError(t, os.WriteFile(filepath.Join(appDir, "app.pipecd.yaml"), []byte(appCfg), 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(appDir, "function.yaml"), []byte(headFunctionManifest), 0644))
+
+			getter := &fakeStateGetter{
+				manifests: make(map[string]provider.FunctionManifest),
+			}
+			if !tc.noLive {
+				// The live manifest does not contain the location of the deployment package.
+				getter.manifests["app-1"] = provider.FunctionManifest{
+					Kind:       "LambdaFunction",
+					APIVersion: "pipecd.dev/v1beta1",
+					Spec: provider.FunctionManifestSpec{
+						Name:    "SimpleFunction",
+						Role:    "arn:aws:iam::123456789012:role/lambda-role",
+						Handler: "app.handler",
+						Runtime: "python3.9",
+						Memory:  tc.liveMemory,
+						Timeout: 5,
+						Tags:    map[string]string{"app": "simple"},
+					},
+				}
+			}
+			reporter := &fakeReporter{
+				states: make(map[string]model.ApplicationSyncState),
+			}
+			d := &detector{
+				stateGetter: getter,
+				reporter:    reporter,
+				logger:      zap.NewNop(),
+			}
+			app := &model.Application{
+				Id:   "app-1",
+				Kind: model.ApplicationKind_LAMBDA,
+				GitPath: &model.ApplicationGitPath{
+					Path:           "app",
+					ConfigFilename: "app.pipecd.yaml",
+				},
+			}
+			repo := git.NewRepo(repoDir, "", "", "master", nil)
+
+			err := d.checkApplication(context.Background(), app, repo, git.Commit{Hash: "0123456789abcdef"})
+			require.NoError(t, err)
+
+			state, ok := reporter.states["app-1"]
+			if tc.noLive {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tc.wantStatus, state.Status)
+			assert.Equal(t, tc.wantShort, state.ShortReason)
+			if tc.wantStatus == model.ApplicationSyncStatus_OUT_OF_SYNC {
+				assert.Contains(t, state.Reason, "at commit 0123456")
+				assert.Contains(t, state.Reason, "#spec.memory")
+			}
+		})
+	}
+}
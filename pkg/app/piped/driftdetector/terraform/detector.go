@@ -13,3 +13,356 @@
 // limitations under the License.
 
 package terraform
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/terraform"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/sourcedecrypter"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/toolregistry"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/git"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type applicationLister interface {
+	ListByCloudProvider(name string) []*model.Application
+}
+
+type gitClient interface {
+	Clone(ctx context.Context, repoID, remote, branch, destination string) (git.Repo, error)
+}
+
+type secretDecrypter interface {
+	Decrypt(string) (string, error)
+}
+
+type reporter interface {
+	ReportApplicationSyncState(ctx context.Context, appID string, state model.ApplicationSyncState) error
+}
+
+// terraformFinder returns the path to the terraform binary of the given version.
+type terraformFinder func(ctx context.Context, version string) (string, bool, error)
+
+type detector struct {
+	provider        config.PipedCloudProvider
+	appLister       applicationLister
+	gitClient       gitClient
+	reporter        reporter
+	findTerraform   terraformFinder
+	interval        time.Duration
+	concurrency     int
+	config          *config.PipedSpec
+	secretDecrypter secretDecrypter
+	logger          *zap.Logger
+
+	gitRepos map[string]git.Repo
+}
+
+func NewDetector(
+	cp config.PipedCloudProvider,
+	appLister applicationLister,
+	gitClient gitClient,
+	reporter reporter,
+	cfg *config.PipedSpec,
+	sd secretDecrypter,
+	logger *zap.Logger,
+) *detector {
+
+	logger = logger.Named("terraform-detector").With(
+		zap.String("cloud-provider", cp.Name),
+	)
+	dd := cp.TerraformConfig.DriftDetection
+	return &detector{
+		provider:        cp,
+		appLister:       appLister,
+		gitClient:       gitClient,
+		reporter:        reporter,
+		findTerraform:   toolregistry.DefaultRegistry().Terraform,
+		interval:        dd.Interval.Duration(),
+		concurrency:     dd.Concurrency,
+		config:          cfg,
+		secretDecrypter: sd,
+		gitRepos:        make(map[string]git.Repo),
+		logger:          logger,
+	}
+}
+
+func (d *detector) Run(ctx context.Context) error {
+	d.logger.Info("start running drift detector for terraform applications")
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+L:
+	for {
+		select {
+		case <-ticker.C:
+			d.check(ctx)
+
+		case <-ctx.Done():
+			break L
+		}
+	}
+
+	d.logger.Info("drift detector for terraform applications has been stopped")
+	return nil
+}
+
+func (d *detector) check(ctx context.Context) error {
+	appsByRepo := d.listGroupedApplication()
+
+	for repoID, apps := range appsByRepo {
+		gitRepo, ok := d.gitRepos[repoID]
+		if !ok {
+			// Clone repository for the first time.
+			repoCfg, ok := d.config.GetRepository(repoID)
+			if !ok {
+				d.logger.Error(fmt.Sprintf("repository %s was not found in piped configuration", repoID))
+				continue
+			}
+			gr, err := d.gitClient.Clone(ctx, repoID, repoCfg.Remote, repoCfg.Branch, "")
+			if err != nil {
+				d.logger.Error("failed to clone repository",
+					zap.String("repo-id", repoID),
+					zap.Error(err),
+				)
+				continue
+			}
+			gitRepo = gr
+			d.gitRepos[repoID] = gitRepo
+		}
+
+		// Fetch the latest commit to compare the states.
+		branch := gitRepo.GetClonedBranch()
+		if err := gitRepo.Pull(ctx, branch); err != nil {
+			d.logger.Error("failed to update repository branch",
+				zap.String("repo-id", repoID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		// Get the head commit of the repository.
+		headCommit, err := gitRepo.GetLatestCommit(ctx)
+		if err != nil {
+			d.logger.Error("failed to get head commit hash",
+				zap.String("repo-id", repoID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		// Start checking all applications in this repository.
+		// Since running terraform plan takes time, multiple applications
+		// are checked at the same time up to the configured concurrency.
+		var (
+			wg  sync.WaitGroup
+			sem = make(chan struct{}, d.concurrency)
+		)
+		for _, app := range apps {
+			app := app
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				if err := d.checkApplication(ctx, app, gitRepo, headCommit); err != nil {
+					d.logger.Error(fmt.Sprintf("failed to check application: %s", app.Id), zap.Error(err))
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	return nil
+}
+
+func (d *detector) checkApplication(ctx context.Context, app *model.Application, repo git.Repo, headCommit git.Commit) error {
+	// Terraform commands write some files such as the downloaded providers
+	// and the lock file into the application directory, and decrypting the sealed secrets
+	// changes the files as well, so we run them on a copy of the repository.
+	dir, err := os.MkdirTemp("", "detector-terraform")
+	if err != nil {
+		return fmt.Errorf("failed to prepare a temporary directory for git repository (%w)", err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err = repo.Copy(filepath.Join(dir, "repo"))
+	if err != nil {
+		return fmt.Errorf("failed to copy the cloned git repository (%w)", err)
+	}
+	var (
+		repoDir = repo.GetPath()
+		appDir  = filepath.Join(repoDir, app.GitPath.Path)
+	)
+
+	cfg, err := d.loadApplicationConfiguration(repoDir, app)
+	if err != nil {
+		return fmt.Errorf("failed to load application configuration: %w", err)
+	}
+	appCfg := cfg.TerraformApplicationSpec
+	if appCfg == nil {
+		return fmt.Errorf("missing Terraform spec field in application configuration")
+	}
+
+	if d.secretDecrypter != nil && appCfg.Encryption != nil {
+		if err := sourcedecrypter.DecryptSecrets(appDir, *appCfg.Encryption, d.secretDecrypter); err != nil {
+			return fmt.Errorf("failed to decrypt secrets (%w)", err)
+		}
+	}
+
+	version := appCfg.Input.TerraformVersion
+	terraformPath, installed, err := d.findTerraform(ctx, version)
+	if err != nil {
+		return fmt.Errorf("unable to find the specified terraform version %q (%w)", version, err)
+	}
+	if installed {
+		d.logger.Info(fmt.Sprintf("terraform %q has just been installed to %q because of no pre-installed binary for that version", version, terraformPath))
+	}
+
+	vars := make([]string, 0, len(d.provider.TerraformConfig.Vars)+len(appCfg.Input.Vars))
+	vars = append(vars, d.provider.TerraformConfig.Vars...)
+	vars = append(vars, appCfg.Input.Vars...)
+	var (
+		flags = appCfg.Input.CommandFlags
+		envs  = appCfg.Input.CommandEnvs
+		cmd   = provider.NewTerraform(
+			terraformPath,
+			appDir,
+			provider.WithoutColor(),
+			provider.WithVars(vars),
+			provider.WithVarFiles(appCfg.Input.VarFiles),
+			provider.WithAdditionalFlags(flags.Shared, flags.Init, flags.Plan, flags.Apply),
+			provider.WithAdditionalEnvs(envs.Shared, envs.Init, envs.Plan, envs.Apply),
+		)
+		buf bytes.Buffer
+	)
+
+	if err := cmd.Init(ctx, &buf); err != nil {
+		return fmt.Errorf("failed while executing terraform init (%w): %s", err, buf.String())
+	}
+	if ws := appCfg.Input.Workspace; ws != "" {
+		if err := cmd.SelectWorkspace(ctx, ws); err != nil {
+			return fmt.Errorf("failed to select workspace %q (%w)", ws, err)
+		}
+	}
+
+	buf.Reset()
+	result, err := cmd.Plan(ctx, &buf)
+	if err != nil {
+		return fmt.Errorf("failed while executing terraform plan (%w): %s", err, buf.String())
+	}
+	d.logger.Info(fmt.Sprintf("application %s has %d to add, %d to change, %d to destroy at commit %s", app.Id, result.Adds, result.Changes, result.Destroys, headCommit.Hash))
+
+	state := makeSyncState(result, buf.String(), headCommit.Hash)
+
+	return d.reporter.ReportApplicationSyncState(ctx, app.Id, state)
+}
+
+// listGroupedApplication retrieves all applications those should be handled by this director
+// and then groups them by repoID.
+func (d *detector) listGroupedApplication() map[string][]*model.Application {
+	var (
+		apps = d.appLister.ListByCloudProvider(d.provider.Name)
+		m    = make(map[string][]*model.Application)
+	)
+	for _, app := range apps {
+		repoID := app.GitPath.Repo.Id
+		if _, ok := m[repoID]; !ok {
+			m[repoID] = []*model.Application{app}
+		} else {
+			m[repoID] = append(m[repoID], app)
+		}
+	}
+	return m
+}
+
+func (d *detector) loadApplicationConfiguration(repoPath string, app *model.Application) (*config.Config, error) {
+	path := filepath.Join(repoPath, app.GitPath.GetApplicationConfigFilePath())
+	cfg, err := config.LoadFromYAML(path)
+	if err != nil {
+		return nil, err
+	}
+	if appKind, ok := config.ToApplicationKind(cfg.Kind); !ok || appKind != app.Kind {
+		return nil, fmt.Errorf("application in application configuration file is not match, got: %s, expected: %s", appKind, app.Kind)
+	}
+	return cfg, nil
+}
+
+func (d *detector) ProviderName() string {
+	return d.provider.Name
+}
+
+func makeSyncState(r provider.PlanResult, planOutput, commit string) model.ApplicationSyncState {
+	if r.NoChanges() {
+		return model.ApplicationSyncState{
+			Status:      model.ApplicationSyncStatus_SYNCED,
+			ShortReason: "",
+			Reason:      "",
+			Timestamp:   time.Now().Unix(),
+		}
+	}
+
+	total := r.Adds + r.Changes + r.Destroys
+	shortReason := fmt.Sprintf("There are %d resources not synced (%d to add, %d to change, %d to destroy)", total, r.Adds, r.Changes, r.Destroys)
+	if len(commit) >= 7 {
+		commit = commit[:7]
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Diff between the defined state in Git at commit %s and actual state of the infrastructure:\n\n", commit))
+	b.WriteString(extractPlanDetails(planOutput))
+
+	return model.ApplicationSyncState{
+		Status:      model.ApplicationSyncStatus_OUT_OF_SYNC,
+		ShortReason: shortReason,
+		Reason:      b.String(),
+		Timestamp:   time.Now().Unix(),
+	}
+}
+
+var planSummaryRegex = regexp.MustCompile(`(?m)^Plan: \d+ to add, \d+ to change, \d+ to destroy\.$`)
+
+// planDetailsStartMarkers are the lines terraform plan prints
+// before describing the changes, in the order they appear.
+var planDetailsStartMarkers = []string{
+	"Note: Objects have changed outside of Terraform",
+	"Terraform used the selected providers to generate the following execution",
+	"An execution plan has been generated and is shown below.",
+	"Terraform will perform the following actions:",
+}
+
+// extractPlanDetails returns the part of the given terraform plan output
+// that describes the changes, from the beginning of the change list to the plan summary.
+// The whole output is returned if that part was not found.
+func extractPlanDetails(out string) string {
+	start := -1
+	for _, m := range planDetailsStartMarkers {
+		if i := strings.Index(out, m); i >= 0 {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return out
+	}
+
+	details := out[start:]
+	if loc := planSummaryRegex.FindStringIndex(details); loc != nil {
+		details = details[:loc[1]]
+	}
+	return strings.TrimSpace(details) + "\n"
+}
@@ -0,0 +1,234 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/terraform"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/git"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+const testPlanOutput = `Terraform used the selected providers to generate the following execution
+plan. Resource actions are indicated with the following symbols:
+  ~ update in-place
+
+Terraform will perform the following actions:
+
+  # google_storage_bucket.bucket will be updated in-place
+  ~ resource "google_storage_bucket" "bucket" {
+      ~ storage_class = "NEARLINE" -> "STANDARD"
+        # (10 unchanged attributes hidden)
+    }
+
+Plan: 0 to add, 1 to change, 0 to destroy.
+
+─────────────────────────────────────────────────────────────────────────────
+
+Note: You didn't use the -out option to save this plan.
+`
+
+type fakeReporter struct {
+	states map[string]model.ApplicationSyncState
+}
+
+func (r *fakeReporter) ReportApplicationSyncState(_ context.Context, appID string, state model.ApplicationSyncState) error {
+	r.states[appID] = state
+	return nil
+}
+
+// writeFakeTerraform writes a script that behaves as a terraform binary
+// whose plan command prints the given output and exits with the given code.
+func writeFakeTerraform(t *testing.T, planOutput string, planExitCode int) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plan-output"), []byte(planOutput), 0644))
+
+	script := fmt.Sprintf(`#!/bin/sh
+case "$1" in
+  init)
+    mkdir -p .terraform
+    echo "Terraform has been successfully initialized!"
+    ;;
+  plan)
+    cat %q
+    exit %d
+    ;;
+esac
+`, filepath.Join(dir, "plan-output"), planExitCode)
+	path := filepath.Join(dir, "terraform")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func TestCheckApplication(t *testing.T) {
+	testcases := []struct {
+		name         string
+		planOutput   string
+		planExitCode int
+		wantStatus   model.ApplicationSyncStatus
+		wantShort    string
+	}{
+		{
+			name:         "synced",
+			planOutput:   "No changes. Your infrastructure matches the configuration.\n",
+			planExitCode: 0,
+			wantStatus:   model.ApplicationSyncStatus_SYNCED,
+		},
+		{
+			name:         "out of sync",
+			planOutput:   testPlanOutput,
+			planExitCode: 2,
+			wantStatus:   model.ApplicationSyncStatus_OUT_OF_SYNC,
+			wantShort:    "There are 1 resources not synced (0 to add, 1 to change, 0 to destroy)",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			repoDir := filepath.Join(t.TempDir(), "repo")
+			appDir := filepath.Join(repoDir, "app")
+			require.NoError(t, os.MkdirAll(appDir, 0755))
+			appCfg := `apiVersion: pipecd.dev/v1beta1
+kind: TerraformApp
+spec:
+  input:
+    terraformVersion: 1.0.0
+`
+			require.NoError(t, os.WriteFile(filepath.Join(appDir, "app.pipecd.yaml"), []byte(appCfg), 0644))
+
+			terraformPath := writeFakeTerraform(t, tc.planOutput, tc.planExitCode)
+			reporter := &fakeReporter{
+				states: make(map[string]model.ApplicationSyncState),
+			}
+			d := &detector{
+				provider: config.PipedCloudProvider{
+					Name:            "terraform",
+					Type:            model.CloudProviderTerraform,
+					TerraformConfig: &config.CloudProviderTerraformConfig{},
+				},
+				reporter: reporter,
+				findTerraform: func(_ context.Context, version string) (string, bool, error) {
+					assert.Equal(t, "1.0.0", version)
+					return terraformPath, false, nil
+				},
+				logger: zap.NewNop(),
+			}
+			app := &model.Application{
+				Id:   "app-1",
+				Kind: model.ApplicationKind_TERRAFORM,
+				GitPath: &model.ApplicationGitPath{
+					Path:           "app",
+					ConfigFilename: "app.pipecd.yaml",
+				},
+			}
+			repo := git.NewRepo(repoDir, "", "", "master", nil)
+
+			err := d.checkApplication(context.Background(), app, repo, git.Commit{Hash: "0123456789abcdef"})
+			require.NoError(t, err)
+
+			state, ok := reporter.states["app-1"]
+			require.True(t, ok)
+			assert.Equal(t, tc.wantStatus, state.Status)
+			assert.Equal(t, tc.wantShort, state.ShortReason)
+
+			// The terraform commands must not change the cloned repository.
+			_, err = os.Stat(filepath.Join(appDir, ".terraform"))
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func TestMakeSyncState(t *testing.T) {
+	state := makeSyncState(provider.PlanResult{Changes: 1}, "terraform plan -lock=false\n"+testPlanOutput, "0123456789abcdef")
+	assert.Equal(t, model.ApplicationSyncStatus_OUT_OF_SYNC, state.Status)
+	assert.Equal(t, "There are 1 resources not synced (0 to add, 1 to change, 0 to destroy)", state.ShortReason)
+
+	want := `Diff between the defined state in Git at commit 0123456 and actual state of the infrastructure:
+
+Terraform used the selected providers to generate the following execution
+plan. Resource actions are indicated with the following symbols:
+  ~ update in-place
+
+Terraform will perform the following actions:
+
+  # google_storage_bucket.bucket will be updated in-place
+  ~ resource "google_storage_bucket" "bucket" {
+      ~ storage_class = "NEARLINE" -> "STANDARD"
+        # (10 unchanged attributes hidden)
+    }
+
+Plan: 0 to add, 1 to change, 0 to destroy.
+`
+	assert.Equal(t, want, state.Reason)
+
+	state = makeSyncState(provider.PlanResult{}, "", "0123456789abcdef")
+	assert.Equal(t, model.ApplicationSyncStatus_SYNCED, state.Status)
+}
+
+func TestExtractPlanDetails(t *testing.T) {
+	testcases := []struct {
+		name string
+		out  string
+		want string
+	}{
+		{
+			name: "objects changed outside of terraform",
+			out: `Refreshing state...
+
+Note: Objects have changed outside of Terraform
+
+Terraform detected the following changes made outside of Terraform since the
+last "terraform apply":
+
+Terraform will perform the following actions:
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+
+Note: You didn't use the -out option to save this plan.
+`,
+			want: `Note: Objects have changed outside of Terraform
+
+Terraform detected the following changes made outside of Terraform since the
+last "terraform apply":
+
+Terraform will perform the following actions:
+
+Plan: 1 to add, 0 to change, 0 to destroy.
+`,
+		},
+		{
+			name: "unknown format",
+			out:  "unknown output\n",
+			want: "unknown output\n",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := extractPlanDetails(tc.out)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -28,7 +28,9 @@ import (
 	logfactory "github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/log/factory"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/metrics"
 	metricsfactory "github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/metrics/factory"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/apistore/analysisresultstore"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/waitapproval"
 	"github.com/pipe-cd/pipecd/pkg/config"
 	"github.com/pipe-cd/pipecd/pkg/model"
 )
@@ -66,10 +68,124 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 		return model.StageStatus_STAGE_FAILURE
 	}
 
+	templateCfg, ok := e.loadAnalysisTemplate(ctx)
+	if !ok {
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	timeout := time.Duration(options.Duration)
+	e.previousElapsedTime = e.retrievePreviousElapsedTime()
+	if e.previousElapsedTime > 0 {
+		// Restart from the middle.
+		timeout -= e.previousElapsedTime
+	}
+	defer e.saveElapsedTime(ctx)
+
+	status, results := e.run(ctx, options, templateCfg, timeout)
+	switch {
+	case status == model.StageStatus_STAGE_SUCCESS:
+		status = executor.DetermineStageStatus(sig.Signal(), e.Stage.Status, model.StageStatus_STAGE_SUCCESS)
+		if status != model.StageStatus_STAGE_SUCCESS {
+			return status
+		}
+	case results == nil:
+		// It failed before starting the analyses, so there is nothing to be stored.
+		return status
+	}
+
+	result := &model.AnalysisResult{
+		StartTime: e.startTime.Unix(),
+	}
+	if options.Scoring != nil {
+		score, analyzerResults, err := e.computeScore(results)
+		result.Score, result.AnalyzerResults = score, analyzerResults
+		switch {
+		case status != model.StageStatus_STAGE_SUCCESS:
+		case err != nil:
+			e.LogPersister.Errorf("Unable to decide the analysis result by the score: %v", err)
+			status = model.StageStatus_STAGE_FAILURE
+		default:
+			status = e.decideByScore(sig, options.Scoring, score)
+		}
+	} else if status == model.StageStatus_STAGE_SUCCESS {
+		e.LogPersister.Success("All analyses were successful")
+	}
+
+	switch status {
+	case model.StageStatus_STAGE_SUCCESS:
+		e.saveAnalysisResult(ctx, result)
+	case model.StageStatus_STAGE_FAILURE:
+		e.saveFailedAnalysisResult(ctx, result)
+	}
+	return status
+}
+
+// saveAnalysisResult stores the given result as the latest analysis result of the application.
+func (e *Executor) saveAnalysisResult(ctx context.Context, result *model.AnalysisResult) {
+	if err := e.AnalysisResultStore.PutLatestAnalysisResult(ctx, result); err != nil {
+		e.Logger.Error("failed to send the analysis result", zap.Error(err))
+	}
+}
+
+// saveFailedAnalysisResult stores the given failed result as the latest analysis result of the application.
+// Since the successful one is used as the baseline for the PREVIOUS strategy of the following deployments,
+// its start time is carried over to the failed one.
+func (e *Executor) saveFailedAnalysisResult(ctx context.Context, result *model.AnalysisResult) {
+	result.Failed = true
+	prev, err := e.AnalysisResultStore.GetLatestAnalysisResult(ctx)
+	switch {
+	case err == nil:
+		result.LastSuccessfulStartTime = prev.StartTime
+		if prev.Failed {
+			result.LastSuccessfulStartTime = prev.LastSuccessfulStartTime
+		}
+	case !errors.Is(err, analysisresultstore.ErrNotFound):
+		// Storing without the start time would lose the baseline of the following deployments.
+		e.Logger.Error("failed to fetch the latest analysis result, so the failed one is not stored", zap.Error(err))
+		return
+	}
+	e.saveAnalysisResult(ctx, result)
+}
+
+// Analyze runs the analyses specified in the given options during its duration
+// on behalf of the stage of the given input.
+// This is used by the stages that run analyses as a part of their own execution,
+// such as the traffic routing stages with steps.
+// Unlike the ANALYSIS stage, it neither restarts from the middle
+// nor stores its result as the latest analysis result.
+func Analyze(sig executor.StopSignal, in executor.Input, options *config.AnalysisStageOptions) model.StageStatus {
+	e := &Executor{
+		Input:     in,
+		startTime: time.Now(),
+	}
+	ctx := sig.Context()
+
+	templateCfg, ok := e.loadAnalysisTemplate(ctx)
+	if !ok {
+		return model.StageStatus_STAGE_FAILURE
+	}
+	status, results := e.run(ctx, options, templateCfg, time.Duration(options.Duration))
+	if status != model.StageStatus_STAGE_SUCCESS {
+		return status
+	}
+	status = executor.DetermineStageStatus(sig.Signal(), e.Stage.Status, model.StageStatus_STAGE_SUCCESS)
+	if status != model.StageStatus_STAGE_SUCCESS || options.Scoring == nil {
+		return status
+	}
+	score, _, err := e.computeScore(results)
+	if err != nil {
+		e.LogPersister.Errorf("Unable to decide the analysis result by the score: %v", err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+	return e.decideByScore(sig, options.Scoring, score)
+}
+
+// loadAnalysisTemplate prepares the target deploy source and loads the AnalysisTemplate from it.
+func (e *Executor) loadAnalysisTemplate(ctx context.Context) (*config.AnalysisTemplateSpec, bool) {
 	ds, err := e.TargetDSP.Get(ctx, e.LogPersister)
 	if err != nil {
 		e.LogPersister.Errorf("Failed to prepare running deploy source data (%v)", err)
-		return model.StageStatus_STAGE_FAILURE
+		return nil, false
 	}
 	e.repoDir = ds.RepoDir
 	e.config = ds.ApplicationConfig
@@ -80,37 +196,39 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 		templateCfg = &config.AnalysisTemplateSpec{}
 	} else if err != nil {
 		e.LogPersister.Error(err.Error())
-		return model.StageStatus_STAGE_FAILURE
-	}
-
-	timeout := time.Duration(options.Duration)
-	e.previousElapsedTime = e.retrievePreviousElapsedTime()
-	if e.previousElapsedTime > 0 {
-		// Restart from the middle.
-		timeout -= e.previousElapsedTime
+		return nil, false
 	}
-	defer e.saveElapsedTime(ctx)
+	return templateCfg, true
+}
 
+// run spawns the analyzers specified in the given options and waits until
+// all of them finish or the given timeout is exceeded.
+// The evaluation results of all analyzers are returned to compute the overall score.
+func (e *Executor) run(ctx context.Context, options *config.AnalysisStageOptions, templateCfg *config.AnalysisTemplateSpec, timeout time.Duration) (model.StageStatus, []*analyzerResult) {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	eg, ctxWithTimeout := errgroup.WithContext(ctxWithTimeout)
+	// Unless scoring is enabled, the stage fails as soon as any analyzer exceeds its failure limit.
+	failFast := options.Scoring == nil
+	results := make([]*analyzerResult, 0, len(options.Metrics)+len(options.Logs)+len(options.Https))
 
 	// Run analyses with metrics providers.
 	for i := range options.Metrics {
 		cfg, err := e.getMetricsConfig(options.Metrics[i], templateCfg)
 		if err != nil {
 			e.LogPersister.Errorf("Failed to get metrics config: %v", err)
-			return model.StageStatus_STAGE_FAILURE
+			return model.StageStatus_STAGE_FAILURE, nil
 		}
 		provider, err := e.newMetricsProvider(cfg.Provider, options.Metrics[i])
 		if err != nil {
 			e.LogPersister.Errorf("Failed to generate metrics provider: %v", err)
-			return model.StageStatus_STAGE_FAILURE
+			return model.StageStatus_STAGE_FAILURE, nil
 		}
 		id := fmt.Sprintf("metrics-%d", i)
 		args := e.buildAppArgs(options.Metrics[i].Template.AppArgs)
-		analyzer := newMetricsAnalyzer(id, *cfg, e.startTime, provider, e.AnalysisResultStore, args, e.Logger, e.LogPersister)
+		analyzer := newMetricsAnalyzer(id, *cfg, e.startTime, provider, e.AnalysisResultStore, args, failFast, e.Logger, e.LogPersister)
+		results = append(results, analyzer.result)
 		eg.Go(func() error {
 			e.LogPersister.Infof("[%s] Start metrics analyzer. Every %s it runs this query: %q", analyzer.id, cfg.Interval.Duration(), cfg.Query)
 			return analyzer.run(ctxWithTimeout)
@@ -118,11 +236,12 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 	}
 	// Run analyses with logging providers.
 	for i := range options.Logs {
-		analyzer, err := e.newAnalyzerForLog(i, &options.Logs[i], templateCfg)
+		analyzer, err := e.newAnalyzerForLog(i, &options.Logs[i], templateCfg, failFast)
 		if err != nil {
 			e.LogPersister.Errorf("Failed to spawn analyzer for %s: %v", options.Logs[i].Provider, err)
-			return model.StageStatus_STAGE_FAILURE
+			return model.StageStatus_STAGE_FAILURE, nil
 		}
+		results = append(results, analyzer.result)
 		eg.Go(func() error {
 			e.LogPersister.Infof("[%s] Start log analyzer", analyzer.id)
 			return analyzer.run(ctxWithTimeout)
@@ -130,11 +249,12 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 	}
 	// Run analyses with http providers.
 	for i := range options.Https {
-		analyzer, err := e.newAnalyzerForHTTP(i, &options.Https[i], templateCfg)
+		analyzer, err := e.newAnalyzerForHTTP(i, &options.Https[i], templateCfg, failFast)
 		if err != nil {
 			e.LogPersister.Errorf("Failed to spawn analyzer for HTTP: %v", err)
-			return model.StageStatus_STAGE_FAILURE
+			return model.StageStatus_STAGE_FAILURE, nil
 		}
+		results = append(results, analyzer.result)
 		eg.Go(func() error {
 			e.LogPersister.Infof("[%s] Start http analyzer", analyzer.id)
 			return analyzer.run(ctxWithTimeout)
@@ -143,25 +263,69 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 
 	if err := eg.Wait(); err != nil {
 		e.LogPersister.Errorf("Analysis failed: %s", err.Error())
-		return model.StageStatus_STAGE_FAILURE
+		return model.StageStatus_STAGE_FAILURE, results
 	}
 
-	status := executor.DetermineStageStatus(sig.Signal(), e.Stage.Status, model.StageStatus_STAGE_SUCCESS)
-	if status != model.StageStatus_STAGE_SUCCESS {
-		return status
+	return model.StageStatus_STAGE_SUCCESS, results
+}
+
+// computeScore computes the overall score as the weighted average of the scores of all analyzers
+// and logs the result of each analyzer.
+// The analyzers that have never been evaluated are excluded from the computation.
+// An error is returned if no analyzer contributed to the score.
+func (e *Executor) computeScore(results []*analyzerResult) (float64, []*model.AnalyzerResult, error) {
+	var (
+		weightedSum, totalWeight float64
+		out                      = make([]*model.AnalyzerResult, 0, len(results))
+	)
+	for _, r := range results {
+		score, ok := r.score()
+		if !ok {
+			e.LogPersister.Infof("[%s] No evaluation was performed, so it is excluded from the score", r.id)
+			continue
+		}
+		e.LogPersister.Infof("[%s] Score: %.2f (succeeded: %d, failed: %d, weight: %g)", r.id, score, r.successCount, r.failureCount, r.weight)
+		out = append(out, &model.AnalyzerResult{
+			Id:           r.id,
+			ProviderType: r.providerType,
+			Weight:       r.weight,
+			SuccessCount: int32(r.successCount),
+			FailureCount: int32(r.failureCount),
+			Score:        score,
+		})
+		weightedSum += score * r.weight
+		totalWeight += r.weight
+	}
+	if totalWeight == 0 {
+		return 0, out, fmt.Errorf("no analyzer with a positive weight was evaluated")
 	}
+	return weightedSum / totalWeight, out, nil
+}
 
-	e.LogPersister.Success("All analyses were successful")
-	err = e.AnalysisResultStore.PutLatestAnalysisResult(ctx, &model.AnalysisResult{
-		StartTime: e.startTime.Unix(),
-	})
-	if err != nil {
-		e.Logger.Error("failed to send the analysis result", zap.Error(err))
+// decideByScore decides the stage status by comparing the given score with the thresholds.
+// It waits for a manual decision if the score is marginal.
+func (e *Executor) decideByScore(sig executor.StopSignal, scoring *config.AnalysisScoring, score float64) model.StageStatus {
+	switch {
+	case score >= scoring.Pass:
+		e.LogPersister.Successf("The analysis passed with the score %.2f (pass: %g)", score, scoring.Pass)
+		return model.StageStatus_STAGE_SUCCESS
+	case scoring.Marginal > 0 && score >= scoring.Marginal:
+		e.LogPersister.Infof("The analysis score %.2f is marginal (pass: %g, marginal: %g), so a manual decision is required", score, scoring.Pass, scoring.Marginal)
+		if err := e.MetadataStore.Stage(e.Stage.Id).Put(sig.Context(), waitingApprovalKey, "true"); err != nil {
+			e.Logger.Error("failed to store metadata", zap.Error(err))
+		}
+		return waitapproval.Wait(sig, e.Input, &scoring.Approval)
+	default:
+		e.LogPersister.Errorf("The analysis failed with the score %.2f (pass: %g, marginal: %g)", score, scoring.Pass, scoring.Marginal)
+		return model.StageStatus_STAGE_FAILURE
 	}
-	return status
 }
 
-const elapsedTimeKey = "elapsedTime"
+const (
+	elapsedTimeKey = "elapsedTime"
+	// The key to tell that the stage is waiting for a manual decision on the marginal score.
+	waitingApprovalKey = "WaitingApproval"
+)
 
 // saveElapsedTime stores the elapsed time of analysis stage into metadata persister.
 // The analysis stage can be restarted from the middle even if it ends unexpectedly,
@@ -190,23 +354,31 @@ func (e *Executor) retrievePreviousElapsedTime() time.Duration {
 	return et
 }
 
-func (e *Executor) newAnalyzerForLog(i int, templatable *config.TemplatableAnalysisLog, templateCfg *config.AnalysisTemplateSpec) (*analyzer, error) {
+func (e *Executor) newAnalyzerForLog(i int, templatable *config.TemplatableAnalysisLog, templateCfg *config.AnalysisTemplateSpec, failFast bool) (*analyzer, error) {
 	cfg, err := e.getLogConfig(templatable, templateCfg)
 	if err != nil {
 		return nil, err
 	}
-	provider, err := e.newLogProvider(cfg.Provider)
+	provider, err := e.newLogProvider(cfg)
 	if err != nil {
 		return nil, err
 	}
 	id := fmt.Sprintf("log-%d", i)
+	interval := time.Duration(cfg.Interval)
 	runner := func(ctx context.Context, query string) (bool, string, error) {
-		return provider.Evaluate(ctx, query)
+		// Evaluate only the logs emitted during the last interval
+		// so that an error log is not counted again at the next evaluations.
+		now := time.Now()
+		queryRange := log.QueryRange{
+			From: now.Add(-interval),
+			To:   now,
+		}
+		return provider.Evaluate(ctx, query, queryRange)
 	}
-	return newAnalyzer(id, provider.Type(), cfg.Query, runner, time.Duration(cfg.Interval), cfg.FailureLimit, cfg.SkipOnNoData, e.Logger, e.LogPersister), nil
+	return newAnalyzer(id, provider.Type(), cfg.Query, runner, interval, cfg.FailureLimit, cfg.SkipOnNoData, cfg.Weight, failFast, e.Logger, e.LogPersister), nil
 }
 
-func (e *Executor) newAnalyzerForHTTP(i int, templatable *config.TemplatableAnalysisHTTP, templateCfg *config.AnalysisTemplateSpec) (*analyzer, error) {
+func (e *Executor) newAnalyzerForHTTP(i int, templatable *config.TemplatableAnalysisHTTP, templateCfg *config.AnalysisTemplateSpec, failFast bool) (*analyzer, error) {
 	cfg, err := e.getHTTPConfig(templatable, templateCfg)
 	if err != nil {
 		return nil, err
@@ -216,7 +388,7 @@ func (e *Executor) newAnalyzerForHTTP(i int, templatable *config.TemplatableAnal
 	runner := func(ctx context.Context, query string) (bool, string, error) {
 		return provider.Run(ctx, cfg)
 	}
-	return newAnalyzer(id, provider.Type(), "", runner, time.Duration(cfg.Interval), cfg.FailureLimit, cfg.SkipOnNoData, e.Logger, e.LogPersister), nil
+	return newAnalyzer(id, provider.Type(), "", runner, time.Duration(cfg.Interval), cfg.FailureLimit, cfg.SkipOnNoData, cfg.Weight, failFast, e.Logger, e.LogPersister), nil
 }
 
 func (e *Executor) newMetricsProvider(providerName string, templatable config.TemplatableAnalysisMetrics) (metrics.Provider, error) {
@@ -231,12 +403,12 @@ func (e *Executor) newMetricsProvider(providerName string, templatable config.Te
 	return provider, nil
 }
 
-func (e *Executor) newLogProvider(providerName string) (log.Provider, error) {
-	cfg, ok := e.PipedConfig.GetAnalysisProvider(providerName)
+func (e *Executor) newLogProvider(analysisLogCfg *config.AnalysisLog) (log.Provider, error) {
+	cfg, ok := e.PipedConfig.GetAnalysisProvider(analysisLogCfg.Provider)
 	if !ok {
-		return nil, fmt.Errorf("unknown provider name %s", providerName)
+		return nil, fmt.Errorf("unknown provider name %s", analysisLogCfg.Provider)
 	}
-	provider, err := logfactory.NewProvider(&cfg, e.Logger)
+	provider, err := logfactory.NewProvider(analysisLogCfg, &cfg, e.Logger)
 	if err != nil {
 		return nil, err
 	}
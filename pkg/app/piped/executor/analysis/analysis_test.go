@@ -0,0 +1,165 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analysis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/apistore/analysisresultstore"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+func TestExecutor_computeScore(t *testing.T) {
+	testcases := []struct {
+		name        string
+		results     []*analyzerResult
+		wantScore   float64
+		wantResults int
+		wantErr     bool
+	}{
+		{
+			name:        "no analyzer given",
+			wantResults: 0,
+			wantErr:     true,
+		},
+		{
+			name: "no evaluation performed",
+			results: []*analyzerResult{
+				{id: "metrics-0", weight: 1},
+			},
+			wantResults: 0,
+			wantErr:     true,
+		},
+		{
+			name: "only zero weight analyzers evaluated",
+			results: []*analyzerResult{
+				{id: "metrics-0", weight: 0, successCount: 4},
+			},
+			wantResults: 1,
+			wantErr:     true,
+		},
+		{
+			name: "weighted average",
+			results: []*analyzerResult{
+				{id: "metrics-0", weight: 3, successCount: 4},
+				{id: "metrics-1", weight: 1, successCount: 2, failureCount: 2},
+				{id: "log-0", weight: 1},
+			},
+			wantScore:   87.5,
+			wantResults: 2,
+		},
+		{
+			name: "zero weight is ignored",
+			results: []*analyzerResult{
+				{id: "metrics-0", weight: 1, successCount: 1, failureCount: 3},
+				{id: "http-0", weight: 0, failureCount: 4},
+			},
+			wantScore:   25,
+			wantResults: 2,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &Executor{
+				Input: executor.Input{
+					LogPersister: &fakeLogPersister{},
+				},
+			}
+			score, results, err := e.computeScore(tc.results)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.wantScore, score)
+			assert.Equal(t, tc.wantResults, len(results))
+		})
+	}
+}
+
+type fakeAnalysisResultStore struct {
+	latest *model.AnalysisResult
+	getErr error
+}
+
+func (s *fakeAnalysisResultStore) GetLatestAnalysisResult(_ context.Context) (*model.AnalysisResult, error) {
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	if s.latest == nil {
+		return nil, analysisresultstore.ErrNotFound
+	}
+	return s.latest, nil
+}
+
+func (s *fakeAnalysisResultStore) PutLatestAnalysisResult(_ context.Context, result *model.AnalysisResult) error {
+	s.latest = result
+	return nil
+}
+
+func TestExecutor_saveFailedAnalysisResult(t *testing.T) {
+	testcases := []struct {
+		name   string
+		store  *fakeAnalysisResultStore
+		result *model.AnalysisResult
+		want   *model.AnalysisResult
+	}{
+		{
+			name:   "first analysis",
+			store:  &fakeAnalysisResultStore{},
+			result: &model.AnalysisResult{StartTime: 200, Score: 40},
+			want:   &model.AnalysisResult{StartTime: 200, Score: 40, Failed: true},
+		},
+		{
+			name: "previous one succeeded",
+			store: &fakeAnalysisResultStore{
+				latest: &model.AnalysisResult{StartTime: 100},
+			},
+			result: &model.AnalysisResult{StartTime: 200},
+			want:   &model.AnalysisResult{StartTime: 200, Failed: true, LastSuccessfulStartTime: 100},
+		},
+		{
+			name: "previous one also failed",
+			store: &fakeAnalysisResultStore{
+				latest: &model.AnalysisResult{StartTime: 150, Failed: true, LastSuccessfulStartTime: 100},
+			},
+			result: &model.AnalysisResult{StartTime: 200},
+			want:   &model.AnalysisResult{StartTime: 200, Failed: true, LastSuccessfulStartTime: 100},
+		},
+		{
+			name: "failed to fetch the previous one",
+			store: &fakeAnalysisResultStore{
+				latest: &model.AnalysisResult{StartTime: 100},
+				getErr: errors.New("unavailable"),
+			},
+			result: &model.AnalysisResult{StartTime: 200},
+			want:   &model.AnalysisResult{StartTime: 100},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &Executor{
+				Input: executor.Input{
+					AnalysisResultStore: tc.store,
+					Logger:              zap.NewNop(),
+				},
+			}
+			e.saveFailedAnalysisResult(context.Background(), tc.result)
+			assert.Equal(t, tc.want, tc.store.latest)
+		})
+	}
+}
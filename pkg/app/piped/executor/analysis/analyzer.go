@@ -22,6 +22,10 @@ type analyzer struct {
 	// The analysis will fail, if this value is exceeded,
 	failureLimit int
 	skipOnNoData bool
+	// If false, the analysis keeps running even after the failure limit is exceeded
+	// to compute the overall score.
+	failFast bool
+	result   *analyzerResult
 
 	logger       *zap.Logger
 	logPersister executor.LogPersister
@@ -37,6 +41,8 @@ func newAnalyzer(
 	interval time.Duration,
 	failureLimit int,
 	skipOnNodata bool,
+	weight float64,
+	failFast bool,
 	logger *zap.Logger,
 	logPersister executor.LogPersister,
 ) *analyzer {
@@ -48,6 +54,12 @@ func newAnalyzer(
 		interval:     interval,
 		failureLimit: failureLimit,
 		skipOnNoData: skipOnNodata,
+		failFast:     failFast,
+		result: &analyzerResult{
+			id:           id,
+			providerType: providerType,
+			weight:       weight,
+		},
 		logPersister: logPersister,
 		logger: logger.With(
 			zap.String("analyzer-id", id),
@@ -81,12 +93,14 @@ func (a *analyzer) run(ctx context.Context) error {
 
 			if expected {
 				a.logPersister.Successf("[%s] The query result is expected one. Reason: %s. Performed query: %q", a.id, reason, a.query)
+				a.result.successCount++
 				continue
 			}
 
 			a.logPersister.Errorf("[%s] The query result is unexpected. Reason: %s. Performed query: %q", a.id, reason, a.query)
+			a.result.failureCount++
 			failureCount++
-			if failureCount > a.failureLimit {
+			if a.failFast && failureCount > a.failureLimit {
 				return fmt.Errorf("analysis '%s' failed because the failure number exceeded the failure limit (%d)", a.id, a.failureLimit)
 			}
 		case <-ctx.Done():
@@ -94,3 +108,23 @@ func (a *analyzer) run(ctx context.Context) error {
 		}
 	}
 }
+
+// analyzerResult holds the number of evaluations performed by an analyzer.
+// It is used to compute the overall score when scoring is enabled.
+type analyzerResult struct {
+	id           string
+	providerType string
+	weight       float64
+	successCount int
+	failureCount int
+}
+
+// score returns the percentage of successful evaluations.
+// False is returned if no evaluation has been performed.
+func (r *analyzerResult) score() (float64, bool) {
+	total := r.successCount + r.failureCount
+	if total == 0 {
+		return 0, false
+	}
+	return float64(r.successCount) / float64(total) * 100, true
+}
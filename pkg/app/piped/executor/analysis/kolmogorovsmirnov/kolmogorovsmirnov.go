@@ -0,0 +1,136 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kolmogorovsmirnov
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// A Hypothesis specifies the alternative hypothesis of the test.
+type Hypothesis int
+
+const (
+	// DistributionLess specifies the alternative hypothesis that the values
+	// of the first sample tend to be less than the second. This is a one-tailed test.
+	DistributionLess Hypothesis = -1
+
+	// DistributionDiffers specifies the alternative hypothesis that
+	// the two samples are drawn from different distributions. This is a two-tailed test.
+	DistributionDiffers Hypothesis = 0
+
+	// DistributionGreater specifies the alternative hypothesis that the values
+	// of the first sample tend to be greater than the second. This is a one-tailed test.
+	DistributionGreater Hypothesis = 1
+)
+
+var ErrSampleSize = errors.New("sample is too small")
+
+// A Result is the result of a two-sample Kolmogorov-Smirnov test.
+type Result struct {
+	// N1 and N2 are the sizes of the input samples.
+	N1, N2 int
+
+	// D is the Kolmogorov-Smirnov statistic, the maximum distance
+	// between the empirical distribution functions of the two samples
+	// in the direction of the alternative hypothesis.
+	D float64
+
+	// P is the p-value of the test.
+	P float64
+}
+
+// Test performs a two-sample Kolmogorov-Smirnov test on the given samples.
+// The p-value is computed by using the asymptotic distribution,
+// so it is an approximation for small samples.
+func Test(x1, x2 []float64, alt Hypothesis) (*Result, error) {
+	n1, n2 := len(x1), len(x2)
+	if n1 == 0 || n2 == 0 {
+		return nil, ErrSampleSize
+	}
+
+	s1 := append([]float64(nil), x1...)
+	s2 := append([]float64(nil), x2...)
+	sort.Float64s(s1)
+	sort.Float64s(s2)
+
+	// dLess is the maximum of F1(x) - F2(x) where F is the empirical distribution function.
+	// It gets large when the values of the first sample tend to be less than the second.
+	// dGreater is the opposite.
+	var (
+		i, j            int
+		dLess, dGreater float64
+	)
+	for i < n1 && j < n2 {
+		v := math.Min(s1[i], s2[j])
+		for i < n1 && s1[i] == v {
+			i++
+		}
+		for j < n2 && s2[j] == v {
+			j++
+		}
+		diff := float64(i)/float64(n1) - float64(j)/float64(n2)
+		dLess = math.Max(dLess, diff)
+		dGreater = math.Max(dGreater, -diff)
+	}
+
+	res := &Result{N1: n1, N2: n2}
+	ne := float64(n1) * float64(n2) / float64(n1+n2)
+	switch alt {
+	case DistributionLess:
+		res.D = dLess
+		res.P = oneSidedP(ne, res.D)
+	case DistributionGreater:
+		res.D = dGreater
+		res.P = oneSidedP(ne, res.D)
+	default:
+		res.D = math.Max(dLess, dGreater)
+		sqrtNe := math.Sqrt(ne)
+		res.P = kolmogorovQ((sqrtNe + 0.12 + 0.11/sqrtNe) * res.D)
+	}
+	return res, nil
+}
+
+// oneSidedP gives back the asymptotic p-value of the one-sided statistic.
+func oneSidedP(ne, d float64) float64 {
+	return math.Min(1, math.Exp(-2*ne*d*d))
+}
+
+// kolmogorovQ computes the complementary cumulative distribution function
+// of the Kolmogorov distribution.
+// See: Numerical Recipes in C, 14.3 "Are Two Distributions Different?"
+func kolmogorovQ(lambda float64) float64 {
+	const (
+		eps1 = 0.001
+		eps2 = 1.0e-8
+	)
+	var (
+		sum, prev float64
+		sign      = 2.0
+		a2        = -2 * lambda * lambda
+	)
+	for k := 1; k <= 100; k++ {
+		term := sign * math.Exp(a2*float64(k*k))
+		sum += term
+		if math.Abs(term) <= eps1*prev || math.Abs(term) <= eps2*sum {
+			return math.Max(0, math.Min(1, sum))
+		}
+		sign = -sign
+		prev = math.Abs(term)
+	}
+	// Failed to converge, which happens only when lambda is small enough.
+	return 1
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kolmogorovsmirnov
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTest(t *testing.T) {
+	testcases := []struct {
+		name       string
+		x1         []float64
+		x2         []float64
+		alt        Hypothesis
+		wantD      float64
+		wantPBelow float64
+		wantPAbove float64
+		wantErr    bool
+	}{
+		{
+			name:    "empty sample given",
+			x1:      []float64{},
+			x2:      []float64{0.1},
+			wantErr: true,
+		},
+		{
+			name:       "same samples",
+			x1:         []float64{0.1, 0.2, 0.3, 0.4, 0.5},
+			x2:         []float64{0.1, 0.2, 0.3, 0.4, 0.5},
+			alt:        DistributionDiffers,
+			wantD:      0,
+			wantPBelow: 1.0001,
+			wantPAbove: 0.99,
+		},
+		{
+			name:       "first sample is greater",
+			x1:         []float64{10.1, 10.2, 10.3, 10.4, 10.5, 10.6, 10.7, 10.8},
+			x2:         []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8},
+			alt:        DistributionGreater,
+			wantD:      1,
+			wantPBelow: 0.05,
+		},
+		{
+			name:       "first sample is greater but testing less",
+			x1:         []float64{10.1, 10.2, 10.3, 10.4, 10.5, 10.6, 10.7, 10.8},
+			x2:         []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8},
+			alt:        DistributionLess,
+			wantD:      0,
+			wantPBelow: 1.0001,
+			wantPAbove: 0.99,
+		},
+		{
+			name:       "first sample is less",
+			x1:         []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8},
+			x2:         []float64{10.1, 10.2, 10.3, 10.4, 10.5, 10.6, 10.7, 10.8},
+			alt:        DistributionDiffers,
+			wantD:      1,
+			wantPBelow: 0.05,
+		},
+		{
+			name:       "partially overlapped",
+			x1:         []float64{1, 2, 3, 4},
+			x2:         []float64{3, 4, 5, 6},
+			alt:        DistributionDiffers,
+			wantD:      0.5,
+			wantPBelow: 1,
+			wantPAbove: 0.05,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Test(tc.x1, tc.x2, tc.alt)
+			assert.Equal(t, tc.wantErr, err != nil)
+			if tc.wantErr {
+				return
+			}
+			require.NotNil(t, got)
+			assert.InDelta(t, tc.wantD, got.D, 1e-9)
+			assert.Less(t, got.P, tc.wantPBelow)
+			assert.Greater(t, got.P, tc.wantPAbove)
+		})
+	}
+}
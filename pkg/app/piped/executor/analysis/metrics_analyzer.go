@@ -19,6 +19,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"text/template"
 	"time"
 
@@ -27,6 +28,7 @@ import (
 	"github.com/pipe-cd/pipecd/pkg/app/piped/analysisprovider/metrics"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/apistore/analysisresultstore"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/analysis/kolmogorovsmirnov"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/analysis/mannwhitney"
 	"github.com/pipe-cd/pipecd/pkg/config"
 )
@@ -35,6 +37,9 @@ const (
 	canaryVariantName   = "canary"
 	baselineVariantName = "baseline"
 	primaryVariantName  = "primary"
+
+	// Typically 5% is used as the significance level.
+	defaultSignificanceLevel = 0.05
 )
 
 type metricsAnalyzer struct {
@@ -45,11 +50,15 @@ type metricsAnalyzer struct {
 	analysisResultStore executor.AnalysisResultStore
 	// Application-specific arguments using when rendering the query.
 	argsTemplate argsTemplate
+	// If false, the analysis keeps running even after the failure limit is exceeded
+	// to compute the overall score.
+	failFast     bool
+	result       *analyzerResult
 	logger       *zap.Logger
 	logPersister executor.LogPersister
 }
 
-func newMetricsAnalyzer(id string, cfg config.AnalysisMetrics, stageStartTime time.Time, provider metrics.Provider, analysisResultStore executor.AnalysisResultStore, argsTemplate argsTemplate, logger *zap.Logger, logPersister executor.LogPersister) *metricsAnalyzer {
+func newMetricsAnalyzer(id string, cfg config.AnalysisMetrics, stageStartTime time.Time, provider metrics.Provider, analysisResultStore executor.AnalysisResultStore, argsTemplate argsTemplate, failFast bool, logger *zap.Logger, logPersister executor.LogPersister) *metricsAnalyzer {
 	return &metricsAnalyzer{
 		id:                  id,
 		cfg:                 cfg,
@@ -57,7 +66,13 @@ func newMetricsAnalyzer(id string, cfg config.AnalysisMetrics, stageStartTime ti
 		provider:            provider,
 		analysisResultStore: analysisResultStore,
 		argsTemplate:        argsTemplate,
-		logPersister:        logPersister,
+		failFast:            failFast,
+		result: &analyzerResult{
+			id:           id,
+			providerType: provider.Type(),
+			weight:       cfg.Weight,
+		},
+		logPersister: logPersister,
 		logger: logger.With(
 			zap.String("analyzer-id", id),
 		),
@@ -108,10 +123,12 @@ func (a *metricsAnalyzer) run(ctx context.Context) error {
 			}
 			if expected {
 				a.logPersister.Successf("[%s] The query result is expected one", a.id)
+				a.result.successCount++
 				continue
 			}
+			a.result.failureCount++
 			failureCount++
-			if failureCount > a.cfg.FailureLimit {
+			if a.failFast && failureCount > a.cfg.FailureLimit {
 				return fmt.Errorf("analysis '%s' failed because the failure number exceeded the failure limit (%d)", a.id, a.cfg.FailureLimit)
 			}
 		case <-ctx.Done():
@@ -182,9 +199,17 @@ func (a *metricsAnalyzer) analyzeWithPrevious(ctx context.Context) (expected, fi
 	if err != nil {
 		return false, false, fmt.Errorf("failed to fetch the most recent successful analysis metadata: %w", err)
 	}
+	// The failed analysis can't be the baseline, so the successful one before it is used instead.
+	prevStartTime := prevMetadata.StartTime
+	if prevMetadata.Failed {
+		prevStartTime = prevMetadata.LastSuccessfulStartTime
+	}
+	if prevStartTime == 0 {
+		return false, true, nil
+	}
 	// Compare it with the previous metrics when the same amount of time as now has passed since the start of the stage.
 	elapsedTime := now.Sub(a.stageStartTime)
-	prevTo := time.Unix(prevMetadata.StartTime, 0).Add(elapsedTime)
+	prevTo := time.Unix(prevStartTime, 0).Add(elapsedTime)
 	prevFrom := prevTo.Add(-a.cfg.Interval.Duration())
 	prevQueryRange := metrics.QueryRange{
 		From: prevFrom,
@@ -200,14 +225,14 @@ func (a *metricsAnalyzer) analyzeWithPrevious(ctx context.Context) (expected, fi
 	for i := range prevPoints {
 		prevValues = append(prevValues, prevPoints[i].Value)
 	}
-	expected, err = compare(values, prevValues, a.cfg.Deviation)
+	expected, reason, err := compare(values, prevValues, a.cfg)
 	if err != nil {
 		a.logPersister.Errorf("[%s] Failed to compare data points: %v", a.id, err)
 		a.logPersister.Infof("[%s] Performed query: %q", a.id, a.cfg.Query)
 		return false, false, err
 	}
 	if !expected {
-		a.logPersister.Errorf("[%s] The difference between Current Primary and Previous one is statistically significant", a.id)
+		a.logPersister.Errorf("[%s] The difference between Current Primary and Previous one is significant: %s", a.id, reason)
 		a.logPersister.Infof("[%s] Performed query range for current Primary: %q", a.id, &queryRange)
 		a.logPersister.Infof("[%s] Performed query range for previous Primary: %q", a.id, &prevQueryRange)
 		a.logPersister.Infof("[%s] Performed query: %q", a.id, a.cfg.Query)
@@ -221,6 +246,7 @@ func (a *metricsAnalyzer) analyzeWithPrevious(ctx context.Context) (expected, fi
 		}
 		return false, false, nil
 	}
+	a.logPersister.Infof("[%s] The difference between Current Primary and Previous one is acceptable: %s", a.id, reason)
 	return true, false, nil
 }
 
@@ -265,7 +291,7 @@ func (a *metricsAnalyzer) analyzeWithCanaryBaseline(ctx context.Context) (bool,
 		baselineValues = append(baselineValues, baselinePoints[i].Value)
 	}
 
-	expected, err := compare(canaryValues, baselineValues, a.cfg.Deviation)
+	expected, reason, err := compare(canaryValues, baselineValues, a.cfg)
 	if err != nil {
 		a.logPersister.Errorf("[%s] Failed to compare data points: %v", a.id, err)
 		a.logPersister.Infof("[%s] Performed query for Canary: %q", a.id, canaryQuery)
@@ -273,7 +299,7 @@ func (a *metricsAnalyzer) analyzeWithCanaryBaseline(ctx context.Context) (bool,
 		return false, err
 	}
 	if !expected {
-		a.logPersister.Errorf("[%s] The difference between Canary and Baseline is statistically significant", a.id)
+		a.logPersister.Errorf("[%s] The difference between Canary and Baseline is significant: %s", a.id, reason)
 		a.logPersister.Infof("[%s] Performed query range: %q", a.id, &queryRange)
 		a.logPersister.Infof("[%s] Performed query for Canary: %q", a.id, canaryQuery)
 		a.logPersister.Infof("[%s] Performed query for Baseline: %q", a.id, baselineQuery)
@@ -287,6 +313,7 @@ func (a *metricsAnalyzer) analyzeWithCanaryBaseline(ctx context.Context) (bool,
 		}
 		return false, nil
 	}
+	a.logPersister.Infof("[%s] The difference between Canary and Baseline is acceptable: %s", a.id, reason)
 	return true, nil
 }
 
@@ -327,7 +354,7 @@ func (a *metricsAnalyzer) analyzeWithCanaryPrimary(ctx context.Context) (bool, e
 	for i := range primaryPoints {
 		primaryValues = append(primaryValues, primaryPoints[i].Value)
 	}
-	expected, err := compare(canaryValues, primaryValues, a.cfg.Deviation)
+	expected, reason, err := compare(canaryValues, primaryValues, a.cfg)
 	if err != nil {
 		a.logPersister.Errorf("[%s] Failed to compare data points: %v", a.id, err)
 		a.logPersister.Infof("[%s] Performed query for Canary: %q", a.id, canaryQuery)
@@ -335,7 +362,7 @@ func (a *metricsAnalyzer) analyzeWithCanaryPrimary(ctx context.Context) (bool, e
 		return false, err
 	}
 	if !expected {
-		a.logPersister.Errorf("[%s] The difference between Canary and Primary is statistically significant", a.id)
+		a.logPersister.Errorf("[%s] The difference between Canary and Primary is significant: %s", a.id, reason)
 		a.logPersister.Infof("[%s] Performed query range: %q", a.id, &queryRange)
 		a.logPersister.Infof("[%s] Performed query for Canary: %q", a.id, canaryQuery)
 		a.logPersister.Infof("[%s] Performed query for Primary: %q", a.id, primaryQuery)
@@ -349,18 +376,40 @@ func (a *metricsAnalyzer) analyzeWithCanaryPrimary(ctx context.Context) (bool, e
 		}
 		return false, nil
 	}
+	a.logPersister.Infof("[%s] The difference between Canary and Primary is acceptable: %s", a.id, reason)
 	return true, nil
 }
 
-// compare compares the given two samples using Mann-Whitney U test.
-// Considered as failure if it deviates in the specified direction as the third argument.
-func compare(experiment, control []float64, deviation string) (acceptable bool, err error) {
+// compare compares the given two samples using the algorithm specified in the given config.
+// Considered as failure if it deviates in the specified direction.
+// The returned reason describes the computed statistic.
+func compare(experiment, control []float64, cfg config.AnalysisMetrics) (acceptable bool, reason string, err error) {
 	if len(experiment) == 0 {
-		return false, fmt.Errorf("no data points of Experiment found")
+		return false, "", fmt.Errorf("no data points of Experiment found")
 	}
 	if len(control) == 0 {
-		return false, fmt.Errorf("no data points of Control found")
+		return false, "", fmt.Errorf("no data points of Control found")
+	}
+	switch cfg.Deviation {
+	case config.AnalysisDeviationEither, config.AnalysisDeviationLow, config.AnalysisDeviationHigh:
+	default:
+		return false, "", fmt.Errorf("unknown deviation %q given", cfg.Deviation)
 	}
+
+	switch cfg.Algorithm {
+	case config.AnalysisAlgorithmMannWhitney, "":
+		return compareWithMannWhitney(experiment, control, cfg.Deviation, cfg.SignificanceLevel)
+	case config.AnalysisAlgorithmKolmogorovSmirnov:
+		return compareWithKolmogorovSmirnov(experiment, control, cfg.Deviation, cfg.SignificanceLevel)
+	case config.AnalysisAlgorithmPercentageDeviation:
+		return compareWithPercentageDeviation(experiment, control, cfg.Deviation, cfg.Tolerance)
+	default:
+		return false, "", fmt.Errorf("unknown algorithm %q given", cfg.Algorithm)
+	}
+}
+
+// compareWithMannWhitney compares the given two samples using Mann-Whitney U test.
+func compareWithMannWhitney(experiment, control []float64, deviation string, alpha float64) (bool, string, error) {
 	var alternativeHypothesis mannwhitney.LocationHypothesis
 	switch deviation {
 	case config.AnalysisDeviationEither:
@@ -369,27 +418,88 @@ func compare(experiment, control []float64, deviation string) (acceptable bool,
 		alternativeHypothesis = mannwhitney.LocationLess
 	case config.AnalysisDeviationHigh:
 		alternativeHypothesis = mannwhitney.LocationGreater
-	default:
-		return false, fmt.Errorf("unknown deviation %q given", deviation)
 	}
 	res, err := mannwhitney.MannWhitneyUTest(experiment, control, alternativeHypothesis)
 	if errors.Is(err, mannwhitney.ErrSamplesEqual) {
 		// All samples are exact the same.
-		return true, nil
+		return true, "Mann-Whitney U test: all data points are the same", nil
 	}
 	if err != nil {
-		return false, fmt.Errorf("failed to perform the Mann-Whitney U test: %w", err)
+		return false, "", fmt.Errorf("failed to perform the Mann-Whitney U test: %w", err)
 	}
 
-	// alpha is the significance level. Typically 5% is used.
-	const alpha = 0.05
+	alpha = significanceLevel(alpha)
+	reason := fmt.Sprintf("Mann-Whitney U test: U=%g, p-value=%.4g (significance level: %g)", res.U, res.P, alpha)
 	// If the p-value is greater than the significance level,
 	// we cannot say that the distributions in the two groups differed significantly.
 	// See: https://support.minitab.com/en-us/minitab-express/1/help-and-how-to/basic-statistics/inference/how-to/two-samples/mann-whitney-test/interpret-the-results/key-results/
-	if res.P > alpha {
-		return true, nil
+	return res.P > alpha, reason, nil
+}
+
+// compareWithKolmogorovSmirnov compares the given two samples using two-sample Kolmogorov-Smirnov test.
+func compareWithKolmogorovSmirnov(experiment, control []float64, deviation string, alpha float64) (bool, string, error) {
+	var alternativeHypothesis kolmogorovsmirnov.Hypothesis
+	switch deviation {
+	case config.AnalysisDeviationEither:
+		alternativeHypothesis = kolmogorovsmirnov.DistributionDiffers
+	case config.AnalysisDeviationLow:
+		alternativeHypothesis = kolmogorovsmirnov.DistributionLess
+	case config.AnalysisDeviationHigh:
+		alternativeHypothesis = kolmogorovsmirnov.DistributionGreater
+	}
+	res, err := kolmogorovsmirnov.Test(experiment, control, alternativeHypothesis)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to perform the Kolmogorov-Smirnov test: %w", err)
+	}
+
+	alpha = significanceLevel(alpha)
+	reason := fmt.Sprintf("Kolmogorov-Smirnov test: D=%.4g, p-value=%.4g (significance level: %g)", res.D, res.P, alpha)
+	return res.P > alpha, reason, nil
+}
+
+// compareWithPercentageDeviation compares the means of the given two samples.
+// Considered as failure if the mean of experiment deviates from the one of control by more than the tolerance percentage.
+func compareWithPercentageDeviation(experiment, control []float64, deviation string, tolerance float64) (bool, string, error) {
+	if tolerance <= 0 {
+		return false, "", fmt.Errorf("tolerance must be greater than 0")
+	}
+	experimentMean, controlMean := mean(experiment), mean(control)
+
+	var diff float64
+	switch {
+	case experimentMean == controlMean:
+		diff = 0
+	case controlMean == 0:
+		diff = math.Copysign(math.Inf(1), experimentMean)
+	default:
+		diff = (experimentMean - controlMean) / math.Abs(controlMean) * 100
+	}
+	reason := fmt.Sprintf("Percentage deviation: experiment mean=%.4g, control mean=%.4g, deviation=%+.2f%% (tolerance: %g%%)", experimentMean, controlMean, diff, tolerance)
+
+	switch deviation {
+	case config.AnalysisDeviationLow:
+		return diff >= -tolerance, reason, nil
+	case config.AnalysisDeviationHigh:
+		return diff <= tolerance, reason, nil
+	default:
+		return math.Abs(diff) <= tolerance, reason, nil
+	}
+}
+
+// significanceLevel returns the given alpha or the typical 5% if it is not specified.
+func significanceLevel(alpha float64) float64 {
+	if alpha <= 0 || alpha >= 1 {
+		return defaultSignificanceLevel
+	}
+	return alpha
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
 	}
-	return false, nil
+	return sum / float64(len(values))
 }
 
 // argsTemplate is a collection of available template arguments.
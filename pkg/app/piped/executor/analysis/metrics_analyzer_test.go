@@ -138,9 +138,12 @@ func Test_metricsAnalyzer_analyzeWithThreshold(t *testing.T) {
 
 func Test_compare(t *testing.T) {
 	type args struct {
-		experiment []float64
-		control    []float64
-		deviation  string
+		experiment        []float64
+		control           []float64
+		deviation         string
+		algorithm         string
+		significanceLevel float64
+		tolerance         float64
 	}
 	testcases := []struct {
 		name         string
@@ -218,10 +221,135 @@ func Test_compare(t *testing.T) {
 			wantExpected: false,
 			wantErr:      false,
 		},
+		{
+			name: "unknown algorithm given",
+			args: args{
+				experiment: []float64{0.1, 0.2, 0.3, 0.4, 0.5},
+				control:    []float64{0.1, 0.2, 0.3, 0.4, 0.5},
+				deviation:  "EITHER",
+				algorithm:  "UNKNOWN",
+			},
+			wantExpected: false,
+			wantErr:      true,
+		},
+		{
+			name: "no significance with the stricter significance level",
+			args: args{
+				experiment:        []float64{0.1, 0.2, 0.3, 0.4, 0.5},
+				control:           []float64{0.4, 0.6, 0.7, 0.8, 0.9},
+				deviation:         "EITHER",
+				algorithm:         "MANN_WHITNEY",
+				significanceLevel: 0.01,
+			},
+			wantExpected: true,
+			wantErr:      false,
+		},
+		{
+			name: "significance with the default significance level",
+			args: args{
+				experiment:        []float64{0.1, 0.2, 0.3, 0.4, 0.5},
+				control:           []float64{0.4, 0.6, 0.7, 0.8, 0.9},
+				deviation:         "EITHER",
+				algorithm:         "MANN_WHITNEY",
+				significanceLevel: 0.05,
+			},
+			wantExpected: false,
+			wantErr:      false,
+		},
+		{
+			name: "kolmogorov-smirnov: no significance",
+			args: args{
+				experiment:        []float64{0.1, 0.2, 0.3, 0.4, 0.5},
+				control:           []float64{0.1, 0.2, 0.3, 0.4, 0.5},
+				deviation:         "EITHER",
+				algorithm:         "KOLMOGOROV_SMIRNOV",
+				significanceLevel: 0.05,
+			},
+			wantExpected: true,
+			wantErr:      false,
+		},
+		{
+			name: "kolmogorov-smirnov: deviation on high direction as expected",
+			args: args{
+				experiment:        []float64{10.1, 10.2, 10.3, 10.4, 10.5, 10.6, 10.7, 10.8},
+				control:           []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8},
+				deviation:         "LOW",
+				algorithm:         "KOLMOGOROV_SMIRNOV",
+				significanceLevel: 0.05,
+			},
+			wantExpected: true,
+			wantErr:      false,
+		},
+		{
+			name: "kolmogorov-smirnov: deviation on high direction as unexpected",
+			args: args{
+				experiment:        []float64{10.1, 10.2, 10.3, 10.4, 10.5, 10.6, 10.7, 10.8},
+				control:           []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8},
+				deviation:         "HIGH",
+				algorithm:         "KOLMOGOROV_SMIRNOV",
+				significanceLevel: 0.05,
+			},
+			wantExpected: false,
+			wantErr:      false,
+		},
+		{
+			name: "percentage deviation: within the tolerance",
+			args: args{
+				experiment: []float64{1.1, 1.0, 1.2},
+				control:    []float64{1.0, 1.0, 1.0},
+				deviation:  "EITHER",
+				algorithm:  "PERCENTAGE_DEVIATION",
+				tolerance:  20,
+			},
+			wantExpected: true,
+			wantErr:      false,
+		},
+		{
+			name: "percentage deviation: deviation on low direction as expected",
+			args: args{
+				experiment: []float64{0.5, 0.5, 0.5},
+				control:    []float64{1.0, 1.0, 1.0},
+				deviation:  "HIGH",
+				algorithm:  "PERCENTAGE_DEVIATION",
+				tolerance:  10,
+			},
+			wantExpected: true,
+			wantErr:      false,
+		},
+		{
+			name: "percentage deviation: deviation on high direction as unexpected",
+			args: args{
+				experiment: []float64{1.5, 1.5, 1.5},
+				control:    []float64{1.0, 1.0, 1.0},
+				deviation:  "HIGH",
+				algorithm:  "PERCENTAGE_DEVIATION",
+				tolerance:  10,
+			},
+			wantExpected: false,
+			wantErr:      false,
+		},
+		{
+			name: "percentage deviation: control is zero",
+			args: args{
+				experiment: []float64{0.1, 0.1, 0.1},
+				control:    []float64{0, 0, 0},
+				deviation:  "EITHER",
+				algorithm:  "PERCENTAGE_DEVIATION",
+				tolerance:  10,
+			},
+			wantExpected: false,
+			wantErr:      false,
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			got, err := compare(tc.args.experiment, tc.args.control, tc.args.deviation)
+			cfg := config.AnalysisMetrics{
+				Deviation:         tc.args.deviation,
+				Algorithm:         tc.args.algorithm,
+				SignificanceLevel: tc.args.significanceLevel,
+				Tolerance:         tc.args.tolerance,
+			}
+			got, _, err := compare(tc.args.experiment, tc.args.control, cfg)
 			assert.Equal(t, tc.wantErr, err != nil)
 			assert.Equal(t, tc.wantExpected, got)
 		})
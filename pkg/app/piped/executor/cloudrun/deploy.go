@@ -21,6 +21,7 @@ import (
 	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/cloudrun"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/deploysource"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/trafficstep"
 	"github.com/pipe-cd/pipecd/pkg/config"
 	"github.com/pipe-cd/pipecd/pkg/model"
 
@@ -73,7 +74,7 @@ func (e *deployExecutor) Execute(sig executor.StopSignal) model.StageStatus {
 		status = e.ensureSync(ctx)
 
 	case model.StageCloudRunPromote:
-		status = e.ensurePromote(ctx)
+		status = e.ensurePromote(sig)
 
 	default:
 		e.LogPersister.Errorf("Unsupported stage %s for cloudrun application", e.Stage.Name)
@@ -115,14 +116,26 @@ func (e *deployExecutor) ensureSync(ctx context.Context) model.StageStatus {
 	return model.StageStatus_STAGE_SUCCESS
 }
 
-func (e *deployExecutor) ensurePromote(ctx context.Context) model.StageStatus {
+func (e *deployExecutor) ensurePromote(sig executor.StopSignal) model.StageStatus {
 	options := e.StageConfig.CloudRunPromoteStageOptions
 	if options == nil {
 		e.LogPersister.Errorf("Malformed configuration for stage %s", e.Stage.Name)
 		return model.StageStatus_STAGE_FAILURE
 	}
+
+	if len(options.Steps) > 0 {
+		route := func(ctx context.Context, percent int) bool {
+			return e.promote(ctx, percent) == model.StageStatus_STAGE_SUCCESS
+		}
+		return trafficstep.Run(sig, e.Input, options.Steps, route)
+	}
+
+	return e.promote(sig.Context(), options.Percent.Int())
+}
+
+func (e *deployExecutor) promote(ctx context.Context, percent int) model.StageStatus {
 	metadata := map[string]string{
-		promotePercentageMetadataKey: strconv.FormatInt(int64(options.Percent.Int()), 10),
+		promotePercentageMetadataKey: strconv.FormatInt(int64(percent), 10),
 	}
 	if err := e.MetadataStore.Stage(e.Stage.Id).PutMulti(ctx, metadata); err != nil {
 		e.Logger.Error("failed to save routing percentages to metadata", zap.Error(err))
@@ -170,11 +183,11 @@ func (e *deployExecutor) ensurePromote(ctx context.Context) model.StageStatus {
 	traffics := []provider.RevisionTraffic{
 		{
 			RevisionName: revision,
-			Percent:      options.Percent.Int(),
+			Percent:      percent,
 		},
 		{
 			RevisionName: lastDeployedRevision,
-			Percent:      100 - options.Percent.Int(),
+			Percent:      100 - percent,
 		},
 	}
 
@@ -19,6 +19,7 @@ import (
 
 	"github.com/pipe-cd/pipecd/pkg/app/piped/deploysource"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/trafficstep"
 	"github.com/pipe-cd/pipecd/pkg/config"
 	"github.com/pipe-cd/pipecd/pkg/model"
 )
@@ -68,7 +69,7 @@ func (e *deployExecutor) Execute(sig executor.StopSignal) model.StageStatus {
 	case model.StageECSCanaryClean:
 		status = e.ensureCanaryClean(ctx)
 	case model.StageECSTrafficRouting:
-		status = e.ensureTrafficRouting(ctx)
+		status = e.ensureTrafficRouting(sig)
 	default:
 		e.LogPersister.Errorf("Unsupported stage %s for ECS application", e.Stage.Name)
 		return model.StageStatus_STAGE_FAILURE
@@ -147,7 +148,13 @@ func (e *deployExecutor) ensureCanaryRollout(ctx context.Context) model.StageSta
 	return model.StageStatus_STAGE_SUCCESS
 }
 
-func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageStatus {
+func (e *deployExecutor) ensureTrafficRouting(sig executor.StopSignal) model.StageStatus {
+	options := e.StageConfig.ECSTrafficRoutingStageOptions
+	if options == nil {
+		e.LogPersister.Errorf("Malformed configuration for stage %s", e.Stage.Name)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
 	primary, canary, ok := loadTargetGroups(&e.Input, e.appCfg, e.deploySource)
 	if !ok {
 		return model.StageStatus_STAGE_FAILURE
@@ -157,7 +164,15 @@ func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageSt
 		return model.StageStatus_STAGE_FAILURE
 	}
 
-	if !routing(ctx, &e.Input, e.cloudProviderName, e.cloudProviderCfg, *primary, *canary) {
+	route := func(ctx context.Context, weight int) bool {
+		return routing(ctx, &e.Input, e.cloudProviderName, e.cloudProviderCfg, *primary, *canary, 100-weight, weight)
+	}
+	if len(options.Steps) > 0 {
+		return trafficstep.Run(sig, e.Input, options.Steps, route)
+	}
+
+	_, canaryPercent := options.Percentage()
+	if !route(sig.Context(), canaryPercent) {
 		return model.StageStatus_STAGE_FAILURE
 	}
 	return model.StageStatus_STAGE_SUCCESS
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 
 	"github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider"
@@ -92,11 +93,31 @@ func loadServiceDefinition(in *executor.Input, serviceDefinitionFile string, ds
 		in.LogPersister.Errorf("Failed to load ECS service definition (%v)", err)
 		return types.Service{}, false
 	}
+	addBuiltinTags(&serviceDefinition, ds.Revision, in.PipedConfig.PipedID, in.Deployment.ApplicationId)
 
 	in.LogPersister.Infof("Successfully loaded the ECS service definition at commit %s", ds.Revision)
 	return serviceDefinition, true
 }
 
+// addBuiltinTags adds the tags used by piped to find the services of each application.
+// The existing tags with the same keys are overwritten.
+func addBuiltinTags(service *types.Service, hash, pipedID, appID string) {
+	builtins := []types.Tag{
+		{Key: aws.String(provider.LabelManagedBy), Value: aws.String(provider.ManagedByPiped)},
+		{Key: aws.String(provider.LabelPiped), Value: aws.String(pipedID)},
+		{Key: aws.String(provider.LabelApplication), Value: aws.String(appID)},
+		{Key: aws.String(provider.LabelCommitHash), Value: aws.String(hash)},
+	}
+	tags := make([]types.Tag, 0, len(service.Tags)+len(builtins))
+	for _, t := range service.Tags {
+		if provider.IsBuiltinTag(aws.ToString(t.Key)) {
+			continue
+		}
+		tags = append(tags, t)
+	}
+	service.Tags = append(tags, builtins...)
+}
+
 func loadTaskDefinition(in *executor.Input, taskDefinitionFile string, ds *deploysource.DeploySource) (types.TaskDefinition, bool) {
 	in.LogPersister.Infof("Loading task definition manifest at commit %s", ds.Revision)
 
@@ -317,19 +338,13 @@ func clean(ctx context.Context, in *executor.Input, cloudProviderName string, cl
 	return true
 }
 
-func routing(ctx context.Context, in *executor.Input, cloudProviderName string, cloudProviderCfg *config.CloudProviderECSConfig, primaryTargetGroup types.LoadBalancer, canaryTargetGroup types.LoadBalancer) bool {
+func routing(ctx context.Context, in *executor.Input, cloudProviderName string, cloudProviderCfg *config.CloudProviderECSConfig, primaryTargetGroup types.LoadBalancer, canaryTargetGroup types.LoadBalancer, primary, canary int) bool {
 	client, err := provider.DefaultRegistry().Client(cloudProviderName, cloudProviderCfg, in.Logger)
 	if err != nil {
 		in.LogPersister.Errorf("Unable to create ECS client for the provider %s: %v", cloudProviderName, err)
 		return false
 	}
 
-	options := in.StageConfig.ECSTrafficRoutingStageOptions
-	if options == nil {
-		in.LogPersister.Errorf("Malformed configuration for stage %s", in.Stage.Name)
-		return false
-	}
-	primary, canary := options.Percentage()
 	routingTrafficCfg := provider.RoutingTrafficConfig{
 		{
 			TargetGroupArn: *primaryTargetGroup.TargetGroupArn,
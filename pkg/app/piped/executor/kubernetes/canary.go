@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/kubernetes"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
@@ -30,6 +31,10 @@ const (
 	addedCanaryResourcesMetadataKey = "canary-resources"
 )
 
+// canaryResourcesMu serializes the updates of the list of CANARY resources stored in metadata
+// because the stages of a deployment may run concurrently with their own executors.
+var canaryResourcesMu sync.Mutex
+
 func (e *deployExecutor) ensureCanaryRollout(ctx context.Context) model.StageStatus {
 	options := e.StageConfig.K8sCanaryRolloutStageOptions
 	if options == nil {
@@ -85,13 +90,11 @@ func (e *deployExecutor) ensureCanaryRollout(ctx context.Context) model.StageSta
 	)
 
 	// Store added resource keys into metadata for cleaning later.
-	addedResources := make([]string, 0, len(canaryManifests))
+	addedResources := make([]provider.ResourceKey, 0, len(canaryManifests))
 	for _, m := range canaryManifests {
-		addedResources = append(addedResources, m.Key.String())
+		addedResources = append(addedResources, m.Key)
 	}
-	metadata := strings.Join(addedResources, ",")
-	err = e.MetadataStore.Shared().Put(ctx, addedCanaryResourcesMetadataKey, metadata)
-	if err != nil {
+	if err := e.addCanaryResources(ctx, addedResources...); err != nil {
 		e.LogPersister.Errorf("Unable to save deployment metadata (%v)", err)
 		return model.StageStatus_STAGE_FAILURE
 	}
@@ -113,6 +116,12 @@ func (e *deployExecutor) ensureCanaryClean(ctx context.Context) model.StageStatu
 		return model.StageStatus_STAGE_FAILURE
 	}
 
+	// Stop routing the matched requests to CANARY variant before removing it.
+	if err := e.removeCanaryMatchRoutes(ctx); err != nil {
+		e.LogPersister.Errorf("Unable to remove the routes for CANARY matches: %v", err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
 	resources := strings.Split(value, ",")
 	if err := removeCanaryResources(ctx, e.provider, resources, e.LogPersister); err != nil {
 		e.LogPersister.Errorf("Unable to remove canary resources: %v", err)
@@ -121,6 +130,39 @@ func (e *deployExecutor) ensureCanaryClean(ctx context.Context) model.StageStatu
 	return model.StageStatus_STAGE_SUCCESS
 }
 
+// addCanaryResources adds the given resources into the list of CANARY resources stored in metadata
+// to ensure that they will be removed while cleaning CANARY variant or rolling back.
+func (e *deployExecutor) addCanaryResources(ctx context.Context, keys ...provider.ResourceKey) error {
+	canaryResourcesMu.Lock()
+	defer canaryResourcesMu.Unlock()
+
+	var resources []string
+	if value, ok := e.MetadataStore.Shared().Get(addedCanaryResourcesMetadataKey); ok && value != "" {
+		resources = strings.Split(value, ",")
+	}
+	existing := make(map[string]struct{}, len(resources))
+	for _, r := range resources {
+		existing[r] = struct{}{}
+	}
+
+	added := make([]string, 0, len(keys))
+	for _, key := range keys {
+		k := key.String()
+		if _, ok := existing[k]; ok {
+			continue
+		}
+		existing[k] = struct{}{}
+		added = append(added, k)
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	// Prepend the resources to remove them before the services they are referencing.
+	resources = append(added, resources...)
+	return e.MetadataStore.Shared().Put(ctx, addedCanaryResourcesMetadataKey, strings.Join(resources, ","))
+}
+
 func (e *deployExecutor) generateCanaryManifests(manifests []provider.Manifest, opts config.K8sCanaryRolloutStageOptions) ([]provider.Manifest, error) {
 	suffix := canaryVariant
 	if opts.Suffix != "" {
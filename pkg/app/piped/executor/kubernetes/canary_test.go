@@ -17,16 +17,22 @@ package kubernetes
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/kubernetes"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/kubernetes/providertest"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/metadatastore"
+	"github.com/pipe-cd/pipecd/pkg/app/server/service/pipedservice"
 	"github.com/pipe-cd/pipecd/pkg/cache"
 	"github.com/pipe-cd/pipecd/pkg/cache/cachetest"
 	"github.com/pipe-cd/pipecd/pkg/config"
@@ -224,3 +230,67 @@ func TestEnsureCanaryRollout(t *testing.T) {
 		})
 	}
 }
+
+type fakeMetadataAPIClient struct{}
+
+func (c *fakeMetadataAPIClient) SaveDeploymentMetadata(_ context.Context, _ *pipedservice.SaveDeploymentMetadataRequest, _ ...grpc.CallOption) (*pipedservice.SaveDeploymentMetadataResponse, error) {
+	return &pipedservice.SaveDeploymentMetadataResponse{}, nil
+}
+
+func (c *fakeMetadataAPIClient) SaveStageMetadata(_ context.Context, _ *pipedservice.SaveStageMetadataRequest, _ ...grpc.CallOption) (*pipedservice.SaveStageMetadataResponse, error) {
+	return &pipedservice.SaveStageMetadataResponse{}, nil
+}
+
+func TestAddCanaryResources(t *testing.T) {
+	ctx := context.Background()
+	store := metadatastore.NewMetadataStore(&fakeMetadataAPIClient{}, &model.Deployment{
+		Metadata: map[string]string{
+			addedCanaryResourcesMetadataKey: "apps/v1:Deployment:default:foo-canary",
+		},
+	})
+
+	// Each stage has its own executor, so they update the shared list concurrently.
+	const num = 20
+	var wg sync.WaitGroup
+	for i := 0; i < num; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			e := &deployExecutor{
+				Input: executor.Input{
+					MetadataStore: store,
+				},
+			}
+			key := provider.ResourceKey{
+				APIVersion: "v1",
+				Kind:       provider.KindService,
+				Namespace:  "default",
+				Name:       fmt.Sprintf("foo-%d", i),
+			}
+			assert.NoError(t, e.addCanaryResources(ctx, key))
+		}(i)
+	}
+	wg.Wait()
+
+	value, ok := store.Shared().Get(addedCanaryResourcesMetadataKey)
+	require.True(t, ok)
+	resources := strings.Split(value, ",")
+	assert.Len(t, resources, num+1)
+	assert.Equal(t, "apps/v1:Deployment:default:foo-canary", resources[num])
+
+	// The already added resource is not duplicated.
+	e := &deployExecutor{
+		Input: executor.Input{
+			MetadataStore: store,
+		},
+	}
+	key := provider.ResourceKey{
+		APIVersion: "v1",
+		Kind:       provider.KindService,
+		Namespace:  "default",
+		Name:       "foo-0",
+	}
+	require.NoError(t, e.addCanaryResources(ctx, key))
+	value, _ = store.Shared().Get(addedCanaryResourcesMetadataKey)
+	assert.Len(t, strings.Split(value, ","), num+1)
+}
@@ -0,0 +1,327 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+const (
+	jobStatusCheckInterval = 5 * time.Second
+	// The maximum duration to wait for the remaining logs after the Job finished.
+	jobLogsFlushTimeout = 30 * time.Second
+	jobDeletionTimeout  = time.Minute
+)
+
+func (e *deployExecutor) ensureJob(sig executor.StopSignal) model.StageStatus {
+	ctx := sig.Context()
+	options := e.StageConfig.K8sJobStageOptions
+	if options == nil {
+		e.LogPersister.Errorf("Malformed configuration for stage %s", e.Stage.Name)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	ds, err := e.TargetDSP.Get(ctx, e.LogPersister)
+	if err != nil {
+		e.LogPersister.Errorf("Failed to prepare target deploy source data (%v)", err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	job, err := loadJobManifest(filepath.Join(ds.AppDir, options.Manifest))
+	if err != nil {
+		e.LogPersister.Errorf("Failed to load the Job manifest from %s (%v)", options.Manifest, err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	if ref := options.InheritFrom; ref.Kind != "" || ref.Name != "" {
+		// Load the manifests at the triggered commit.
+		e.LogPersister.Infof("Loading manifests at commit %s for handling", e.commit)
+		manifests, err := loadManifests(
+			ctx,
+			e.Deployment.ApplicationId,
+			e.commit,
+			e.AppManifestsCache,
+			e.provider,
+			e.Logger,
+		)
+		if err != nil {
+			e.LogPersister.Errorf("Failed while loading manifests (%v)", err)
+			return model.StageStatus_STAGE_FAILURE
+		}
+		e.LogPersister.Successf("Successfully loaded %d manifests", len(manifests))
+
+		workloads := findWorkloadManifests(manifests, []config.K8sResourceReference{ref})
+		if len(workloads) != 1 {
+			e.LogPersister.Errorf("Expected exactly one workload matching %s/%s to inherit from but found %d", ref.Kind, ref.Name, len(workloads))
+			return model.StageStatus_STAGE_FAILURE
+		}
+
+		if job, err = inheritContainerSpecs(job, workloads[0]); err != nil {
+			e.LogPersister.Errorf("Unable to inherit the container specs from workload %s (%v)", workloads[0].Key.ReadableLogString(), err)
+			return model.StageStatus_STAGE_FAILURE
+		}
+		e.LogPersister.Infof("Inherited the images and env of containers from workload %s", workloads[0].Key.ReadableLogString())
+	}
+
+	// Because the pod template of a Job is immutable,
+	// the Job remaining from a previous run must be removed before applying.
+	if err := e.provider.Delete(ctx, job.Key); err != nil && !errors.Is(err, provider.ErrNotFound) {
+		e.LogPersister.Errorf("Failed to delete the previous Job %s (%v)", job.Key.ReadableLogString(), err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	if err := e.provider.ApplyManifest(ctx, job); err != nil {
+		e.LogPersister.Errorf("Failed to apply the Job %s (%v)", job.Key.ReadableLogString(), err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+	e.LogPersister.Successf("Successfully applied the Job %s", job.Key.ReadableLogString())
+
+	status := e.waitJob(sig, job.Key)
+
+	// Use a new context to ensure that the Job will be deleted
+	// even when the stage was cancelled or timed out.
+	deleteCtx, cancel := context.WithTimeout(context.Background(), jobDeletionTimeout)
+	defer cancel()
+
+	if err := e.provider.Delete(deleteCtx, job.Key); err != nil && !errors.Is(err, provider.ErrNotFound) {
+		e.LogPersister.Errorf("Failed to delete the Job %s (%v)", job.Key.ReadableLogString(), err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+	e.LogPersister.Successf("Successfully deleted the Job %s", job.Key.ReadableLogString())
+
+	return status
+}
+
+// waitJob streams the logs of the given Job into the stage log
+// and blocks until the Job completed, failed or the stage was stopped.
+// Since a Job may create new pods to retry the failed ones,
+// the logs of all its pods are streamed one by one in the order of their creation.
+func (e *deployExecutor) waitJob(sig executor.StopSignal, key provider.ResourceKey) model.StageStatus {
+	ctx := sig.Context()
+	logsCtx, cancelLogs := context.WithCancel(ctx)
+	defer cancelLogs()
+
+	var (
+		streamedPods = make(map[string]struct{})
+		pendingPods  []string
+		// Nil means no logs are being streamed currently.
+		logsDone chan error
+	)
+	streamNextPodLogs := func() {
+		if logsDone != nil || len(pendingPods) == 0 {
+			return
+		}
+		pod := provider.ResourceKey{
+			APIVersion: "v1",
+			Kind:       provider.KindPod,
+			Namespace:  key.Namespace,
+			Name:       pendingPods[0],
+		}
+		pendingPods = pendingPods[1:]
+
+		e.LogPersister.Infof("Streaming the logs of pod %s of Job %s", pod.Name, key.ReadableLogString())
+		done := make(chan error, 1)
+		go func() {
+			done <- e.provider.StreamLogs(logsCtx, pod, e.LogPersister)
+		}()
+		logsDone = done
+	}
+	findNewPods := func() {
+		names, err := e.provider.GetJobPodNames(ctx, key)
+		if err != nil {
+			e.Logger.Warn("failed to get the pods of job", zap.String("job", key.String()), zap.Error(err))
+			return
+		}
+		for _, name := range names {
+			if _, ok := streamedPods[name]; ok {
+				continue
+			}
+			streamedPods[name] = struct{}{}
+			pendingPods = append(pendingPods, name)
+		}
+		streamNextPodLogs()
+	}
+	handleLogsDone := func(err error) {
+		if err != nil && logsCtx.Err() == nil {
+			e.LogPersister.Errorf("Unable to stream the logs of Job %s (%v)", key.ReadableLogString(), err)
+		}
+		logsDone = nil
+	}
+
+	ticker := time.NewTicker(jobStatusCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			e.LogPersister.Errorf("The Job %s was interrupted before finishing", key.ReadableLogString())
+			return executor.DetermineStageStatus(sig.Signal(), e.Stage.Status, model.StageStatus_STAGE_FAILURE)
+
+		case err := <-logsDone:
+			handleLogsDone(err)
+			streamNextPodLogs()
+
+		case <-ticker.C:
+			findNewPods()
+
+			m, err := e.provider.GetLiveManifest(ctx, key)
+			if err != nil {
+				e.Logger.Warn("failed to get the live manifest of job", zap.String("job", key.String()), zap.Error(err))
+				continue
+			}
+			finished, succeeded, message, err := determineJobStatus(m)
+			if err != nil {
+				e.LogPersister.Errorf("Unable to determine the status of Job %s (%v)", key.ReadableLogString(), err)
+				return model.StageStatus_STAGE_FAILURE
+			}
+			if !finished {
+				continue
+			}
+
+			// Wait a bit to ensure that the remaining logs of all pods were written.
+			findNewPods()
+			flushTimeout := time.After(jobLogsFlushTimeout)
+		L:
+			for logsDone != nil {
+				select {
+				case err := <-logsDone:
+					handleLogsDone(err)
+					streamNextPodLogs()
+				case <-flushTimeout:
+					break L
+				}
+			}
+
+			if !succeeded {
+				e.LogPersister.Errorf("The Job %s has failed: %s", key.ReadableLogString(), message)
+				return model.StageStatus_STAGE_FAILURE
+			}
+			e.LogPersister.Successf("The Job %s has completed successfully", key.ReadableLogString())
+			return model.StageStatus_STAGE_SUCCESS
+		}
+	}
+}
+
+func loadJobManifest(path string) (provider.Manifest, error) {
+	manifests, err := provider.LoadManifestsFromYAMLFile(path)
+	if err != nil {
+		return provider.Manifest{}, err
+	}
+	if len(manifests) != 1 {
+		return provider.Manifest{}, fmt.Errorf("the file must contain exactly one manifest but found %d", len(manifests))
+	}
+	if manifests[0].Key.Kind != provider.KindJob {
+		return provider.Manifest{}, fmt.Errorf("the manifest must be a %s but got %s", provider.KindJob, manifests[0].Key.Kind)
+	}
+	return manifests[0], nil
+}
+
+// inheritContainerSpecs returns a copy of the given Job manifest whose containers
+// use the image and env of the workload's containers with the same names.
+// The env defined in the Job takes precedence over the inherited one.
+func inheritContainerSpecs(job, workload provider.Manifest) (provider.Manifest, error) {
+	j := &batchv1.Job{}
+	if err := job.ConvertToStructuredObject(j); err != nil {
+		return provider.Manifest{}, err
+	}
+
+	// All kinds of workload have the pod template at the same path.
+	w := &struct {
+		Spec struct {
+			Template corev1.PodTemplateSpec `json:"template"`
+		} `json:"spec"`
+	}{}
+	if err := workload.ConvertToStructuredObject(w); err != nil {
+		return provider.Manifest{}, err
+	}
+
+	containers := make(map[string]corev1.Container, len(w.Spec.Template.Spec.Containers))
+	for _, c := range w.Spec.Template.Spec.Containers {
+		containers[c.Name] = c
+	}
+
+	var inherited int
+	for i := range j.Spec.Template.Spec.Containers {
+		c := &j.Spec.Template.Spec.Containers[i]
+		wc, ok := containers[c.Name]
+		if !ok {
+			continue
+		}
+		c.Image = wc.Image
+		c.Env = mergeEnvVars(wc.Env, c.Env)
+		c.EnvFrom = append(append([]corev1.EnvFromSource{}, wc.EnvFrom...), c.EnvFrom...)
+		inherited++
+	}
+	if inherited == 0 {
+		return provider.Manifest{}, fmt.Errorf("no container of the Job has the same name with the workload's containers")
+	}
+
+	return provider.ParseFromStructuredObject(j)
+}
+
+func mergeEnvVars(base, overrides []corev1.EnvVar) []corev1.EnvVar {
+	out := make([]corev1.EnvVar, 0, len(base)+len(overrides))
+	indexes := make(map[string]int, len(base)+len(overrides))
+	for _, envs := range [][]corev1.EnvVar{base, overrides} {
+		for _, env := range envs {
+			if i, ok := indexes[env.Name]; ok {
+				out[i] = env
+				continue
+			}
+			indexes[env.Name] = len(out)
+			out = append(out, env)
+		}
+	}
+	return out
+}
+
+// determineJobStatus reports whether the given Job has finished and succeeded
+// based on its status conditions.
+func determineJobStatus(m provider.Manifest) (finished, succeeded bool, message string, err error) {
+	j := &batchv1.Job{}
+	if err = m.ConvertToStructuredObject(j); err != nil {
+		return
+	}
+
+	for _, c := range j.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return true, true, c.Message, nil
+		case batchv1.JobFailed:
+			message = c.Reason
+			if c.Message != "" {
+				message = fmt.Sprintf("%s: %s", c.Reason, c.Message)
+			}
+			return true, false, message, nil
+		}
+	}
+	return false, false, "", nil
+}
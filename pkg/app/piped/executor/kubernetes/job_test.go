@@ -0,0 +1,200 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/kubernetes"
+)
+
+func TestInheritContainerSpecs(t *testing.T) {
+	workload := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: simple
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: gcr.io/pipecd/helloworld:v0.2.0
+        env:
+        - name: DB_HOST
+          value: db.internal
+        - name: LOG_LEVEL
+          value: info
+        envFrom:
+        - secretRef:
+            name: db-credentials
+      - name: sidecar
+        image: gcr.io/pipecd/sidecar:v1.0.0
+`
+	testcases := []struct {
+		name        string
+		job         string
+		expected    []corev1.Container
+		expectedErr bool
+	}{
+		{
+			name: "inherit from the container with the same name",
+			job: `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: gcr.io/pipecd/helloworld:latest
+        command: ["/migrate"]
+        env:
+        - name: LOG_LEVEL
+          value: debug
+      restartPolicy: Never
+`,
+			expected: []corev1.Container{
+				{
+					Name:    "app",
+					Image:   "gcr.io/pipecd/helloworld:v0.2.0",
+					Command: []string{"/migrate"},
+					Env: []corev1.EnvVar{
+						{Name: "DB_HOST", Value: "db.internal"},
+						{Name: "LOG_LEVEL", Value: "debug"},
+					},
+					EnvFrom: []corev1.EnvFromSource{
+						{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "db-credentials"}}},
+					},
+				},
+			},
+		},
+		{
+			name: "no container with the same name",
+			job: `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  template:
+    spec:
+      containers:
+      - name: migrate
+        image: gcr.io/pipecd/migrate:v0.1.0
+      restartPolicy: Never
+`,
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			workloads, err := provider.ParseManifests(workload)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(workloads))
+
+			jobs, err := provider.ParseManifests(tc.job)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(jobs))
+
+			got, err := inheritContainerSpecs(jobs[0], workloads[0])
+			assert.Equal(t, tc.expectedErr, err != nil)
+			if err != nil {
+				return
+			}
+
+			j := &batchv1.Job{}
+			require.NoError(t, got.ConvertToStructuredObject(j))
+			assert.Equal(t, tc.expected, j.Spec.Template.Spec.Containers)
+			assert.Equal(t, jobs[0].Key, got.Key)
+		})
+	}
+}
+
+func TestDetermineJobStatus(t *testing.T) {
+	testcases := []struct {
+		name              string
+		manifest          string
+		expectedFinished  bool
+		expectedSucceeded bool
+		expectedMessage   string
+	}{
+		{
+			name: "running",
+			manifest: `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+status:
+  active: 1
+`,
+		},
+		{
+			name: "completed",
+			manifest: `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+status:
+  conditions:
+  - type: Complete
+    status: "True"
+  succeeded: 1
+`,
+			expectedFinished:  true,
+			expectedSucceeded: true,
+		},
+		{
+			name: "failed",
+			manifest: `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+status:
+  conditions:
+  - type: Failed
+    status: "True"
+    reason: BackoffLimitExceeded
+    message: Job has reached the specified backoff limit
+  failed: 2
+`,
+			expectedFinished: true,
+			expectedMessage:  "BackoffLimitExceeded: Job has reached the specified backoff limit",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := provider.ParseManifests(tc.manifest)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(manifests))
+
+			finished, succeeded, message, err := determineJobStatus(manifests[0])
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedFinished, finished)
+			assert.Equal(t, tc.expectedSucceeded, succeeded)
+			assert.Equal(t, tc.expectedMessage, message)
+		})
+	}
+}
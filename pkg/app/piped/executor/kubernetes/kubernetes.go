@@ -65,6 +65,7 @@ func Register(r registerer) {
 	r.Register(model.StageK8sBaselineRollout, f)
 	r.Register(model.StageK8sBaselineClean, f)
 	r.Register(model.StageK8sTrafficRouting, f)
+	r.Register(model.StageK8sJob, f)
 
 	r.RegisterRollback(model.ApplicationKind_KUBERNETES, func(in executor.Input) executor.Executor {
 		return &rollbackExecutor{
@@ -127,7 +128,10 @@ func (e *deployExecutor) Execute(sig executor.StopSignal) model.StageStatus {
 		status = e.ensureBaselineClean(ctx)
 
 	case model.StageK8sTrafficRouting:
-		status = e.ensureTrafficRouting(ctx)
+		status = e.ensureTrafficRouting(sig)
+
+	case model.StageK8sJob:
+		status = e.ensureJob(sig)
 
 	default:
 		e.LogPersister.Errorf("Unsupported stage %s for kubernetes application", e.Stage.Name)
@@ -511,101 +515,22 @@ func makeSuffixedName(name, suffix string) string {
 	return name
 }
 
-// annotateConfigHash appends a hash annotation into the workload manifests.
-// The hash value is calculated by hashing the content of all configmaps/secrets
-// that are referenced by the workload.
-// This appending ensures that the workload should be restarted when
-// one of its configurations changed.
-func annotateConfigHash(manifests []provider.Manifest) error {
-	if len(manifests) == 0 {
-		return nil
-	}
-
-	configMaps := make(map[string]provider.Manifest)
-	secrets := make(map[string]provider.Manifest)
-	for _, m := range manifests {
-		if m.Key.IsConfigMap() {
-			configMaps[m.Key.Name] = m
-			continue
-		}
-		if m.Key.IsSecret() {
-			secrets[m.Key.Name] = m
-		}
-	}
-
-	// This application is not containing any config manifests
-	// so nothing to do.
-	if len(configMaps)+len(secrets) == 0 {
-		return nil
-	}
-
-	for _, m := range manifests {
-		if m.Key.IsDeployment() {
-			if err := annotateConfigHashToDeployment(m, configMaps, secrets); err != nil {
-				return err
-			}
-		}
-
-		// TODO: Anotate config hash into other workload kinds such as DaemonSet, StatefulSet...
-	}
-
-	return nil
-}
-
-func annotateConfigHashToDeployment(m provider.Manifest, managedConfigMaps, managedSecrets map[string]provider.Manifest) error {
-	d := &appsv1.Deployment{}
-	if err := m.ConvertToStructuredObject(d); err != nil {
-		return err
-	}
-
-	configMaps := provider.FindReferencingConfigMapsInDeployment(d)
-	secrets := provider.FindReferencingSecretsInDeployment(d)
-
-	// The deployment is not referencing any config resources.
-	if len(configMaps)+len(secrets) == 0 {
-		return nil
+// findVariantSuffixes returns the suffixes used while naming the resources of CANARY and BASELINE variants.
+// They can be changed by the suffix option of K8S_CANARY_ROLLOUT and K8S_BASELINE_ROLLOUT stages.
+func findVariantSuffixes(appCfg *config.KubernetesApplicationSpec) (canarySuffix, baselineSuffix string) {
+	canarySuffix, baselineSuffix = canaryVariant, baselineVariant
+	if appCfg == nil || appCfg.Pipeline == nil {
+		return
 	}
-
-	cfgs := make([]provider.Manifest, 0, len(configMaps)+len(secrets))
-	for _, cm := range configMaps {
-		m, ok := managedConfigMaps[cm]
-		if !ok {
-			// We do not return error here because the deployment may use
-			// a config resource that is not managed by PipeCD.
-			continue
+	for _, s := range appCfg.Pipeline.Stages {
+		if opts := s.K8sCanaryRolloutStageOptions; opts != nil && opts.Suffix != "" {
+			canarySuffix = opts.Suffix
 		}
-		cfgs = append(cfgs, m)
-	}
-	for _, s := range secrets {
-		m, ok := managedSecrets[s]
-		if !ok {
-			// We do not return error here because the deployment may use
-			// a config resource that is not managed by PipeCD.
-			continue
+		if opts := s.K8sBaselineRolloutStageOptions; opts != nil && opts.Suffix != "" {
+			baselineSuffix = opts.Suffix
 		}
-		cfgs = append(cfgs, m)
 	}
-
-	if len(cfgs) == 0 {
-		return nil
-	}
-
-	hash, err := provider.HashManifests(cfgs)
-	if err != nil {
-		fmt.Println(err)
-		return err
-	}
-
-	m.AddStringMapValues(
-		map[string]string{
-			provider.AnnotationConfigHash: hash,
-		},
-		"spec",
-		"template",
-		"metadata",
-		"annotations",
-	)
-	return nil
+	return
 }
 
 type patcher func(m provider.Manifest, cfg config.K8sResourcePatch) (*provider.Manifest, error)
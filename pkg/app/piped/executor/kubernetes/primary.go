@@ -125,7 +125,7 @@ func (e *deployExecutor) ensurePrimaryRollout(ctx context.Context) model.StageSt
 	)
 
 	// Add config-hash annotation to the workloads.
-	if err := annotateConfigHash(primaryManifests); err != nil {
+	if err := provider.AnnotateConfigHash(primaryManifests); err != nil {
 		e.LogPersister.Errorf("Unable to set %q annotation into the workload manifest (%v)", provider.AnnotationConfigHash, err)
 		return model.StageStatus_STAGE_FAILURE
 	}
@@ -16,12 +16,14 @@ package kubernetes
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"go.uber.org/zap"
 
 	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/kubernetes"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/config"
 	"github.com/pipe-cd/pipecd/pkg/model"
 )
 
@@ -118,7 +120,7 @@ func (e *rollbackExecutor) ensureRollback(ctx context.Context) model.StageStatus
 	)
 
 	// Add config-hash annotation to the workloads.
-	if err := annotateConfigHash(manifests); err != nil {
+	if err := provider.AnnotateConfigHash(manifests); err != nil {
 		e.LogPersister.Errorf("Unable to set %q annotation into the workload manifest (%v)", provider.AnnotationConfigHash, err)
 		return model.StageStatus_STAGE_FAILURE
 	}
@@ -128,6 +130,12 @@ func (e *rollbackExecutor) ensureRollback(ctx context.Context) model.StageStatus
 		return model.StageStatus_STAGE_FAILURE
 	}
 
+	// Because the TrafficSplit is not defined in Git, it must be restored
+	// before removing the CANARY and BASELINE variants.
+	if err := e.rollbackTrafficSplit(ctx, p, manifests, appCfg); err != nil {
+		return model.StageStatus_STAGE_FAILURE
+	}
+
 	var errs []error
 
 	// Next we delete all resources of CANARY variant.
@@ -153,3 +161,42 @@ func (e *rollbackExecutor) ensureRollback(ctx context.Context) model.StageStatus
 	}
 	return model.StageStatus_STAGE_SUCCESS
 }
+
+// rollbackTrafficSplit routes all traffic back to PRIMARY variant
+// when the running commit is using SMI for traffic routing.
+// Otherwise the TrafficSplit generated by this deployment will be removed.
+func (e *rollbackExecutor) rollbackTrafficSplit(ctx context.Context, applier provider.Applier, manifests []provider.Manifest, appCfg *config.KubernetesApplicationSpec) error {
+	generated, ok := e.MetadataStore.Shared().Get(generatedTrafficSplitMetadataKey)
+
+	if config.DetermineKubernetesTrafficRoutingMethod(appCfg.TrafficRouting) != config.KubernetesTrafficRoutingMethodSMI {
+		if !ok {
+			return nil
+		}
+		key, err := provider.DecodeResourceKey(generated)
+		if err != nil {
+			e.LogPersister.Errorf("Had an error while decoding TrafficSplit resource key: %s, %v", generated, err)
+			return err
+		}
+		e.LogPersister.Info("Start removing the TrafficSplit generated by this deployment")
+		return deleteResources(ctx, applier, []provider.ResourceKey{key}, e.LogPersister)
+	}
+
+	services := findManifests(provider.KindService, appCfg.Service.Name, manifests)
+	if len(services) == 0 {
+		e.LogPersister.Errorf("Unable to find any service for name=%q to generate TrafficSplit", appCfg.Service.Name)
+		return fmt.Errorf("unable to find any service for name=%q", appCfg.Service.Name)
+	}
+	canarySuffix, baselineSuffix := findVariantSuffixes(appCfg)
+	trafficSplit := generateTrafficSplitManifest(services[0], appCfg.TrafficRouting.SMI, canarySuffix, baselineSuffix, 100, 0, 0)
+
+	addBuiltinAnnontations(
+		[]provider.Manifest{trafficSplit},
+		primaryVariant,
+		e.Deployment.RunningCommitHash,
+		e.PipedConfig.PipedID,
+		e.Deployment.ApplicationId,
+	)
+
+	e.LogPersister.Info("Start updating TrafficSplit to route all traffic to PRIMARY variant")
+	return applyManifests(ctx, applier, []provider.Manifest{trafficSplit}, appCfg.Input.Namespace, e.LogPersister)
+}
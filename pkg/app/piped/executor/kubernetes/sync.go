@@ -65,7 +65,7 @@ func (e *deployExecutor) ensureSync(ctx context.Context) model.StageStatus {
 	)
 
 	// Add config-hash annotation to the workloads.
-	if err := annotateConfigHash(manifests); err != nil {
+	if err := provider.AnnotateConfigHash(manifests); err != nil {
 		e.LogPersister.Errorf("Unable to set %q annotation into the workload manifest (%v)", provider.AnnotationConfigHash, err)
 		return model.StageStatus_STAGE_FAILURE
 	}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -26,6 +27,8 @@ import (
 	istiov1beta1 "istio.io/api/networking/v1beta1"
 
 	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/trafficstep"
 	"github.com/pipe-cd/pipecd/pkg/config"
 	"github.com/pipe-cd/pipecd/pkg/model"
 )
@@ -34,18 +37,40 @@ const (
 	primaryMetadataKey  = "primary-percentage"
 	canaryMetadataKey   = "canary-percentage"
 	baselineMetadataKey = "baseline-percentage"
+
+	generatedTrafficSplitMetadataKey = "generated-traffic-split"
+
+	canaryMatchRoutesMetadataKey = "canary-match-routes"
+
+	nginxCanaryAnnotation       = "nginx.ingress.kubernetes.io/canary"
+	nginxCanaryWeightAnnotation = "nginx.ingress.kubernetes.io/canary-weight"
 )
 
-func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageStatus {
-	var (
-		commitHash = e.Deployment.Trigger.Commit.Hash
-		options    = e.StageConfig.K8sTrafficRoutingStageOptions
-	)
+func (e *deployExecutor) ensureTrafficRouting(sig executor.StopSignal) model.StageStatus {
+	options := e.StageConfig.K8sTrafficRoutingStageOptions
 	if options == nil {
 		e.LogPersister.Errorf("Malformed configuration for stage %s", e.Stage.Name)
 		return model.StageStatus_STAGE_FAILURE
 	}
-	method := config.DetermineKubernetesTrafficRoutingMethod(e.appCfg.TrafficRouting)
+
+	if len(options.Steps) == 0 {
+		// Decide traffic routing percentage for all variants.
+		primaryPercent, canaryPercent, baselinePercent := options.Percentages()
+		return e.routeTraffic(sig.Context(), options, primaryPercent, canaryPercent, baselinePercent)
+	}
+
+	// Progressively route the traffic from PRIMARY to CANARY variant through the steps.
+	route := func(ctx context.Context, weight int) bool {
+		return e.routeTraffic(ctx, options, 100-weight, weight, 0) == model.StageStatus_STAGE_SUCCESS
+	}
+	return trafficstep.Run(sig, e.Input, options.Steps, route)
+}
+
+func (e *deployExecutor) routeTraffic(ctx context.Context, options *config.K8sTrafficRoutingStageOptions, primaryPercent, canaryPercent, baselinePercent int) model.StageStatus {
+	var (
+		commitHash = e.Deployment.Trigger.Commit.Hash
+		method     = config.DetermineKubernetesTrafficRoutingMethod(e.appCfg.TrafficRouting)
+	)
 
 	// Load the manifests at the triggered commit.
 	e.LogPersister.Infof("Loading manifests at commit %s for handling", commitHash)
@@ -68,8 +93,11 @@ func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageSt
 		return model.StageStatus_STAGE_FAILURE
 	}
 
-	// Decide traffic routing percentage for all variants.
-	primaryPercent, canaryPercent, baselinePercent := options.Percentages()
+	if len(options.CanaryMatches) > 0 && method != config.KubernetesTrafficRoutingMethodIstio {
+		e.LogPersister.Errorf("Traffic routing by %s does not support canaryMatches", method)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
 	e.saveTrafficRoutingMetadata(ctx, primaryPercent, canaryPercent, baselinePercent)
 
 	// Find traffic routing manifests.
@@ -94,10 +122,11 @@ func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageSt
 	}
 	trafficRoutingManifest := trafficRoutingManifests[0]
 
-	// In case we are routing by PodSelector, the service manifest must contain variantLabel inside its selector.
-	if method == config.KubernetesTrafficRoutingMethodPodSelector {
+	// In case we are routing by PodSelector or SMI, the service manifest must contain variantLabel inside its selector.
+	if method == config.KubernetesTrafficRoutingMethodPodSelector || method == config.KubernetesTrafficRoutingMethodSMI {
 		if err := checkVariantSelectorInService(trafficRoutingManifest, primaryVariant); err != nil {
-			e.LogPersister.Errorf("Traffic routing by PodSelector requires %q inside the selector of Service manifest but it was unable to check that field in manifest %s (%v)",
+			e.LogPersister.Errorf("Traffic routing by %s requires %q inside the selector of Service manifest but it was unable to check that field in manifest %s (%v)",
+				method,
 				variantLabel+": "+primaryVariant,
 				trafficRoutingManifest.Key.ReadableLogString(),
 				err,
@@ -106,11 +135,24 @@ func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageSt
 		}
 	}
 
+	// In case we are routing by Ingress or HTTPRoute, the Service used as their backend must be determined.
+	var serviceName string
+	if method == config.KubernetesTrafficRoutingMethodNginx || method == config.KubernetesTrafficRoutingMethodGatewayAPI {
+		services := findManifests(provider.KindService, e.appCfg.Service.Name, manifests)
+		if len(services) == 0 {
+			e.LogPersister.Errorf("Unable to find any service for name=%q", e.appCfg.Service.Name)
+			return model.StageStatus_STAGE_FAILURE
+		}
+		serviceName = services[0].Key.Name
+	}
+
 	trafficRoutingManifest, err = e.generateTrafficRoutingManifest(
 		trafficRoutingManifest,
+		serviceName,
 		primaryPercent,
 		canaryPercent,
 		baselinePercent,
+		options.CanaryMatches,
 		e.appCfg.TrafficRouting,
 	)
 	if err != nil {
@@ -118,24 +160,66 @@ func (e *deployExecutor) ensureTrafficRouting(ctx context.Context) model.StageSt
 		return model.StageStatus_STAGE_FAILURE
 	}
 
+	// The generated canary Ingress is a part of CANARY variant.
+	variant := primaryVariant
+	if method == config.KubernetesTrafficRoutingMethodNginx {
+		variant = canaryVariant
+	}
+
 	// Add builtin annotations for tracking application live state.
 	addBuiltinAnnontations(
 		[]provider.Manifest{trafficRoutingManifest},
-		primaryVariant,
+		variant,
 		commitHash,
 		e.PipedConfig.PipedID,
 		e.Deployment.ApplicationId,
 	)
 
+	// Store the key of generated TrafficSplit into metadata
+	// to be able to restore or remove it while rolling back.
+	if method == config.KubernetesTrafficRoutingMethodSMI {
+		if err := e.MetadataStore.Shared().Put(ctx, generatedTrafficSplitMetadataKey, trafficRoutingManifest.Key.String()); err != nil {
+			e.LogPersister.Errorf("Unable to save deployment metadata (%v)", err)
+			return model.StageStatus_STAGE_FAILURE
+		}
+	}
+
+	// Store the key of generated canary Ingress as a CANARY resource
+	// to ensure that it will be removed while cleaning CANARY variant or rolling back.
+	if method == config.KubernetesTrafficRoutingMethodNginx {
+		if err := e.addCanaryResources(ctx, trafficRoutingManifest.Key); err != nil {
+			e.LogPersister.Errorf("Unable to save deployment metadata (%v)", err)
+			return model.StageStatus_STAGE_FAILURE
+		}
+	}
+
 	e.LogPersister.Infof("Start updating traffic routing to be percentages: primary=%d, canary=%d, baseline=%d",
 		primaryPercent,
 		canaryPercent,
 		baselinePercent,
 	)
+	for _, m := range options.CanaryMatches {
+		e.LogPersister.Infof("- requests matching %s will be routed to CANARY variant", m.String())
+	}
 	if err := applyManifests(ctx, e.provider, []provider.Manifest{trafficRoutingManifest}, e.appCfg.Input.Namespace, e.LogPersister); err != nil {
 		return model.StageStatus_STAGE_FAILURE
 	}
 
+	// Store the current percentages while the match routes are injected
+	// to be able to remove them while cleaning CANARY variant.
+	if method == config.KubernetesTrafficRoutingMethodIstio {
+		var value string
+		if len(options.CanaryMatches) > 0 {
+			value = fmt.Sprintf("%d,%d,%d", primaryPercent, canaryPercent, baselinePercent)
+		}
+		if cur, _ := e.MetadataStore.Shared().Get(canaryMatchRoutesMetadataKey); cur != value {
+			if err := e.MetadataStore.Shared().Put(ctx, canaryMatchRoutesMetadataKey, value); err != nil {
+				e.LogPersister.Errorf("Unable to save deployment metadata (%v)", err)
+				return model.StageStatus_STAGE_FAILURE
+			}
+		}
+	}
+
 	e.LogPersister.Success("Successfully updated traffic routing")
 	return model.StageStatus_STAGE_SUCCESS
 }
@@ -154,20 +238,51 @@ func findTrafficRoutingManifests(manifests []provider.Manifest, serviceName stri
 		}
 		return findIstioVirtualServiceManifests(manifests, istioConfig.VirtualService)
 
+	case config.KubernetesTrafficRoutingMethodSMI:
+		// The TrafficSplit will be generated from the Service manifest.
+		return findManifests(provider.KindService, serviceName, manifests), nil
+
+	case config.KubernetesTrafficRoutingMethodNginx:
+		nginxConfig := cfg.Nginx
+		if nginxConfig == nil {
+			nginxConfig = &config.NginxTrafficRouting{}
+		}
+		return findIngressManifests(manifests, nginxConfig.Ingress)
+
+	case config.KubernetesTrafficRoutingMethodGatewayAPI:
+		gatewayConfig := cfg.GatewayAPI
+		if gatewayConfig == nil {
+			gatewayConfig = &config.GatewayAPITrafficRouting{}
+		}
+		return findHTTPRouteManifests(manifests, gatewayConfig.HTTPRoute)
+
 	default:
 		return nil, fmt.Errorf("unsupport traffic routing method %v", method)
 	}
 }
 
-func (e *deployExecutor) generateTrafficRoutingManifest(manifest provider.Manifest, primaryPercent, canaryPercent, baselinePercent int, cfg *config.KubernetesTrafficRouting) (provider.Manifest, error) {
+func (e *deployExecutor) generateTrafficRoutingManifest(manifest provider.Manifest, serviceName string, primaryPercent, canaryPercent, baselinePercent int, canaryMatches []config.K8sTrafficRoutingMatch, cfg *config.KubernetesTrafficRouting) (provider.Manifest, error) {
 	// Because the loaded manifests are read-only
 	// so we duplicate them to avoid updating the shared manifests data in cache.
 	manifest = duplicateManifest(manifest, "")
 
+	// The TrafficSplit is not defined in Git, so it must always be generated
+	// even when all traffic should be routed to primary variant.
+	canarySuffix, baselineSuffix := findVariantSuffixes(e.appCfg)
+	if cfg != nil && cfg.Method == config.KubernetesTrafficRoutingMethodSMI {
+		return generateTrafficSplitManifest(manifest, cfg.SMI, canarySuffix, baselineSuffix, primaryPercent, canaryPercent, baselinePercent), nil
+	}
+
+	// The canary Ingress is always generated to be able to set its weight back to zero
+	// since it will be removed only while cleaning CANARY variant.
+	if cfg != nil && cfg.Method == config.KubernetesTrafficRoutingMethodNginx {
+		return generateCanaryIngressManifest(manifest, serviceName, canarySuffix, canaryPercent, baselinePercent)
+	}
+
 	// When all traffic should be routed to primary variant
 	// we do not need to change the traffic manifest
 	// just copy and return the one specified in the target commit.
-	if primaryPercent == 100 {
+	if primaryPercent == 100 && len(canaryMatches) == 0 {
 		return manifest, nil
 	}
 
@@ -177,10 +292,23 @@ func (e *deployExecutor) generateTrafficRoutingManifest(manifest provider.Manife
 			istioConfig = &config.IstioTrafficRouting{}
 		}
 
+		var (
+			generated provider.Manifest
+			err       error
+		)
 		if strings.HasPrefix(manifest.Key.APIVersion, "v1alpha3") {
-			return generateVirtualServiceManifestV1Alpha3(manifest, istioConfig.Host, istioConfig.EditableRoutes, int32(canaryPercent), int32(baselinePercent))
+			generated, err = generateVirtualServiceManifestV1Alpha3(manifest, istioConfig.Host, istioConfig.EditableRoutes, int32(canaryPercent), int32(baselinePercent))
+		} else {
+			generated, err = generateVirtualServiceManifest(manifest, istioConfig.Host, istioConfig.EditableRoutes, int32(canaryPercent), int32(baselinePercent))
+		}
+		if err != nil || len(canaryMatches) == 0 {
+			return generated, err
 		}
-		return generateVirtualServiceManifest(manifest, istioConfig.Host, istioConfig.EditableRoutes, int32(canaryPercent), int32(baselinePercent))
+		return injectCanaryMatchRoutes(manifest, generated, istioConfig.Host, istioConfig.EditableRoutes, canaryMatches)
+	}
+
+	if cfg != nil && cfg.Method == config.KubernetesTrafficRoutingMethodGatewayAPI {
+		return generateHTTPRouteManifest(manifest, serviceName, canarySuffix, baselineSuffix, canaryPercent, baselinePercent)
 	}
 
 	// Determine which variant will receive 100% percent of traffic.
@@ -411,6 +539,445 @@ func generateVirtualServiceManifestV1Alpha3(m provider.Manifest, host string, ed
 	return m, nil
 }
 
+// generateTrafficSplitManifest generates an SMI TrafficSplit manifest
+// that splits the traffic sent to the given Service into its variant Services.
+// The given Service itself is used as the backend of PRIMARY variant.
+func generateTrafficSplitManifest(service provider.Manifest, cfg *config.SMITrafficRouting, canarySuffix, baselineSuffix string, primaryPercent, canaryPercent, baselinePercent int) provider.Manifest {
+	name := service.Key.Name
+	if cfg != nil && cfg.TrafficSplitName != "" {
+		name = cfg.TrafficSplitName
+	}
+
+	backends := []provider.TrafficSplitBackend{
+		{
+			Service: service.Key.Name,
+			Weight:  primaryPercent,
+		},
+		{
+			Service: makeSuffixedName(service.Key.Name, canarySuffix),
+			Weight:  canaryPercent,
+		},
+		{
+			Service: makeSuffixedName(service.Key.Name, baselineSuffix),
+			Weight:  baselinePercent,
+		},
+	}
+
+	// Use the namespace specified in the Service manifest instead of the one in its key
+	// because the key is always filled with the default namespace.
+	var namespace string
+	if metadata, err := service.GetNestedMap("metadata"); err == nil {
+		namespace, _ = metadata["namespace"].(string)
+	}
+
+	return provider.MakeTrafficSplitManifest(
+		cfg.TrafficSplitAPIVersion(),
+		name,
+		namespace,
+		service.Key.Name,
+		backends,
+	)
+}
+
+func findIngressManifests(manifests []provider.Manifest, ref config.K8sResourceReference) ([]provider.Manifest, error) {
+	if ref.Kind != "" && ref.Kind != provider.KindIngress {
+		return nil, fmt.Errorf("support only %q kind for Ingress reference", provider.KindIngress)
+	}
+	return findManifests(provider.KindIngress, ref.Name, manifests), nil
+}
+
+// generateCanaryIngressManifest generates a canary Ingress from the given Ingress
+// to route the specified percentage of traffic to CANARY variant by using the NGINX canary annotations.
+func generateCanaryIngressManifest(m provider.Manifest, serviceName, canarySuffix string, canaryPercent, baselinePercent int) (provider.Manifest, error) {
+	if baselinePercent > 0 {
+		return m, fmt.Errorf("traffic routing by nginx does not support BASELINE variant (baseline=%d)", baselinePercent)
+	}
+
+	// Because the loaded manifests are read-only
+	// so we duplicate them to avoid updating the shared manifests data in cache.
+	m = duplicateManifest(m, canarySuffix)
+
+	spec, err := m.GetSpec()
+	if err != nil {
+		return m, err
+	}
+	canaryServiceName := makeSuffixedName(serviceName, canarySuffix)
+	if n := replaceIngressBackendService(spec, serviceName, canaryServiceName); n == 0 {
+		return m, fmt.Errorf("there is no backend referencing service %q in ingress %q", serviceName, m.Key.Name)
+	}
+
+	m.AddAnnotations(map[string]string{
+		nginxCanaryAnnotation:       "true",
+		nginxCanaryWeightAnnotation: strconv.Itoa(canaryPercent),
+	})
+	return m, nil
+}
+
+// replaceIngressBackendService replaces the name of all backend services those are matching the given name.
+// Both "service.name" of networking.k8s.io/v1 and "serviceName" of networking.k8s.io/v1beta1 are supported.
+// The number of replaced backends is returned.
+func replaceIngressBackendService(obj interface{}, from, to string) int {
+	var replaced int
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		if name, ok := v["serviceName"].(string); ok && name == from {
+			v["serviceName"] = to
+			replaced++
+		}
+		if svc, ok := v["service"].(map[string]interface{}); ok {
+			if name, ok := svc["name"].(string); ok && name == from {
+				svc["name"] = to
+				replaced++
+			}
+		}
+		for _, child := range v {
+			replaced += replaceIngressBackendService(child, from, to)
+		}
+	case []interface{}:
+		for _, child := range v {
+			replaced += replaceIngressBackendService(child, from, to)
+		}
+	}
+	return replaced
+}
+
+func findHTTPRouteManifests(manifests []provider.Manifest, ref config.K8sResourceReference) ([]provider.Manifest, error) {
+	const (
+		gatewayAPIVersionPrefix = "gateway.networking.k8s.io/"
+		httpRouteKind           = "HTTPRoute"
+	)
+
+	if ref.Kind != "" && ref.Kind != httpRouteKind {
+		return nil, fmt.Errorf("support only %q kind for HTTPRoute reference", httpRouteKind)
+	}
+
+	var out []provider.Manifest
+	for _, m := range manifests {
+		if !strings.HasPrefix(m.Key.APIVersion, gatewayAPIVersionPrefix) {
+			continue
+		}
+		if m.Key.Kind != httpRouteKind {
+			continue
+		}
+		if ref.Name != "" && m.Key.Name != ref.Name {
+			continue
+		}
+		out = append(out, m)
+	}
+
+	return out, nil
+}
+
+// generateHTTPRouteManifest updates the backendRefs of all rules those are referencing the given Service
+// to split their traffic into the variant Services.
+// The given Service itself is used as the backend of PRIMARY variant.
+func generateHTTPRouteManifest(m provider.Manifest, serviceName, canarySuffix, baselineSuffix string, canaryPercent, baselinePercent int) (provider.Manifest, error) {
+	// Because the loaded manifests are read-only
+	// so we duplicate them to avoid updating the shared manifests data in cache.
+	m = duplicateManifest(m, "")
+
+	spec, err := m.GetNestedMap("spec")
+	if err != nil {
+		return m, err
+	}
+	rules, _ := spec["rules"].([]interface{})
+
+	var updated int
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		refs, _ := rule["backendRefs"].([]interface{})
+
+		var (
+			serviceRef         map[string]interface{}
+			otherBackendRefs   = make([]interface{}, 0)
+			otherBackendWeight int64
+		)
+		for _, ref := range refs {
+			br, ok := ref.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if serviceRef == nil && isServiceBackendRef(br, serviceName) {
+				serviceRef = br
+				continue
+			}
+			otherBackendWeight += backendRefWeight(br)
+			otherBackendRefs = append(otherBackendRefs, br)
+		}
+		if serviceRef == nil {
+			continue
+		}
+		// The weights of the variants are calculated from the remaining weight
+		// so the other backends must not use more than 100 in total.
+		if otherBackendWeight > 100 {
+			return m, fmt.Errorf("the total weight of the other backendRefs must not exceed 100 in HTTPRoute %q (got %d)", m.Key.Name, otherBackendWeight)
+		}
+
+		var (
+			variantsWeight = 100 - otherBackendWeight
+			canaryWeight   = clampWeight(int64(canaryPercent)*variantsWeight/100, variantsWeight)
+			baselineWeight = clampWeight(int64(baselinePercent)*variantsWeight/100, variantsWeight-canaryWeight)
+			primaryWeight  = variantsWeight - canaryWeight - baselineWeight
+			backendRefs    = make([]interface{}, 0, len(otherBackendRefs)+3)
+		)
+
+		backendRefs = append(backendRefs, makeBackendRef(serviceRef, serviceName, primaryWeight))
+		if canaryWeight > 0 {
+			backendRefs = append(backendRefs, makeBackendRef(serviceRef, makeSuffixedName(serviceName, canarySuffix), canaryWeight))
+		}
+		if baselineWeight > 0 {
+			backendRefs = append(backendRefs, makeBackendRef(serviceRef, makeSuffixedName(serviceName, baselineSuffix), baselineWeight))
+		}
+		backendRefs = append(backendRefs, otherBackendRefs...)
+		rule["backendRefs"] = backendRefs
+		updated++
+	}
+
+	if updated == 0 {
+		return m, fmt.Errorf("there is no backendRef referencing service %q in HTTPRoute %q", serviceName, m.Key.Name)
+	}
+
+	if err := m.SetStructuredSpec(spec); err != nil {
+		return m, err
+	}
+
+	return m, nil
+}
+
+func isServiceBackendRef(ref map[string]interface{}, serviceName string) bool {
+	if name, _ := ref["name"].(string); name != serviceName {
+		return false
+	}
+	if kind, ok := ref["kind"].(string); ok && kind != provider.KindService {
+		return false
+	}
+	if group, ok := ref["group"].(string); ok && group != "" {
+		return false
+	}
+	return true
+}
+
+// backendRefWeight returns the weight of the given backendRef.
+// The default weight defined by Gateway API is 1.
+func backendRefWeight(ref map[string]interface{}) int64 {
+	switch w := ref["weight"].(type) {
+	case int64:
+		return w
+	case float64:
+		return int64(w)
+	default:
+		return 1
+	}
+}
+
+// clampWeight returns the given weight limited to the range from 0 to max.
+func clampWeight(weight, max int64) int64 {
+	if weight < 0 {
+		return 0
+	}
+	if weight > max {
+		return max
+	}
+	return weight
+}
+
+func makeBackendRef(base map[string]interface{}, name string, weight int64) map[string]interface{} {
+	ref := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		ref[k] = v
+	}
+	ref["name"] = name
+	ref["weight"] = weight
+	return ref
+}
+
+// injectCanaryMatchRoutes injects a route ahead of every editable route of the generated VirtualService
+// to route the requests matching the given rules to CANARY variant regardless of the weights.
+// The injected route inherits all fields of its original route except the match conditions and destinations.
+func injectCanaryMatchRoutes(original, generated provider.Manifest, host string, editableRoutes []string, matches []config.K8sTrafficRoutingMatch) (provider.Manifest, error) {
+	originalSpec, err := original.GetNestedMap("spec")
+	if err != nil {
+		return generated, err
+	}
+	spec, err := generated.GetNestedMap("spec")
+	if err != nil {
+		return generated, err
+	}
+
+	originalRoutes, _ := originalSpec["http"].([]interface{})
+	generatedRoutes, _ := spec["http"].([]interface{})
+	if len(originalRoutes) != len(generatedRoutes) {
+		return generated, fmt.Errorf("unexpected number of http routes in the generated VirtualService")
+	}
+
+	editableMap := make(map[string]struct{}, len(editableRoutes))
+	for _, r := range editableRoutes {
+		editableMap[r] = struct{}{}
+	}
+
+	routes := make([]interface{}, 0, len(generatedRoutes)*2)
+	for i, r := range originalRoutes {
+		route, ok := r.(map[string]interface{})
+		if ok && isEditableRoute(route, host, editableMap) {
+			routes = append(routes, makeCanaryMatchRoute(route, host, matches))
+		}
+		routes = append(routes, generatedRoutes[i])
+	}
+
+	spec["http"] = routes
+	if err := generated.SetStructuredSpec(spec); err != nil {
+		return generated, err
+	}
+	return generated, nil
+}
+
+func isEditableRoute(route map[string]interface{}, host string, editableMap map[string]struct{}) bool {
+	if len(editableMap) > 0 {
+		name, _ := route["name"].(string)
+		if _, ok := editableMap[name]; !ok {
+			return false
+		}
+	}
+	destinations, _ := route["route"].([]interface{})
+	for _, d := range destinations {
+		dest, _ := d.(map[string]interface{})
+		target, _ := dest["destination"].(map[string]interface{})
+		if h, _ := target["host"].(string); h == host {
+			return true
+		}
+	}
+	return false
+}
+
+func makeCanaryMatchRoute(route map[string]interface{}, host string, matches []config.K8sTrafficRoutingMatch) map[string]interface{} {
+	out := make(map[string]interface{}, len(route))
+	for k, v := range route {
+		out[k] = v
+	}
+	if name, _ := route["name"].(string); name != "" {
+		out["name"] = name + "-" + canaryVariant + "-match"
+	}
+
+	// Each original match condition is combined with every given rule.
+	// Since the match conditions are ORed, a request matching any rule will be routed to CANARY.
+	originalMatches, _ := route["match"].([]interface{})
+	if len(originalMatches) == 0 {
+		originalMatches = []interface{}{map[string]interface{}{}}
+	}
+	combined := make([]interface{}, 0, len(originalMatches)*len(matches))
+	for _, om := range originalMatches {
+		for _, m := range matches {
+			base, _ := om.(map[string]interface{})
+			c := make(map[string]interface{}, len(base)+1)
+			for k, v := range base {
+				c[k] = v
+			}
+			headers := make(map[string]interface{})
+			if h, ok := base["headers"].(map[string]interface{}); ok {
+				for k, v := range h {
+					headers[k] = v
+				}
+			}
+			name, value := istioHeaderMatch(m)
+			headers[name] = value
+			c["headers"] = headers
+			combined = append(combined, c)
+		}
+	}
+	out["match"] = combined
+
+	out["route"] = []interface{}{
+		map[string]interface{}{
+			"destination": map[string]interface{}{
+				"host":   host,
+				"subset": canaryVariant,
+			},
+		},
+	}
+	return out
+}
+
+// istioHeaderMatch returns the header name and the Istio StringMatch for the given rule.
+// A cookie rule is converted into a regular expression against the "cookie" header.
+func istioHeaderMatch(m config.K8sTrafficRoutingMatch) (string, map[string]interface{}) {
+	if m.Header != "" {
+		switch {
+		case m.Exact != "":
+			return strings.ToLower(m.Header), map[string]interface{}{"exact": m.Exact}
+		case m.Prefix != "":
+			return strings.ToLower(m.Header), map[string]interface{}{"prefix": m.Prefix}
+		default:
+			return strings.ToLower(m.Header), map[string]interface{}{"regex": m.Regex}
+		}
+	}
+
+	var value string
+	switch {
+	case m.Exact != "":
+		value = regexp.QuoteMeta(m.Exact)
+	case m.Prefix != "":
+		value = regexp.QuoteMeta(m.Prefix) + "[^;]*"
+	default:
+		value = "(?:" + strings.TrimSuffix(strings.TrimPrefix(m.Regex, "^"), "$") + ")"
+	}
+	regex := fmt.Sprintf("^(.*?;\\s*)?%s=%s(;.*)?$", regexp.QuoteMeta(m.Cookie), value)
+	return "cookie", map[string]interface{}{"regex": regex}
+}
+
+// removeCanaryMatchRoutes removes the routes injected for CANARY matches from the VirtualService
+// and routes the traffic of CANARY variant to PRIMARY variant.
+func (e *deployExecutor) removeCanaryMatchRoutes(ctx context.Context) error {
+	value, ok := e.MetadataStore.Shared().Get(canaryMatchRoutesMetadataKey)
+	if !ok || value == "" {
+		return nil
+	}
+
+	var primaryPercent, canaryPercent, baselinePercent int
+	if _, err := fmt.Sscanf(value, "%d,%d,%d", &primaryPercent, &canaryPercent, &baselinePercent); err != nil {
+		e.LogPersister.Errorf("Malformed traffic routing metadata %q (%v)", value, err)
+		return err
+	}
+	primaryPercent += canaryPercent
+
+	e.LogPersister.Info("Start removing the routes injected for CANARY matches")
+	manifests, err := loadManifests(ctx, e.Deployment.ApplicationId, e.commit, e.AppManifestsCache, e.provider, e.Logger)
+	if err != nil {
+		e.LogPersister.Errorf("Failed while loading manifests (%v)", err)
+		return err
+	}
+	trafficRoutingManifests, err := findTrafficRoutingManifests(manifests, e.appCfg.Service.Name, e.appCfg.TrafficRouting)
+	if err != nil {
+		e.LogPersister.Errorf("Failed while finding traffic routing manifest: (%v)", err)
+		return err
+	}
+	if len(trafficRoutingManifests) == 0 {
+		e.LogPersister.Error("Unable to find any traffic routing manifests")
+		return fmt.Errorf("unable to find any traffic routing manifests")
+	}
+
+	trafficRoutingManifest, err := e.generateTrafficRoutingManifest(trafficRoutingManifests[0], "", primaryPercent, 0, baselinePercent, nil, e.appCfg.TrafficRouting)
+	if err != nil {
+		e.LogPersister.Errorf("Unable generate traffic routing manifest: (%v)", err)
+		return err
+	}
+	addBuiltinAnnontations(
+		[]provider.Manifest{trafficRoutingManifest},
+		primaryVariant,
+		e.commit,
+		e.PipedConfig.PipedID,
+		e.Deployment.ApplicationId,
+	)
+
+	e.LogPersister.Infof("Start updating traffic routing to be percentages: primary=%d, canary=0, baseline=%d", primaryPercent, baselinePercent)
+	if err := applyManifests(ctx, e.provider, []provider.Manifest{trafficRoutingManifest}, e.appCfg.Input.Namespace, e.LogPersister); err != nil {
+		return err
+	}
+	return e.MetadataStore.Shared().Put(ctx, canaryMatchRoutesMetadataKey, "")
+}
+
 func checkVariantSelectorInService(m provider.Manifest, variant string) error {
 	selector, err := m.GetNestedStringMap("spec", "selector")
 	if err != nil {
@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/kubernetes"
+	"github.com/pipe-cd/pipecd/pkg/config"
 )
 
 func TestGenerateVirtualServiceManifest(t *testing.T) {
@@ -66,6 +67,633 @@ func TestGenerateVirtualServiceManifest(t *testing.T) {
 	}
 }
 
+func TestInjectCanaryMatchRoutes(t *testing.T) {
+	manifests, err := provider.ParseManifests(`
+apiVersion: networking.istio.io/v1beta1
+kind: VirtualService
+metadata:
+  name: helloworld
+spec:
+  hosts:
+  - helloworld
+  http:
+  - name: other-host
+    route:
+    - destination:
+        host: other
+  - name: primary
+    route:
+    - destination:
+        host: helloworld
+        subset: primary
+      weight: 100
+`)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(manifests))
+
+	generated, err := generateVirtualServiceManifest(manifests[0], "helloworld", []string{"primary"}, 30, 0)
+	require.NoError(t, err)
+
+	matches := []config.K8sTrafficRoutingMatch{
+		{Header: "X-Canary", Exact: "true"},
+		{Cookie: "user", Prefix: "tester"},
+	}
+	generated, err = injectCanaryMatchRoutes(manifests[0], generated, "helloworld", []string{"primary"}, matches)
+	require.NoError(t, err)
+
+	expectedManifests, err := provider.ParseManifests(`
+apiVersion: networking.istio.io/v1beta1
+kind: VirtualService
+metadata:
+  name: helloworld
+spec:
+  hosts:
+  - helloworld
+  http:
+  - name: other-host
+    route:
+    - destination:
+        host: other
+  - name: primary-canary-match
+    match:
+    - headers:
+        x-canary:
+          exact: "true"
+    - headers:
+        cookie:
+          regex: '^(.*?;\s*)?user=tester[^;]*(;.*)?$'
+    route:
+    - destination:
+        host: helloworld
+        subset: canary
+  - name: primary
+    route:
+    - destination:
+        host: helloworld
+        subset: primary
+      weight: 70
+    - destination:
+        host: helloworld
+        subset: canary
+      weight: 30
+`)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(expectedManifests))
+
+	expected, err := expectedManifests[0].YamlBytes()
+	require.NoError(t, err)
+	got, err := generated.YamlBytes()
+	require.NoError(t, err)
+
+	assert.EqualValues(t, string(expected), string(got))
+}
+
+func TestGenerateTrafficSplitManifest(t *testing.T) {
+	testcases := []struct {
+		name            string
+		cfg             *config.SMITrafficRouting
+		canarySuffix    string
+		baselineSuffix  string
+		primaryPercent  int
+		canaryPercent   int
+		baselinePercent int
+		expected        string
+	}{
+		{
+			name:           "all traffic to primary",
+			primaryPercent: 100,
+			expected: `
+apiVersion: split.smi-spec.io/v1alpha2
+kind: TrafficSplit
+metadata:
+  name: helloworld
+spec:
+  service: helloworld
+  backends:
+  - service: helloworld
+    weight: 100
+`,
+		},
+		{
+			name:            "split to all variants",
+			primaryPercent:  50,
+			canaryPercent:   30,
+			baselinePercent: 20,
+			expected: `
+apiVersion: split.smi-spec.io/v1alpha2
+kind: TrafficSplit
+metadata:
+  name: helloworld
+spec:
+  service: helloworld
+  backends:
+  - service: helloworld
+    weight: 50
+  - service: helloworld-canary
+    weight: 30
+  - service: helloworld-baseline
+    weight: 20
+`,
+		},
+		{
+			name: "specified api version and name",
+			cfg: &config.SMITrafficRouting{
+				APIVersion:       "split.smi-spec.io/v1alpha3",
+				TrafficSplitName: "helloworld-split",
+			},
+			primaryPercent: 90,
+			canaryPercent:  10,
+			expected: `
+apiVersion: split.smi-spec.io/v1alpha3
+kind: TrafficSplit
+metadata:
+  name: helloworld-split
+spec:
+  service: helloworld
+  backends:
+  - service: helloworld
+    weight: 90
+  - service: helloworld-canary
+    weight: 10
+`,
+		},
+		{
+			name:            "custom variant suffixes",
+			canarySuffix:    "cn",
+			baselineSuffix:  "bl",
+			primaryPercent:  50,
+			canaryPercent:   30,
+			baselinePercent: 20,
+			expected: `
+apiVersion: split.smi-spec.io/v1alpha2
+kind: TrafficSplit
+metadata:
+  name: helloworld
+spec:
+  service: helloworld
+  backends:
+  - service: helloworld
+    weight: 50
+  - service: helloworld-cn
+    weight: 30
+  - service: helloworld-bl
+    weight: 20
+`,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			services, err := provider.ParseManifests(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: helloworld
+spec:
+  selector:
+    app: helloworld
+`)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(services))
+
+			canarySuffix, baselineSuffix := tc.canarySuffix, tc.baselineSuffix
+			if canarySuffix == "" {
+				canarySuffix = canaryVariant
+			}
+			if baselineSuffix == "" {
+				baselineSuffix = baselineVariant
+			}
+			generatedManifest := generateTrafficSplitManifest(services[0], tc.cfg, canarySuffix, baselineSuffix, tc.primaryPercent, tc.canaryPercent, tc.baselinePercent)
+
+			expectedManifests, err := provider.ParseManifests(tc.expected)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(expectedManifests))
+
+			expected, err := expectedManifests[0].YamlBytes()
+			require.NoError(t, err)
+			got, err := generatedManifest.YamlBytes()
+			require.NoError(t, err)
+
+			assert.Equal(t, expectedManifests[0].Key, generatedManifest.Key)
+			assert.EqualValues(t, string(expected), string(got))
+		})
+	}
+}
+
+func TestGenerateCanaryIngressManifest(t *testing.T) {
+	testcases := []struct {
+		name            string
+		manifest        string
+		canarySuffix    string
+		canaryPercent   int
+		baselinePercent int
+		expected        string
+		expectedErr     bool
+	}{
+		{
+			name: "networking.k8s.io/v1",
+			manifest: `
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: helloworld
+spec:
+  rules:
+  - host: helloworld.example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: helloworld
+            port:
+              number: 9085
+      - path: /other
+        pathType: Prefix
+        backend:
+          service:
+            name: other
+            port:
+              number: 9085
+`,
+			canaryPercent: 30,
+			expected: `
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: helloworld-canary
+  annotations:
+    nginx.ingress.kubernetes.io/canary: "true"
+    nginx.ingress.kubernetes.io/canary-weight: "30"
+spec:
+  rules:
+  - host: helloworld.example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: helloworld-canary
+            port:
+              number: 9085
+      - path: /other
+        pathType: Prefix
+        backend:
+          service:
+            name: other
+            port:
+              number: 9085
+`,
+		},
+		{
+			name: "networking.k8s.io/v1beta1",
+			manifest: `
+apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: helloworld
+spec:
+  backend:
+    serviceName: helloworld
+    servicePort: 9085
+`,
+			canaryPercent: 0,
+			expected: `
+apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: helloworld-canary
+  annotations:
+    nginx.ingress.kubernetes.io/canary: "true"
+    nginx.ingress.kubernetes.io/canary-weight: "0"
+spec:
+  backend:
+    serviceName: helloworld-canary
+    servicePort: 9085
+`,
+		},
+		{
+			name: "custom canary suffix",
+			manifest: `
+apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: helloworld
+spec:
+  backend:
+    serviceName: helloworld
+    servicePort: 9085
+`,
+			canarySuffix:  "cn",
+			canaryPercent: 10,
+			expected: `
+apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: helloworld-cn
+  annotations:
+    nginx.ingress.kubernetes.io/canary: "true"
+    nginx.ingress.kubernetes.io/canary-weight: "10"
+spec:
+  backend:
+    serviceName: helloworld-cn
+    servicePort: 9085
+`,
+		},
+		{
+			name: "no backend referencing service",
+			manifest: `
+apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: helloworld
+spec:
+  backend:
+    serviceName: other
+    servicePort: 9085
+`,
+			canaryPercent: 30,
+			expectedErr:   true,
+		},
+		{
+			name: "baseline is not supported",
+			manifest: `
+apiVersion: networking.k8s.io/v1beta1
+kind: Ingress
+metadata:
+  name: helloworld
+spec:
+  backend:
+    serviceName: helloworld
+    servicePort: 9085
+`,
+			canaryPercent:   30,
+			baselinePercent: 20,
+			expectedErr:     true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := provider.ParseManifests(tc.manifest)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(manifests))
+
+			canarySuffix := tc.canarySuffix
+			if canarySuffix == "" {
+				canarySuffix = canaryVariant
+			}
+			generatedManifest, err := generateCanaryIngressManifest(manifests[0], "helloworld", canarySuffix, tc.canaryPercent, tc.baselinePercent)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			expectedManifests, err := provider.ParseManifests(tc.expected)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(expectedManifests))
+
+			expected, err := expectedManifests[0].YamlBytes()
+			require.NoError(t, err)
+			got, err := generatedManifest.YamlBytes()
+			require.NoError(t, err)
+
+			assert.EqualValues(t, string(expected), string(got))
+		})
+	}
+}
+
+func TestGenerateHTTPRouteManifest(t *testing.T) {
+	testcases := []struct {
+		name            string
+		manifest        string
+		canarySuffix    string
+		baselineSuffix  string
+		canaryPercent   int
+		baselinePercent int
+		expected        string
+		expectedErr     bool
+	}{
+		{
+			name: "split to all variants",
+			manifest: `
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: HTTPRoute
+metadata:
+  name: helloworld
+spec:
+  parentRefs:
+  - name: gateway
+  rules:
+  - matches:
+    - path:
+        type: PathPrefix
+        value: /
+    backendRefs:
+    - name: helloworld
+      port: 9085
+  - matches:
+    - path:
+        type: PathPrefix
+        value: /other
+    backendRefs:
+    - name: other
+      port: 9085
+`,
+			canaryPercent:   30,
+			baselinePercent: 20,
+			expected: `
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: HTTPRoute
+metadata:
+  name: helloworld
+spec:
+  parentRefs:
+  - name: gateway
+  rules:
+  - matches:
+    - path:
+        type: PathPrefix
+        value: /
+    backendRefs:
+    - name: helloworld
+      port: 9085
+      weight: 50
+    - name: helloworld-canary
+      port: 9085
+      weight: 30
+    - name: helloworld-baseline
+      port: 9085
+      weight: 20
+  - matches:
+    - path:
+        type: PathPrefix
+        value: /other
+    backendRefs:
+    - name: other
+      port: 9085
+`,
+		},
+		{
+			name: "keep other backends",
+			manifest: `
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: HTTPRoute
+metadata:
+  name: helloworld
+spec:
+  rules:
+  - backendRefs:
+    - name: helloworld
+      port: 9085
+      weight: 80
+    - name: other
+      port: 9085
+      weight: 20
+`,
+			canaryPercent: 50,
+			expected: `
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: HTTPRoute
+metadata:
+  name: helloworld
+spec:
+  rules:
+  - backendRefs:
+    - name: helloworld
+      port: 9085
+      weight: 40
+    - name: helloworld-canary
+      port: 9085
+      weight: 40
+    - name: other
+      port: 9085
+      weight: 20
+`,
+		},
+		{
+			name: "split the remaining weight of pre-existing backends",
+			manifest: `
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: HTTPRoute
+metadata:
+  name: helloworld
+spec:
+  rules:
+  - backendRefs:
+    - name: helloworld
+      port: 9085
+      weight: 40
+    - name: other
+      port: 9085
+      weight: 30
+    - name: another
+      port: 9085
+      weight: 30
+`,
+			canarySuffix:    "cn",
+			baselineSuffix:  "bl",
+			canaryPercent:   50,
+			baselinePercent: 50,
+			expected: `
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: HTTPRoute
+metadata:
+  name: helloworld
+spec:
+  rules:
+  - backendRefs:
+    - name: helloworld
+      port: 9085
+      weight: 0
+    - name: helloworld-cn
+      port: 9085
+      weight: 20
+    - name: helloworld-bl
+      port: 9085
+      weight: 20
+    - name: other
+      port: 9085
+      weight: 30
+    - name: another
+      port: 9085
+      weight: 30
+`,
+		},
+		{
+			name: "pre-existing backends use more than 100",
+			manifest: `
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: HTTPRoute
+metadata:
+  name: helloworld
+spec:
+  rules:
+  - backendRefs:
+    - name: helloworld
+      port: 9085
+    - name: other
+      port: 9085
+      weight: 80
+    - name: another
+      port: 9085
+      weight: 40
+`,
+			canaryPercent: 50,
+			expectedErr:   true,
+		},
+		{
+			name: "no backendRef referencing service",
+			manifest: `
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: HTTPRoute
+metadata:
+  name: helloworld
+spec:
+  rules:
+  - backendRefs:
+    - name: other
+      port: 9085
+`,
+			canaryPercent: 50,
+			expectedErr:   true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			manifests, err := provider.ParseManifests(tc.manifest)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(manifests))
+
+			canarySuffix, baselineSuffix := tc.canarySuffix, tc.baselineSuffix
+			if canarySuffix == "" {
+				canarySuffix = canaryVariant
+			}
+			if baselineSuffix == "" {
+				baselineSuffix = baselineVariant
+			}
+			generatedManifest, err := generateHTTPRouteManifest(manifests[0], "helloworld", canarySuffix, baselineSuffix, tc.canaryPercent, tc.baselinePercent)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			expectedManifests, err := provider.ParseManifests(tc.expected)
+			require.NoError(t, err)
+			require.Equal(t, 1, len(expectedManifests))
+
+			expected, err := expectedManifests[0].YamlBytes()
+			require.NoError(t, err)
+			got, err := generatedManifest.YamlBytes()
+			require.NoError(t, err)
+
+			assert.EqualValues(t, string(expected), string(got))
+		})
+	}
+}
+
 func TestCheckVariantSelectorInService(t *testing.T) {
 	testcases := []struct {
 		name     string
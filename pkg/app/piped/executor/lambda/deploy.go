@@ -20,6 +20,7 @@ import (
 
 	"github.com/pipe-cd/pipecd/pkg/app/piped/deploysource"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/trafficstep"
 	"github.com/pipe-cd/pipecd/pkg/config"
 	"github.com/pipe-cd/pipecd/pkg/model"
 
@@ -67,7 +68,7 @@ func (e *deployExecutor) Execute(sig executor.StopSignal) model.StageStatus {
 	case model.StageLambdaSync:
 		status = e.ensureSync(ctx)
 	case model.StageLambdaPromote:
-		status = e.ensurePromote(ctx)
+		status = e.ensurePromote(sig)
 	case model.StageLambdaCanaryRollout:
 		status = e.ensureRollout(ctx)
 	default:
@@ -91,25 +92,32 @@ func (e *deployExecutor) ensureSync(ctx context.Context) model.StageStatus {
 	return model.StageStatus_STAGE_SUCCESS
 }
 
-func (e *deployExecutor) ensurePromote(ctx context.Context) model.StageStatus {
+func (e *deployExecutor) ensurePromote(sig executor.StopSignal) model.StageStatus {
 	options := e.StageConfig.LambdaPromoteStageOptions
 	if options == nil {
 		e.LogPersister.Errorf("Malformed configuration for stage %s", e.Stage.Name)
 		return model.StageStatus_STAGE_FAILURE
 	}
-	metadata := map[string]string{
-		promotePercentageMetadataKey: strconv.FormatInt(int64(options.Percent.Int()), 10),
-	}
-	if err := e.MetadataStore.Stage(e.Stage.Id).PutMulti(ctx, metadata); err != nil {
-		e.Logger.Error("failed to save routing percentages to metadata", zap.Error(err))
-	}
 
 	fm, ok := loadFunctionManifest(&e.Input, e.appCfg.Input.FunctionManifestFile, e.deploySource)
 	if !ok {
 		return model.StageStatus_STAGE_FAILURE
 	}
 
-	if !promote(ctx, &e.Input, e.cloudProviderName, e.cloudProviderCfg, fm) {
+	route := func(ctx context.Context, percent int) bool {
+		metadata := map[string]string{
+			promotePercentageMetadataKey: strconv.FormatInt(int64(percent), 10),
+		}
+		if err := e.MetadataStore.Stage(e.Stage.Id).PutMulti(ctx, metadata); err != nil {
+			e.Logger.Error("failed to save routing percentages to metadata", zap.Error(err))
+		}
+		return promote(ctx, &e.Input, e.cloudProviderName, e.cloudProviderCfg, fm, percent)
+	}
+	if len(options.Steps) > 0 {
+		return trafficstep.Run(sig, e.Input, options.Steps, route)
+	}
+
+	if !route(sig.Context(), options.Percent.Int()) {
 		return model.StageStatus_STAGE_FAILURE
 	}
 
@@ -81,11 +81,23 @@ func loadFunctionManifest(in *executor.Input, functionManifestFile string, ds *d
 		in.LogPersister.Errorf("Failed to load lambda function manifest (%v)", err)
 		return provider.FunctionManifest{}, false
 	}
+	addBuiltinTags(&fm, ds.Revision, in.PipedConfig.PipedID, in.Deployment.ApplicationId)
 
 	in.LogPersister.Infof("Successfully loaded the lambda function manifest at commit %s", ds.Revision)
 	return fm, true
 }
 
+// addBuiltinTags adds the tags used by piped to find the functions of each application.
+func addBuiltinTags(fm *provider.FunctionManifest, hash, pipedID, appID string) {
+	tags := map[string]string{
+		provider.LabelManagedBy:   provider.ManagedByPiped,
+		provider.LabelPiped:       pipedID,
+		provider.LabelApplication: appID,
+		provider.LabelCommitHash:  hash,
+	}
+	fm.AddTags(tags)
+}
+
 func sync(ctx context.Context, in *executor.Input, cloudProviderName string, cloudProviderCfg *config.CloudProviderLambdaConfig, fm provider.FunctionManifest) bool {
 	in.LogPersister.Infof("Start applying the lambda function manifest")
 	client, err := provider.DefaultRegistry().Client(cloudProviderName, cloudProviderCfg, in.Logger)
@@ -184,7 +196,7 @@ func rollout(ctx context.Context, in *executor.Input, cloudProviderName string,
 	return true
 }
 
-func promote(ctx context.Context, in *executor.Input, cloudProviderName string, cloudProviderCfg *config.CloudProviderLambdaConfig, fm provider.FunctionManifest) bool {
+func promote(ctx context.Context, in *executor.Input, cloudProviderName string, cloudProviderCfg *config.CloudProviderLambdaConfig, fm provider.FunctionManifest, percent int) bool {
 	in.LogPersister.Infof("Start promote new version of the lambda function: %s", fm.Spec.Name)
 	client, err := provider.DefaultRegistry().Client(cloudProviderName, cloudProviderCfg, in.Logger)
 	if err != nil {
@@ -199,16 +211,10 @@ func promote(ctx context.Context, in *executor.Input, cloudProviderName string,
 		return false
 	}
 
-	options := in.StageConfig.LambdaPromoteStageOptions
-	if options == nil {
-		in.LogPersister.Errorf("Malformed configuration for stage %s", in.Stage.Name)
-		return false
-	}
-
 	trafficCfg, err := client.GetTrafficConfig(ctx, fm)
 	// Create Alias on not yet existed.
 	if errors.Is(err, provider.ErrNotFound) {
-		if options.Percent.Int() != 100 {
+		if percent != 100 {
 			in.LogPersister.Errorf("Not previous version available to handle traffic, new version has to get 100 percent of traffic")
 			return false
 		}
@@ -225,7 +231,7 @@ func promote(ctx context.Context, in *executor.Input, cloudProviderName string,
 	}
 
 	// Update traffic to the new lambda version.
-	if !configureTrafficRouting(trafficCfg, version, options.Percent.Int()) {
+	if !configureTrafficRouting(trafficCfg, version, percent) {
 		in.LogPersister.Errorf("Failed to prepare traffic routing for Lambda function %s", fm.Spec.Name)
 		return false
 	}
@@ -247,7 +253,7 @@ func promote(ctx context.Context, in *executor.Input, cloudProviderName string,
 		return false
 	}
 
-	in.LogPersister.Infof("Successfully promote new version (v%s) of Lambda function %s, it will handle %v percent of traffic", version, fm.Spec.Name, options.Percent)
+	in.LogPersister.Infof("Successfully promote new version (v%s) of Lambda function %s, it will handle %d percent of traffic", version, fm.Spec.Name, percent)
 	return true
 }
 
@@ -24,6 +24,7 @@ import (
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/ecs"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/kubernetes"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/lambda"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/scriptrun"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/terraform"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/wait"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/waitapproval"
@@ -82,7 +83,22 @@ func (r *registry) RollbackExecutor(kind model.ApplicationKind, in executor.Inpu
 	if !ok {
 		return nil, false
 	}
-	return f(in), true
+	// The rollback scripts of SCRIPT_RUN stages are executed after rolling back the application.
+	return sequentialExecutor{f(in), scriptrun.NewRollbackExecutor(in)}, true
+}
+
+// sequentialExecutor runs the given executors one by one
+// until one of them was not completed successfully.
+type sequentialExecutor []executor.Executor
+
+func (e sequentialExecutor) Execute(sig executor.StopSignal) model.StageStatus {
+	status := model.StageStatus_STAGE_SUCCESS
+	for _, ex := range e {
+		if status = ex.Execute(sig); status != model.StageStatus_STAGE_SUCCESS {
+			break
+		}
+	}
+	return status
 }
 
 var defaultRegistry = &registry{
@@ -100,6 +116,7 @@ func init() {
 	cloudrun.Register(defaultRegistry)
 	kubernetes.Register(defaultRegistry)
 	lambda.Register(defaultRegistry)
+	scriptrun.Register(defaultRegistry)
 	terraform.Register(defaultRegistry)
 	ecs.Register(defaultRegistry)
 	wait.Register(defaultRegistry)
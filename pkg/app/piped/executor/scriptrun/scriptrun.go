@@ -0,0 +1,295 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scriptrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/deploysource"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+const (
+	// The stage metadata key to mark that the script of the stage has been started.
+	// Only the rollback scripts of the started stages are executed while rolling back.
+	startedKey = "ScriptRunStarted"
+
+	defaultCanarySuffix   = "canary"
+	defaultBaselineSuffix = "baseline"
+)
+
+// The environment variables of the piped process always passed to the scripts.
+// The others must be allowed explicitly in the piped configuration
+// to avoid leaking the credentials given to the piped process.
+var defaultAllowedEnv = []string{
+	"PATH",
+	"HOME",
+	"TMPDIR",
+}
+
+type Executor struct {
+	executor.Input
+}
+
+type registerer interface {
+	Register(stage model.Stage, f executor.Factory) error
+}
+
+// Register registers this executor factory into a given registerer.
+func Register(r registerer) {
+	f := func(in executor.Input) executor.Executor {
+		return &Executor{
+			Input: in,
+		}
+	}
+	r.Register(model.StageScriptRun, f)
+}
+
+// Execute runs the configured script and waits until it exits.
+// The stage is succeeded only when the script exited with zero code.
+func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
+	var (
+		ctx            = sig.Context()
+		originalStatus = e.Stage.Status
+		opts           = e.StageConfig.ScriptRunStageOptions
+	)
+	if opts == nil {
+		e.LogPersister.Errorf("Malformed configuration for stage %s", e.Stage.Name)
+		return model.StageStatus_STAGE_FAILURE
+	}
+	if !e.PipedConfig.ScriptRun.Enabled {
+		e.LogPersister.Errorf("Unable to run the script because %s stage is not enabled in the piped configuration (scriptRun.enabled)", model.StageScriptRun)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	ds, err := e.TargetDSP.Get(ctx, e.LogPersister)
+	if err != nil {
+		e.LogPersister.Errorf("Failed to prepare target deploy source data (%v)", err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	if err := e.MetadataStore.Stage(e.Stage.Id).Put(ctx, startedKey, "true"); err != nil {
+		e.Logger.Error("failed to store metadata", zap.Error(err))
+	}
+
+	env := append(inheritEnv(e.PipedConfig.ScriptRun.AllowedEnv), buildEnv(e.Deployment, ds, opts.Env, false)...)
+	status := runScript(ctx, opts.Run, ds.AppDir, env, e.LogPersister)
+	return executor.DetermineStageStatus(sig.Signal(), originalStatus, status)
+}
+
+type rollbackExecutor struct {
+	executor.Input
+}
+
+// NewRollbackExecutor returns an executor which runs the rollback scripts
+// of the started SCRIPT_RUN stages in the reverse order of the pipeline.
+func NewRollbackExecutor(in executor.Input) executor.Executor {
+	return &rollbackExecutor{
+		Input: in,
+	}
+}
+
+func (e *rollbackExecutor) Execute(sig executor.StopSignal) model.StageStatus {
+	var (
+		ctx            = sig.Context()
+		originalStatus = e.Stage.Status
+		ds             *deploysource.DeploySource
+	)
+
+	for i := len(e.Deployment.Stages) - 1; i >= 0; i-- {
+		ps := e.Deployment.Stages[i]
+		if ps.Name != model.StageScriptRun.String() {
+			continue
+		}
+		if _, ok := e.MetadataStore.Stage(ps.Id).Get(startedKey); !ok {
+			continue
+		}
+
+		// Load the deploy source lazily since most of the deployments are not using SCRIPT_RUN stage.
+		if ds == nil {
+			var err error
+			if ds, err = e.TargetDSP.Get(ctx, e.LogPersister); err != nil {
+				e.LogPersister.Errorf("Failed to prepare target deploy source data (%v)", err)
+				return model.StageStatus_STAGE_FAILURE
+			}
+		}
+
+		cfg, ok := ds.GenericApplicationConfig.GetStage(ps.Index)
+		if !ok || cfg.ScriptRunStageOptions == nil || cfg.ScriptRunStageOptions.OnRollback == "" {
+			continue
+		}
+
+		// The piped configuration could be changed after the script of the stage was started.
+		if !e.PipedConfig.ScriptRun.Enabled {
+			e.LogPersister.Errorf("Unable to run the rollback script of stage %s because %s stage is not enabled in the piped configuration (scriptRun.enabled)", ps.Id, model.StageScriptRun)
+			return model.StageStatus_STAGE_FAILURE
+		}
+
+		e.LogPersister.Infof("Start running the rollback script of stage %s", ps.Id)
+		env := append(inheritEnv(e.PipedConfig.ScriptRun.AllowedEnv), buildEnv(e.Deployment, ds, cfg.ScriptRunStageOptions.Env, true)...)
+		status := runScript(ctx, cfg.ScriptRunStageOptions.OnRollback, ds.AppDir, env, e.LogPersister)
+		if status != model.StageStatus_STAGE_SUCCESS {
+			return executor.DetermineStageStatus(sig.Signal(), originalStatus, status)
+		}
+	}
+
+	return executor.DetermineStageStatus(sig.Signal(), originalStatus, model.StageStatus_STAGE_SUCCESS)
+}
+
+// runScript executes the given script by "sh -c" and streams its output into the log persister.
+// The script runs only with the given environment variables.
+// The running script and all of its child processes are killed when the given context is done.
+func runScript(ctx context.Context, script, dir string, env []string, lp executor.LogPersister) model.StageStatus {
+	lp.Infof("Running script: %s", script)
+
+	cmd := exec.Command("sh", "-c", script)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdout = lp
+	cmd.Stderr = lp
+	// Run the script in a new process group to be able to kill its child processes together.
+	// Otherwise, a child process could keep the output open and block the stage.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		lp.Errorf("Failed to start the script (%v)", err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	go func() {
+		select {
+		case <-ctx.Done():
+			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		case <-doneCh:
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			lp.Errorf("The script was killed because the stage was stopped (%v)", ctx.Err())
+			return model.StageStatus_STAGE_FAILURE
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			lp.Errorf("The script exited with code %d", exitErr.ExitCode())
+		} else {
+			lp.Errorf("Failed to run the script (%v)", err)
+		}
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	lp.Success("Successfully ran the script")
+	return model.StageStatus_STAGE_SUCCESS
+}
+
+// inheritEnv returns the environment variables of the piped process passed to the scripts.
+// Only the default ones and the given allowed ones are returned if they are set.
+func inheritEnv(allowed []string) []string {
+	names := make([]string, 0, len(defaultAllowedEnv)+len(allowed))
+	names = append(names, defaultAllowedEnv...)
+	names = append(names, allowed...)
+
+	env := make([]string, 0, len(names))
+	seen := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		if v, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+v)
+		}
+	}
+	return env
+}
+
+// buildEnv returns the environment variables to be set while running the script.
+// The variables derived from the deployment are prefixed with "SR_"
+// and they can be overridden by the specified custom variables.
+func buildEnv(d *model.Deployment, ds *deploysource.DeploySource, custom map[string]string, rollback bool) []string {
+	env := []string{
+		"SR_DEPLOYMENT_ID=" + d.Id,
+		"SR_APPLICATION_ID=" + d.ApplicationId,
+		"SR_APPLICATION_NAME=" + d.ApplicationName,
+		"SR_APPLICATION_KIND=" + d.Kind.String(),
+		"SR_TRIGGERED_COMMIT_HASH=" + d.Trigger.Commit.Hash,
+		"SR_RUNNING_COMMIT_HASH=" + d.RunningCommitHash,
+		"SR_IS_ROLLBACK=" + strconv.FormatBool(rollback),
+	}
+	if d.GitPath != nil && d.GitPath.Repo != nil {
+		env = append(env, "SR_REPOSITORY_URL="+d.GitPath.Repo.Remote)
+	}
+	if ds != nil && ds.ApplicationConfig != nil {
+		if spec := ds.ApplicationConfig.KubernetesApplicationSpec; spec != nil {
+			env = append(env, kubernetesVariantEndpoints(spec)...)
+		}
+	}
+
+	keys := make([]string, 0, len(custom))
+	for k := range custom {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, fmt.Sprintf("%s=%s", k, custom[k]))
+	}
+	return env
+}
+
+// kubernetesVariantEndpoints returns the addresses of the Services of each variant.
+// The CANARY and BASELINE ones are available only when their stages were configured to create the Service.
+func kubernetesVariantEndpoints(spec *config.KubernetesApplicationSpec) []string {
+	name := spec.Service.Name
+	if name == "" {
+		return nil
+	}
+
+	canarySuffix, baselineSuffix := defaultCanarySuffix, defaultBaselineSuffix
+	if spec.Pipeline != nil {
+		for _, s := range spec.Pipeline.Stages {
+			if opts := s.K8sCanaryRolloutStageOptions; opts != nil && opts.Suffix != "" {
+				canarySuffix = opts.Suffix
+			}
+			if opts := s.K8sBaselineRolloutStageOptions; opts != nil && opts.Suffix != "" {
+				baselineSuffix = opts.Suffix
+			}
+		}
+	}
+
+	host := func(name string) string {
+		if ns := spec.Input.Namespace; ns != "" {
+			return name + "." + ns
+		}
+		return name
+	}
+	return []string{
+		"SR_PRIMARY_ENDPOINT=" + host(name),
+		"SR_CANARY_ENDPOINT=" + host(name+"-"+canarySuffix),
+		"SR_BASELINE_ENDPOINT=" + host(name+"-"+baselineSuffix),
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scriptrun
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/deploysource"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type fakeLogPersister struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *fakeLogPersister) Write(log []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(log)
+}
+func (l *fakeLogPersister) Info(log string)                          { l.Write([]byte(log + "\n")) }
+func (l *fakeLogPersister) Infof(format string, a ...interface{})    { l.Info(fmt.Sprintf(format, a...)) }
+func (l *fakeLogPersister) Success(log string)                       { l.Info(log) }
+func (l *fakeLogPersister) Successf(format string, a ...interface{}) { l.Infof(format, a...) }
+func (l *fakeLogPersister) Error(log string)                         { l.Info(log) }
+func (l *fakeLogPersister) Errorf(format string, a ...interface{})   { l.Infof(format, a...) }
+
+func (l *fakeLogPersister) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+func TestRunScript(t *testing.T) {
+	testcases := []struct {
+		name       string
+		script     string
+		env        []string
+		timeout    time.Duration
+		wantStatus model.StageStatus
+		wantLog    string
+	}{
+		{
+			name:       "succeeded script",
+			script:     "echo hello $SR_APPLICATION_NAME",
+			env:        []string{"SR_APPLICATION_NAME=app"},
+			wantStatus: model.StageStatus_STAGE_SUCCESS,
+			wantLog:    "hello app",
+		},
+		{
+			name:       "stderr is also persisted",
+			script:     "echo oops >&2",
+			wantStatus: model.StageStatus_STAGE_SUCCESS,
+			wantLog:    "oops",
+		},
+		{
+			name:       "non-zero exit code",
+			script:     "exit 3",
+			wantStatus: model.StageStatus_STAGE_FAILURE,
+			wantLog:    "The script exited with code 3",
+		},
+		{
+			name:       "killed by context",
+			script:     "sleep 10",
+			timeout:    100 * time.Millisecond,
+			wantStatus: model.StageStatus_STAGE_FAILURE,
+			wantLog:    "The script was killed",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+			lp := &fakeLogPersister{}
+
+			status := runScript(ctx, tc.script, t.TempDir(), tc.env, lp)
+			assert.Equal(t, tc.wantStatus, status)
+			assert.Contains(t, lp.String(), tc.wantLog)
+		})
+	}
+}
+
+func TestRunScriptWithInheritedEnv(t *testing.T) {
+	t.Setenv("SR_TEST_SECRET", "secret")
+	t.Setenv("SR_TEST_ALLOWED", "allowed")
+
+	lp := &fakeLogPersister{}
+	env := append(inheritEnv([]string{"SR_TEST_ALLOWED"}), "SR_APPLICATION_NAME=app")
+	status := runScript(context.Background(), `echo "[$SR_TEST_SECRET][$SR_TEST_ALLOWED][$SR_APPLICATION_NAME]"`, t.TempDir(), env, lp)
+	assert.Equal(t, model.StageStatus_STAGE_SUCCESS, status)
+	assert.Contains(t, lp.String(), "[][allowed][app]")
+}
+
+func TestInheritEnv(t *testing.T) {
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("SR_TEST_SECRET", "secret")
+	t.Setenv("SR_TEST_ALLOWED", "allowed")
+
+	got := inheritEnv([]string{"SR_TEST_ALLOWED", "SR_TEST_UNSET", "PATH"})
+	assert.Contains(t, got, "PATH=/usr/bin")
+	assert.Contains(t, got, "SR_TEST_ALLOWED=allowed")
+	assert.NotContains(t, got, "SR_TEST_SECRET=secret")
+	for _, e := range got {
+		assert.NotEqual(t, "SR_TEST_UNSET=", e)
+	}
+
+	// PATH is listed only once even though it is also allowed explicitly.
+	count := 0
+	for _, e := range got {
+		if e == "PATH=/usr/bin" {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestBuildEnv(t *testing.T) {
+	d := &model.Deployment{
+		Id:              "deployment-id",
+		ApplicationId:   "app-id",
+		ApplicationName: "app",
+		Kind:            model.ApplicationKind_KUBERNETES,
+		Trigger: &model.DeploymentTrigger{
+			Commit: &model.Commit{
+				Hash: "target-hash",
+			},
+		},
+		RunningCommitHash: "running-hash",
+	}
+	ds := &deploysource.DeploySource{
+		ApplicationConfig: &config.Config{
+			KubernetesApplicationSpec: &config.KubernetesApplicationSpec{
+				Service: config.K8sResourceReference{
+					Name: "helloworld",
+				},
+				Input: config.KubernetesDeploymentInput{
+					Namespace: "demo",
+				},
+				GenericApplicationSpec: config.GenericApplicationSpec{
+					Pipeline: &config.DeploymentPipeline{
+						Stages: []config.PipelineStage{
+							{
+								Name: model.StageK8sCanaryRollout,
+								K8sCanaryRolloutStageOptions: &config.K8sCanaryRolloutStageOptions{
+									Suffix: "next",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	custom := map[string]string{
+		"FOO":                "bar",
+		"SR_APPLICATION_ENV": "prod",
+	}
+
+	got := buildEnv(d, ds, custom, true)
+	want := []string{
+		"SR_DEPLOYMENT_ID=deployment-id",
+		"SR_APPLICATION_ID=app-id",
+		"SR_APPLICATION_NAME=app",
+		"SR_APPLICATION_KIND=KUBERNETES",
+		"SR_TRIGGERED_COMMIT_HASH=target-hash",
+		"SR_RUNNING_COMMIT_HASH=running-hash",
+		"SR_IS_ROLLBACK=true",
+		"SR_PRIMARY_ENDPOINT=helloworld.demo",
+		"SR_CANARY_ENDPOINT=helloworld-next.demo",
+		"SR_BASELINE_ENDPOINT=helloworld-baseline.demo",
+		"FOO=bar",
+		"SR_APPLICATION_ENV=prod",
+	}
+	assert.Equal(t, want, got)
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trafficstep provides a way to progressively route the traffic
+// to the new version through multiple steps inside a single traffic routing stage.
+package trafficstep
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor/analysis"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+const (
+	totalStepsMetadataKey  = "total-steps"
+	currentStepMetadataKey = "current-step"
+	stepWeightKeyFormat    = "step-%d-weight"
+	stepStatusKeyFormat    = "step-%d-status"
+)
+
+// RouteFunc routes the given percentage of traffic to the new version
+// and the rest to the old version. It returns false if the routing failed.
+type RouteFunc func(ctx context.Context, weight int) bool
+
+// Run walks through the given steps in order.
+// At each step, it routes the traffic by using the given function, then keeps that traffic
+// during the interval of the step while running the analysis of the step if specified.
+// It returns immediately with failure status once a step failed, so that the deployment
+// can be rolled back. The progress of the steps is recorded into the stage metadata.
+// When the stage was restarted from the middle, it resumes from the last running step.
+func Run(sig executor.StopSignal, in executor.Input, steps []config.TrafficRoutingStep, route RouteFunc) model.StageStatus {
+	if err := validateSteps(steps); err != nil {
+		in.LogPersister.Errorf("Unable to route traffic through steps: %v", err)
+		return model.StageStatus_STAGE_FAILURE
+	}
+
+	ctx := sig.Context()
+	start := retrieveCurrentStep(in, len(steps))
+
+	in.LogPersister.Infof("Start routing traffic through %d steps", len(steps))
+	for i := start; i < len(steps); i++ {
+		var (
+			step   = steps[i]
+			num    = i + 1
+			weight = step.Weight.Int()
+		)
+		saveStep(ctx, in, len(steps), num, weight, model.StageStatus_STAGE_RUNNING)
+
+		in.LogPersister.Infof("[step %d/%d] Routing %d%% of traffic to the new version", num, len(steps), weight)
+		if !route(ctx, weight) {
+			saveStep(ctx, in, len(steps), num, weight, model.StageStatus_STAGE_FAILURE)
+			return model.StageStatus_STAGE_FAILURE
+		}
+
+		status := hold(sig, in, step)
+		saveStep(ctx, in, len(steps), num, weight, status)
+		if status != model.StageStatus_STAGE_SUCCESS {
+			in.LogPersister.Errorf("[step %d/%d] Stopped routing traffic through steps", num, len(steps))
+			return status
+		}
+		in.LogPersister.Successf("[step %d/%d] Successfully finished", num, len(steps))
+	}
+
+	in.LogPersister.Success("Successfully routed traffic through all steps")
+	return model.StageStatus_STAGE_SUCCESS
+}
+
+// validateSteps ensures that the given steps can be used to route traffic.
+// The stage options were already validated while loading the application configuration,
+// but they are checked again here to avoid routing with an unexpected weight.
+func validateSteps(steps []config.TrafficRoutingStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("no step was specified")
+	}
+	for i, s := range steps {
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("step %d is invalid: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// hold keeps the current traffic during the interval of the given step.
+func hold(sig executor.StopSignal, in executor.Input, step config.TrafficRoutingStep) model.StageStatus {
+	if opts := step.AnalysisOptions(); opts != nil {
+		return analysis.Analyze(sig, in, opts)
+	}
+
+	interval := step.Interval.Duration()
+	if interval <= 0 {
+		return executor.DetermineStageStatus(sig.Signal(), in.Stage.Status, model.StageStatus_STAGE_SUCCESS)
+	}
+
+	in.LogPersister.Infof("Waiting for %v before moving to the next step...", interval)
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return model.StageStatus_STAGE_SUCCESS
+	case <-sig.Context().Done():
+		return executor.DetermineStageStatus(sig.Signal(), in.Stage.Status, model.StageStatus_STAGE_FAILURE)
+	}
+}
+
+// retrieveCurrentStep returns the index of the step that was running
+// when the stage ended unexpectedly. Zero is returned for a fresh start.
+func retrieveCurrentStep(in executor.Input, total int) int {
+	s, ok := in.MetadataStore.Stage(in.Stage.Id).Get(currentStepMetadataKey)
+	if !ok {
+		return 0
+	}
+	num, err := strconv.Atoi(s)
+	if err != nil || num < 1 || num > total {
+		in.Logger.Error("unexpected current step is stored", zap.String("stored-value", s), zap.Error(err))
+		return 0
+	}
+	return num - 1
+}
+
+func saveStep(ctx context.Context, in executor.Input, total, num, weight int, status model.StageStatus) {
+	metadata := map[string]string{
+		totalStepsMetadataKey:                 strconv.Itoa(total),
+		currentStepMetadataKey:                strconv.Itoa(num),
+		fmt.Sprintf(stepWeightKeyFormat, num): strconv.Itoa(weight),
+		fmt.Sprintf(stepStatusKeyFormat, num): status.String(),
+	}
+	if err := in.MetadataStore.Stage(in.Stage.Id).PutMulti(ctx, metadata); err != nil {
+		in.Logger.Error("failed to store traffic routing step to metadata store", zap.Error(err))
+	}
+}
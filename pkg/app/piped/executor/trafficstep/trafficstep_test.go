@@ -0,0 +1,281 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trafficstep
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/metadatastore"
+	"github.com/pipe-cd/pipecd/pkg/app/server/service/pipedservice"
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type fakeLogPersister struct{}
+
+func (l *fakeLogPersister) Write(_ []byte) (int, error)         { return 0, nil }
+func (l *fakeLogPersister) Info(_ string)                       {}
+func (l *fakeLogPersister) Infof(_ string, _ ...interface{})    {}
+func (l *fakeLogPersister) Success(_ string)                    {}
+func (l *fakeLogPersister) Successf(_ string, _ ...interface{}) {}
+func (l *fakeLogPersister) Error(_ string)                      {}
+func (l *fakeLogPersister) Errorf(_ string, _ ...interface{})   {}
+
+type metadata map[string]string
+
+type fakeAPIClient struct {
+	shared metadata
+	stages map[string]metadata
+}
+
+func (c *fakeAPIClient) SaveDeploymentMetadata(_ context.Context, req *pipedservice.SaveDeploymentMetadataRequest, _ ...grpc.CallOption) (*pipedservice.SaveDeploymentMetadataResponse, error) {
+	md := make(map[string]string, len(c.shared)+len(req.Metadata))
+	for k, v := range c.shared {
+		md[k] = v
+	}
+	for k, v := range req.Metadata {
+		md[k] = v
+	}
+	c.shared = md
+	return &pipedservice.SaveDeploymentMetadataResponse{}, nil
+}
+
+func (c *fakeAPIClient) SaveStageMetadata(_ context.Context, req *pipedservice.SaveStageMetadataRequest, _ ...grpc.CallOption) (*pipedservice.SaveStageMetadataResponse, error) {
+	ori := c.stages[req.StageId]
+	md := make(map[string]string, len(ori)+len(req.Metadata))
+	for k, v := range ori {
+		md[k] = v
+	}
+	for k, v := range req.Metadata {
+		md[k] = v
+	}
+	c.stages[req.StageId] = md
+	return &pipedservice.SaveStageMetadataResponse{}, nil
+}
+
+func makeSteps(weights ...int) []config.TrafficRoutingStep {
+	steps := make([]config.TrafficRoutingStep, 0, len(weights))
+	for _, w := range weights {
+		steps = append(steps, config.TrafficRoutingStep{
+			Weight: config.Percentage{Number: w, HasSuffix: true},
+		})
+	}
+	return steps
+}
+
+// route records the traffic split each platform applies for the given weight.
+// Kubernetes and ECS split the traffic between the primary and canary variants,
+// while Cloud Run and Lambda promote the given percentage to the new version.
+type route struct {
+	primary int
+	canary  int
+}
+
+func TestRun(t *testing.T) {
+	var (
+		splitRoute = func(weight int) route {
+			return route{primary: 100 - weight, canary: weight}
+		}
+		promoteRoute = func(weight int) route {
+			return route{canary: weight}
+		}
+	)
+	testcases := []struct {
+		name           string
+		steps          []config.TrafficRoutingStep
+		toRoute        func(weight int) route
+		storedMetadata map[string]string
+		failAtWeight   int
+		expectedRoutes []route
+		expectedStatus model.StageStatus
+		expectedStage  map[string]string
+	}{
+		{
+			name:    "kubernetes: route through all steps",
+			steps:   makeSteps(10, 50, 100),
+			toRoute: splitRoute,
+			expectedRoutes: []route{
+				{primary: 90, canary: 10},
+				{primary: 50, canary: 50},
+				{primary: 0, canary: 100},
+			},
+			expectedStatus: model.StageStatus_STAGE_SUCCESS,
+			expectedStage: map[string]string{
+				"total-steps":   "3",
+				"current-step":  "3",
+				"step-1-weight": "10",
+				"step-1-status": "STAGE_SUCCESS",
+				"step-2-weight": "50",
+				"step-2-status": "STAGE_SUCCESS",
+				"step-3-weight": "100",
+				"step-3-status": "STAGE_SUCCESS",
+			},
+		},
+		{
+			name:    "ecs: stop at the failed step",
+			steps:   makeSteps(20, 60, 100),
+			toRoute: splitRoute,
+			expectedRoutes: []route{
+				{primary: 80, canary: 20},
+				{primary: 40, canary: 60},
+			},
+			failAtWeight:   60,
+			expectedStatus: model.StageStatus_STAGE_FAILURE,
+			expectedStage: map[string]string{
+				"total-steps":   "3",
+				"current-step":  "2",
+				"step-1-weight": "20",
+				"step-1-status": "STAGE_SUCCESS",
+				"step-2-weight": "60",
+				"step-2-status": "STAGE_FAILURE",
+			},
+		},
+		{
+			name:    "cloudrun: promote through all steps",
+			steps:   makeSteps(0, 30, 100),
+			toRoute: promoteRoute,
+			expectedRoutes: []route{
+				{canary: 0},
+				{canary: 30},
+				{canary: 100},
+			},
+			expectedStatus: model.StageStatus_STAGE_SUCCESS,
+			expectedStage: map[string]string{
+				"total-steps":   "3",
+				"current-step":  "3",
+				"step-1-weight": "0",
+				"step-1-status": "STAGE_SUCCESS",
+				"step-2-weight": "30",
+				"step-2-status": "STAGE_SUCCESS",
+				"step-3-weight": "100",
+				"step-3-status": "STAGE_SUCCESS",
+			},
+		},
+		{
+			name:    "lambda: resume from the stored step",
+			steps:   makeSteps(10, 50, 100),
+			toRoute: promoteRoute,
+			storedMetadata: map[string]string{
+				"total-steps":   "3",
+				"current-step":  "2",
+				"step-1-weight": "10",
+				"step-1-status": "STAGE_SUCCESS",
+				"step-2-weight": "50",
+				"step-2-status": "STAGE_RUNNING",
+			},
+			expectedRoutes: []route{
+				{canary: 50},
+				{canary: 100},
+			},
+			expectedStatus: model.StageStatus_STAGE_SUCCESS,
+			expectedStage: map[string]string{
+				"total-steps":   "3",
+				"current-step":  "3",
+				"step-1-weight": "10",
+				"step-1-status": "STAGE_SUCCESS",
+				"step-2-weight": "50",
+				"step-2-status": "STAGE_SUCCESS",
+				"step-3-weight": "100",
+				"step-3-status": "STAGE_SUCCESS",
+			},
+		},
+		{
+			name:    "start from the beginning when the stored step is out of range",
+			steps:   makeSteps(50, 100),
+			toRoute: promoteRoute,
+			storedMetadata: map[string]string{
+				"current-step": "5",
+			},
+			expectedRoutes: []route{
+				{canary: 50},
+				{canary: 100},
+			},
+			expectedStatus: model.StageStatus_STAGE_SUCCESS,
+			expectedStage: map[string]string{
+				"total-steps":   "2",
+				"current-step":  "2",
+				"step-1-weight": "50",
+				"step-1-status": "STAGE_SUCCESS",
+				"step-2-weight": "100",
+				"step-2-status": "STAGE_SUCCESS",
+			},
+		},
+		{
+			name:           "no step",
+			steps:          nil,
+			toRoute:        splitRoute,
+			expectedStatus: model.StageStatus_STAGE_FAILURE,
+			expectedStage:  map[string]string{},
+		},
+		{
+			name:           "weight over 100",
+			steps:          makeSteps(50, 120),
+			toRoute:        splitRoute,
+			expectedStatus: model.StageStatus_STAGE_FAILURE,
+			expectedStage:  map[string]string{},
+		},
+		{
+			name:           "negative weight",
+			steps:          makeSteps(-10, 100),
+			toRoute:        promoteRoute,
+			expectedStatus: model.StageStatus_STAGE_FAILURE,
+			expectedStage:  map[string]string{},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			stored := make(map[string]string, len(tc.storedMetadata))
+			for k, v := range tc.storedMetadata {
+				stored[k] = v
+			}
+			ac := &fakeAPIClient{
+				shared: make(map[string]string),
+				stages: map[string]metadata{"stage-1": stored},
+			}
+			stage := &model.PipelineStage{
+				Id:       "stage-1",
+				Metadata: tc.storedMetadata,
+			}
+			in := executor.Input{
+				Stage:         stage,
+				LogPersister:  &fakeLogPersister{},
+				MetadataStore: metadatastore.NewMetadataStore(ac, &model.Deployment{Stages: []*model.PipelineStage{stage}}),
+				Logger:        zap.NewNop(),
+			}
+			sig, _ := executor.NewStopSignal()
+
+			var routes []route
+			routeFunc := func(_ context.Context, weight int) bool {
+				routes = append(routes, tc.toRoute(weight))
+				return weight != tc.failAtWeight || tc.failAtWeight == 0
+			}
+
+			status := Run(sig, in, tc.steps, routeFunc)
+			assert.Equal(t, tc.expectedStatus, status)
+			assert.Equal(t, tc.expectedRoutes, routes)
+
+			md, ok := ac.stages["stage-1"]
+			require.True(t, ok)
+			assert.Equal(t, tc.expectedStage, map[string]string(md))
+		})
+	}
+}
@@ -29,9 +29,21 @@ import (
 )
 
 const (
-	approvedByKey = "ApprovedBy"
+	approvedByKey      = "ApprovedBy"
+	approvalsKey       = "Approvals"
+	rejectedByKey      = "RejectedBy"
+	rejectionReasonKey = "RejectionReason"
 )
 
+// approval represents an approval given to the stage.
+// The list of approvals is stored in the stage metadata as a JSON array.
+type approval struct {
+	Approver string `json:"approver"`
+	// The names of the approver groups the approver belongs to.
+	Groups     []string `json:"groups,omitempty"`
+	ApprovedAt int64    `json:"approvedAt"`
+}
+
 type Executor struct {
 	executor.Input
 }
@@ -52,24 +64,43 @@ func Register(r registerer) {
 
 // Execute starts waiting until an approval from one of the specified users.
 func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
+	return e.wait(sig, e.StageConfig.WaitApprovalStageOptions)
+}
+
+// Wait starts waiting until the stage of the given input gets approved as configured in the given options.
+// This is used by the stages that require a manual decision as a part of their own execution,
+// such as the ANALYSIS stage whose score is marginal.
+func Wait(sig executor.StopSignal, in executor.Input, options *config.WaitApprovalStageOptions) model.StageStatus {
+	e := &Executor{
+		Input: in,
+	}
+	return e.wait(sig, options)
+}
+
+func (e *Executor) wait(sig executor.StopSignal, options *config.WaitApprovalStageOptions) model.StageStatus {
 	var (
 		originalStatus = e.Stage.Status
 		ctx            = sig.Context()
 		ticker         = time.NewTicker(5 * time.Second)
 	)
 	defer ticker.Stop()
-	timeout := e.StageConfig.WaitApprovalStageOptions.Timeout.Duration()
+	timeout := options.Timeout.Duration()
 	timer := time.NewTimer(timeout)
 
 	e.reportRequiringApproval()
 
-	num := e.StageConfig.WaitApprovalStageOptions.MinApproverNum
-	e.LogPersister.Infof("Waiting for approval from at least %d user(s)...", num)
+	if len(options.ApproverGroups) > 0 {
+		for _, g := range options.ApproverGroups {
+			e.LogPersister.Infof("Waiting for approval from at least %d user(s) of group %s...", g.MinApproverNum, g.Name)
+		}
+	} else {
+		e.LogPersister.Infof("Waiting for approval from at least %d user(s)...", options.MinApproverNum)
+	}
 	for {
 		select {
 		case <-ticker.C:
-			if e.checkApproval(ctx, num) {
-				return model.StageStatus_STAGE_SUCCESS
+			if status, decided := e.checkApproval(ctx, options); decided {
+				return status
 			}
 
 		case s := <-sig.Ch():
@@ -88,25 +119,52 @@ func (e *Executor) Execute(sig executor.StopSignal) model.StageStatus {
 	}
 }
 
-func (e *Executor) checkApproval(ctx context.Context, num int) bool {
-	var approveCmd *model.ReportableCommand
+// checkApproval handles the approval and rejection commands sent to the stage.
+// The returned bool is true when the stage got its final decision.
+func (e *Executor) checkApproval(ctx context.Context, options *config.WaitApprovalStageOptions) (model.StageStatus, bool) {
 	commands := e.CommandLister.ListCommands()
 
-	for i, cmd := range commands {
-		if cmd.GetApproveStage() != nil {
-			approveCmd = &commands[i]
-			break
+	for i := range commands {
+		var (
+			cmd     = &commands[i]
+			status  model.StageStatus
+			decided bool
+		)
+		switch {
+		case cmd.GetRejectStage() != nil:
+			e.reject(ctx, cmd.Commander, cmd.GetRejectStage().Reason)
+			status, decided = model.StageStatus_STAGE_FAILURE, true
+		case cmd.GetApproveStage() != nil:
+			if len(options.ApproverGroups) > 0 || options.ApprovalExpiry > 0 {
+				decided = e.validateApprovals(ctx, cmd.Commander, cmd.GetApproveStage().ApproverGroups, options)
+			} else {
+				decided = e.validateApproverNum(ctx, cmd.Commander, options.MinApproverNum)
+			}
+			status = model.StageStatus_STAGE_SUCCESS
+		default:
+			continue
+		}
+
+		if err := cmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil, nil); err != nil {
+			e.Logger.Error("failed to report handled command", zap.Error(err))
+		}
+		if decided {
+			return status, true
 		}
 	}
-	if approveCmd == nil {
-		return false
-	}
+	return model.StageStatus_STAGE_NOT_STARTED_YET, false
+}
 
-	reached := e.validateApproverNum(ctx, approveCmd.Commander, num)
-	if err := approveCmd.Report(ctx, model.CommandStatus_COMMAND_SUCCEEDED, nil, nil); err != nil {
-		e.Logger.Error("failed to report handled command", zap.Error(err))
+// reject records who rejected the stage and why.
+func (e *Executor) reject(ctx context.Context, rejector, reason string) {
+	md := map[string]string{
+		rejectedByKey:      rejector,
+		rejectionReasonKey: reason,
 	}
-	return reached
+	if err := e.MetadataStore.Stage(e.Stage.Id).PutMulti(ctx, md); err != nil {
+		e.LogPersister.Errorf("Unable to save rejection information to deployment, %v", err)
+	}
+	e.LogPersister.Errorf("This stage has been rejected by %s: %s", rejector, reason)
 }
 
 func (e *Executor) reportApproved(approver string) {
@@ -197,3 +255,121 @@ func (e *Executor) validateApproverNum(ctx context.Context, approver string, min
 	e.LogPersister.Infof("This stage has been approved by %d users (%s)", minApproverNum, aus)
 	return true
 }
+
+// validateApprovals records the given approval and checks whether the stage
+// received all of the required approvals which have not expired yet.
+func (e *Executor) validateApprovals(ctx context.Context, approver string, groups []string, options *config.WaitApprovalStageOptions) bool {
+	var (
+		now       = time.Now()
+		approvals []approval
+		store     = e.MetadataStore.Stage(e.Stage.Id)
+	)
+	if data, ok := store.Get(approvalsKey); ok && data != "" {
+		if err := json.Unmarshal([]byte(data), &approvals); err != nil {
+			e.LogPersister.Errorf("Unable to parse the previous approvals, %v", err)
+		}
+	}
+
+	// The approval from the same user replaces the previous one
+	// so that it can be refreshed before expiring.
+	valid := make([]approval, 0, len(approvals)+1)
+	for _, a := range approvals {
+		if a.Approver == approver {
+			continue
+		}
+		if expiry := options.ApprovalExpiry.Duration(); expiry > 0 && now.Sub(time.Unix(a.ApprovedAt, 0)) > expiry {
+			e.LogPersister.Infof("Approval from %q has expired and will not be counted", a.Approver)
+			continue
+		}
+		valid = append(valid, a)
+	}
+	e.LogPersister.Infof("Got approval from %q", approver)
+	valid = append(valid, approval{
+		Approver:   approver,
+		Groups:     groups,
+		ApprovedAt: now.Unix(),
+	})
+
+	approvers := make([]string, 0, len(valid))
+	for _, a := range valid {
+		approvers = append(approvers, a.Approver)
+	}
+	aus := strings.Join(approvers, ", ")
+
+	data, err := json.Marshal(valid)
+	if err != nil {
+		e.LogPersister.Errorf("Unable to marshal approvals, %v", err)
+	}
+	md := map[string]string{
+		approvalsKey:  string(data),
+		approvedByKey: aus,
+	}
+	if err := store.PutMulti(ctx, md); err != nil {
+		e.LogPersister.Errorf("Unable to save approver information to deployment, %v", err)
+	}
+
+	if len(options.ApproverGroups) == 0 {
+		if remain := options.MinApproverNum - len(valid); remain > 0 {
+			e.LogPersister.Infof("Waiting for %d other approvers...", remain)
+			return false
+		}
+	} else if remains := unsatisfiedGroups(options.ApproverGroups, valid); len(remains) > 0 {
+		e.LogPersister.Infof("Waiting for approvals from group(s): %s", strings.Join(remains, ", "))
+		return false
+	}
+
+	e.reportApproved(aus)
+	e.LogPersister.Info("Received all needed approvals")
+	e.LogPersister.Infof("This stage has been approved by %d users (%s)", len(valid), aus)
+	return true
+}
+
+// unsatisfiedGroups returns the names of the approver groups which have not received
+// their required number of approvals yet. Each approval is counted for at most one
+// of the groups its approver belongs to, so that a single approver cannot satisfy
+// multiple groups alone. The approvals are assigned to the groups in the way
+// that satisfies as many of the required approvals as possible.
+func unsatisfiedGroups(groups []config.ApproverGroup, approvals []approval) []string {
+	index := make(map[string]int, len(groups))
+	for i, g := range groups {
+		index[g.Name] = i
+	}
+
+	// Map from group index to the indexes of the approvals assigned to that group.
+	assigned := make([][]int, len(groups))
+
+	// assign tries to assign the given approval to one of its groups
+	// by moving the already assigned approvals to their other groups if needed.
+	var assign func(a int, visited []bool) bool
+	assign = func(a int, visited []bool) bool {
+		for _, name := range approvals[a].Groups {
+			g, ok := index[name]
+			if !ok || visited[g] {
+				continue
+			}
+			visited[g] = true
+			if len(assigned[g]) < groups[g].MinApproverNum {
+				assigned[g] = append(assigned[g], a)
+				return true
+			}
+			for i, other := range assigned[g] {
+				if assign(other, visited) {
+					assigned[g][i] = a
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for a := range approvals {
+		assign(a, make([]bool, len(groups)))
+	}
+
+	var remains []string
+	for i, g := range groups {
+		if len(assigned[i]) < g.MinApproverNum {
+			remains = append(remains, g.Name)
+		}
+	}
+	return remains
+}
@@ -16,14 +16,18 @@ package waitapproval
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 
 	"github.com/pipe-cd/pipecd/pkg/app/piped/executor"
 	"github.com/pipe-cd/pipecd/pkg/app/piped/metadatastore"
 	"github.com/pipe-cd/pipecd/pkg/app/server/service/pipedservice"
+	"github.com/pipe-cd/pipecd/pkg/config"
 	"github.com/pipe-cd/pipecd/pkg/model"
 )
 
@@ -215,3 +219,189 @@ func TestValidateApproverNum(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateApprovals(t *testing.T) {
+	ctx := context.Background()
+
+	groups := []config.ApproverGroup{
+		{Name: "sre", MinApproverNum: 1},
+		{Name: "product-owner", MinApproverNum: 1},
+	}
+	makeApprovals := func(approvals ...approval) string {
+		data, _ := json.Marshal(approvals)
+		return string(data)
+	}
+	now := time.Now().Unix()
+
+	testcases := []struct {
+		name      string
+		approver  string
+		groups    []string
+		options   *config.WaitApprovalStageOptions
+		approvals string
+		want      bool
+	}{
+		{
+			name:     "not approved because another group has not approved yet",
+			approver: "user-1",
+			groups:   []string{"sre"},
+			options: &config.WaitApprovalStageOptions{
+				ApproverGroups: groups,
+			},
+			want: false,
+		},
+		{
+			name:     "approved because all groups have approved",
+			approver: "user-2",
+			groups:   []string{"product-owner"},
+			options: &config.WaitApprovalStageOptions{
+				ApproverGroups: groups,
+			},
+			approvals: makeApprovals(approval{Approver: "user-1", Groups: []string{"sre"}, ApprovedAt: now}),
+			want:      true,
+		},
+		{
+			name:     "not approved because the approver belonging to all groups is counted for only one of them",
+			approver: "user-1",
+			groups:   []string{"sre", "product-owner"},
+			options: &config.WaitApprovalStageOptions{
+				ApproverGroups: groups,
+			},
+			want: false,
+		},
+		{
+			name:     "approved because the approver belonging to all groups is counted for the unsatisfied one",
+			approver: "user-2",
+			groups:   []string{"sre", "product-owner"},
+			options: &config.WaitApprovalStageOptions{
+				ApproverGroups: groups,
+			},
+			approvals: makeApprovals(approval{Approver: "user-1", Groups: []string{"sre"}, ApprovedAt: now}),
+			want:      true,
+		},
+		{
+			name:     "not approved because the approval from the same user is not counted twice",
+			approver: "user-1",
+			groups:   []string{"product-owner"},
+			options: &config.WaitApprovalStageOptions{
+				ApproverGroups: []config.ApproverGroup{
+					{Name: "product-owner", MinApproverNum: 2},
+				},
+			},
+			approvals: makeApprovals(approval{Approver: "user-1", Groups: []string{"product-owner"}, ApprovedAt: now}),
+			want:      false,
+		},
+		{
+			name:     "not approved because the previous approval has expired",
+			approver: "user-2",
+			groups:   []string{"product-owner"},
+			options: &config.WaitApprovalStageOptions{
+				ApproverGroups: groups,
+				ApprovalExpiry: config.Duration(time.Hour),
+			},
+			approvals: makeApprovals(approval{Approver: "user-1", Groups: []string{"sre"}, ApprovedAt: now - 7200}),
+			want:      false,
+		},
+		{
+			name:     "approved because the number of valid approvals is enough",
+			approver: "user-2",
+			options: &config.WaitApprovalStageOptions{
+				MinApproverNum: 2,
+				ApprovalExpiry: config.Duration(time.Hour),
+			},
+			approvals: makeApprovals(approval{Approver: "user-1", ApprovedAt: now - 60}),
+			want:      true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ac := &fakeAPIClient{
+				shared: make(map[string]string, 0),
+				stages: make(map[string]metadata, 0),
+			}
+			md := map[string]string{}
+			if tc.approvals != "" {
+				md[approvalsKey] = tc.approvals
+			}
+			e := &Executor{
+				Input: executor.Input{
+					Stage: &model.PipelineStage{
+						Id: "stage-1",
+					},
+					LogPersister: &fakeLogPersister{},
+					MetadataStore: metadatastore.NewMetadataStore(ac, &model.Deployment{
+						Stages: []*model.PipelineStage{
+							{
+								Id:       "stage-1",
+								Metadata: md,
+							},
+						},
+					}),
+					Notifier: &fakeNotifier{},
+				},
+			}
+			got := e.validateApprovals(ctx, tc.approver, tc.groups, tc.options)
+			assert.Equal(t, tc.want, got)
+
+			var approvals []approval
+			require.NoError(t, json.Unmarshal([]byte(ac.stages["stage-1"][approvalsKey]), &approvals))
+			assert.Equal(t, tc.approver, approvals[len(approvals)-1].Approver)
+			assert.Equal(t, tc.groups, approvals[len(approvals)-1].Groups)
+		})
+	}
+}
+
+func TestUnsatisfiedGroups(t *testing.T) {
+	groups := []config.ApproverGroup{
+		{Name: "sre", MinApproverNum: 1},
+		{Name: "product-owner", MinApproverNum: 2},
+	}
+	testcases := []struct {
+		name      string
+		approvals []approval
+		want      []string
+	}{
+		{
+			name: "no approval",
+			want: []string{"sre", "product-owner"},
+		},
+		{
+			name: "only one group is satisfied",
+			approvals: []approval{
+				{Approver: "user-1", Groups: []string{"sre", "product-owner"}},
+			},
+			want: []string{"product-owner"},
+		},
+		{
+			name: "an approver in multiple groups is counted for only one group",
+			approvals: []approval{
+				{Approver: "user-1", Groups: []string{"sre", "product-owner"}},
+				{Approver: "user-2", Groups: []string{"product-owner"}},
+			},
+			want: []string{"product-owner"},
+		},
+		{
+			name: "all groups are satisfied",
+			approvals: []approval{
+				{Approver: "user-1", Groups: []string{"sre", "product-owner"}},
+				{Approver: "user-2", Groups: []string{"product-owner"}},
+				{Approver: "user-3", Groups: []string{"sre"}},
+			},
+			want: nil,
+		},
+		{
+			name: "approvals from unknown groups are ignored",
+			approvals: []approval{
+				{Approver: "user-1", Groups: []string{"qa"}},
+				{Approver: "user-2", Groups: []string{"sre"}},
+			},
+			want: []string{"product-owner"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := unsatisfiedGroups(groups, tc.approvals)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
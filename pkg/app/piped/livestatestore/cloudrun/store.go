@@ -16,22 +16,57 @@ package cloudrun
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
+	"google.golang.org/api/run/v1"
 
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/cloudrun"
 	"github.com/pipe-cd/pipecd/pkg/config"
 	"github.com/pipe-cd/pipecd/pkg/model"
 )
 
+const (
+	syncInterval      = time.Minute
+	listServicesLimit = 100
+
+	conditionReady = "Ready"
+)
+
 type applicationLister interface {
 	List() []*model.Application
 }
 
 type Store struct {
-	logger *zap.Logger
+	config        *config.CloudProviderCloudRunConfig
+	cloudProvider string
+	appLister     applicationLister
+	client        provider.Client
+	interval      time.Duration
+	firstSyncedCh chan error
+	logger        *zap.Logger
+
+	apps map[string]app
+	mu   sync.RWMutex
 }
 
 type Getter interface {
+	GetCloudRunAppLiveState(appID string) (AppState, bool)
+	GetServiceManifest(appID string) (provider.ServiceManifest, bool)
+
+	WaitForReady(ctx context.Context, timeout time.Duration) error
+}
+
+type AppState struct {
+	Resources []*model.CloudRunResourceState
+	Version   model.ApplicationLiveStateVersion
+}
+
+type app struct {
+	state           AppState
+	serviceManifest provider.ServiceManifest
 }
 
 func NewStore(cfg *config.CloudProviderCloudRunConfig, cloudProvider string, appLister applicationLister, logger *zap.Logger) *Store {
@@ -39,13 +74,249 @@ func NewStore(cfg *config.CloudProviderCloudRunConfig, cloudProvider string, app
 		With(zap.String("cloud-provider", cloudProvider))
 
 	return &Store{
-		logger: logger,
+		config:        cfg,
+		cloudProvider: cloudProvider,
+		appLister:     appLister,
+		interval:      syncInterval,
+		firstSyncedCh: make(chan error, 1),
+		logger:        logger,
+		apps:          make(map[string]app),
 	}
 }
 
 func (s *Store) Run(ctx context.Context) error {
 	s.logger.Info("start running cloudrun app state store")
 
+	if s.client == nil {
+		client, err := provider.DefaultRegistry().Client(ctx, s.cloudProvider, s.config, s.logger)
+		if err != nil {
+			s.logger.Error("failed to create cloudrun client", zap.Error(err))
+			s.firstSyncedCh <- err
+			return err
+		}
+		s.client = client
+	}
+
+	if err := s.sync(ctx); err != nil {
+		s.logger.Error("failed to sync the live state of cloudrun services", zap.Error(err))
+	}
+	s.logger.Info("the store has done the first sync")
+	close(s.firstSyncedCh)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+L:
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.sync(ctx); err != nil {
+				s.logger.Error("failed to sync the live state of cloudrun services", zap.Error(err))
+			}
+
+		case <-ctx.Done():
+			break L
+		}
+	}
+
 	s.logger.Info("cloudrun app state store has been stopped")
 	return nil
 }
+
+// sync fetches all services managed by piped and rebuilds the live state
+// of the applications handled by this cloud provider.
+func (s *Store) sync(ctx context.Context) error {
+	services, err := s.listServices(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		appIDs  = s.listApplicationIDs()
+		now     = time.Now()
+		version = model.ApplicationLiveStateVersion{
+			Timestamp: now.Unix(),
+		}
+		apps = make(map[string]app, len(appIDs))
+	)
+	for _, svc := range services {
+		if svc.Metadata == nil {
+			continue
+		}
+		appID := svc.Metadata.Labels[provider.LabelApplication]
+		if _, ok := appIDs[appID]; !ok {
+			continue
+		}
+		if _, ok := apps[appID]; ok {
+			s.logger.Warn(fmt.Sprintf("application %s has more than one service, service %s will be ignored", appID, svc.Metadata.Name))
+			continue
+		}
+
+		sm, err := svc.ServiceManifest()
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("failed to build manifest of service %s", svc.Metadata.Name), zap.Error(err))
+			continue
+		}
+		apps[appID] = app{
+			state: AppState{
+				Resources: s.makeResourceStates(ctx, svc, now),
+				Version:   version,
+			},
+			serviceManifest: sm,
+		}
+	}
+
+	s.mu.Lock()
+	s.apps = apps
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Store) listServices(ctx context.Context) ([]*provider.Service, error) {
+	var (
+		services []*provider.Service
+		options  = &provider.ListOptions{
+			Limit:         listServicesLimit,
+			LabelSelector: fmt.Sprintf("%s=%s", provider.LabelManagedBy, provider.ManagedByPiped),
+		}
+	)
+	for {
+		svcs, cursor, err := s.client.List(ctx, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services: %w", err)
+		}
+		services = append(services, svcs...)
+		if cursor == "" {
+			return services, nil
+		}
+		options.Cursor = cursor
+	}
+}
+
+func (s *Store) listApplicationIDs() map[string]struct{} {
+	apps := s.appLister.List()
+	ids := make(map[string]struct{}, len(apps))
+	for _, app := range apps {
+		if app.CloudProvider != s.cloudProvider {
+			continue
+		}
+		ids[app.Id] = struct{}{}
+	}
+	return ids
+}
+
+// makeResourceStates returns the states of the given service
+// and all revisions currently receiving its traffic.
+func (s *Store) makeResourceStates(ctx context.Context, svc *provider.Service, now time.Time) []*model.CloudRunResourceState {
+	var conditions []*run.GoogleCloudRunV1Condition
+	if svc.Status != nil {
+		conditions = svc.Status.Conditions
+	}
+	states := []*model.CloudRunResourceState{
+		makeResourceState(svc.ApiVersion, svc.Kind, svc.Metadata, nil, conditions, now),
+	}
+	if svc.Status == nil {
+		return states
+	}
+
+	parentIDs := []string{svc.Metadata.Uid}
+	fetched := make(map[string]struct{}, len(svc.Status.Traffic))
+	for _, t := range svc.Status.Traffic {
+		if t.RevisionName == "" {
+			continue
+		}
+		if _, ok := fetched[t.RevisionName]; ok {
+			continue
+		}
+		fetched[t.RevisionName] = struct{}{}
+
+		rev, err := s.client.GetRevision(ctx, t.RevisionName)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("failed to get revision %s", t.RevisionName), zap.Error(err))
+			continue
+		}
+		if rev.Metadata == nil {
+			continue
+		}
+		var conditions []*run.GoogleCloudRunV1Condition
+		if rev.Status != nil {
+			conditions = rev.Status.Conditions
+		}
+		states = append(states, makeResourceState(rev.ApiVersion, rev.Kind, rev.Metadata, parentIDs, conditions, now))
+	}
+	return states
+}
+
+func makeResourceState(apiVersion, kind string, meta *run.ObjectMeta, parentIDs []string, conditions []*run.GoogleCloudRunV1Condition, now time.Time) *model.CloudRunResourceState {
+	createdAt := now.Unix()
+	if t, err := time.Parse(time.RFC3339, meta.CreationTimestamp); err == nil {
+		createdAt = t.Unix()
+	}
+	status, desc := determineHealthStatus(conditions)
+
+	return &model.CloudRunResourceState{
+		Id:                meta.Uid,
+		ParentIds:         parentIDs,
+		Name:              meta.Name,
+		ApiVersion:        apiVersion,
+		Kind:              kind,
+		Namespace:         meta.Namespace,
+		HealthStatus:      status,
+		HealthDescription: desc,
+		CreatedAt:         createdAt,
+		UpdatedAt:         now.Unix(),
+	}
+}
+
+// determineHealthStatus decides the health status of a resource based on its Ready condition.
+func determineHealthStatus(conditions []*run.GoogleCloudRunV1Condition) (model.CloudRunResourceState_HealthStatus, string) {
+	for _, c := range conditions {
+		if c.Type != conditionReady {
+			continue
+		}
+		switch c.Status {
+		case "True":
+			return model.CloudRunResourceState_HEALTHY, ""
+		case "False":
+			return model.CloudRunResourceState_OTHER, c.Message
+		default:
+			return model.CloudRunResourceState_UNKNOWN, c.Message
+		}
+	}
+	return model.CloudRunResourceState_UNKNOWN, "Ready condition was not found"
+}
+
+func (s *Store) WaitForReady(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-s.firstSyncedCh:
+		return err
+	}
+}
+
+func (s *Store) GetCloudRunAppLiveState(appID string) (AppState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	app, ok := s.apps[appID]
+	if !ok {
+		return AppState{}, false
+	}
+	return app.state, true
+}
+
+func (s *Store) GetServiceManifest(appID string) (provider.ServiceManifest, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	app, ok := s.apps[appID]
+	if !ok {
+		return provider.ServiceManifest{}, false
+	}
+	return app.serviceManifest, true
+}
@@ -0,0 +1,242 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudrun
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/api/run/v1"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/cloudrun"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type fakeClient struct {
+	provider.Client
+	services  []*provider.Service
+	revisions map[string]*provider.Revision
+}
+
+func (c *fakeClient) List(_ context.Context, options *provider.ListOptions) ([]*provider.Service, string, error) {
+	// Return one service per page to test the pagination.
+	var idx int
+	if options.Cursor != "" {
+		idx = int(options.Cursor[0] - '0')
+	}
+	if idx >= len(c.services) {
+		return nil, "", nil
+	}
+	var cursor string
+	if idx+1 < len(c.services) {
+		cursor = string(rune('0' + idx + 1))
+	}
+	return c.services[idx : idx+1], cursor, nil
+}
+
+func (c *fakeClient) GetRevision(_ context.Context, name string) (*provider.Revision, error) {
+	r, ok := c.revisions[name]
+	if !ok {
+		return nil, provider.ErrRevisionNotFound
+	}
+	return r, nil
+}
+
+type fakeApplicationLister struct {
+	apps []*model.Application
+}
+
+func (l *fakeApplicationLister) List() []*model.Application {
+	return l.apps
+}
+
+func makeService(name, appID, revision, ready string) *provider.Service {
+	return &provider.Service{
+		ApiVersion: "serving.knative.dev/v1",
+		Kind:       "Service",
+		Metadata: &run.ObjectMeta{
+			Name:              name,
+			Namespace:         "project",
+			Uid:               name + "-uid",
+			CreationTimestamp: "2022-01-01T00:00:00Z",
+			Labels: map[string]string{
+				provider.LabelManagedBy:   provider.ManagedByPiped,
+				provider.LabelApplication: appID,
+			},
+		},
+		Spec: &run.ServiceSpec{
+			Template: &run.RevisionTemplate{
+				Metadata: &run.ObjectMeta{
+					Name: revision,
+				},
+			},
+		},
+		Status: &run.ServiceStatus{
+			Conditions: []*run.GoogleCloudRunV1Condition{
+				{Type: "Ready", Status: ready, Message: "not ready"},
+			},
+			Traffic: []*run.TrafficTarget{
+				{RevisionName: revision, Percent: 100},
+			},
+		},
+	}
+}
+
+func TestStoreSync(t *testing.T) {
+	client := &fakeClient{
+		services: []*provider.Service{
+			makeService("service-1", "app-1", "service-1-v1", "True"),
+			makeService("service-2", "app-2", "service-2-v1", "False"),
+			makeService("service-3", "app-3", "service-3-v1", "True"),
+		},
+		revisions: map[string]*provider.Revision{
+			"service-1-v1": {
+				ApiVersion: "serving.knative.dev/v1",
+				Kind:       "Revision",
+				Metadata: &run.ObjectMeta{
+					Name:      "service-1-v1",
+					Namespace: "project",
+					Uid:       "service-1-v1-uid",
+				},
+				Status: &run.RevisionStatus{
+					Conditions: []*run.GoogleCloudRunV1Condition{
+						{Type: "Ready", Status: "True"},
+					},
+				},
+			},
+		},
+	}
+	appLister := &fakeApplicationLister{
+		apps: []*model.Application{
+			{Id: "app-1", CloudProvider: "cloudrun"},
+			{Id: "app-2", CloudProvider: "cloudrun"},
+			// This application is handled by another cloud provider.
+			{Id: "app-3", CloudProvider: "another"},
+		},
+	}
+	s := NewStore(nil, "cloudrun", appLister, zap.NewNop())
+	s.client = client
+
+	err := s.sync(context.Background())
+	require.NoError(t, err)
+
+	state, ok := s.GetCloudRunAppLiveState("app-1")
+	require.True(t, ok)
+	require.Len(t, state.Resources, 2)
+	assert.Equal(t, "service-1", state.Resources[0].Name)
+	assert.Equal(t, "Service", state.Resources[0].Kind)
+	assert.Equal(t, model.CloudRunResourceState_HEALTHY, state.Resources[0].HealthStatus)
+	assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).Unix(), state.Resources[0].CreatedAt)
+	assert.Equal(t, "service-1-v1", state.Resources[1].Name)
+	assert.Equal(t, "Revision", state.Resources[1].Kind)
+	assert.Equal(t, []string{"service-1-uid"}, state.Resources[1].ParentIds)
+	assert.Equal(t, model.CloudRunResourceState_HEALTHY, state.Resources[1].HealthStatus)
+
+	sm, ok := s.GetServiceManifest("app-1")
+	require.True(t, ok)
+	assert.Equal(t, "service-1", sm.Name)
+	assert.Equal(t, "app-1", sm.Labels()[provider.LabelApplication])
+
+	// The revision of app-2 was not found so only the service is included.
+	state, ok = s.GetCloudRunAppLiveState("app-2")
+	require.True(t, ok)
+	require.Len(t, state.Resources, 1)
+	assert.Equal(t, model.CloudRunResourceState_OTHER, state.Resources[0].HealthStatus)
+	assert.Equal(t, "not ready", state.Resources[0].HealthDescription)
+
+	_, ok = s.GetCloudRunAppLiveState("app-3")
+	assert.False(t, ok)
+}
+
+type failingClient struct {
+	provider.Client
+}
+
+func (c *failingClient) List(_ context.Context, _ *provider.ListOptions) ([]*provider.Service, string, error) {
+	return nil, "", errors.New("unavailable")
+}
+
+func TestStoreSyncFailure(t *testing.T) {
+	appLister := &fakeApplicationLister{
+		apps: []*model.Application{
+			{Id: "app-1", CloudProvider: "cloudrun"},
+		},
+	}
+	s := NewStore(nil, "cloudrun", appLister, zap.NewNop())
+	s.client = &fakeClient{
+		services: []*provider.Service{
+			makeService("service-1", "app-1", "service-1-v1", "True"),
+		},
+	}
+	require.NoError(t, s.sync(context.Background()))
+
+	// The last known state should be kept when failed to sync.
+	s.client = &failingClient{}
+	require.Error(t, s.sync(context.Background()))
+
+	_, ok := s.GetCloudRunAppLiveState("app-1")
+	assert.True(t, ok)
+}
+
+func TestDetermineHealthStatus(t *testing.T) {
+	testcases := []struct {
+		name       string
+		conditions []*run.GoogleCloudRunV1Condition
+		want       model.CloudRunResourceState_HealthStatus
+		wantDesc   string
+	}{
+		{
+			name: "ready",
+			conditions: []*run.GoogleCloudRunV1Condition{
+				{Type: "ConfigurationsReady", Status: "False", Message: "ignored"},
+				{Type: "Ready", Status: "True"},
+			},
+			want: model.CloudRunResourceState_HEALTHY,
+		},
+		{
+			name: "not ready",
+			conditions: []*run.GoogleCloudRunV1Condition{
+				{Type: "Ready", Status: "False", Message: "container failed to start"},
+			},
+			want:     model.CloudRunResourceState_OTHER,
+			wantDesc: "container failed to start",
+		},
+		{
+			name: "in progress",
+			conditions: []*run.GoogleCloudRunV1Condition{
+				{Type: "Ready", Status: "Unknown", Message: "deploying"},
+			},
+			want:     model.CloudRunResourceState_UNKNOWN,
+			wantDesc: "deploying",
+		},
+		{
+			name:     "missing ready condition",
+			want:     model.CloudRunResourceState_UNKNOWN,
+			wantDesc: "Ready condition was not found",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, desc := determineHealthStatus(tc.conditions)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantDesc, desc)
+		})
+	}
+}
@@ -16,22 +16,72 @@ package ecs
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
 	"go.uber.org/zap"
 
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/ecs"
 	"github.com/pipe-cd/pipecd/pkg/config"
 	"github.com/pipe-cd/pipecd/pkg/model"
 )
 
+const (
+	syncInterval = time.Minute
+
+	kindService        = "Service"
+	kindTaskSet        = "TaskSet"
+	kindTaskDefinition = "TaskDefinition"
+
+	serviceStatusActive   = "ACTIVE"
+	taskSetStatusPrimary  = "PRIMARY"
+	taskSetStatusDraining = "DRAINING"
+)
+
 type applicationLister interface {
 	List() []*model.Application
 }
 
 type Store struct {
-	logger *zap.Logger
+	config        *config.CloudProviderECSConfig
+	cloudProvider string
+	appLister     applicationLister
+	client        provider.Client
+	interval      time.Duration
+	firstSyncedCh chan error
+	logger        *zap.Logger
+
+	apps map[string]app
+	mu   sync.RWMutex
+
+	// Map from the ARN to the task definitions used by the current task sets.
+	// Task definitions are immutable so they are fetched only once.
+	taskDefinitions map[string]*types.TaskDefinition
 }
 
 type Getter interface {
+	GetECSAppLiveState(appID string) (AppState, bool)
+	GetServiceDefinition(appID string) (types.Service, bool)
+	GetTaskDefinition(appID string) (types.TaskDefinition, bool)
+
+	WaitForReady(ctx context.Context, timeout time.Duration) error
+}
+
+type AppState struct {
+	Resources []*model.ECSResourceState
+	Version   model.ApplicationLiveStateVersion
+}
+
+type app struct {
+	state             AppState
+	serviceDefinition types.Service
+	// The task definition of the PRIMARY task set.
+	// This is nil when the service has no PRIMARY task set.
+	taskDefinition *types.TaskDefinition
 }
 
 func NewStore(cfg *config.CloudProviderECSConfig, cloudProvider string, appLister applicationLister, logger *zap.Logger) *Store {
@@ -39,13 +89,328 @@ func NewStore(cfg *config.CloudProviderECSConfig, cloudProvider string, appListe
 		With(zap.String("cloud-provider", cloudProvider))
 
 	return &Store{
-		logger: logger,
+		config:          cfg,
+		cloudProvider:   cloudProvider,
+		appLister:       appLister,
+		interval:        syncInterval,
+		firstSyncedCh:   make(chan error, 1),
+		logger:          logger,
+		apps:            make(map[string]app),
+		taskDefinitions: make(map[string]*types.TaskDefinition),
 	}
 }
 
 func (s *Store) Run(ctx context.Context) error {
 	s.logger.Info("start running ecs app state store")
 
+	if s.client == nil {
+		client, err := provider.DefaultRegistry().Client(s.cloudProvider, s.config, s.logger)
+		if err != nil {
+			s.logger.Error("failed to create ecs client", zap.Error(err))
+			s.firstSyncedCh <- err
+			return err
+		}
+		s.client = client
+	}
+
+	if err := s.sync(ctx); err != nil {
+		s.logger.Error("failed to sync the live state of ecs services", zap.Error(err))
+	}
+	s.logger.Info("the store has done the first sync")
+	close(s.firstSyncedCh)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+L:
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.sync(ctx); err != nil {
+				s.logger.Error("failed to sync the live state of ecs services", zap.Error(err))
+			}
+
+		case <-ctx.Done():
+			break L
+		}
+	}
+
 	s.logger.Info("ecs app state store has been stopped")
 	return nil
 }
+
+// sync fetches all services managed by piped from all clusters and rebuilds the live state
+// of the applications handled by this cloud provider.
+// The services are associated with the applications by the builtin tags added while deploying.
+func (s *Store) sync(ctx context.Context) error {
+	clusters, err := s.client.ListClusters(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		appIDs  = s.listApplicationIDs()
+		now     = time.Now()
+		version = model.ApplicationLiveStateVersion{
+			Timestamp: now.Unix(),
+		}
+		apps            = make(map[string]app, len(appIDs))
+		taskDefinitions = make(map[string]*types.TaskDefinition, len(s.taskDefinitions))
+	)
+
+	for _, cluster := range clusters {
+		services, err := s.client.GetServices(ctx, cluster)
+		if err != nil {
+			return err
+		}
+
+		for _, svc := range services {
+			tags := tagsToMap(svc.Tags)
+			if tags[provider.LabelManagedBy] != provider.ManagedByPiped {
+				continue
+			}
+			appID := tags[provider.LabelApplication]
+			if _, ok := appIDs[appID]; !ok {
+				continue
+			}
+			name := aws.ToString(svc.ServiceName)
+			if _, ok := apps[appID]; ok {
+				s.logger.Warn(fmt.Sprintf("application %s has more than one service, service %s will be ignored", appID, name))
+				continue
+			}
+
+			a := app{
+				state: AppState{
+					Resources: []*model.ECSResourceState{
+						makeServiceResourceState(svc, now),
+					},
+					Version: version,
+				},
+			}
+
+			// Task definitions shared by multiple task sets are reported once with all of them as the parents.
+			taskDefinitionStates := make(map[string]*model.ECSResourceState)
+			var primary *types.TaskSet
+			for i := range svc.TaskSets {
+				ts := &svc.TaskSets[i]
+				a.state.Resources = append(a.state.Resources, makeTaskSetResourceState(ts, now))
+				if aws.ToString(ts.Status) == taskSetStatusPrimary {
+					primary = ts
+				}
+
+				arn := aws.ToString(ts.TaskDefinition)
+				if state, ok := taskDefinitionStates[arn]; ok {
+					state.ParentIds = append(state.ParentIds, aws.ToString(ts.TaskSetArn))
+					sort.Strings(state.ParentIds)
+					continue
+				}
+				td, err := s.getTaskDefinition(ctx, arn)
+				if err != nil {
+					s.logger.Error(fmt.Sprintf("failed to get task definition %s", arn), zap.Error(err))
+					continue
+				}
+				taskDefinitions[arn] = td
+				state := makeTaskDefinitionResourceState(td, aws.ToString(ts.TaskSetArn), now)
+				taskDefinitionStates[arn] = state
+				a.state.Resources = append(a.state.Resources, state)
+			}
+
+			a.serviceDefinition = makeServiceDefinition(*svc, primary)
+			if primary != nil {
+				a.taskDefinition = taskDefinitions[aws.ToString(primary.TaskDefinition)]
+			}
+			apps[appID] = a
+		}
+	}
+
+	s.mu.Lock()
+	s.apps = apps
+	s.mu.Unlock()
+	s.taskDefinitions = taskDefinitions
+
+	return nil
+}
+
+func (s *Store) getTaskDefinition(ctx context.Context, arn string) (*types.TaskDefinition, error) {
+	if td, ok := s.taskDefinitions[arn]; ok {
+		return td, nil
+	}
+	return s.client.GetTaskDefinition(ctx, arn)
+}
+
+func (s *Store) listApplicationIDs() map[string]struct{} {
+	apps := s.appLister.List()
+	ids := make(map[string]struct{}, len(apps))
+	for _, app := range apps {
+		if app.CloudProvider != s.cloudProvider {
+			continue
+		}
+		ids[app.Id] = struct{}{}
+	}
+	return ids
+}
+
+// makeServiceDefinition builds the service definition comparable with the one stored in Git.
+func makeServiceDefinition(svc types.Service, primary *types.TaskSet) types.Service {
+	// Since the EXTERNAL deployment controller is used, the below configurations
+	// are set to the task sets instead of the service while deploying.
+	if primary != nil {
+		if svc.LaunchType == "" {
+			svc.LaunchType = primary.LaunchType
+		}
+		if svc.NetworkConfiguration == nil {
+			svc.NetworkConfiguration = primary.NetworkConfiguration
+		}
+	}
+
+	tags := make([]types.Tag, 0, len(svc.Tags))
+	for _, t := range svc.Tags {
+		if provider.IsBuiltinTag(aws.ToString(t.Key)) {
+			continue
+		}
+		tags = append(tags, t)
+	}
+	svc.Tags = tags
+
+	return svc
+}
+
+func makeServiceResourceState(svc *types.Service, now time.Time) *model.ECSResourceState {
+	status, desc := determineServiceHealthStatus(svc)
+	createdAt := unixOrDefault(svc.CreatedAt, now)
+
+	return &model.ECSResourceState{
+		Id:                aws.ToString(svc.ServiceArn),
+		Name:              aws.ToString(svc.ServiceName),
+		Kind:              kindService,
+		HealthStatus:      status,
+		HealthDescription: desc,
+		CreatedAt:         createdAt,
+		UpdatedAt:         createdAt,
+	}
+}
+
+func makeTaskSetResourceState(ts *types.TaskSet, now time.Time) *model.ECSResourceState {
+	status, desc := determineTaskSetHealthStatus(ts)
+
+	return &model.ECSResourceState{
+		Id:                aws.ToString(ts.TaskSetArn),
+		ParentIds:         []string{aws.ToString(ts.ServiceArn)},
+		Name:              aws.ToString(ts.Id),
+		Kind:              kindTaskSet,
+		HealthStatus:      status,
+		HealthDescription: desc,
+		CreatedAt:         unixOrDefault(ts.CreatedAt, now),
+		UpdatedAt:         unixOrDefault(ts.UpdatedAt, now),
+	}
+}
+
+func makeTaskDefinitionResourceState(td *types.TaskDefinition, taskSetArn string, now time.Time) *model.ECSResourceState {
+	status, desc := determineTaskDefinitionHealthStatus(td)
+
+	return &model.ECSResourceState{
+		Id:                aws.ToString(td.TaskDefinitionArn),
+		ParentIds:         []string{taskSetArn},
+		Name:              fmt.Sprintf("%s:%d", aws.ToString(td.Family), td.Revision),
+		Kind:              kindTaskDefinition,
+		HealthStatus:      status,
+		HealthDescription: desc,
+		CreatedAt:         now.Unix(),
+		UpdatedAt:         now.Unix(),
+	}
+}
+
+// determineServiceHealthStatus decides the health status of a service
+// based on its status and the number of running tasks.
+func determineServiceHealthStatus(svc *types.Service) (model.ECSResourceState_HealthStatus, string) {
+	status := aws.ToString(svc.Status)
+	if status != serviceStatusActive {
+		return model.ECSResourceState_OTHER, fmt.Sprintf("The service is %s", status)
+	}
+	if svc.RunningCount < svc.DesiredCount {
+		return model.ECSResourceState_OTHER, fmt.Sprintf("%d/%d tasks are running", svc.RunningCount, svc.DesiredCount)
+	}
+	return model.ECSResourceState_HEALTHY, ""
+}
+
+// determineTaskSetHealthStatus decides the health status of a task set based on its stability status.
+func determineTaskSetHealthStatus(ts *types.TaskSet) (model.ECSResourceState_HealthStatus, string) {
+	if aws.ToString(ts.Status) == taskSetStatusDraining {
+		return model.ECSResourceState_OTHER, "The task set is DRAINING"
+	}
+	switch ts.StabilityStatus {
+	case types.StabilityStatusSteadyState:
+		return model.ECSResourceState_HEALTHY, ""
+	case types.StabilityStatusStabilizing:
+		return model.ECSResourceState_UNKNOWN, fmt.Sprintf("%d/%d tasks are running", ts.RunningCount, ts.ComputedDesiredCount)
+	default:
+		return model.ECSResourceState_UNKNOWN, ""
+	}
+}
+
+func determineTaskDefinitionHealthStatus(td *types.TaskDefinition) (model.ECSResourceState_HealthStatus, string) {
+	if td.Status == types.TaskDefinitionStatusActive {
+		return model.ECSResourceState_HEALTHY, ""
+	}
+	return model.ECSResourceState_OTHER, fmt.Sprintf("The task definition is %s", td.Status)
+}
+
+func tagsToMap(tags []types.Tag) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, t := range tags {
+		m[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	return m
+}
+
+func unixOrDefault(t *time.Time, def time.Time) int64 {
+	if t == nil {
+		return def.Unix()
+	}
+	return t.Unix()
+}
+
+func (s *Store) WaitForReady(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-s.firstSyncedCh:
+		return err
+	}
+}
+
+func (s *Store) GetECSAppLiveState(appID string) (AppState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	app, ok := s.apps[appID]
+	if !ok {
+		return AppState{}, false
+	}
+	return app.state, true
+}
+
+func (s *Store) GetServiceDefinition(appID string) (types.Service, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	app, ok := s.apps[appID]
+	if !ok {
+		return types.Service{}, false
+	}
+	return app.serviceDefinition, true
+}
+
+func (s *Store) GetTaskDefinition(appID string) (types.TaskDefinition, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	app, ok := s.apps[appID]
+	if !ok || app.taskDefinition == nil {
+		return types.TaskDefinition{}, false
+	}
+	return *app.taskDefinition, true
+}
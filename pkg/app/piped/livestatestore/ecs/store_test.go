@@ -0,0 +1,231 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider"
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/ecs"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type fakeClient struct {
+	provider.Client
+	services        map[string][]*types.Service
+	taskDefinitions map[string]*types.TaskDefinition
+	// The number of GetTaskDefinition calls for each task definition.
+	getCalls map[string]int
+}
+
+func (c *fakeClient) ListClusters(_ context.Context) ([]string, error) {
+	clusters := make([]string, 0, len(c.services))
+	for cluster := range c.services {
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+func (c *fakeClient) GetServices(_ context.Context, cluster string) ([]*types.Service, error) {
+	return c.services[cluster], nil
+}
+
+func (c *fakeClient) GetTaskDefinition(_ context.Context, arn string) (*types.TaskDefinition, error) {
+	c.getCalls[arn]++
+	td, ok := c.taskDefinitions[arn]
+	if !ok {
+		return nil, cloudprovider.ErrNotFound
+	}
+	return td, nil
+}
+
+type fakeApplicationLister struct {
+	apps []*model.Application
+}
+
+func (l *fakeApplicationLister) List() []*model.Application {
+	return l.apps
+}
+
+var createdAt = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func makeService(name, appID string, taskSets ...types.TaskSet) *types.Service {
+	svc := &types.Service{
+		ServiceArn:   aws.String("arn:aws:ecs:ap-northeast-1:123456789012:service/cluster/" + name),
+		ServiceName:  aws.String(name),
+		Status:       aws.String("ACTIVE"),
+		DesiredCount: 2,
+		RunningCount: 2,
+		CreatedAt:    &createdAt,
+		TaskSets:     taskSets,
+		Tags: []types.Tag{
+			{Key: aws.String("team"), Value: aws.String("pipecd")},
+		},
+	}
+	if appID != "" {
+		svc.Tags = append(svc.Tags,
+			types.Tag{Key: aws.String(provider.LabelManagedBy), Value: aws.String(provider.ManagedByPiped)},
+			types.Tag{Key: aws.String(provider.LabelApplication), Value: aws.String(appID)},
+		)
+	}
+	return svc
+}
+
+func makeTaskSet(id, service, status, taskDefinition string) types.TaskSet {
+	return types.TaskSet{
+		Id:              aws.String(id),
+		TaskSetArn:      aws.String("arn:aws:ecs:ap-northeast-1:123456789012:task-set/cluster/" + service + "/" + id),
+		ServiceArn:      aws.String("arn:aws:ecs:ap-northeast-1:123456789012:service/cluster/" + service),
+		Status:          aws.String(status),
+		StabilityStatus: types.StabilityStatusSteadyState,
+		TaskDefinition:  aws.String(taskDefinition),
+		LaunchType:      types.LaunchTypeFargate,
+		CreatedAt:       &createdAt,
+		UpdatedAt:       &createdAt,
+	}
+}
+
+func TestStoreSync(t *testing.T) {
+	const (
+		taskDefV1 = "arn:aws:ecs:ap-northeast-1:123456789012:task-definition/web:1"
+		taskDefV2 = "arn:aws:ecs:ap-northeast-1:123456789012:task-definition/web:2"
+	)
+	client := &fakeClient{
+		services: map[string][]*types.Service{
+			"cluster": {
+				makeService("service-1", "app-1",
+					makeTaskSet("ecs-svc/1", "service-1", "PRIMARY", taskDefV2),
+					makeTaskSet("ecs-svc/2", "service-1", "ACTIVE", taskDefV1),
+				),
+				makeService("service-2", "app-2"),
+				makeService("unmanaged", ""),
+			},
+		},
+		taskDefinitions: map[string]*types.TaskDefinition{
+			taskDefV1: {
+				TaskDefinitionArn: aws.String(taskDefV1),
+				Family:            aws.String("web"),
+				Revision:          1,
+				Status:            types.TaskDefinitionStatusInactive,
+			},
+			taskDefV2: {
+				TaskDefinitionArn: aws.String(taskDefV2),
+				Family:            aws.String("web"),
+				Revision:          2,
+				Status:            types.TaskDefinitionStatusActive,
+			},
+		},
+		getCalls: make(map[string]int),
+	}
+	appLister := &fakeApplicationLister{
+		apps: []*model.Application{
+			{Id: "app-1", CloudProvider: "ecs"},
+			// This application is handled by another cloud provider.
+			{Id: "app-2", CloudProvider: "another"},
+		},
+	}
+	s := NewStore(nil, "ecs", appLister, zap.NewNop())
+	s.client = client
+
+	err := s.sync(context.Background())
+	require.NoError(t, err)
+
+	state, ok := s.GetECSAppLiveState("app-1")
+	require.True(t, ok)
+	require.Len(t, state.Resources, 5)
+	assert.Equal(t, &model.ECSResourceState{
+		Id:           "arn:aws:ecs:ap-northeast-1:123456789012:service/cluster/service-1",
+		Name:         "service-1",
+		Kind:         "Service",
+		HealthStatus: model.ECSResourceState_HEALTHY,
+		CreatedAt:    createdAt.Unix(),
+		UpdatedAt:    createdAt.Unix(),
+	}, state.Resources[0])
+	assert.Equal(t, "TaskSet", state.Resources[1].Kind)
+	assert.Equal(t, []string{"arn:aws:ecs:ap-northeast-1:123456789012:service/cluster/service-1"}, state.Resources[1].ParentIds)
+	assert.Equal(t, "web:2", state.Resources[2].Name)
+	assert.Equal(t, model.ECSResourceState_HEALTHY, state.Resources[2].HealthStatus)
+	assert.Equal(t, "web:1", state.Resources[4].Name)
+	assert.Equal(t, model.ECSResourceState_OTHER, state.Resources[4].HealthStatus)
+
+	svc, ok := s.GetServiceDefinition("app-1")
+	require.True(t, ok)
+	assert.Equal(t, types.LaunchTypeFargate, svc.LaunchType)
+	assert.Equal(t, []types.Tag{{Key: aws.String("team"), Value: aws.String("pipecd")}}, svc.Tags)
+
+	td, ok := s.GetTaskDefinition("app-1")
+	require.True(t, ok)
+	assert.Equal(t, taskDefV2, aws.ToString(td.TaskDefinitionArn))
+
+	_, ok = s.GetECSAppLiveState("app-2")
+	assert.False(t, ok)
+
+	// The task definitions should not be fetched again since they are immutable.
+	require.NoError(t, s.sync(context.Background()))
+	assert.Equal(t, 1, client.getCalls[taskDefV1])
+	assert.Equal(t, 1, client.getCalls[taskDefV2])
+}
+
+func TestDetermineServiceHealthStatus(t *testing.T) {
+	testcases := []struct {
+		name     string
+		svc      types.Service
+		want     model.ECSResourceState_HealthStatus
+		wantDesc string
+	}{
+		{
+			name: "all tasks are running",
+			svc: types.Service{
+				Status:       aws.String("ACTIVE"),
+				DesiredCount: 2,
+				RunningCount: 2,
+			},
+			want: model.ECSResourceState_HEALTHY,
+		},
+		{
+			name: "some tasks are not running",
+			svc: types.Service{
+				Status:       aws.String("ACTIVE"),
+				DesiredCount: 2,
+				RunningCount: 1,
+			},
+			want:     model.ECSResourceState_OTHER,
+			wantDesc: "1/2 tasks are running",
+		},
+		{
+			name: "draining",
+			svc: types.Service{
+				Status: aws.String("DRAINING"),
+			},
+			want:     model.ECSResourceState_OTHER,
+			wantDesc: "The service is DRAINING",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, desc := determineServiceHealthStatus(&tc.svc)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantDesc, desc)
+		})
+	}
+}
@@ -93,6 +93,14 @@ var (
 		"IngressClass":             {},
 		"Namespace":                {},
 	}
+	// This is the list of resources those are generated by piped while deploying.
+	// They are watched by default even though their groups and versions are not in the above whitelists.
+	generatedResourceWhitelist = map[string]struct{}{
+		"split.smi-spec.io/v1alpha1:TrafficSplit": {},
+		"split.smi-spec.io/v1alpha2:TrafficSplit": {},
+		"split.smi-spec.io/v1alpha3:TrafficSplit": {},
+		"split.smi-spec.io/v1alpha4:TrafficSplit": {},
+	}
 	ignoreResourceKeys = map[string]struct{}{
 		"v1:Service:default:kubernetes":               {},
 		"v1:Service:kube-system:heapster":             {},
@@ -410,6 +418,9 @@ func (m *resourceMatcher) Match(gvk schema.GroupVersionKind) bool {
 	}
 
 	// Check the predefined list.
+	if _, ok := generatedResourceWhitelist[key]; ok {
+		return true
+	}
 	if _, ok := kindWhitelist[gvk.Kind]; !ok {
 		return false
 	}
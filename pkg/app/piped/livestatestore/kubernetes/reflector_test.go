@@ -34,10 +34,12 @@ func TestResourceMatcher(t *testing.T) {
 			name: "empty config",
 			cfg:  config.KubernetesAppStateInformer{},
 			gvks: map[schema.GroupVersionKind]bool{
-				{"pipecd.dev", "v1beta1", "Foo"}:       false,
-				{"", "v1", "Foo"}:                      false,
-				{"", "v1", "Service"}:                  true,
-				{"networking.k8s.io", "v1", "Ingress"}: true,
+				{"pipecd.dev", "v1beta1", "Foo"}:                  false,
+				{"", "v1", "Foo"}:                                 false,
+				{"", "v1", "Service"}:                             true,
+				{"networking.k8s.io", "v1", "Ingress"}:            true,
+				{"split.smi-spec.io", "v1alpha2", "TrafficSplit"}: true,
+				{"split.smi-spec.io", "v1alpha2", "Foo"}:          false,
 			},
 		},
 		{
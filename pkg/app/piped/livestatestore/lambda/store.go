@@ -16,22 +16,66 @@ package lambda
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"go.uber.org/zap"
 
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/lambda"
 	"github.com/pipe-cd/pipecd/pkg/config"
 	"github.com/pipe-cd/pipecd/pkg/model"
 )
 
+const (
+	syncInterval = time.Minute
+	// How long the functions known as not managed by piped are skipped.
+	unmanagedFunctionsTTL = 10 * time.Minute
+
+	kindFunction = "Function"
+	// The format of the last modified time returned by AWS Lambda.
+	lastModifiedLayout = "2006-01-02T15:04:05.000-0700"
+)
+
 type applicationLister interface {
 	List() []*model.Application
 }
 
 type Store struct {
-	logger *zap.Logger
+	config        *config.CloudProviderLambdaConfig
+	cloudProvider string
+	appLister     applicationLister
+	client        provider.Client
+	interval      time.Duration
+	firstSyncedCh chan error
+	logger        *zap.Logger
+
+	apps map[string]app
+	mu   sync.RWMutex
+
+	// Map from the ARN to the revision ID of the functions not managed by piped.
+	// Getting the tags of those functions is skipped while their revisions are unchanged.
+	unmanagedFunctions        map[string]string
+	unmanagedFunctionsResetAt time.Time
 }
 
 type Getter interface {
+	GetLambdaAppLiveState(appID string) (AppState, bool)
+	GetFunctionManifest(appID string) (provider.FunctionManifest, bool)
+
+	WaitForReady(ctx context.Context, timeout time.Duration) error
+}
+
+type AppState struct {
+	Resources []*model.LambdaResourceState
+	Version   model.ApplicationLiveStateVersion
+}
+
+type app struct {
+	state            AppState
+	functionManifest provider.FunctionManifest
 }
 
 func NewStore(cfg *config.CloudProviderLambdaConfig, cloudProvider string, appLister applicationLister, logger *zap.Logger) *Store {
@@ -39,13 +83,198 @@ func NewStore(cfg *config.CloudProviderLambdaConfig, cloudProvider string, appLi
 		With(zap.String("cloud-provider", cloudProvider))
 
 	return &Store{
-		logger: logger,
+		config:             cfg,
+		cloudProvider:      cloudProvider,
+		appLister:          appLister,
+		interval:           syncInterval,
+		firstSyncedCh:      make(chan error, 1),
+		logger:             logger,
+		apps:               make(map[string]app),
+		unmanagedFunctions: make(map[string]string),
 	}
 }
 
 func (s *Store) Run(ctx context.Context) error {
 	s.logger.Info("start running lambda app state store")
 
+	if s.client == nil {
+		client, err := provider.DefaultRegistry().Client(s.cloudProvider, s.config, s.logger)
+		if err != nil {
+			s.logger.Error("failed to create lambda client", zap.Error(err))
+			s.firstSyncedCh <- err
+			return err
+		}
+		s.client = client
+	}
+
+	if err := s.sync(ctx); err != nil {
+		s.logger.Error("failed to sync the live state of lambda functions", zap.Error(err))
+	}
+	s.logger.Info("the store has done the first sync")
+	close(s.firstSyncedCh)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+L:
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.sync(ctx); err != nil {
+				s.logger.Error("failed to sync the live state of lambda functions", zap.Error(err))
+			}
+
+		case <-ctx.Done():
+			break L
+		}
+	}
+
 	s.logger.Info("lambda app state store has been stopped")
 	return nil
 }
+
+// sync fetches all functions managed by piped and rebuilds the live state
+// of the applications handled by this cloud provider.
+// The functions are associated with the applications by the builtin tags added while deploying.
+func (s *Store) sync(ctx context.Context) error {
+	functions, err := s.client.ListFunctions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		appIDs  = s.listApplicationIDs()
+		now     = time.Now()
+		version = model.ApplicationLiveStateVersion{
+			Timestamp: now.Unix(),
+		}
+		apps = make(map[string]app, len(appIDs))
+	)
+	if now.Sub(s.unmanagedFunctionsResetAt) > unmanagedFunctionsTTL {
+		s.unmanagedFunctions = make(map[string]string)
+		s.unmanagedFunctionsResetAt = now
+	}
+
+	for _, cfg := range functions {
+		var (
+			arn      = aws.ToString(cfg.FunctionArn)
+			name     = aws.ToString(cfg.FunctionName)
+			revision = aws.ToString(cfg.RevisionId)
+		)
+		if rev, ok := s.unmanagedFunctions[arn]; ok && rev == revision {
+			continue
+		}
+
+		fn, err := s.client.GetFunction(ctx, name)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("failed to get lambda function %s", name), zap.Error(err))
+			continue
+		}
+		if fn.Tags[provider.LabelManagedBy] != provider.ManagedByPiped {
+			s.unmanagedFunctions[arn] = revision
+			continue
+		}
+
+		appID := fn.Tags[provider.LabelApplication]
+		if _, ok := appIDs[appID]; !ok {
+			continue
+		}
+		if _, ok := apps[appID]; ok {
+			s.logger.Warn(fmt.Sprintf("application %s has more than one function, function %s will be ignored", appID, name))
+			continue
+		}
+		apps[appID] = app{
+			state: AppState{
+				Resources: []*model.LambdaResourceState{
+					makeResourceState(fn.Configuration, now),
+				},
+				Version: version,
+			},
+			functionManifest: fn.FunctionManifest(),
+		}
+	}
+
+	s.mu.Lock()
+	s.apps = apps
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Store) listApplicationIDs() map[string]struct{} {
+	apps := s.appLister.List()
+	ids := make(map[string]struct{}, len(apps))
+	for _, app := range apps {
+		if app.CloudProvider != s.cloudProvider {
+			continue
+		}
+		ids[app.Id] = struct{}{}
+	}
+	return ids
+}
+
+func makeResourceState(cfg types.FunctionConfiguration, now time.Time) *model.LambdaResourceState {
+	updatedAt := now.Unix()
+	if t, err := time.Parse(lastModifiedLayout, aws.ToString(cfg.LastModified)); err == nil {
+		updatedAt = t.Unix()
+	}
+	status, desc := determineHealthStatus(cfg)
+
+	return &model.LambdaResourceState{
+		Id:                aws.ToString(cfg.FunctionArn),
+		Name:              aws.ToString(cfg.FunctionName),
+		Kind:              kindFunction,
+		HealthStatus:      status,
+		HealthDescription: desc,
+		UpdatedAt:         updatedAt,
+	}
+}
+
+// determineHealthStatus decides the health status of a function based on its state and last update status.
+func determineHealthStatus(cfg types.FunctionConfiguration) (model.LambdaResourceState_HealthStatus, string) {
+	switch cfg.State {
+	case types.StateActive:
+		if cfg.LastUpdateStatus == types.LastUpdateStatusFailed {
+			return model.LambdaResourceState_OTHER, aws.ToString(cfg.LastUpdateStatusReason)
+		}
+		return model.LambdaResourceState_HEALTHY, ""
+	case types.StateInactive, types.StateFailed:
+		return model.LambdaResourceState_OTHER, aws.ToString(cfg.StateReason)
+	default:
+		return model.LambdaResourceState_UNKNOWN, aws.ToString(cfg.StateReason)
+	}
+}
+
+func (s *Store) WaitForReady(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-s.firstSyncedCh:
+		return err
+	}
+}
+
+func (s *Store) GetLambdaAppLiveState(appID string) (AppState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	app, ok := s.apps[appID]
+	if !ok {
+		return AppState{}, false
+	}
+	return app.state, true
+}
+
+func (s *Store) GetFunctionManifest(appID string) (provider.FunctionManifest, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	app, ok := s.apps[appID]
+	if !ok {
+		return provider.FunctionManifest{}, false
+	}
+	return app.functionManifest, true
+}
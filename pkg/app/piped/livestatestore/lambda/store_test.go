@@ -0,0 +1,198 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lambda
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/lambda"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type fakeClient struct {
+	provider.Client
+	functions map[string]*provider.Function
+	// The number of GetFunction calls for each function.
+	getCalls map[string]int
+}
+
+func (c *fakeClient) ListFunctions(_ context.Context) ([]types.FunctionConfiguration, error) {
+	out := make([]types.FunctionConfiguration, 0, len(c.functions))
+	for _, f := range c.functions {
+		out = append(out, f.Configuration)
+	}
+	return out, nil
+}
+
+func (c *fakeClient) GetFunction(_ context.Context, name string) (*provider.Function, error) {
+	c.getCalls[name]++
+	f, ok := c.functions[name]
+	if !ok {
+		return nil, provider.ErrNotFound
+	}
+	return f, nil
+}
+
+type fakeApplicationLister struct {
+	apps []*model.Application
+}
+
+func (l *fakeApplicationLister) List() []*model.Application {
+	return l.apps
+}
+
+func makeFunction(name, appID string, state types.State) *provider.Function {
+	f := &provider.Function{
+		Configuration: types.FunctionConfiguration{
+			FunctionArn:  aws.String("arn:aws:lambda:ap-northeast-1:123456789012:function:" + name),
+			FunctionName: aws.String(name),
+			RevisionId:   aws.String("rev-1"),
+			Role:         aws.String("arn:aws:iam::123456789012:role/lambda-role"),
+			MemorySize:   aws.Int32(128),
+			Timeout:      aws.Int32(5),
+			State:        state,
+			StateReason:  aws.String("reason of " + name),
+			LastModified: aws.String("2022-01-01T00:00:00.000+0000"),
+		},
+		ImageURI: "ecr.region.amazonaws.com/" + name + ":v0.0.1",
+	}
+	if appID != "" {
+		f.Tags = map[string]string{
+			provider.LabelManagedBy:   provider.ManagedByPiped,
+			provider.LabelApplication: appID,
+		}
+	}
+	return f
+}
+
+func TestStoreSync(t *testing.T) {
+	client := &fakeClient{
+		functions: map[string]*provider.Function{
+			"function-1": makeFunction("function-1", "app-1", types.StateActive),
+			"function-2": makeFunction("function-2", "app-2", types.StateFailed),
+			"function-3": makeFunction("function-3", "app-3", types.StateActive),
+			"unmanaged":  makeFunction("unmanaged", "", types.StateActive),
+		},
+		getCalls: make(map[string]int),
+	}
+	appLister := &fakeApplicationLister{
+		apps: []*model.Application{
+			{Id: "app-1", CloudProvider: "lambda"},
+			{Id: "app-2", CloudProvider: "lambda"},
+			// This application is handled by another cloud provider.
+			{Id: "app-3", CloudProvider: "another"},
+		},
+	}
+	s := NewStore(nil, "lambda", appLister, zap.NewNop())
+	s.client = client
+
+	err := s.sync(context.Background())
+	require.NoError(t, err)
+
+	state, ok := s.GetLambdaAppLiveState("app-1")
+	require.True(t, ok)
+	require.Len(t, state.Resources, 1)
+	assert.Equal(t, &model.LambdaResourceState{
+		Id:           "arn:aws:lambda:ap-northeast-1:123456789012:function:function-1",
+		Name:         "function-1",
+		Kind:         "Function",
+		HealthStatus: model.LambdaResourceState_HEALTHY,
+		UpdatedAt:    time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).Unix(),
+	}, state.Resources[0])
+
+	fm, ok := s.GetFunctionManifest("app-1")
+	require.True(t, ok)
+	assert.Equal(t, "function-1", fm.Spec.Name)
+	assert.Equal(t, "ecr.region.amazonaws.com/function-1:v0.0.1", fm.Spec.ImageURI)
+	assert.Empty(t, fm.Spec.Tags)
+
+	state, ok = s.GetLambdaAppLiveState("app-2")
+	require.True(t, ok)
+	assert.Equal(t, model.LambdaResourceState_OTHER, state.Resources[0].HealthStatus)
+	assert.Equal(t, "reason of function-2", state.Resources[0].HealthDescription)
+
+	_, ok = s.GetLambdaAppLiveState("app-3")
+	assert.False(t, ok)
+
+	// The unmanaged function should not be fetched again while its revision is unchanged.
+	require.NoError(t, s.sync(context.Background()))
+	assert.Equal(t, 2, client.getCalls["function-1"])
+	assert.Equal(t, 1, client.getCalls["unmanaged"])
+
+	client.functions["unmanaged"].Configuration.RevisionId = aws.String("rev-2")
+	require.NoError(t, s.sync(context.Background()))
+	assert.Equal(t, 2, client.getCalls["unmanaged"])
+}
+
+func TestDetermineHealthStatus(t *testing.T) {
+	testcases := []struct {
+		name     string
+		cfg      types.FunctionConfiguration
+		want     model.LambdaResourceState_HealthStatus
+		wantDesc string
+	}{
+		{
+			name: "active",
+			cfg: types.FunctionConfiguration{
+				State:            types.StateActive,
+				LastUpdateStatus: types.LastUpdateStatusSuccessful,
+			},
+			want: model.LambdaResourceState_HEALTHY,
+		},
+		{
+			name: "last update was failed",
+			cfg: types.FunctionConfiguration{
+				State:                  types.StateActive,
+				LastUpdateStatus:       types.LastUpdateStatusFailed,
+				LastUpdateStatusReason: aws.String("image not found"),
+			},
+			want:     model.LambdaResourceState_OTHER,
+			wantDesc: "image not found",
+		},
+		{
+			name: "inactive",
+			cfg: types.FunctionConfiguration{
+				State:       types.StateInactive,
+				StateReason: aws.String("idle"),
+			},
+			want:     model.LambdaResourceState_OTHER,
+			wantDesc: "idle",
+		},
+		{
+			name: "pending",
+			cfg: types.FunctionConfiguration{
+				State:       types.StatePending,
+				StateReason: aws.String("creating"),
+			},
+			want:     model.LambdaResourceState_UNKNOWN,
+			wantDesc: "creating",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, desc := determineHealthStatus(tc.cfg)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantDesc, desc)
+		})
+	}
+}
@@ -63,14 +63,17 @@ type terraformStore interface {
 
 type cloudRunStore interface {
 	Run(ctx context.Context) error
+	cloudrun.Getter
 }
 
 type lambdaStore interface {
 	Run(ctx context.Context) error
+	lambda.Getter
 }
 
 type ecsStore interface {
 	Run(ctx context.Context) error
+	ecs.Getter
 }
 
 // store manages a list of particular stores for all cloud providers.
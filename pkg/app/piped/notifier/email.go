@@ -0,0 +1,184 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+const emailSubjectPrefix = "[PipeCD]"
+
+type email struct {
+	name    string
+	config  config.NotificationReceiverEmail
+	webURL  string
+	auth    smtp.Auth
+	eventCh chan model.NotificationEvent
+	logger  *zap.Logger
+}
+
+func newEmailSender(name string, cfg config.NotificationReceiverEmail, webURL string, logger *zap.Logger) (*email, error) {
+	e := &email{
+		name:    name,
+		config:  cfg,
+		webURL:  strings.TrimRight(webURL, "/"),
+		eventCh: make(chan model.NotificationEvent, 100),
+		logger:  logger.Named("email").With(zap.String("name", name)),
+	}
+
+	if cfg.UsernameFile != "" {
+		username, err := os.ReadFile(cfg.UsernameFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the username file: %w", err)
+		}
+		password, err := os.ReadFile(cfg.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the password file: %w", err)
+		}
+		e.auth = smtp.PlainAuth("", strings.TrimSpace(string(username)), strings.TrimSpace(string(password)), cfg.Host)
+	}
+	return e, nil
+}
+
+func (e *email) Run(ctx context.Context) error {
+	for {
+		select {
+		case event, ok := <-e.eventCh:
+			if ok {
+				e.sendEvent(ctx, event)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (e *email) Notify(event model.NotificationEvent) {
+	e.eventCh <- event
+}
+
+func (e *email) Close(ctx context.Context) {
+	close(e.eventCh)
+
+	// Send all remaining events.
+	for {
+		select {
+		case event, ok := <-e.eventCh:
+			if !ok {
+				return
+			}
+			e.sendEvent(ctx, event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (e *email) sendEvent(_ context.Context, event model.NotificationEvent) {
+	msg, ok := buildMessage(event, e.webURL)
+	if !ok {
+		e.logger.Info(fmt.Sprintf("ignore event %s", event.Type.String()))
+		return
+	}
+
+	to := e.recipients(event)
+	if len(to) == 0 {
+		e.logger.Info(fmt.Sprintf("ignore event %s since there is no recipient", event.Type.String()))
+		return
+	}
+
+	body := makeEmailBody(e.config.From, to, msg, time.Now())
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	if err := smtp.SendMail(addr, e.auth, e.config.From, to, body); err != nil {
+		e.logger.Error(fmt.Sprintf("unable to send notification via email: %v", err))
+	}
+}
+
+// recipients returns the addresses configured in the receiver
+// and the ones mentioned for the event in the application configuration.
+func (e *email) recipients(event model.NotificationEvent) []string {
+	rs := make(map[string]struct{}, len(e.config.To))
+	for _, t := range e.config.To {
+		rs[t] = struct{}{}
+	}
+
+	if d := getDeployment(event); d != nil {
+		for _, m := range findMentionedEmails(d, event.Type, e.logger) {
+			rs[m] = struct{}{}
+		}
+	}
+
+	to := make([]string, 0, len(rs))
+	for r := range rs {
+		to = append(to, r)
+	}
+	sort.Strings(to)
+	return to
+}
+
+func findMentionedEmails(d *model.Deployment, event model.NotificationEventType, logger *zap.Logger) []string {
+	n, ok := d.Metadata[model.MetadataKeyDeploymentNotification]
+	if !ok {
+		return nil
+	}
+
+	var notification config.DeploymentNotification
+	if err := json.Unmarshal([]byte(n), &notification); err != nil {
+		logger.Error("could not extract mentions config", zap.Error(err))
+		return nil
+	}
+	return notification.FindEmails(event)
+}
+
+func makeEmailBody(from string, to []string, msg message, now time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s %s\r\n", emailSubjectPrefix, msg.Title)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n")
+	b.WriteString("\r\n")
+
+	if msg.Text != "" {
+		b.WriteString(msg.Text)
+		b.WriteString("\r\n\r\n")
+	}
+	for _, f := range msg.Fields {
+		if f.Value == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s\r\n", f.Title, f.Value)
+	}
+	if msg.Link != "" {
+		fmt.Fprintf(&b, "\r\n%s\r\n", msg.Link)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type receivedMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// startFakeSMTPServer starts an SMTP server that accepts only one message
+// without any authentication and sends it to the returned channel.
+func startFakeSMTPServer(t *testing.T) (string, int, <-chan receivedMail) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	ch := make(chan receivedMail, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		var mail receivedMail
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				tp.PrintfLine("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				mail.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				tp.PrintfLine("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				mail.to = append(mail.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+				tp.PrintfLine("250 OK")
+			case cmd == "DATA":
+				tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := tp.ReadDotLines()
+				if err != nil {
+					return
+				}
+				mail.data = strings.Join(data, "\n")
+				tp.PrintfLine("250 OK")
+			case cmd == "QUIT":
+				tp.PrintfLine("221 Bye")
+				ch <- mail
+				return
+			default:
+				tp.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	p, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return host, p, ch
+}
+
+func TestEmailSendEvent(t *testing.T) {
+	host, port, ch := startFakeSMTPServer(t)
+
+	cfg := config.NotificationReceiverEmail{
+		Host: host,
+		Port: port,
+		From: "pipecd@example.com",
+		To:   []string{"oncall@example.com"},
+	}
+	s, err := newEmailSender("email", cfg, "https://pipecd.dev", zap.NewNop())
+	require.NoError(t, err)
+
+	s.sendEvent(context.Background(), model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPLOYMENT_FAILED,
+		Metadata: &model.NotificationEventDeploymentFailed{
+			Deployment: &model.Deployment{
+				Id:              "deployment-1",
+				ApplicationId:   "app-1",
+				ApplicationName: "simple",
+				ProjectId:       "project-1",
+				Metadata: map[string]string{
+					model.MetadataKeyDeploymentNotification: `{"mentions":[{"event":"DEPLOYMENT_FAILED","email":["owner@example.com"]},{"event":"DEPLOYMENT_SUCCEEDED","email":["other@example.com"]}]}`,
+				},
+			},
+			EnvName: "prod",
+			Reason:  "unable to apply manifests",
+		},
+	})
+
+	var mail receivedMail
+	select {
+	case mail = <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the email to be received")
+	}
+	assert.Equal(t, "pipecd@example.com", mail.from)
+	assert.Equal(t, []string{"oncall@example.com", "owner@example.com"}, mail.to)
+
+	r := textproto.NewReader(bufio.NewReader(strings.NewReader(mail.data + "\n")))
+	header, err := r.ReadMIMEHeader()
+	require.NoError(t, err)
+	assert.Equal(t, "[PipeCD] Deployment for \"simple\" was failed", header.Get("Subject"))
+	assert.Equal(t, "oncall@example.com, owner@example.com", header.Get("To"))
+	assert.Contains(t, mail.data, "unable to apply manifests")
+	assert.Contains(t, mail.data, "https://pipecd.dev/deployments/deployment-1?project=project-1")
+}
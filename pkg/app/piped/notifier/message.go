@@ -0,0 +1,194 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type messageLevel int
+
+const (
+	messageLevelInfo messageLevel = iota
+	messageLevelSuccess
+	messageLevelWarn
+	messageLevelError
+)
+
+// message is a platform-agnostic representation of a notification event.
+// It is used by the senders that render the event as a plain card or document
+// instead of a platform-specific layout like Slack attachments.
+type message struct {
+	Title  string
+	Text   string
+	Link   string
+	Level  messageLevel
+	Fields []messageField
+}
+
+type messageField struct {
+	Title string
+	Value string
+}
+
+func buildMessage(event model.NotificationEvent, webURL string) (message, bool) {
+	var msg message
+
+	generateDeploymentEventData := func(d *model.Deployment, envName string) {
+		msg.Link = fmt.Sprintf("%s/deployments/%s?project=%s", webURL, d.Id, d.ProjectId)
+		msg.Fields = []messageField{
+			{"Project", d.ProjectId},
+			{"Application", d.ApplicationName},
+			{"Kind", strings.ToLower(d.Kind.String())},
+			{"Deployment", d.Id},
+			{"Env", envName},
+			{"Triggered By", d.TriggeredBy()},
+		}
+	}
+	generatePipedEventData := func(id, name, version, project string) {
+		msg.Link = fmt.Sprintf("%s/settings/piped?project=%s", webURL, project)
+		msg.Fields = []messageField{
+			{"Name", name},
+			{"Version", version},
+			{"Project", project},
+			{"Id", id},
+		}
+	}
+
+	switch event.Type {
+	case model.NotificationEventType_EVENT_DEPLOYMENT_TRIGGERED:
+		md := event.Metadata.(*model.NotificationEventDeploymentTriggered)
+		msg.Title = fmt.Sprintf("Triggered a new deployment for %q", md.Deployment.ApplicationName)
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_PLANNED:
+		md := event.Metadata.(*model.NotificationEventDeploymentPlanned)
+		msg.Title = fmt.Sprintf("Deployment for %q was planned", md.Deployment.ApplicationName)
+		msg.Text = md.Summary
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_WAIT_APPROVAL:
+		md := event.Metadata.(*model.NotificationEventDeploymentWaitApproval)
+		msg.Title = fmt.Sprintf("Deployment for %q is waiting for an approval", md.Deployment.ApplicationName)
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_APPROVED:
+		md := event.Metadata.(*model.NotificationEventDeploymentApproved)
+		msg.Title = fmt.Sprintf("Deployment for %q was approved", md.Deployment.ApplicationName)
+		msg.Text = fmt.Sprintf("Approved by %s", md.Approver)
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_SUCCEEDED:
+		md := event.Metadata.(*model.NotificationEventDeploymentSucceeded)
+		msg.Title = fmt.Sprintf("Deployment for %q was completed successfully", md.Deployment.ApplicationName)
+		msg.Level = messageLevelSuccess
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_FAILED:
+		md := event.Metadata.(*model.NotificationEventDeploymentFailed)
+		msg.Title = fmt.Sprintf("Deployment for %q was failed", md.Deployment.ApplicationName)
+		msg.Text = md.Reason
+		msg.Level = messageLevelError
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_CANCELLED:
+		md := event.Metadata.(*model.NotificationEventDeploymentCancelled)
+		msg.Title = fmt.Sprintf("Deployment for %q was cancelled", md.Deployment.ApplicationName)
+		msg.Text = fmt.Sprintf("Cancelled by %s", md.Commander)
+		msg.Level = messageLevelWarn
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_BLOCKED:
+		md := event.Metadata.(*model.NotificationEventDeploymentBlocked)
+		msg.Title = fmt.Sprintf("Deployment for %q is blocked by deploy windows", md.Deployment.ApplicationName)
+		msg.Text = md.Reason
+		msg.Level = messageLevelWarn
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_DEPLOY_WINDOW_OVERRIDDEN:
+		md := event.Metadata.(*model.NotificationEventDeploymentDeployWindowOverridden)
+		msg.Title = fmt.Sprintf("Deploy windows were overridden for deployment of %q", md.Deployment.ApplicationName)
+		msg.Text = makeDeployWindowOverriddenText(md.Commander, md.Reason)
+		msg.Level = messageLevelWarn
+		generateDeploymentEventData(md.Deployment, md.EnvName)
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_TRIGGER_FAILED:
+		md := event.Metadata.(*model.NotificationEventDeploymentTriggerFailed)
+		msg.Title = fmt.Sprintf("Failed to trigger a new deployment for %s", md.Application.Name)
+		msg.Text = md.Reason
+		msg.Link = fmt.Sprintf("%s/applications/%s?project=%s", webURL, md.Application.Id, md.Application.ProjectId)
+		msg.Fields = []messageField{
+			{"Project", md.Application.ProjectId},
+			{"Application", md.Application.Name},
+			{"Kind", strings.ToLower(md.Application.Kind.String())},
+			{"Commit", md.CommitHash},
+		}
+
+	case model.NotificationEventType_EVENT_PIPED_STARTED:
+		md := event.Metadata.(*model.NotificationEventPipedStarted)
+		msg.Title = "A piped has been started"
+		generatePipedEventData(md.Id, md.Name, md.Version, md.ProjectId)
+
+	case model.NotificationEventType_EVENT_PIPED_STOPPED:
+		md := event.Metadata.(*model.NotificationEventPipedStopped)
+		msg.Title = "A piped has been stopped"
+		generatePipedEventData(md.Id, md.Name, md.Version, md.ProjectId)
+
+	// TODO: Support application type of notification event.
+	default:
+		return message{}, false
+	}
+
+	return msg, true
+}
+
+// getDeployment returns the deployment that the given event is about.
+// Nil is returned if the event is not related to any deployment.
+func getDeployment(event model.NotificationEvent) *model.Deployment {
+	switch md := event.Metadata.(type) {
+	case *model.NotificationEventDeploymentTriggered:
+		return md.Deployment
+	case *model.NotificationEventDeploymentPlanned:
+		return md.Deployment
+	case *model.NotificationEventDeploymentWaitApproval:
+		return md.Deployment
+	case *model.NotificationEventDeploymentApproved:
+		return md.Deployment
+	case *model.NotificationEventDeploymentRollingBack:
+		return md.Deployment
+	case *model.NotificationEventDeploymentSucceeded:
+		return md.Deployment
+	case *model.NotificationEventDeploymentFailed:
+		return md.Deployment
+	case *model.NotificationEventDeploymentCancelled:
+		return md.Deployment
+	case *model.NotificationEventDeploymentBlocked:
+		return md.Deployment
+	case *model.NotificationEventDeploymentDeployWindowOverridden:
+		return md.Deployment
+	default:
+		return nil
+	}
+}
+
+func makeDeployWindowOverriddenText(commander, reason string) string {
+	if reason == "" {
+		return fmt.Sprintf("Overridden by %s", commander)
+	}
+	return fmt.Sprintf("Overridden by %s: %s", commander, reason)
+}
@@ -69,6 +69,20 @@ func NewNotifier(cfg *config.PipedSpec, logger *zap.Logger) (*Notifier, error) {
 			sd = newSlackSender(receiver.Name, *receiver.Slack, cfg.WebAddress, logger)
 		case receiver.Webhook != nil:
 			sd = newWebhookSender(receiver.Name, *receiver.Webhook, cfg.WebAddress, logger)
+		case receiver.Teams != nil:
+			sd = newTeamsSender(receiver.Name, *receiver.Teams, cfg.WebAddress, logger)
+		case receiver.Email != nil:
+			s, err := newEmailSender(receiver.Name, *receiver.Email, cfg.WebAddress, logger)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create email sender for receiver %s: %w", receiver.Name, err)
+			}
+			sd = s
+		case receiver.PagerDuty != nil:
+			s, err := newPagerDutySender(receiver.Name, *receiver.PagerDuty, cfg.WebAddress, logger)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create pagerduty sender for receiver %s: %w", receiver.Name, err)
+			}
+			sd = s
 		default:
 			continue
 		}
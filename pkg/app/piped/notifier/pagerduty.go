@@ -0,0 +1,211 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+const (
+	pagerDutyEventActionTrigger = "trigger"
+	pagerDutyEventActionResolve = "resolve"
+	pagerDutySource             = "pipecd"
+)
+
+// pagerDuty opens an incident via PagerDuty Events API v2 when a deployment failed
+// and resolves it once a later deployment of the same application succeeded.
+// All other events are ignored.
+type pagerDuty struct {
+	name       string
+	config     config.NotificationReceiverPagerDuty
+	routingKey string
+	webURL     string
+	httpClient *http.Client
+	eventCh    chan model.NotificationEvent
+	logger     *zap.Logger
+}
+
+func newPagerDutySender(name string, cfg config.NotificationReceiverPagerDuty, webURL string, logger *zap.Logger) (*pagerDuty, error) {
+	key, err := os.ReadFile(cfg.RoutingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the routing key file: %w", err)
+	}
+	return &pagerDuty{
+		name:       name,
+		config:     cfg,
+		routingKey: strings.TrimSpace(string(key)),
+		webURL:     strings.TrimRight(webURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		eventCh: make(chan model.NotificationEvent, 100),
+		logger:  logger.Named("pagerduty").With(zap.String("name", name)),
+	}, nil
+}
+
+func (p *pagerDuty) Run(ctx context.Context) error {
+	for {
+		select {
+		case event, ok := <-p.eventCh:
+			if ok {
+				p.sendEvent(ctx, event)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (p *pagerDuty) Notify(event model.NotificationEvent) {
+	p.eventCh <- event
+}
+
+func (p *pagerDuty) Close(ctx context.Context) {
+	close(p.eventCh)
+
+	// Send all remaining events.
+	for {
+		select {
+		case event, ok := <-p.eventCh:
+			if !ok {
+				return
+			}
+			p.sendEvent(ctx, event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *pagerDuty) sendEvent(ctx context.Context, event model.NotificationEvent) {
+	pe, ok := p.buildPagerDutyEvent(event)
+	if !ok {
+		p.logger.Debug(fmt.Sprintf("ignore event %s", event.Type.String()))
+		return
+	}
+	if err := p.sendPagerDutyEvent(ctx, pe); err != nil {
+		p.logger.Error(fmt.Sprintf("unable to send event to pagerduty: %v", err))
+	}
+}
+
+func (p *pagerDuty) buildPagerDutyEvent(event model.NotificationEvent) (pagerDutyEvent, bool) {
+	switch event.Type {
+	case model.NotificationEventType_EVENT_DEPLOYMENT_FAILED:
+		md := event.Metadata.(*model.NotificationEventDeploymentFailed)
+		d := md.Deployment
+		link := fmt.Sprintf("%s/deployments/%s?project=%s", p.webURL, d.Id, d.ProjectId)
+		return pagerDutyEvent{
+			RoutingKey:  p.routingKey,
+			EventAction: pagerDutyEventActionTrigger,
+			DedupKey:    makePagerDutyDedupKey(d),
+			Payload: &pagerDutyPayload{
+				Summary:   fmt.Sprintf("Deployment for %q was failed: %s", d.ApplicationName, truncateText(md.Reason, 512)),
+				Source:    pagerDutySource,
+				Severity:  "error",
+				Component: d.ApplicationName,
+				Group:     md.EnvName,
+				Class:     strings.ToLower(d.Kind.String()),
+				CustomDetails: map[string]string{
+					"project":     d.ProjectId,
+					"application": d.ApplicationId,
+					"deployment":  d.Id,
+					"reason":      md.Reason,
+				},
+			},
+			Links: []pagerDutyLink{{Href: link, Text: "Open in PipeCD"}},
+		}, true
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_SUCCEEDED:
+		md := event.Metadata.(*model.NotificationEventDeploymentSucceeded)
+		return pagerDutyEvent{
+			RoutingKey:  p.routingKey,
+			EventAction: pagerDutyEventActionResolve,
+			DedupKey:    makePagerDutyDedupKey(md.Deployment),
+		}, true
+
+	default:
+		return pagerDutyEvent{}, false
+	}
+}
+
+func (p *pagerDuty) sendPagerDutyEvent(ctx context.Context, event pagerDutyEvent) error {
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(event); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.EventsURL, buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		return fmt.Errorf("%s from PagerDuty: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// makePagerDutyDedupKey returns the key used to group the incidents of an application
+// so that the incident opened by a failed deployment can be resolved by a later one.
+func makePagerDutyDedupKey(d *model.Deployment) string {
+	return fmt.Sprintf("pipecd/%s/%s", d.ProjectId, d.ApplicationId)
+}
+
+// pagerDutyEvent represents an event of PagerDuty Events API v2.
+// See https://developer.pagerduty.com/docs/events-api-v2/trigger-events/
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Component     string            `json:"component,omitempty"`
+	Group         string            `json:"group,omitempty"`
+	Class         string            `json:"class,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
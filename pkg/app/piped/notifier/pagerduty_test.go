@@ -0,0 +1,105 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+func TestPagerDutySendEvent(t *testing.T) {
+	var events []pagerDutyEvent
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e pagerDutyEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+		events = append(events, e)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	keyFile := filepath.Join(t.TempDir(), "routing-key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("routing-key\n"), 0600))
+
+	cfg := config.NotificationReceiverPagerDuty{
+		RoutingKeyFile: keyFile,
+		EventsURL:      ts.URL,
+	}
+	s, err := newPagerDutySender("pagerduty", cfg, "https://pipecd.dev", zap.NewNop())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	s.sendEvent(ctx, model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPLOYMENT_FAILED,
+		Metadata: &model.NotificationEventDeploymentFailed{
+			Deployment: &model.Deployment{
+				Id:              "deployment-1",
+				ApplicationId:   "app-1",
+				ApplicationName: "simple",
+				ProjectId:       "project-1",
+			},
+			EnvName: "prod",
+			Reason:  "unable to apply manifests",
+		},
+	})
+	// Events other than DEPLOYMENT_FAILED and DEPLOYMENT_SUCCEEDED must be ignored.
+	s.sendEvent(ctx, model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPLOYMENT_TRIGGERED,
+		Metadata: &model.NotificationEventDeploymentTriggered{
+			Deployment: &model.Deployment{
+				Id:            "deployment-2",
+				ApplicationId: "app-1",
+				ProjectId:     "project-1",
+			},
+		},
+	})
+	s.sendEvent(ctx, model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPLOYMENT_SUCCEEDED,
+		Metadata: &model.NotificationEventDeploymentSucceeded{
+			Deployment: &model.Deployment{
+				Id:            "deployment-2",
+				ApplicationId: "app-1",
+				ProjectId:     "project-1",
+			},
+		},
+	})
+
+	require.Len(t, events, 2)
+
+	trigger := events[0]
+	assert.Equal(t, "routing-key", trigger.RoutingKey)
+	assert.Equal(t, pagerDutyEventActionTrigger, trigger.EventAction)
+	require.NotNil(t, trigger.Payload)
+	assert.Equal(t, "error", trigger.Payload.Severity)
+	assert.Equal(t, "simple", trigger.Payload.Component)
+	assert.Equal(t, "prod", trigger.Payload.Group)
+	assert.Equal(t, "unable to apply manifests", trigger.Payload.CustomDetails["reason"])
+
+	resolve := events[1]
+	assert.Equal(t, pagerDutyEventActionResolve, resolve.EventAction)
+	assert.Equal(t, trigger.DedupKey, resolve.DedupKey)
+	assert.Nil(t, resolve.Payload)
+}
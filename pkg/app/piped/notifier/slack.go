@@ -221,6 +221,20 @@ func (s *slack) buildSlackMessage(event model.NotificationEvent, webURL string)
 		color = slackWarnColor
 		generateDeploymentEventData(md.Deployment, md.EnvName, getAccountsAsString(md.MentionedAccounts))
 
+	case model.NotificationEventType_EVENT_DEPLOYMENT_BLOCKED:
+		md := event.Metadata.(*model.NotificationEventDeploymentBlocked)
+		title = fmt.Sprintf("Deployment for %q is blocked by deploy windows", md.Deployment.ApplicationName)
+		text = md.Reason
+		color = slackWarnColor
+		generateDeploymentEventData(md.Deployment, md.EnvName, getAccountsAsString(md.MentionedAccounts))
+
+	case model.NotificationEventType_EVENT_DEPLOYMENT_DEPLOY_WINDOW_OVERRIDDEN:
+		md := event.Metadata.(*model.NotificationEventDeploymentDeployWindowOverridden)
+		title = fmt.Sprintf("Deploy windows were overridden for deployment of %q", md.Deployment.ApplicationName)
+		text = makeDeployWindowOverriddenText(md.Commander, md.Reason)
+		color = slackWarnColor
+		generateDeploymentEventData(md.Deployment, md.EnvName, getAccountsAsString(md.MentionedAccounts))
+
 	case model.NotificationEventType_EVENT_DEPLOYMENT_TRIGGER_FAILED:
 		md := event.Metadata.(*model.NotificationEventDeploymentTriggerFailed)
 		title = fmt.Sprintf("Failed to trigger a new deployment for %s", md.Application.Name)
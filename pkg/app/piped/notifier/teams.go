@@ -0,0 +1,195 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+var teamsThemeColors = map[messageLevel]string{
+	messageLevelInfo:    "222429",
+	messageLevelSuccess: "629650",
+	messageLevelWarn:    "C1A337",
+	messageLevelError:   "9C3C31",
+}
+
+type teams struct {
+	name       string
+	config     config.NotificationReceiverTeams
+	webURL     string
+	httpClient *http.Client
+	eventCh    chan model.NotificationEvent
+	logger     *zap.Logger
+}
+
+func newTeamsSender(name string, cfg config.NotificationReceiverTeams, webURL string, logger *zap.Logger) *teams {
+	return &teams{
+		name:   name,
+		config: cfg,
+		webURL: strings.TrimRight(webURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		eventCh: make(chan model.NotificationEvent, 100),
+		logger:  logger.Named("teams").With(zap.String("name", name)),
+	}
+}
+
+func (t *teams) Run(ctx context.Context) error {
+	for {
+		select {
+		case event, ok := <-t.eventCh:
+			if ok {
+				t.sendEvent(ctx, event)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (t *teams) Notify(event model.NotificationEvent) {
+	t.eventCh <- event
+}
+
+func (t *teams) Close(ctx context.Context) {
+	close(t.eventCh)
+
+	// Send all remaining events.
+	for {
+		select {
+		case event, ok := <-t.eventCh:
+			if !ok {
+				return
+			}
+			t.sendEvent(ctx, event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (t *teams) sendEvent(ctx context.Context, event model.NotificationEvent) {
+	msg, ok := buildMessage(event, t.webURL)
+	if !ok {
+		t.logger.Info(fmt.Sprintf("ignore event %s", event.Type.String()))
+		return
+	}
+	if err := t.sendMessage(ctx, makeTeamsMessage(msg)); err != nil {
+		t.logger.Error(fmt.Sprintf("unable to send notification to teams: %v", err))
+	}
+}
+
+func (t *teams) sendMessage(ctx context.Context, msg teamsMessage) error {
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(msg); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", t.config.HookURL, buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+		return fmt.Errorf("%s from Teams: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// teamsMessage is the legacy actionable message card
+// accepted by the Incoming Webhook connector of Microsoft Teams.
+// See https://docs.microsoft.com/en-us/outlook/actionable-messages/message-card-reference
+type teamsMessage struct {
+	Type            string         `json:"@type"`
+	Context         string         `json:"@context"`
+	Summary         string         `json:"summary"`
+	ThemeColor      string         `json:"themeColor,omitempty"`
+	Title           string         `json:"title"`
+	Text            string         `json:"text,omitempty"`
+	Sections        []teamsSection `json:"sections,omitempty"`
+	PotentialAction []teamsAction  `json:"potentialAction,omitempty"`
+}
+
+type teamsSection struct {
+	Facts []teamsFact `json:"facts"`
+}
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type teamsAction struct {
+	Type    string              `json:"@type"`
+	Name    string              `json:"name"`
+	Targets []teamsActionTarget `json:"targets"`
+}
+
+type teamsActionTarget struct {
+	OS  string `json:"os"`
+	URI string `json:"uri"`
+}
+
+func makeTeamsMessage(msg message) teamsMessage {
+	facts := make([]teamsFact, 0, len(msg.Fields))
+	for _, f := range msg.Fields {
+		if f.Value == "" {
+			continue
+		}
+		facts = append(facts, teamsFact{Name: f.Title, Value: f.Value})
+	}
+
+	tm := teamsMessage{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		Summary:    msg.Title,
+		ThemeColor: teamsThemeColors[msg.Level],
+		Title:      msg.Title,
+		Text:       msg.Text,
+	}
+	if len(facts) > 0 {
+		tm.Sections = []teamsSection{{Facts: facts}}
+	}
+	if msg.Link != "" {
+		tm.PotentialAction = []teamsAction{{
+			Type:    "OpenUri",
+			Name:    "Open in PipeCD",
+			Targets: []teamsActionTarget{{OS: "default", URI: msg.Link}},
+		}}
+	}
+	return tm
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+func TestTeamsSendEvent(t *testing.T) {
+	var got teamsMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	s := newTeamsSender("teams", config.NotificationReceiverTeams{HookURL: ts.URL}, "https://pipecd.dev/", zap.NewNop())
+	s.sendEvent(context.Background(), model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_DEPLOYMENT_FAILED,
+		Metadata: &model.NotificationEventDeploymentFailed{
+			Deployment: &model.Deployment{
+				Id:              "deployment-1",
+				ApplicationId:   "app-1",
+				ApplicationName: "simple",
+				ProjectId:       "project-1",
+				Kind:            model.ApplicationKind_KUBERNETES,
+			},
+			EnvName: "prod",
+			Reason:  "unable to apply manifests",
+		},
+	})
+
+	assert.Equal(t, "MessageCard", got.Type)
+	assert.Equal(t, "Deployment for \"simple\" was failed", got.Title)
+	assert.Equal(t, "unable to apply manifests", got.Text)
+	assert.Equal(t, teamsThemeColors[messageLevelError], got.ThemeColor)
+	require.Len(t, got.Sections, 1)
+	assert.Contains(t, got.Sections[0].Facts, teamsFact{Name: "Env", Value: "prod"})
+	require.Len(t, got.PotentialAction, 1)
+	assert.Equal(t, "https://pipecd.dev/deployments/deployment-1?project=project-1", got.PotentialAction[0].Targets[0].URI)
+}
+
+func TestTeamsSendEventIgnoreUnsupported(t *testing.T) {
+	called := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer ts.Close()
+
+	s := newTeamsSender("teams", config.NotificationReceiverTeams{HookURL: ts.URL}, "https://pipecd.dev", zap.NewNop())
+	s.sendEvent(context.Background(), model.NotificationEvent{
+		Type: model.NotificationEventType_EVENT_APPLICATION_SYNCED,
+	})
+	assert.False(t, called)
+}
@@ -95,9 +95,7 @@ func buildProgressivePipeline(pp *config.DeploymentPipeline, autoRollback bool,
 			CreatedAt:  now.Unix(),
 			UpdatedAt:  now.Unix(),
 		}
-		if preStageID != "" {
-			stage.Requires = []string{preStageID}
-		}
+		stage.Requires = planner.MakeStageRequires(s, preStageID)
 		preStageID = id
 		out = append(out, stage)
 	}
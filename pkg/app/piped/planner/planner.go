@@ -21,6 +21,7 @@ package planner
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
 
 	"go.uber.org/zap"
@@ -65,12 +66,46 @@ type Output struct {
 
 // MakeInitialStageMetadata makes the initial metadata for the given state configuration.
 func MakeInitialStageMetadata(cfg config.PipelineStage) map[string]string {
+	var md map[string]string
 	switch cfg.Name {
 	case model.StageWaitApproval:
-		return map[string]string{
-			"Approvers": strings.Join(cfg.WaitApprovalStageOptions.Approvers, ","),
+		md = makeApprovalMetadata(cfg.WaitApprovalStageOptions)
+	case model.StageAnalysis:
+		// The approvers are needed for a manual decision on the marginal score.
+		if cfg.AnalysisStageOptions != nil && cfg.AnalysisStageOptions.Scoring != nil {
+			md = makeApprovalMetadata(&cfg.AnalysisStageOptions.Scoring.Approval)
 		}
-	default:
-		return nil
 	}
+	// Control-plane uses this to reject a SKIP_STAGE command for the stage not configured as skippable.
+	if cfg.Skippable {
+		if md == nil {
+			md = make(map[string]string, 1)
+		}
+		md[model.MetadataKeyStageSkippable] = "true"
+	}
+	return md
+}
+
+// makeApprovalMetadata makes the metadata used by control-plane
+// to decide who can approve or reject the stage.
+func makeApprovalMetadata(opts *config.WaitApprovalStageOptions) map[string]string {
+	md := map[string]string{
+		"Approvers": strings.Join(opts.Approvers, ","),
+	}
+	if len(opts.ApproverGroups) > 0 {
+		// The groups were already validated while loading the configuration
+		// so marshaling them should never fail.
+		if data, err := json.Marshal(opts.ApproverGroups); err == nil {
+			md["ApproverGroups"] = string(data)
+		}
+	}
+	if opts.PreventSelfApproval {
+		md["PreventSelfApproval"] = "true"
+		if len(opts.CommitAuthors) > 0 {
+			if data, err := json.Marshal(opts.CommitAuthors); err == nil {
+				md["CommitAuthors"] = string(data)
+			}
+		}
+	}
+	return md
 }
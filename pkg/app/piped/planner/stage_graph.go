@@ -0,0 +1,93 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+// MakeStageRequires returns the IDs of the stages required by the given stage configuration.
+// When the configuration does not specify them, the stage requires the previous one.
+func MakeStageRequires(cfg config.PipelineStage, preStageID string) []string {
+	if cfg.Requires != nil {
+		return cfg.Requires
+	}
+	if preStageID == "" {
+		return nil
+	}
+	return []string{preStageID}
+}
+
+// ValidateStageGraph checks that all stages required by the given stages exist
+// and that there is no cycle in the graph built from their requirements.
+func ValidateStageGraph(stages []*model.PipelineStage) error {
+	index := make(map[string]*model.PipelineStage, len(stages))
+	for _, s := range stages {
+		index[s.Id] = s
+	}
+	for _, s := range stages {
+		for _, r := range s.Requires {
+			if _, ok := index[r]; !ok {
+				return fmt.Errorf("stage %s requires an unknown stage %s", s.Id, r)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	var (
+		states = make(map[string]int, len(stages))
+		path   = make([]string, 0, len(stages))
+		visit  func(id string) error
+	)
+	visit = func(id string) error {
+		switch states[id] {
+		case visited:
+			return nil
+		case visiting:
+			for i := range path {
+				if path[i] == id {
+					return fmt.Errorf("found a cycle in the stage requirements: %s", strings.Join(append(path[i:], id), " -> "))
+				}
+			}
+			return fmt.Errorf("found a cycle in the stage requirements at stage %s", id)
+		}
+
+		states[id] = visiting
+		path = append(path, id)
+		for _, r := range index[id].Requires {
+			if err := visit(r); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		states[id] = visited
+		return nil
+	}
+
+	for _, s := range stages {
+		if err := visit(s.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
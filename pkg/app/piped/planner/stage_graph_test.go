@@ -0,0 +1,121 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+func TestMakeStageRequires(t *testing.T) {
+	testcases := []struct {
+		name       string
+		cfg        config.PipelineStage
+		preStageID string
+		want       []string
+	}{
+		{
+			name: "first stage",
+			cfg:  config.PipelineStage{},
+			want: nil,
+		},
+		{
+			name:       "requires the previous stage by default",
+			cfg:        config.PipelineStage{},
+			preStageID: "stage-0",
+			want:       []string{"stage-0"},
+		},
+		{
+			name:       "specified requirements",
+			cfg:        config.PipelineStage{Requires: []string{"stage-0", "stage-1"}},
+			preStageID: "stage-2",
+			want:       []string{"stage-0", "stage-1"},
+		},
+		{
+			name:       "specified no requirement",
+			cfg:        config.PipelineStage{Requires: []string{}},
+			preStageID: "stage-2",
+			want:       []string{},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := MakeStageRequires(tc.cfg, tc.preStageID)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestValidateStageGraph(t *testing.T) {
+	testcases := []struct {
+		name    string
+		stages  []*model.PipelineStage
+		wantErr bool
+	}{
+		{
+			name: "sequential stages",
+			stages: []*model.PipelineStage{
+				{Id: "a"},
+				{Id: "b", Requires: []string{"a"}},
+				{Id: "c", Requires: []string{"b"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "parallel stages",
+			stages: []*model.PipelineStage{
+				{Id: "a"},
+				{Id: "b", Requires: []string{"a"}},
+				{Id: "c", Requires: []string{"a"}},
+				{Id: "d", Requires: []string{"b", "c"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown required stage",
+			stages: []*model.PipelineStage{
+				{Id: "a"},
+				{Id: "b", Requires: []string{"x"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "cyclic requirements",
+			stages: []*model.PipelineStage{
+				{Id: "a", Requires: []string{"c"}},
+				{Id: "b", Requires: []string{"a"}},
+				{Id: "c", Requires: []string{"b"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "self requirement",
+			stages: []*model.PipelineStage{
+				{Id: "a", Requires: []string{"a"}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateStageGraph(tc.stages)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -239,12 +239,15 @@ func (b *builder) buildApp(ctx context.Context, worker int, command string, app
 		b.secretDecrypter,
 	)
 
-	strategy, err := b.plan(ctx, app, targetDSP, preCommit)
+	strategy, stages, err := b.plan(ctx, app, targetDSP, preCommit)
 	if err != nil {
 		r.Error = fmt.Sprintf("failed while planning, %v", err)
 		return r
 	}
 	r.SyncStrategy = strategy
+	if strategy == model.SyncStrategy_PIPELINE {
+		r.PipelineStages = stageNames(stages)
+	}
 
 	logger.Info("successfully decided sync strategy for a application", zap.String("strategy", strategy.String()))
 
@@ -256,6 +259,12 @@ func (b *builder) buildApp(ctx context.Context, worker int, command string, app
 		dr, err = b.kubernetesDiff(ctx, app, targetDSP, preCommit, &buf)
 	case model.ApplicationKind_TERRAFORM:
 		dr, err = b.terraformDiff(ctx, app, targetDSP, &buf)
+	case model.ApplicationKind_ECS:
+		dr, err = b.ecsDiff(ctx, app, targetDSP, preCommit, &buf)
+	case model.ApplicationKind_CLOUDRUN:
+		dr, err = b.cloudrunDiff(ctx, app, targetDSP, preCommit, &buf)
+	case model.ApplicationKind_LAMBDA:
+		dr, err = b.lambdaDiff(ctx, app, targetDSP, preCommit, &buf)
 	default:
 		// TODO: Calculating planpreview's diff for other application kinds.
 		dr = &diffResult{
@@ -339,7 +348,7 @@ func (b *builder) findTriggerApps(ctx context.Context, repo git.Repo, apps []*mo
 	return
 }
 
-func (b *builder) plan(ctx context.Context, app *model.Application, targetDSP deploysource.Provider, lastSuccessfulCommit string) (strategy model.SyncStrategy, err error) {
+func (b *builder) plan(ctx context.Context, app *model.Application, targetDSP deploysource.Provider, lastSuccessfulCommit string) (strategy model.SyncStrategy, stages []*model.PipelineStage, err error) {
 	p, ok := defaultPlannerRegistry.Planner(app.Kind)
 	if !ok {
 		err = fmt.Errorf("application kind %s is not supported yet", app.Kind.String())
@@ -380,9 +389,24 @@ func (b *builder) plan(ctx context.Context, app *model.Application, targetDSP de
 	}
 
 	strategy = out.SyncStrategy
+	stages = out.Stages
 	return
 }
 
+// stageNames returns the names of the visible stages in the given pipeline.
+// The invisible ones such as the rollback stage are excluded
+// since they are executed only when the deployment failed.
+func stageNames(stages []*model.PipelineStage) []string {
+	names := make([]string, 0, len(stages))
+	for _, s := range stages {
+		if !s.Visible {
+			continue
+		}
+		names = append(names, s.Name)
+	}
+	return names
+}
+
 func (b *builder) listApplications(repo config.PipedRepository) []*model.Application {
 	apps := b.applicationLister.List()
 	out := make([]*model.Application, 0, len(apps))
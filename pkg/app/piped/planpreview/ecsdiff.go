@@ -0,0 +1,143 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planpreview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/ecs"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/deploysource"
+	"github.com/pipe-cd/pipecd/pkg/diff"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+type ecsDefinitions struct {
+	serviceDefinitionFile string
+	serviceDefinition     types.Service
+	taskDefinitionFile    string
+	taskDefinition        types.TaskDefinition
+}
+
+func (b *builder) ecsDiff(
+	ctx context.Context,
+	app *model.Application,
+	targetDSP deploysource.Provider,
+	lastSuccessfulCommit string,
+	buf *bytes.Buffer,
+) (*diffResult, error) {
+
+	var oldDefs, newDefs ecsDefinitions
+	var err error
+
+	newDefs, err = loadECSDefinitions(ctx, targetDSP)
+	if err != nil {
+		fmt.Fprintf(buf, "failed to load ecs definitions at the head commit (%v)\n", err)
+		return nil, err
+	}
+
+	if lastSuccessfulCommit != "" {
+		runningDSP := deploysource.NewProvider(
+			b.workingDir,
+			deploysource.NewGitSourceCloner(b.gitClient, b.repoCfg, "running", lastSuccessfulCommit),
+			*app.GitPath,
+			b.secretDecrypter,
+		)
+		oldDefs, err = loadECSDefinitions(ctx, runningDSP)
+		if err != nil {
+			fmt.Fprintf(buf, "failed to load ecs definitions at the running commit (%v)\n", err)
+			return nil, err
+		}
+	}
+
+	opts := []diff.Option{
+		diff.WithEquateEmpty(),
+		diff.WithCompareNumberAndNumericString(),
+	}
+	serviceResult, err := provider.DiffServiceDefinitions(oldDefs.serviceDefinition, newDefs.serviceDefinition, opts...)
+	if err != nil {
+		fmt.Fprintf(buf, "failed to compare service definitions (%v)\n", err)
+		return nil, err
+	}
+	taskDefResult, err := provider.DiffTaskDefinitions(oldDefs.taskDefinition, newDefs.taskDefinition, opts...)
+	if err != nil {
+		fmt.Fprintf(buf, "failed to compare task definitions (%v)\n", err)
+		return nil, err
+	}
+
+	if serviceResult.NoChange() && taskDefResult.NoChange() {
+		fmt.Fprintln(buf, "No changes were detected")
+		return &diffResult{
+			summary:  "No changes were detected",
+			noChange: true,
+		}, nil
+	}
+
+	var (
+		changedFiles []string
+		details      strings.Builder
+		renderOpts   = provider.DiffRenderOptions{
+			UseDiffCommand: true,
+		}
+	)
+	if !serviceResult.NoChange() {
+		changedFiles = append(changedFiles, newDefs.serviceDefinitionFile)
+		fmt.Fprintf(&details, "# %s\n%s\n", newDefs.serviceDefinitionFile, serviceResult.Render(renderOpts))
+	}
+	if !taskDefResult.NoChange() {
+		changedFiles = append(changedFiles, newDefs.taskDefinitionFile)
+		fmt.Fprintf(&details, "# %s\n%s\n", newDefs.taskDefinitionFile, taskDefResult.Render(renderOpts))
+	}
+
+	summary := fmt.Sprintf("Changes were detected in %s", strings.Join(changedFiles, " and "))
+	fmt.Fprintf(buf, "--- Last Deploy\n+++ Head Commit\n\n%s", details.String())
+
+	return &diffResult{
+		summary: summary,
+	}, nil
+}
+
+func loadECSDefinitions(ctx context.Context, dsp deploysource.Provider) (ecsDefinitions, error) {
+	ds, err := dsp.Get(ctx, io.Discard)
+	if err != nil {
+		return ecsDefinitions{}, err
+	}
+
+	appCfg := ds.ApplicationConfig.ECSApplicationSpec
+	if appCfg == nil {
+		return ecsDefinitions{}, fmt.Errorf("malformed application configuration file")
+	}
+
+	serviceDefinition, err := provider.LoadServiceDefinition(ds.AppDir, appCfg.Input.ServiceDefinitionFile)
+	if err != nil {
+		return ecsDefinitions{}, err
+	}
+	taskDefinition, err := provider.LoadTaskDefinition(ds.AppDir, appCfg.Input.TaskDefinitionFile)
+	if err != nil {
+		return ecsDefinitions{}, err
+	}
+
+	return ecsDefinitions{
+		serviceDefinitionFile: appCfg.Input.ServiceDefinitionFile,
+		serviceDefinition:     serviceDefinition,
+		taskDefinitionFile:    appCfg.Input.TaskDefinitionFile,
+		taskDefinition:        taskDefinition,
+	}, nil
+}
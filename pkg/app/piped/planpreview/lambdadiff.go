@@ -0,0 +1,105 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planpreview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	provider "github.com/pipe-cd/pipecd/pkg/app/piped/cloudprovider/lambda"
+	"github.com/pipe-cd/pipecd/pkg/app/piped/deploysource"
+	"github.com/pipe-cd/pipecd/pkg/diff"
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+func (b *builder) lambdaDiff(
+	ctx context.Context,
+	app *model.Application,
+	targetDSP deploysource.Provider,
+	lastSuccessfulCommit string,
+	buf *bytes.Buffer,
+) (*diffResult, error) {
+
+	var oldManifest provider.FunctionManifest
+
+	newManifest, filename, err := loadLambdaFunctionManifest(ctx, targetDSP)
+	if err != nil {
+		fmt.Fprintf(buf, "failed to load function manifest at the head commit (%v)\n", err)
+		return nil, err
+	}
+
+	if lastSuccessfulCommit != "" {
+		runningDSP := deploysource.NewProvider(
+			b.workingDir,
+			deploysource.NewGitSourceCloner(b.gitClient, b.repoCfg, "running", lastSuccessfulCommit),
+			*app.GitPath,
+			b.secretDecrypter,
+		)
+		oldManifest, _, err = loadLambdaFunctionManifest(ctx, runningDSP)
+		if err != nil {
+			fmt.Fprintf(buf, "failed to load function manifest at the running commit (%v)\n", err)
+			return nil, err
+		}
+	}
+
+	result, err := provider.Diff(
+		oldManifest,
+		newManifest,
+		diff.WithEquateEmpty(),
+		diff.WithCompareNumberAndNumericString(),
+	)
+	if err != nil {
+		fmt.Fprintf(buf, "failed to compare function manifests (%v)\n", err)
+		return nil, err
+	}
+
+	if result.NoChange() {
+		fmt.Fprintln(buf, "No changes were detected")
+		return &diffResult{
+			summary:  "No changes were detected",
+			noChange: true,
+		}, nil
+	}
+
+	details := result.Render(provider.DiffRenderOptions{
+		UseDiffCommand: true,
+	})
+	fmt.Fprintf(buf, "--- Last Deploy\n+++ Head Commit\n\n# %s\n%s\n", filename, details)
+
+	return &diffResult{
+		summary: fmt.Sprintf("Changes were detected in %s", filename),
+	}, nil
+}
+
+func loadLambdaFunctionManifest(ctx context.Context, dsp deploysource.Provider) (provider.FunctionManifest, string, error) {
+	ds, err := dsp.Get(ctx, io.Discard)
+	if err != nil {
+		return provider.FunctionManifest{}, "", err
+	}
+
+	appCfg := ds.ApplicationConfig.LambdaApplicationSpec
+	if appCfg == nil {
+		return provider.FunctionManifest{}, "", fmt.Errorf("malformed application configuration file")
+	}
+
+	filename := appCfg.Input.FunctionManifestFile
+	manifest, err := provider.LoadFunctionManifest(ds.AppDir, filename)
+	if err != nil {
+		return provider.FunctionManifest{}, "", err
+	}
+	return manifest, filename, nil
+}
@@ -45,10 +45,12 @@ func buildDeployment(
 	branch string,
 	commit git.Commit,
 	commander string,
+	triggerKind model.TriggerKind,
 	syncStrategy model.SyncStrategy,
 	strategySummary string,
 	now time.Time,
 	noti *config.DeploymentNotification,
+	deployWindows *config.DeployWindows,
 	deploymentChainID string,
 	deploymentChainBlockIndex uint32,
 ) (*model.Deployment, error) {
@@ -70,6 +72,13 @@ func buildDeployment(
 		}
 		metadata[model.MetadataKeyDeploymentNotification] = string(value)
 	}
+	if deployWindows != nil && !deployWindows.IsEmpty() {
+		value, err := json.Marshal(deployWindows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save deploy windows config to deployment metadata: %w", err)
+		}
+		metadata[model.MetadataKeyDeployWindows] = string(value)
+	}
 
 	deployment := &model.Deployment{
 		Id:              uuid.New().String(),
@@ -89,6 +98,7 @@ func buildDeployment(
 				CreatedAt: int64(commit.CreatedAt),
 			},
 			Commander:       commander,
+			Kind:            triggerKind,
 			Timestamp:       now.Unix(),
 			SyncStrategy:    syncStrategy,
 			StrategySummary: strategySummary,
@@ -84,12 +84,18 @@ func (d *OnChainDeterminer) ShouldTrigger(_ context.Context, _ *model.Applicatio
 }
 
 type OnOutOfSyncDeterminer struct {
-	client apiClient
+	client        apiClient
+	headCommit    string
+	deployWindows []*config.DeployWindows
 }
 
-func NewOnOutOfSyncDeterminer(client apiClient) *OnOutOfSyncDeterminer {
+// NewOnOutOfSyncDeterminer creates a determiner which does not trigger
+// any deployment outside of the given deploy windows.
+func NewOnOutOfSyncDeterminer(client apiClient, headCommit string, deployWindows ...*config.DeployWindows) *OnOutOfSyncDeterminer {
 	return &OnOutOfSyncDeterminer{
-		client: client,
+		client:        client,
+		headCommit:    headCommit,
+		deployWindows: deployWindows,
 	}
 }
 
@@ -99,6 +105,26 @@ func (d *OnOutOfSyncDeterminer) ShouldTrigger(ctx context.Context, app *model.Ap
 		return false, nil
 	}
 
+	// The drift of the application already running the head commit is caused by
+	// changes made directly to the running resources, and it is reverted by self-heal.
+	// So only the changes not yet deployed should trigger a new deployment.
+	if appCfg.Trigger.OnOutOfSync.SelfHeal.Enabled {
+		if s := app.MostRecentlySuccessfulDeployment; s != nil && s.Trigger.GetCommit().GetHash() == d.headCommit {
+			return false, nil
+		}
+	}
+
+	// Unlike the other triggers, no deployment should be triggered to wait for the next
+	// deploy window since the out of sync state will be detected again after that.
+	windows := append(append([]*config.DeployWindows{}, d.deployWindows...), appCfg.DeployWindows)
+	allowed, _, err := config.CheckDeployWindows(time.Now(), windows...)
+	if err != nil {
+		return false, err
+	}
+	if !allowed {
+		return false, nil
+	}
+
 	// Find the most recently triggered deployment.
 	// Nil means it seems the application has been added recently
 	// and no deployment was triggered yet.
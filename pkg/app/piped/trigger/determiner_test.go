@@ -15,9 +15,14 @@
 package trigger
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipecd/pkg/config"
+	"github.com/pipe-cd/pipecd/pkg/model"
 )
 
 func TestIsTouchedByChangedFiles(t *testing.T) {
@@ -77,3 +82,64 @@ func TestIsTouchedByChangedFiles(t *testing.T) {
 		})
 	}
 }
+
+func TestOnOutOfSyncDeterminerWithSelfHeal(t *testing.T) {
+	disabled := false
+	makeApp := func(deployedCommit string) *model.Application {
+		return &model.Application{
+			Id: "app-id",
+			MostRecentlySuccessfulDeployment: &model.ApplicationDeploymentReference{
+				DeploymentId: "deployment-id",
+				Trigger: &model.DeploymentTrigger{
+					Commit: &model.Commit{Hash: deployedCommit},
+				},
+			},
+		}
+	}
+	makeAppCfg := func(selfHeal bool) *config.GenericApplicationSpec {
+		return &config.GenericApplicationSpec{
+			Trigger: config.Trigger{
+				OnOutOfSync: config.OnOutOfSync{
+					Disabled: &disabled,
+					SelfHeal: config.SelfHeal{Enabled: selfHeal},
+				},
+			},
+		}
+	}
+
+	testcases := []struct {
+		name     string
+		app      *model.Application
+		appCfg   *config.GenericApplicationSpec
+		expected bool
+	}{
+		{
+			name:     "not triggered because the drift of the head commit is self-healed",
+			app:      makeApp("head"),
+			appCfg:   makeAppCfg(true),
+			expected: false,
+		},
+		{
+			name:     "triggered because the head commit has not been deployed yet",
+			app:      makeApp("previous"),
+			appCfg:   makeAppCfg(true),
+			expected: true,
+		},
+		{
+			name:     "triggered because self-heal is disabled",
+			app:      makeApp("head"),
+			appCfg:   makeAppCfg(false),
+			expected: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			// No deployment was triggered for the application,
+			// so the api client is never called.
+			d := NewOnOutOfSyncDeterminer(nil, "head")
+			got, err := d.ShouldTrigger(context.Background(), tc.app, tc.appCfg)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -64,6 +64,10 @@ type environmentLister interface {
 	Get(ctx context.Context, id string) (*model.Environment, error)
 }
 
+type deployWindowLister interface {
+	Get() *config.DeployWindows
+}
+
 type notifier interface {
 	Notify(event model.NotificationEvent)
 }
@@ -79,17 +83,18 @@ func (c *candidate) HasCommand() bool {
 }
 
 type Trigger struct {
-	apiClient         apiClient
-	gitClient         gitClient
-	applicationLister applicationLister
-	commandLister     commandLister
-	environmentLister environmentLister
-	notifier          notifier
-	config            *config.PipedSpec
-	commitStore       *lastTriggeredCommitStore
-	gitRepos          map[string]git.Repo
-	gracePeriod       time.Duration
-	logger            *zap.Logger
+	apiClient          apiClient
+	gitClient          gitClient
+	applicationLister  applicationLister
+	commandLister      commandLister
+	environmentLister  environmentLister
+	deployWindowLister deployWindowLister
+	notifier           notifier
+	config             *config.PipedSpec
+	commitStore        *lastTriggeredCommitStore
+	gitRepos           map[string]git.Repo
+	gracePeriod        time.Duration
+	logger             *zap.Logger
 }
 
 func NewTrigger(
@@ -98,6 +103,7 @@ func NewTrigger(
 	appLister applicationLister,
 	commandLister commandLister,
 	environmentLister environmentLister,
+	deployWindowLister deployWindowLister,
 	notifier notifier,
 	cfg *config.PipedSpec,
 	gracePeriod time.Duration,
@@ -114,17 +120,18 @@ func NewTrigger(
 	}
 
 	t := &Trigger{
-		apiClient:         apiClient,
-		gitClient:         gitClient,
-		applicationLister: appLister,
-		commandLister:     commandLister,
-		environmentLister: environmentLister,
-		notifier:          notifier,
-		config:            cfg,
-		commitStore:       commitStore,
-		gitRepos:          make(map[string]git.Repo, len(cfg.Repositories)),
-		gracePeriod:       gracePeriod,
-		logger:            logger.Named("trigger"),
+		apiClient:          apiClient,
+		gitClient:          gitClient,
+		applicationLister:  appLister,
+		commandLister:      commandLister,
+		environmentLister:  environmentLister,
+		deployWindowLister: deployWindowLister,
+		notifier:           notifier,
+		config:             cfg,
+		commitStore:        commitStore,
+		gitRepos:           make(map[string]git.Repo, len(cfg.Repositories)),
+		gracePeriod:        gracePeriod,
+		logger:             logger.Named("trigger"),
 	}
 
 	return t, nil
@@ -212,7 +219,7 @@ func (t *Trigger) checkRepoCandidates(ctx context.Context, repoID string, cs []c
 
 	ds := &determiners{
 		onCommand:   NewOnCommandDeterminer(),
-		onOutOfSync: NewOnOutOfSyncDeterminer(t.apiClient),
+		onOutOfSync: NewOnOutOfSyncDeterminer(t.apiClient, headCommit.Hash, t.deployWindowLister.Get(), t.config.DeployWindows),
 		onCommit:    NewOnCommitDeterminer(gitRepo, headCommit.Hash, t.commitStore, t.logger),
 		onChain:     NewOnChainDeterminer(),
 	}
@@ -295,10 +302,12 @@ func (t *Trigger) checkRepoCandidates(ctx context.Context, repoID string, cs []c
 			branch,
 			headCommit,
 			commander,
+			c.kind,
 			strategy,
 			strategySummary,
 			time.Now(),
 			appCfg.DeploymentNotification,
+			appCfg.DeployWindows,
 			deploymentChainID,
 			deploymentChainBlockIndex,
 		)
@@ -24,9 +24,9 @@ import (
 )
 
 type Store interface {
-	// GetLatestAnalysisResult gives back the most recent successful analysis result of the specified application.
+	// GetLatestAnalysisResult gives back the most recent analysis result of the specified application.
 	GetLatestAnalysisResult(ctx context.Context, applicationID string) (*model.AnalysisResult, error)
-	// PutStateSnapshot updates the most recent successful analysis result of the specified application.
+	// PutStateSnapshot updates the most recent analysis result of the specified application.
 	PutLatestAnalysisResult(ctx context.Context, applicationID string, snapshot *model.AnalysisResult) error
 }
 
@@ -330,6 +330,62 @@ func (a *API) GetDeployment(ctx context.Context, req *apiservice.GetDeploymentRe
 	}, nil
 }
 
+func (a *API) RetryStage(ctx context.Context, req *apiservice.RetryStageRequest) (*apiservice.RetryStageResponse, error) {
+	key, err := requireAPIKey(ctx, model.APIKey_READ_WRITE, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := getDeployment(ctx, a.deploymentStore, req.DeploymentId, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.ProjectId != deployment.ProjectId {
+		return nil, status.Error(codes.InvalidArgument, "Requested deployment does not belong to your project")
+	}
+	if err := validateRecoverableStage(deployment, req.StageId, model.Command_RETRY_STAGE); err != nil {
+		return nil, err
+	}
+
+	cmd := makeStageRecoveryCommand(deployment, req.StageId, key.Id, model.Command_RETRY_STAGE)
+	if err := addCommand(ctx, a.commandStore, cmd, a.logger); err != nil {
+		return nil, err
+	}
+
+	return &apiservice.RetryStageResponse{
+		CommandId: cmd.Id,
+	}, nil
+}
+
+func (a *API) SkipStage(ctx context.Context, req *apiservice.SkipStageRequest) (*apiservice.SkipStageResponse, error) {
+	key, err := requireAPIKey(ctx, model.APIKey_READ_WRITE, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment, err := getDeployment(ctx, a.deploymentStore, req.DeploymentId, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.ProjectId != deployment.ProjectId {
+		return nil, status.Error(codes.InvalidArgument, "Requested deployment does not belong to your project")
+	}
+	if err := validateRecoverableStage(deployment, req.StageId, model.Command_SKIP_STAGE); err != nil {
+		return nil, err
+	}
+
+	cmd := makeStageRecoveryCommand(deployment, req.StageId, key.Id, model.Command_SKIP_STAGE)
+	if err := addCommand(ctx, a.commandStore, cmd, a.logger); err != nil {
+		return nil, err
+	}
+
+	return &apiservice.SkipStageResponse{
+		CommandId: cmd.Id,
+	}, nil
+}
+
 func (a *API) GetCommand(ctx context.Context, req *apiservice.GetCommandRequest) (*apiservice.GetCommandResponse, error) {
 	_, err := requireAPIKey(ctx, model.APIKey_READ_ONLY, a.logger)
 	if err != nil {
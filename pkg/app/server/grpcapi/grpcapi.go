@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -109,6 +110,58 @@ func addCommand(ctx context.Context, store commandstore.Store, cmd *model.Comman
 	return nil
 }
 
+// validateRecoverableStage checks whether the given stage of the deployment
+// can be retried or skipped by a RETRY_STAGE or SKIP_STAGE command.
+func validateRecoverableStage(d *model.Deployment, stageID string, cmdType model.Command_Type) error {
+	if model.IsCompletedDeployment(d.Status) {
+		return status.Error(codes.FailedPrecondition, "Could not handle the stage because the deployment was already completed")
+	}
+	var stage *model.PipelineStage
+	for _, s := range d.Stages {
+		if s.Id == stageID {
+			stage = s
+			break
+		}
+	}
+	if stage == nil {
+		return status.Error(codes.FailedPrecondition, "The stage was not found in the deployment")
+	}
+	if stage.Status != model.StageStatus_STAGE_FAILURE {
+		return status.Error(codes.FailedPrecondition, "Could not handle the stage because it is not in failure status")
+	}
+	if cmdType == model.Command_SKIP_STAGE && stage.Metadata[model.MetadataKeyStageSkippable] != "true" {
+		return status.Error(codes.FailedPrecondition, "Could not skip the stage because it is not configured as skippable")
+	}
+	return nil
+}
+
+// makeStageRecoveryCommand returns a RETRY_STAGE or SKIP_STAGE command for the given stage.
+func makeStageRecoveryCommand(d *model.Deployment, stageID, commander string, cmdType model.Command_Type) *model.Command {
+	cmd := &model.Command{
+		Id:            uuid.New().String(),
+		PipedId:       d.PipedId,
+		ApplicationId: d.ApplicationId,
+		ProjectId:     d.ProjectId,
+		DeploymentId:  d.Id,
+		StageId:       stageID,
+		Type:          cmdType,
+		Commander:     commander,
+	}
+	switch cmdType {
+	case model.Command_RETRY_STAGE:
+		cmd.RetryStage = &model.Command_RetryStage{
+			DeploymentId: d.Id,
+			StageId:      stageID,
+		}
+	case model.Command_SKIP_STAGE:
+		cmd.SkipStage = &model.Command_SkipStage{
+			DeploymentId: d.Id,
+			StageId:      stageID,
+		}
+	}
+	return cmd
+}
+
 // makeGitPath returns an ApplicationGitPath by adding Repository info and GitPath URL to given args.
 func makeGitPath(repoID, path, cfgFilename string, piped *model.Piped, logger *zap.Logger) (*model.ApplicationGitPath, error) {
 	var repo *model.ApplicationGitRepository
@@ -185,6 +185,28 @@ func (a *PipedAPI) GetEnvironment(ctx context.Context, req *pipedservice.GetEnvi
 	}, nil
 }
 
+// GetProjectDeployWindows returns the deploy windows configured
+// for the project of the requested piped.
+func (a *PipedAPI) GetProjectDeployWindows(ctx context.Context, req *pipedservice.GetProjectDeployWindowsRequest) (*pipedservice.GetProjectDeployWindowsResponse, error) {
+	projectID, _, _, err := rpcauth.ExtractPipedToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := a.projectStore.GetProject(ctx, projectID)
+	if errors.Is(err, datastore.ErrNotFound) {
+		// The projects specified in the control-plane configuration are not stored in the datastore.
+		return &pipedservice.GetProjectDeployWindowsResponse{}, nil
+	}
+	if err != nil {
+		a.logger.Error("failed to get project", zap.String("project", projectID), zap.Error(err))
+		return nil, status.Error(codes.Internal, "failed to get project")
+	}
+	return &pipedservice.GetProjectDeployWindowsResponse{
+		DeployWindows: project.DeployWindows,
+	}, nil
+}
+
 // ListApplications returns a list of registered applications
 // that should be managed by the requested piped.
 // Disabled applications should not be included in the response.
@@ -467,7 +489,14 @@ func (a *PipedAPI) ReportDeploymentStatusChanged(ctx context.Context, req *piped
 		return nil, err
 	}
 
+	if req.QueuePosition > 0 && req.Status != model.DeploymentStatus_DEPLOYMENT_PENDING {
+		return nil, status.Error(codes.InvalidArgument, "queue position can be set only for PENDING deployment")
+	}
+
 	updater := datastore.DeploymentStatusUpdater(req.Status, req.StatusReason)
+	if req.QueuePosition > 0 {
+		updater = datastore.DeploymentToQueuedUpdater(req.StatusReason, req.QueuePosition)
+	}
 	err = a.deploymentStore.UpdateDeployment(ctx, req.DeploymentId, updater)
 	if err != nil {
 		switch err {
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sort"
@@ -1314,6 +1315,13 @@ func (a *WebAPI) ApproveStage(ctx context.Context, req *webservice.ApproveStageR
 	if err := validateApprover(deployment.Stages, claims.Subject, req.StageId); err != nil {
 		return nil, err
 	}
+	groups, err := validateApproverGroups(deployment.Stages, claims.Subject, claims.Teams, req.StageId)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSelfApproval(deployment, claims.Subject, req.StageId); err != nil {
+		return nil, err
+	}
 	if err := a.validateDeploymentBelongsToProject(ctx, req.DeploymentId, claims.Role.ProjectId); err != nil {
 		return nil, err
 	}
@@ -1336,8 +1344,9 @@ func (a *WebAPI) ApproveStage(ctx context.Context, req *webservice.ApproveStageR
 		Type:          model.Command_APPROVE_STAGE,
 		Commander:     claims.Subject,
 		ApproveStage: &model.Command_ApproveStage{
-			DeploymentId: req.DeploymentId,
-			StageId:      req.StageId,
+			DeploymentId:   req.DeploymentId,
+			StageId:        req.StageId,
+			ApproverGroups: groups,
 		},
 	}
 	if err := addCommand(ctx, a.commandStore, &cmd, a.logger); err != nil {
@@ -1349,6 +1358,165 @@ func (a *WebAPI) ApproveStage(ctx context.Context, req *webservice.ApproveStageR
 	}, nil
 }
 
+func (a *WebAPI) RejectStage(ctx context.Context, req *webservice.RejectStageRequest) (*webservice.RejectStageResponse, error) {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return nil, err
+	}
+
+	deployment, err := getDeployment(ctx, a.deploymentStore, req.DeploymentId, a.logger)
+	if err != nil {
+		return nil, err
+	}
+	// The users who can approve the stage can also reject it.
+	if err := validateApprover(deployment.Stages, claims.Subject, req.StageId); err != nil {
+		return nil, err
+	}
+	if _, err := validateApproverGroups(deployment.Stages, claims.Subject, claims.Teams, req.StageId); err != nil {
+		return nil, err
+	}
+	if err := a.validateDeploymentBelongsToProject(ctx, req.DeploymentId, claims.Role.ProjectId); err != nil {
+		return nil, err
+	}
+	stage, ok := deployment.StageStatusMap()[req.StageId]
+	if !ok {
+		return nil, status.Error(codes.FailedPrecondition, "The stage was not found in the deployment")
+	}
+	if model.IsCompletedStage(stage) {
+		return nil, status.Errorf(codes.FailedPrecondition, "Could not reject the stage because it was already completed")
+	}
+
+	cmd := model.Command{
+		Id:            uuid.New().String(),
+		PipedId:       deployment.PipedId,
+		ApplicationId: deployment.ApplicationId,
+		ProjectId:     deployment.ProjectId,
+		DeploymentId:  req.DeploymentId,
+		StageId:       req.StageId,
+		Type:          model.Command_REJECT_STAGE,
+		Commander:     claims.Subject,
+		RejectStage: &model.Command_RejectStage{
+			DeploymentId: req.DeploymentId,
+			StageId:      req.StageId,
+			Reason:       req.Reason,
+		},
+	}
+	if err := addCommand(ctx, a.commandStore, &cmd, a.logger); err != nil {
+		return nil, err
+	}
+
+	return &webservice.RejectStageResponse{
+		CommandId: cmd.Id,
+	}, nil
+}
+
+func (a *WebAPI) RetryStage(ctx context.Context, req *webservice.RetryStageRequest) (*webservice.RetryStageResponse, error) {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return nil, err
+	}
+
+	deployment, err := getDeployment(ctx, a.deploymentStore, req.DeploymentId, a.logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.validateDeploymentBelongsToProject(ctx, req.DeploymentId, claims.Role.ProjectId); err != nil {
+		return nil, err
+	}
+	if err := validateRecoverableStage(deployment, req.StageId, model.Command_RETRY_STAGE); err != nil {
+		return nil, err
+	}
+
+	cmd := makeStageRecoveryCommand(deployment, req.StageId, claims.Subject, model.Command_RETRY_STAGE)
+	if err := addCommand(ctx, a.commandStore, cmd, a.logger); err != nil {
+		return nil, err
+	}
+
+	return &webservice.RetryStageResponse{
+		CommandId: cmd.Id,
+	}, nil
+}
+
+func (a *WebAPI) SkipStage(ctx context.Context, req *webservice.SkipStageRequest) (*webservice.SkipStageResponse, error) {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return nil, err
+	}
+
+	deployment, err := getDeployment(ctx, a.deploymentStore, req.DeploymentId, a.logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.validateDeploymentBelongsToProject(ctx, req.DeploymentId, claims.Role.ProjectId); err != nil {
+		return nil, err
+	}
+	if err := validateRecoverableStage(deployment, req.StageId, model.Command_SKIP_STAGE); err != nil {
+		return nil, err
+	}
+
+	cmd := makeStageRecoveryCommand(deployment, req.StageId, claims.Subject, model.Command_SKIP_STAGE)
+	if err := addCommand(ctx, a.commandStore, cmd, a.logger); err != nil {
+		return nil, err
+	}
+
+	return &webservice.SkipStageResponse{
+		CommandId: cmd.Id,
+	}, nil
+}
+
+func (a *WebAPI) OverrideDeployWindow(ctx context.Context, req *webservice.OverrideDeployWindowRequest) (*webservice.OverrideDeployWindowResponse, error) {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return nil, err
+	}
+
+	deployment, err := getDeployment(ctx, a.deploymentStore, req.DeploymentId, a.logger)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.validateDeploymentBelongsToProject(ctx, req.DeploymentId, claims.Role.ProjectId); err != nil {
+		return nil, err
+	}
+	if deployment.Status != model.DeploymentStatus_DEPLOYMENT_PENDING {
+		return nil, status.Error(codes.FailedPrecondition, "Could not override the deploy windows because the deployment is no longer pending")
+	}
+
+	cmd := model.Command{
+		Id:            uuid.New().String(),
+		PipedId:       deployment.PipedId,
+		ApplicationId: deployment.ApplicationId,
+		ProjectId:     deployment.ProjectId,
+		DeploymentId:  req.DeploymentId,
+		Type:          model.Command_OVERRIDE_DEPLOY_WINDOW,
+		Commander:     claims.Subject,
+		OverrideDeployWindow: &model.Command_OverrideDeployWindow{
+			DeploymentId: req.DeploymentId,
+			Reason:       req.Reason,
+		},
+	}
+	if err := addCommand(ctx, a.commandStore, &cmd, a.logger); err != nil {
+		return nil, err
+	}
+
+	// Keep a record of who forced the deployment through the deploy windows and why.
+	a.logger.Info("deploy windows were overridden",
+		zap.String("deployment", req.DeploymentId),
+		zap.String("application", deployment.ApplicationId),
+		zap.String("project", deployment.ProjectId),
+		zap.String("commander", claims.Subject),
+		zap.String("reason", req.Reason),
+		zap.String("command", cmd.Id),
+	)
+
+	return &webservice.OverrideDeployWindowResponse{
+		CommandId: cmd.Id,
+	}, nil
+}
+
 // No error means that the given commander is valid.
 func validateApprover(stages []*model.PipelineStage, commander, stageID string) error {
 	var approvers []string
@@ -1373,6 +1541,71 @@ func validateApprover(stages []*model.PipelineStage, commander, stageID string)
 	return status.Error(codes.PermissionDenied, fmt.Sprintf("You can't approve this deployment because you (%s) are not in the approver list: %v", commander, approvers))
 }
 
+// validateApproverGroups checks whether the given commander belongs to one of the approver groups of the stage.
+// It returns the names of all groups the commander belongs to.
+func validateApproverGroups(stages []*model.PipelineStage, commander string, teams []string, stageID string) ([]string, error) {
+	var data string
+	for _, s := range stages {
+		if s.Id == stageID {
+			data = s.Metadata["ApproverGroups"]
+			break
+		}
+	}
+	if data == "" {
+		return nil, nil
+	}
+
+	var groups []config.ApproverGroup
+	if err := json.Unmarshal([]byte(data), &groups); err != nil {
+		return nil, status.Error(codes.Internal, "Failed to parse the approver groups of the stage")
+	}
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		if g.HasMember(commander, teams) {
+			names = append(names, g.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, status.Error(codes.PermissionDenied, fmt.Sprintf("You can't approve this deployment because you (%s) are not in any of the approver groups", commander))
+	}
+	return names, nil
+}
+
+// validateSelfApproval checks that the given commander is neither the one who triggered the deployment
+// nor the author of the deploying commit when the stage does not allow self approval.
+// Since the git author name is not a PipeCD username, the commit author is recognized
+// only through the mapping given by the commitAuthors field of the stage options.
+func validateSelfApproval(d *model.Deployment, commander, stageID string) error {
+	var stage *model.PipelineStage
+	for _, s := range d.Stages {
+		if s.Id == stageID {
+			stage = s
+			break
+		}
+	}
+	if stage == nil || stage.Metadata["PreventSelfApproval"] != "true" || d.Trigger == nil {
+		return nil
+	}
+	if d.Trigger.Commander != "" && d.Trigger.Commander == commander {
+		return status.Error(codes.PermissionDenied, fmt.Sprintf("You can't approve this deployment because you (%s) triggered it", commander))
+	}
+	if d.Trigger.Commit == nil {
+		return nil
+	}
+	data, ok := stage.Metadata["CommitAuthors"]
+	if !ok {
+		return nil
+	}
+	var authors map[string]string
+	if err := json.Unmarshal([]byte(data), &authors); err != nil {
+		return status.Error(codes.Internal, "Failed to parse the commit authors of the stage")
+	}
+	if user, ok := authors[d.Trigger.Commit.Author]; ok && user == commander {
+		return status.Error(codes.PermissionDenied, fmt.Sprintf("You can't approve this deployment because you (%s) are the author of the deploying commit", commander))
+	}
+	return nil
+}
+
 func (a *WebAPI) GetApplicationLiveState(ctx context.Context, req *webservice.GetApplicationLiveStateRequest) (*webservice.GetApplicationLiveStateResponse, error) {
 	claims, err := rpcauth.ExtractClaims(ctx)
 	if err != nil {
@@ -1541,6 +1774,35 @@ func (a *WebAPI) UpdateProjectRBACConfig(ctx context.Context, req *webservice.Up
 	return &webservice.UpdateProjectRBACConfigResponse{}, nil
 }
 
+// UpdateProjectDeployWindows updates the deploy windows applied to all applications of the project.
+func (a *WebAPI) UpdateProjectDeployWindows(ctx context.Context, req *webservice.UpdateProjectDeployWindowsRequest) (*webservice.UpdateProjectDeployWindowsResponse, error) {
+	claims, err := rpcauth.ExtractClaims(ctx)
+	if err != nil {
+		a.logger.Error("failed to authenticate the current user", zap.Error(err))
+		return nil, err
+	}
+
+	if _, ok := a.projectsInConfig[claims.Role.ProjectId]; ok {
+		return nil, status.Error(codes.FailedPrecondition, "Failed to update a debug project specified in the control-plane configuration")
+	}
+
+	if _, err := config.NewDeployWindowsFromProject(req.DeployWindows); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid deploy windows: %v", err))
+	}
+
+	if err := a.projectStore.UpdateProjectDeployWindows(ctx, claims.Role.ProjectId, req.DeployWindows); err != nil {
+		a.logger.Error("failed to update project deploy windows", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to update project deploy windows")
+	}
+
+	// Keep a record of who changed the deploy windows of the project.
+	a.logger.Info("project deploy windows were updated",
+		zap.String("project", claims.Role.ProjectId),
+		zap.String("commander", claims.Subject),
+	)
+	return &webservice.UpdateProjectDeployWindowsResponse{}, nil
+}
+
 // GetMe gets information about the current user.
 func (a *WebAPI) GetMe(ctx context.Context, req *webservice.GetMeRequest) (*webservice.GetMeResponse, error) {
 	claims, err := rpcauth.ExtractClaims(ctx)
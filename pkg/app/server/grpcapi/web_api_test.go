@@ -374,3 +374,267 @@ func TestValidateApprover(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateApproverGroups(t *testing.T) {
+	groups := `[{"name":"sre","teams":["org/sre"],"minApproverNum":1},{"name":"product-owner","users":["user1"],"minApproverNum":1}]`
+	tests := []struct {
+		name       string
+		stages     []*model.PipelineStage
+		commander  string
+		teams      []string
+		stageID    string
+		wantGroups []string
+		wantErr    bool
+	}{
+		{
+			name: "valid if the ApproverGroups key isn't contained in metadata",
+			stages: []*model.PipelineStage{
+				{
+					Id: "stage-id",
+				},
+			},
+			commander:  "user1",
+			stageID:    "stage-id",
+			wantGroups: nil,
+			wantErr:    false,
+		},
+		{
+			name: "valid if a commander is included in users of a group",
+			stages: []*model.PipelineStage{
+				{
+					Id: "stage-id",
+					Metadata: map[string]string{
+						"ApproverGroups": groups,
+					},
+				},
+			},
+			commander:  "user1",
+			stageID:    "stage-id",
+			wantGroups: []string{"product-owner"},
+			wantErr:    false,
+		},
+		{
+			name: "valid if a commander belongs to teams of groups",
+			stages: []*model.PipelineStage{
+				{
+					Id: "stage-id",
+					Metadata: map[string]string{
+						"ApproverGroups": groups,
+					},
+				},
+			},
+			commander:  "user1",
+			teams:      []string{"org/dev", "org/sre"},
+			stageID:    "stage-id",
+			wantGroups: []string{"sre", "product-owner"},
+			wantErr:    false,
+		},
+		{
+			name: "invalid if a commander doesn't belong to any group",
+			stages: []*model.PipelineStage{
+				{
+					Id: "stage-id",
+					Metadata: map[string]string{
+						"ApproverGroups": groups,
+					},
+				},
+			},
+			commander: "user2",
+			teams:     []string{"org/dev"},
+			stageID:   "stage-id",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateApproverGroups(tt.stages, tt.commander, tt.teams, tt.stageID)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantGroups, got)
+		})
+	}
+}
+
+func TestValidateSelfApproval(t *testing.T) {
+	makeDeployment := func(prevent bool) *model.Deployment {
+		d := &model.Deployment{
+			Trigger: &model.DeploymentTrigger{
+				Commit: &model.Commit{
+					Author: "Author",
+				},
+				Commander: "commander",
+			},
+			Stages: []*model.PipelineStage{
+				{
+					Id:       "stage-id",
+					Metadata: map[string]string{},
+				},
+			},
+		}
+		if prevent {
+			d.Stages[0].Metadata["PreventSelfApproval"] = "true"
+			d.Stages[0].Metadata["CommitAuthors"] = `{"Author":"author-user"}`
+		}
+		return d
+	}
+	tests := []struct {
+		name       string
+		deployment *model.Deployment
+		commander  string
+		wantErr    bool
+	}{
+		{
+			name:       "valid if self approval is allowed",
+			deployment: makeDeployment(false),
+			commander:  "author",
+			wantErr:    false,
+		},
+		{
+			name:       "valid if a commander is neither the commit author nor the trigger commander",
+			deployment: makeDeployment(true),
+			commander:  "user1",
+			wantErr:    false,
+		},
+		{
+			name:       "valid if a commander has the same name with the commit author but is not mapped to it",
+			deployment: makeDeployment(true),
+			commander:  "author",
+			wantErr:    false,
+		},
+		{
+			name:       "invalid if a commander is mapped to the commit author",
+			deployment: makeDeployment(true),
+			commander:  "author-user",
+			wantErr:    true,
+		},
+		{
+			name: "valid if the commit author is not mapped to any user",
+			deployment: func() *model.Deployment {
+				d := makeDeployment(true)
+				delete(d.Stages[0].Metadata, "CommitAuthors")
+				return d
+			}(),
+			commander: "author-user",
+			wantErr:   false,
+		},
+		{
+			name:       "invalid if a commander triggered the deployment",
+			deployment: makeDeployment(true),
+			commander:  "commander",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSelfApproval(tt.deployment, tt.commander, "stage-id")
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestValidateRecoverableStage(t *testing.T) {
+	tests := []struct {
+		name       string
+		deployment *model.Deployment
+		stageID    string
+		cmdType    model.Command_Type
+		wantErr    bool
+	}{
+		{
+			name: "valid if the stage was failed",
+			deployment: &model.Deployment{
+				Status: model.DeploymentStatus_DEPLOYMENT_RUNNING,
+				Stages: []*model.PipelineStage{
+					{
+						Id:     "stage-id",
+						Status: model.StageStatus_STAGE_FAILURE,
+					},
+				},
+			},
+			stageID: "stage-id",
+			cmdType: model.Command_RETRY_STAGE,
+			wantErr: false,
+		},
+		{
+			name: "invalid if the deployment was already completed",
+			deployment: &model.Deployment{
+				Status: model.DeploymentStatus_DEPLOYMENT_FAILURE,
+				Stages: []*model.PipelineStage{
+					{
+						Id:     "stage-id",
+						Status: model.StageStatus_STAGE_FAILURE,
+					},
+				},
+			},
+			stageID: "stage-id",
+			cmdType: model.Command_RETRY_STAGE,
+			wantErr: true,
+		},
+		{
+			name: "invalid if the stage is still running",
+			deployment: &model.Deployment{
+				Status: model.DeploymentStatus_DEPLOYMENT_RUNNING,
+				Stages: []*model.PipelineStage{
+					{
+						Id:     "stage-id",
+						Status: model.StageStatus_STAGE_RUNNING,
+					},
+				},
+			},
+			stageID: "stage-id",
+			cmdType: model.Command_RETRY_STAGE,
+			wantErr: true,
+		},
+		{
+			name: "invalid if the stage was not found",
+			deployment: &model.Deployment{
+				Status: model.DeploymentStatus_DEPLOYMENT_RUNNING,
+				Stages: []*model.PipelineStage{
+					{
+						Id:     "stage-id",
+						Status: model.StageStatus_STAGE_FAILURE,
+					},
+				},
+			},
+			stageID: "unknown-stage-id",
+			cmdType: model.Command_RETRY_STAGE,
+			wantErr: true,
+		},
+		{
+			name: "valid to skip if the stage is skippable",
+			deployment: &model.Deployment{
+				Status: model.DeploymentStatus_DEPLOYMENT_RUNNING,
+				Stages: []*model.PipelineStage{
+					{
+						Id:       "stage-id",
+						Status:   model.StageStatus_STAGE_FAILURE,
+						Metadata: map[string]string{model.MetadataKeyStageSkippable: "true"},
+					},
+				},
+			},
+			stageID: "stage-id",
+			cmdType: model.Command_SKIP_STAGE,
+			wantErr: false,
+		},
+		{
+			name: "invalid to skip if the stage is not skippable",
+			deployment: &model.Deployment{
+				Status: model.DeploymentStatus_DEPLOYMENT_RUNNING,
+				Stages: []*model.PipelineStage{
+					{
+						Id:     "stage-id",
+						Status: model.StageStatus_STAGE_FAILURE,
+					},
+				},
+			},
+			stageID: "stage-id",
+			cmdType: model.Command_SKIP_STAGE,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRecoverableStage(tt.deployment, tt.stageID, tt.cmdType)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
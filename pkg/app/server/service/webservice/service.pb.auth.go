@@ -72,6 +72,10 @@ func (a *authorizer) Authorize(method string, r model.Role) bool {
 		return isAdmin(r)
 	case "/grpc.service.webservice.WebService/UpdateProjectRBACConfig":
 		return isAdmin(r)
+	case "/grpc.service.webservice.WebService/UpdateProjectDeployWindows":
+		return isAdmin(r)
+	case "/grpc.service.webservice.WebService/OverrideDeployWindow":
+		return isAdmin(r)
 	case "/grpc.service.webservice.WebService/GenerateAPIKey":
 		return isAdmin(r)
 	case "/grpc.service.webservice.WebService/DisableAPIKey":
@@ -97,6 +101,12 @@ func (a *authorizer) Authorize(method string, r model.Role) bool {
 		return isAdmin(r) || isEditor(r)
 	case "/grpc.service.webservice.WebService/ApproveStage":
 		return isAdmin(r) || isEditor(r)
+	case "/grpc.service.webservice.WebService/RejectStage":
+		return isAdmin(r) || isEditor(r)
+	case "/grpc.service.webservice.WebService/RetryStage":
+		return isAdmin(r) || isEditor(r)
+	case "/grpc.service.webservice.WebService/SkipStage":
+		return isAdmin(r) || isEditor(r)
 	case "/grpc.service.webservice.WebService/GenerateApplicationSealedSecret":
 		return isAdmin(r) || isEditor(r)
 
@@ -66,8 +66,9 @@ export const dummyApplicationLiveState: ApplicationLiveState = {
   pipedId: dummyPiped.id,
   version: { index: 1, timestamp: 0 },
   projectId: "project-1",
-  cloudrun: {},
-  lambda: {},
+  cloudrun: { resourcesList: [] },
+  lambda: { resourcesList: [] },
+  ecs: { resourcesList: [] },
   terraform: {},
   kubernetes: { resourcesList },
 };
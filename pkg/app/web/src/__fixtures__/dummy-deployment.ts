@@ -45,6 +45,7 @@ export const dummyDeployment: Deployment.AsObject = {
   metadataMap: [],
   deploymentChainId: "",
   deploymentChainBlockIndex: 0,
+  queuePosition: 0,
 };
 
 export function createDeploymentFromObject(o: Deployment.AsObject): Deployment {
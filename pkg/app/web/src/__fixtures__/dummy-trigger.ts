@@ -2,6 +2,7 @@ import { SyncStrategy } from "pipe/pkg/app/web/model/common_pb";
 import {
   DeploymentTrigger,
   Commit,
+  TriggerKind,
 } from "pipe/pkg/app/web/model/deployment_pb";
 import { createRandTime, randomUUID } from "./utils";
 
@@ -21,6 +22,7 @@ export const dummyTrigger: DeploymentTrigger.AsObject = {
   },
   syncStrategy: SyncStrategy.AUTO,
   strategySummary: "",
+  kind: TriggerKind.ON_COMMIT,
 };
 
 function createCommitFromObject(o: Commit.AsObject): Commit {
@@ -42,6 +44,7 @@ export function createTriggerFromObject(
   trigger.setCommander(o.commander);
   trigger.setTimestamp(o.timestamp);
   trigger.setSyncStrategy(o.syncStrategy);
+  trigger.setKind(o.kind);
   if (o.commit) {
     trigger.setCommit(createCommitFromObject(o.commit));
   }
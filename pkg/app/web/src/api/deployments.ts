@@ -8,6 +8,14 @@ import {
   CancelDeploymentResponse,
   ApproveStageRequest,
   ApproveStageResponse,
+  RejectStageRequest,
+  RejectStageResponse,
+  RetryStageRequest,
+  RetryStageResponse,
+  SkipStageRequest,
+  SkipStageResponse,
+  OverrideDeployWindowRequest,
+  OverrideDeployWindowResponse,
 } from "pipe/pkg/app/web/api_client/service_pb";
 
 export const getDeployment = ({
@@ -68,3 +76,47 @@ export const approveStage = ({
   req.setStageId(stageId);
   return apiRequest(req, apiClient.approveStage);
 };
+
+export const rejectStage = ({
+  deploymentId,
+  stageId,
+  reason,
+}: RejectStageRequest.AsObject): Promise<RejectStageResponse.AsObject> => {
+  const req = new RejectStageRequest();
+  req.setDeploymentId(deploymentId);
+  req.setStageId(stageId);
+  req.setReason(reason);
+  return apiRequest(req, apiClient.rejectStage);
+};
+
+export const retryStage = ({
+  deploymentId,
+  stageId,
+}: RetryStageRequest.AsObject): Promise<RetryStageResponse.AsObject> => {
+  const req = new RetryStageRequest();
+  req.setDeploymentId(deploymentId);
+  req.setStageId(stageId);
+  return apiRequest(req, apiClient.retryStage);
+};
+
+export const skipStage = ({
+  deploymentId,
+  stageId,
+}: SkipStageRequest.AsObject): Promise<SkipStageResponse.AsObject> => {
+  const req = new SkipStageRequest();
+  req.setDeploymentId(deploymentId);
+  req.setStageId(stageId);
+  return apiRequest(req, apiClient.skipStage);
+};
+
+export const overrideDeployWindow = ({
+  deploymentId,
+  reason,
+}: OverrideDeployWindowRequest.AsObject): Promise<
+  OverrideDeployWindowResponse.AsObject
+> => {
+  const req = new OverrideDeployWindowRequest();
+  req.setDeploymentId(deploymentId);
+  req.setReason(reason);
+  return apiRequest(req, apiClient.overrideDeployWindow);
+};
@@ -5,6 +5,8 @@ import {
   EnableStaticAdminResponse,
   GetProjectRequest,
   GetProjectResponse,
+  UpdateProjectDeployWindowsRequest,
+  UpdateProjectDeployWindowsResponse,
   UpdateProjectRBACConfigRequest,
   UpdateProjectRBACConfigResponse,
   UpdateProjectSSOConfigRequest,
@@ -13,6 +15,8 @@ import {
   UpdateProjectStaticAdminResponse,
 } from "pipe/pkg/app/web/api_client/service_pb";
 import {
+  ProjectDeployWindow,
+  ProjectDeployWindows,
   ProjectRBACConfig,
   ProjectSSOConfig,
 } from "pipe/pkg/app/web/model/project_pb";
@@ -89,3 +93,29 @@ export const updateGitHubSSO = ({
   req.setSso(sso);
   return apiRequest(req, apiClient.updateProjectSSOConfig);
 };
+
+const createDeployWindow = ({
+  schedule,
+  duration,
+  description,
+}: ProjectDeployWindow.AsObject): ProjectDeployWindow => {
+  const window = new ProjectDeployWindow();
+  window.setSchedule(schedule);
+  window.setDuration(duration);
+  window.setDescription(description);
+  return window;
+};
+
+export const updateDeployWindows = (
+  deployWindows: ProjectDeployWindows.AsObject | null
+): Promise<UpdateProjectDeployWindowsResponse.AsObject> => {
+  const req = new UpdateProjectDeployWindowsRequest();
+  if (deployWindows) {
+    const windows = new ProjectDeployWindows();
+    windows.setTimezone(deployWindows.timezone);
+    windows.setAllowList(deployWindows.allowList.map(createDeployWindow));
+    windows.setDenyList(deployWindows.denyList.map(createDeployWindow));
+    req.setDeployWindows(windows);
+  }
+  return apiRequest(req, apiClient.updateProjectDeployWindows);
+};
@@ -0,0 +1,29 @@
+import { Story } from "@storybook/react";
+import { HealthStatus } from "~/modules/applications-live-state";
+import { CloudResourcesView, CloudResourcesViewProps } from ".";
+
+export default {
+  title: "APPLICATION/CloudResourcesView",
+  component: CloudResourcesView,
+};
+
+const Template: Story<CloudResourcesViewProps> = (args) => (
+  <CloudResourcesView {...args} />
+);
+export const Overview = Template.bind({});
+Overview.args = {
+  resources: [
+    {
+      id: "service-1",
+      kind: "Service",
+      name: "helloworld",
+      healthStatus: HealthStatus.HEALTHY,
+    },
+    {
+      id: "revision-1",
+      kind: "Revision",
+      name: "helloworld-v010-1234567",
+      healthStatus: HealthStatus.OTHER,
+    },
+  ],
+};
@@ -0,0 +1,64 @@
+import { Box, makeStyles, Paper, Typography } from "@material-ui/core";
+import { FC, memo } from "react";
+import { KubernetesResourceHealthStatusIcon } from "../kubernetes-state-view/kubernetes-resource/health-status-icon";
+
+const useStyles = makeStyles((theme) => ({
+  root: {
+    display: "flex",
+    flex: 1,
+    flexWrap: "wrap",
+    alignContent: "flex-start",
+    padding: theme.spacing(2),
+    overflow: "auto",
+  },
+  resource: {
+    display: "inline-flex",
+    flexDirection: "column",
+    padding: theme.spacing(2),
+    margin: theme.spacing(1),
+    width: 300,
+  },
+  nameLine: {
+    display: "flex",
+  },
+  name: {
+    marginLeft: theme.spacing(0.5),
+    wordBreak: "break-all",
+  },
+}));
+
+// CloudResource is the common shape of the resource states of
+// the cloud providers that have no resource graph (e.g. Cloud Run, Lambda, ECS).
+export interface CloudResource {
+  id: string;
+  kind: string;
+  name: string;
+  healthStatus: number;
+}
+
+export interface CloudResourcesViewProps {
+  resources: CloudResource[];
+}
+
+export const CloudResourcesView: FC<CloudResourcesViewProps> = memo(
+  function CloudResourcesView({ resources }) {
+    const classes = useStyles();
+    return (
+      <Box className={classes.root}>
+        {resources.map((resource) => (
+          <Paper square className={classes.resource} key={resource.id}>
+            <Typography variant="caption">{resource.kind}</Typography>
+            <div className={classes.nameLine}>
+              <KubernetesResourceHealthStatusIcon
+                health={resource.healthStatus}
+              />
+              <Typography variant="subtitle2" className={classes.name}>
+                {resource.name}
+              </Typography>
+            </div>
+          </Paper>
+        ))}
+      </Box>
+    );
+  }
+);
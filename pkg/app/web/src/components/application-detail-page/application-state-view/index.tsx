@@ -21,10 +21,23 @@ import {
   selectById as selectLiveStateById,
   selectHasError,
 } from "~/modules/applications-live-state";
+import { CloudResourcesView } from "./cloud-resources-view";
 import { KubernetesStateView } from "./kubernetes-state-view";
 
 const FETCH_INTERVAL = 4000;
 
+// The kinds of application whose live state is reported by piped.
+const LIVE_STATE_SUPPORTED_KINDS: ApplicationKind[] = [
+  ApplicationKind.KUBERNETES,
+  ApplicationKind.CLOUDRUN,
+  ApplicationKind.LAMBDA,
+  ApplicationKind.ECS,
+];
+
+function isLiveStateSupported(app?: Application.AsObject): boolean {
+  return app !== undefined && LIVE_STATE_SUPPORTED_KINDS.includes(app.kind);
+}
+
 export interface ApplicationStateViewProps {
   applicationId: string;
 }
@@ -62,22 +75,18 @@ export const ApplicationStateView: FC<ApplicationStateViewProps> = memo(
     ]);
 
     useEffect(() => {
-      if (app?.kind === ApplicationKind.KUBERNETES) {
+      if (app && isLiveStateSupported(app)) {
         dispatch(fetchApplicationStateById(app.id));
       }
     }, [app, dispatch]);
 
     useInterval(
       () => {
-        // Only fetch kubernetes application.
-        if (app?.kind === ApplicationKind.KUBERNETES) {
+        if (app && isLiveStateSupported(app)) {
           dispatch(fetchApplicationStateById(app.id));
         }
       },
-      // Only fetch kubernetes application.
-      app?.kind === ApplicationKind.KUBERNETES && hasError === false
-        ? FETCH_INTERVAL
-        : null
+      isLiveStateSupported(app) && hasError === false ? FETCH_INTERVAL : null
     );
 
     if (app?.disabled) {
@@ -114,7 +123,7 @@ export const ApplicationStateView: FC<ApplicationStateViewProps> = memo(
     if (!liveState) {
       return (
         <>
-          {app?.kind === ApplicationKind.KUBERNETES ? (
+          {isLiveStateSupported(app) ? (
             <div className={classes.container}>
               <CircularProgress />
             </div>
@@ -139,6 +148,18 @@ export const ApplicationStateView: FC<ApplicationStateViewProps> = memo(
         const resources = liveState.kubernetes?.resourcesList || [];
         return <KubernetesStateView resources={resources} />;
       }
+      case ApplicationKind.CLOUDRUN: {
+        const resources = liveState.cloudrun?.resourcesList || [];
+        return <CloudResourcesView resources={resources} />;
+      }
+      case ApplicationKind.LAMBDA: {
+        const resources = liveState.lambda?.resourcesList || [];
+        return <CloudResourcesView resources={resources} />;
+      }
+      case ApplicationKind.ECS: {
+        const resources = liveState.ecs?.resourcesList || [];
+        return <CloudResourcesView resources={resources} />;
+      }
       default:
     }
 
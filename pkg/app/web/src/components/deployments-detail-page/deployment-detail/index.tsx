@@ -1,5 +1,7 @@
 import {
   Box,
+  Button,
+  Chip,
   CircularProgress,
   Link,
   makeStyles,
@@ -8,8 +10,9 @@ import {
 } from "@material-ui/core";
 import CancelIcon from "@material-ui/icons/Cancel";
 import OpenInNewIcon from "@material-ui/icons/OpenInNew";
+import PlayArrowIcon from "@material-ui/icons/PlayArrow";
 import dayjs from "dayjs";
-import { FC, memo } from "react";
+import { FC, memo, useState } from "react";
 import { Link as RouterLink } from "react-router-dom";
 import { DeploymentStatusIcon } from "~/components/deployment-status-icon";
 import { DetailTableRow } from "~/components/detail-table-row";
@@ -21,13 +24,16 @@ import { useInterval } from "~/hooks/use-interval";
 import {
   cancelDeployment,
   Deployment,
+  DeploymentStatus,
   isDeploymentRunning,
+  overrideDeployWindow,
   selectById as selectDeploymentById,
   selectDeploymentIsCanceling,
 } from "~/modules/deployments";
 import { selectEnvById } from "~/modules/environments";
 import { selectPipedById } from "~/modules/pipeds";
 import { fetchStageLog } from "~/modules/stage-logs";
+import { OverrideDeployWindowDialog } from "./override-deploy-window-dialog";
 
 const useStyles = makeStyles((theme) => ({
   root: {
@@ -48,12 +54,19 @@ const useStyles = makeStyles((theme) => ({
   content: {
     flex: 1,
   },
-  actionButtons: {
-    color: theme.palette.error.main,
+  actions: {
+    display: "flex",
     position: "absolute",
     top: theme.spacing(2),
     right: theme.spacing(2),
   },
+  actionButtons: {
+    color: theme.palette.error.main,
+    marginLeft: theme.spacing(1),
+  },
+  queueChip: {
+    marginLeft: theme.spacing(1),
+  },
   statusReason: {
     paddingTop: theme.spacing(1),
     paddingBottom: theme.spacing(1),
@@ -90,6 +103,7 @@ export const DeploymentDetail: FC<DeploymentDetailProps> = memo(
     const isCanceling = useAppSelector(
       selectDeploymentIsCanceling(deploymentId)
     );
+    const [isOpenOverrideDialog, setIsOpenOverrideDialog] = useState(false);
 
     useInterval(
       () => {
@@ -139,6 +153,13 @@ export const DeploymentDetail: FC<DeploymentDetailProps> = memo(
               <Typography variant="body1" className={classes.age}>
                 {dayjs(deployment.createdAt * 1000).fromNow()}
               </Typography>
+              {deployment.queuePosition > 0 && (
+                <Chip
+                  size="small"
+                  label={`Queued #${deployment.queuePosition}`}
+                  className={classes.queueChip}
+                />
+              )}
             </Box>
             <Typography
               variant="body2"
@@ -211,27 +232,46 @@ export const DeploymentDetail: FC<DeploymentDetailProps> = memo(
                 </tbody>
               </table>
             </div>
-            {isDeploymentRunning(deployment.status) && (
-              <SplitButton
-                className={classes.actionButtons}
-                options={CANCEL_OPTIONS}
-                label="select merge strategy"
-                onClick={(index) => {
-                  dispatch(
-                    cancelDeployment({
-                      deploymentId,
-                      forceRollback: index === 1,
-                      forceNoRollback: index === 2,
-                    })
-                  );
-                }}
-                startIcon={<CancelIcon />}
-                loading={isCanceling}
-                disabled={isCanceling}
-              />
-            )}
+            <div className={classes.actions}>
+              {deployment.status === DeploymentStatus.DEPLOYMENT_PENDING && (
+                <Button
+                  variant="outlined"
+                  startIcon={<PlayArrowIcon />}
+                  onClick={() => setIsOpenOverrideDialog(true)}
+                >
+                  Force Deploy
+                </Button>
+              )}
+              {isDeploymentRunning(deployment.status) && (
+                <SplitButton
+                  className={classes.actionButtons}
+                  options={CANCEL_OPTIONS}
+                  label="select merge strategy"
+                  onClick={(index) => {
+                    dispatch(
+                      cancelDeployment({
+                        deploymentId,
+                        forceRollback: index === 1,
+                        forceNoRollback: index === 2,
+                      })
+                    );
+                  }}
+                  startIcon={<CancelIcon />}
+                  loading={isCanceling}
+                  disabled={isCanceling}
+                />
+              )}
+            </div>
           </Box>
         </Box>
+        <OverrideDeployWindowDialog
+          open={isOpenOverrideDialog}
+          onClose={() => setIsOpenOverrideDialog(false)}
+          onSubmit={(reason) => {
+            dispatch(overrideDeployWindow({ deploymentId, reason }));
+            setIsOpenOverrideDialog(false);
+          }}
+        />
       </Paper>
     );
   }
@@ -0,0 +1,62 @@
+import {
+  Button,
+  Dialog,
+  DialogActions,
+  DialogContent,
+  DialogContentText,
+  DialogTitle,
+  TextField,
+} from "@material-ui/core";
+import { FC, useState } from "react";
+import { UI_TEXT_CANCEL } from "~/constants/ui-text";
+
+const DIALOG_TITLE = "Force Deploy";
+const DIALOG_DESCRIPTION =
+  "This deployment will be started regardless of the configured deploy windows. The reason will be recorded for auditing.";
+const SUBMIT_TEXT = "Force Deploy";
+
+interface Props {
+  open: boolean;
+  onClose: () => void;
+  onSubmit: (reason: string) => void;
+}
+
+export const OverrideDeployWindowDialog: FC<Props> = ({
+  open,
+  onClose,
+  onSubmit,
+}) => {
+  const [reason, setReason] = useState("");
+
+  return (
+    <Dialog open={open} onClose={onClose} fullWidth>
+      <form
+        onSubmit={(e) => {
+          e.preventDefault();
+          onSubmit(reason);
+        }}
+      >
+        <DialogTitle>{DIALOG_TITLE}</DialogTitle>
+        <DialogContent>
+          <DialogContentText>{DIALOG_DESCRIPTION}</DialogContentText>
+          <TextField
+            value={reason}
+            variant="outlined"
+            margin="dense"
+            label="Reason"
+            fullWidth
+            required
+            autoFocus
+            onChange={(e) => setReason(e.currentTarget.value)}
+          />
+        </DialogContent>
+        <DialogActions>
+          <Button onClick={onClose}>{UI_TEXT_CANCEL}</Button>
+          <Button type="submit" color="primary" disabled={reason === ""}>
+            {SUBMIT_TEXT}
+          </Button>
+        </DialogActions>
+      </form>
+    </Dialog>
+  );
+};
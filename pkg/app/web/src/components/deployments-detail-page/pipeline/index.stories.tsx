@@ -1,6 +1,10 @@
 import { Story } from "@storybook/react";
 import { METADATA_APPROVED_BY } from "~/constants/metadata-keys";
-import { Deployment, SyncStrategy } from "~/modules/deployments";
+import {
+  Deployment,
+  SyncStrategy,
+  TriggerKind,
+} from "~/modules/deployments";
 import { createPipelineStage } from "~/__fixtures__/dummy-pipeline";
 import { createDecoratorRedux } from "~~/.storybook/redux-decorator";
 import { Pipeline, PipelineProps } from ".";
@@ -42,6 +46,7 @@ const fakeDeployment: Deployment.AsObject = {
     timestamp: 1592201366,
     syncStrategy: SyncStrategy.AUTO,
     strategySummary: "",
+    kind: TriggerKind.ON_COMMIT,
   },
   runningCommitHash: "3808585b46f1e90196d7ffe8dd04c807a251febc",
   summary: "This deployment is debug",
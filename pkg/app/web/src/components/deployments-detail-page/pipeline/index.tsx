@@ -7,17 +7,25 @@ import {
   DialogContentText,
   DialogTitle,
   makeStyles,
+  TextField,
 } from "@material-ui/core";
 import clsx from "clsx";
 import { FC, memo, useCallback, useEffect, useState } from "react";
-import { METADATA_APPROVED_BY } from "~/constants/metadata-keys";
+import {
+  METADATA_APPROVED_BY,
+  METADATA_SKIPPABLE,
+  METADATA_WAITING_APPROVAL,
+} from "~/constants/metadata-keys";
 import { useAppDispatch, useAppSelector } from "~/hooks/redux";
 import { ActiveStage, updateActiveStage } from "~/modules/active-stage";
 import {
   approveStage,
   Deployment,
   isDeploymentRunning,
+  rejectStage,
+  retryStage,
   selectById,
+  skipStage,
   Stage,
   StageStatus,
 } from "~/modules/deployments";
@@ -62,18 +70,36 @@ const createStagesForRendering = (
 
   const stages: Stage[][] = [];
   const visibleStages = deployment.stagesList.filter((stage) => stage.visible);
+  const levels: Record<string, number> = {};
+
+  // Each stage is placed next to the deepest one of its required stages,
+  // so the stages executed concurrently are rendered in the same column.
+  const findLevel = (stage: Stage, visiting: string[]): number => {
+    if (levels[stage.id] !== undefined) {
+      return levels[stage.id];
+    }
+    let level = 0;
+    stage.requiresList.forEach((id) => {
+      const required = visibleStages.find((s) => s.id === id);
+      if (!required || visiting.includes(id)) {
+        return;
+      }
+      level = Math.max(
+        level,
+        findLevel(required, [...visiting, id]) + 1
+      );
+    });
+    levels[stage.id] = level;
+    return level;
+  };
 
-  stages[0] = visibleStages.filter((stage) => stage.requiresList.length === 0);
+  visibleStages.forEach((stage) => {
+    const level = findLevel(stage, [stage.id]);
+    stages[level] = [...(stages[level] || []), stage];
+  });
 
-  let index = 0;
-  while (stages[index].length > 0) {
-    const previousIds = stages[index].map((stage) => stage.id);
-    index++;
-    stages[index] = visibleStages.filter((stage) =>
-      stage.requiresList.some((id) => previousIds.includes(id))
-    );
-  }
-  return stages;
+  // Remove the empty columns.
+  return stages.filter((column) => column !== undefined);
 };
 
 const LARGE_STAGE_NAMES = ["WAIT_APPROVAL", "K8S_TRAFFIC_ROUTING"];
@@ -147,6 +173,20 @@ const findApprover = (
   return undefined;
 };
 
+// Stages other than WAIT_APPROVAL can also wait for an approval,
+// e.g. ANALYSIS stage whose score is marginal.
+const isWaitingApproval = (stage: Stage): boolean => {
+  if (stage.status !== StageStatus.STAGE_RUNNING) {
+    return false;
+  }
+  if (stage.name === WAIT_APPROVAL_NAME) {
+    return true;
+  }
+  return stage.metadataMap.some(
+    ([key, value]) => key === METADATA_WAITING_APPROVAL && value === "true"
+  );
+};
+
 export const Pipeline: FC<PipelineProps> = memo(function Pipeline({
   deploymentId,
 }) {
@@ -157,6 +197,19 @@ export const Pipeline: FC<PipelineProps> = memo(function Pipeline({
   );
   const [approveTargetId, setApproveTargetId] = useState<string | null>(null);
   const isOpenApproveDialog = Boolean(approveTargetId);
+  const [rejectReason, setRejectReason] = useState("");
+  const [recoveryTargetId, setRecoveryTargetId] = useState<string | null>(
+    null
+  );
+  const isOpenRecoveryDialog = Boolean(recoveryTargetId);
+  // Only the stage configured as skippable can be skipped.
+  const isRecoveryTargetSkippable = Boolean(
+    deployment?.stagesList
+      .find((s) => s.id === recoveryTargetId)
+      ?.metadataMap.some(
+        ([key, value]) => key === METADATA_SKIPPABLE && value === "true"
+      )
+  );
 
   const defaultActiveStage = findDefaultActiveStage(deployment);
   const stages = createStagesForRendering(deployment);
@@ -193,14 +246,51 @@ export const Pipeline: FC<PipelineProps> = memo(function Pipeline({
   const handleOnClickStage = useCallback(
     (stageId: string, stageName: string) => {
       dispatch(updateActiveStage({ deploymentId, stageId, name: stageName }));
+      // A failed stage can be retried or skipped while the deployment is waiting for the decision.
+      const stage = deployment?.stagesList.find((s) => s.id === stageId);
+      if (isRunning && stage?.status === StageStatus.STAGE_FAILURE) {
+        setRecoveryTargetId(stageId);
+      }
     },
-    [dispatch, deploymentId]
+    [dispatch, deploymentId, deployment, isRunning]
   );
 
+  const handleCloseApproveDialog = (): void => {
+    setApproveTargetId(null);
+    setRejectReason("");
+  };
+
   const handleApprove = (): void => {
     if (approveTargetId) {
       dispatch(approveStage({ deploymentId, stageId: approveTargetId }));
-      setApproveTargetId(null);
+      handleCloseApproveDialog();
+    }
+  };
+
+  const handleReject = (): void => {
+    if (approveTargetId) {
+      dispatch(
+        rejectStage({
+          deploymentId,
+          stageId: approveTargetId,
+          reason: rejectReason,
+        })
+      );
+      handleCloseApproveDialog();
+    }
+  };
+
+  const handleRetry = (): void => {
+    if (recoveryTargetId) {
+      dispatch(retryStage({ deploymentId, stageId: recoveryTargetId }));
+      setRecoveryTargetId(null);
+    }
+  };
+
+  const handleSkip = (): void => {
+    if (recoveryTargetId) {
+      dispatch(skipStage({ deploymentId, stageId: recoveryTargetId }));
+      setRecoveryTargetId(null);
     }
   };
 
@@ -235,8 +325,7 @@ export const Pipeline: FC<PipelineProps> = memo(function Pipeline({
                         : undefined
                     )}
                   >
-                    {stage.name === WAIT_APPROVAL_NAME &&
-                    stage.status === StageStatus.STAGE_RUNNING ? (
+                    {isWaitingApproval(stage) ? (
                       <ApprovalStage
                         id={stage.id}
                         name={stage.name}
@@ -250,6 +339,7 @@ export const Pipeline: FC<PipelineProps> = memo(function Pipeline({
                         id={stage.id}
                         name={stage.name}
                         status={stage.status}
+                        statusReason={stage.statusReason}
                         metadata={stage.metadataMap}
                         onClick={handleOnClickStage}
                         active={isActive}
@@ -266,23 +356,60 @@ export const Pipeline: FC<PipelineProps> = memo(function Pipeline({
           );
         })}
 
-        <Dialog
-          open={isOpenApproveDialog}
-          onClose={() => setApproveTargetId(null)}
-        >
+        <Dialog open={isOpenApproveDialog} onClose={handleCloseApproveDialog}>
           <DialogTitle>Approve stage</DialogTitle>
           <DialogContent>
             <DialogContentText>
-              {`To continue deploying, click "APPROVE".`}
+              {`To continue deploying, click "APPROVE". To fail the stage, enter the reason and click "REJECT".`}
             </DialogContentText>
+            <TextField
+              value={rejectReason}
+              variant="outlined"
+              margin="dense"
+              label="Reason for rejection"
+              fullWidth
+              onChange={(e) => setRejectReason(e.currentTarget.value)}
+            />
           </DialogContent>
           <DialogActions>
-            <Button onClick={() => setApproveTargetId(null)}>CANCEL</Button>
+            <Button onClick={handleCloseApproveDialog}>CANCEL</Button>
+            <Button
+              color="primary"
+              onClick={handleReject}
+              disabled={rejectReason === ""}
+            >
+              REJECT
+            </Button>
             <Button color="primary" onClick={handleApprove}>
               APPROVE
             </Button>
           </DialogActions>
         </Dialog>
+
+        <Dialog
+          open={isOpenRecoveryDialog}
+          onClose={() => setRecoveryTargetId(null)}
+        >
+          <DialogTitle>Failed stage</DialogTitle>
+          <DialogContent>
+            <DialogContentText>
+              {isRecoveryTargetSkippable
+                ? `To run the stage again, click "RETRY". To continue deploying without this stage, click "SKIP".`
+                : `To run the stage again, click "RETRY".`}
+            </DialogContentText>
+          </DialogContent>
+          <DialogActions>
+            <Button onClick={() => setRecoveryTargetId(null)}>CANCEL</Button>
+            {isRecoveryTargetSkippable && (
+              <Button color="primary" onClick={handleSkip}>
+                SKIP
+              </Button>
+            )}
+            <Button color="primary" onClick={handleRetry}>
+              RETRY
+            </Button>
+          </DialogActions>
+        </Dialog>
       </Box>
     </Box>
   );
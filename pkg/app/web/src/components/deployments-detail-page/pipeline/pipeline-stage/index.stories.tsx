@@ -79,3 +79,15 @@ PromotePercentage.args = {
   metadata: [["promote-percentage", "75"]],
   isDeploymentRunning: true,
 };
+
+export const Skipped = Template.bind({});
+Skipped.args = {
+  id: "stage-1",
+  status: StageStatus.STAGE_SKIPPED,
+  statusReason:
+    'Skipped because the condition `changed("apps/web/**")` was not satisfied',
+  name: "ANALYSIS",
+  active: false,
+  metadata: [],
+  isDeploymentRunning: true,
+};
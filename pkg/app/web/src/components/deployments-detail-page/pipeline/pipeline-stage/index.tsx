@@ -52,6 +52,7 @@ export interface PipelineStageProps {
   id: string;
   name: string;
   status: StageStatus;
+  statusReason?: string;
   active: boolean;
   isDeploymentRunning: boolean;
   approver?: string;
@@ -110,6 +111,7 @@ export const PipelineStage: FC<PipelineStageProps> = memo(
     id,
     name,
     status,
+    statusReason,
     onClick,
     active,
     approver,
@@ -138,6 +140,11 @@ export const PipelineStage: FC<PipelineStageProps> = memo(
           [classes.notStartedYet]: disabled,
         })}
         onClick={handleOnClick}
+        title={
+          status === StageStatus.STAGE_SKIPPED && statusReason
+            ? statusReason
+            : undefined
+        }
       >
         <div className={classes.main}>
           <StageStatusIcon status={status} />
@@ -25,3 +25,6 @@ Running.args = { status: StageStatus.STAGE_RUNNING };
 
 export const Success = Template.bind({});
 Success.args = { status: StageStatus.STAGE_SUCCESS };
+
+export const Skipped = Template.bind({});
+Skipped.args = { status: StageStatus.STAGE_SKIPPED };
@@ -1,4 +1,10 @@
-import { Box, ListItem, makeStyles, Typography } from "@material-ui/core";
+import {
+  Box,
+  Chip,
+  ListItem,
+  makeStyles,
+  Typography,
+} from "@material-ui/core";
 import dayjs from "dayjs";
 import { FC, memo } from "react";
 import { Link as RouterLink } from "react-router-dom";
@@ -36,6 +42,9 @@ const useStyles = makeStyles((theme) => ({
     ...ellipsis,
     color: theme.palette.text.hint,
   },
+  queueChip: {
+    marginLeft: theme.spacing(1),
+  },
 }));
 
 export interface DeploymentItemProps {
@@ -102,6 +111,13 @@ export const DeploymentItem: FC<DeploymentItemProps> = memo(
             >
               {APPLICATION_KIND_TEXT[deployment.kind]}
             </Typography>
+            {deployment.queuePosition > 0 && (
+              <Chip
+                size="small"
+                label={`Queued #${deployment.queuePosition}`}
+                className={classes.queueChip}
+              />
+            )}
           </Box>
           <Typography variant="body1" className={classes.description}>
             {deployment.summary || NO_DESCRIPTION}
@@ -0,0 +1,45 @@
+import { Story } from "@storybook/react";
+import { Provider } from "react-redux";
+import { createStore } from "~~/test-utils";
+import { DeployWindowsForm } from ".";
+
+export default {
+  title: "DeployWindowsForm",
+  component: DeployWindowsForm,
+};
+
+export const Overview: Story = () => (
+  <Provider
+    store={createStore({
+      project: {
+        deployWindows: {
+          timezone: "Asia/Tokyo",
+          allowList: [
+            { schedule: "0 10 * * 1-5", duration: "8h", description: "" },
+          ],
+          denyList: [
+            {
+              schedule: "0 0 28 12 *",
+              duration: "168h",
+              description: "Year-end freeze",
+            },
+          ],
+        },
+      },
+    })}
+  >
+    <DeployWindowsForm />
+  </Provider>
+);
+
+export const NotConfigured: Story = () => (
+  <Provider
+    store={createStore({
+      project: {
+        deployWindows: null,
+      },
+    })}
+  >
+    <DeployWindowsForm />
+  </Provider>
+);
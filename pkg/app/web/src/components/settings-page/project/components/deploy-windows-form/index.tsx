@@ -0,0 +1,269 @@
+import {
+  Button,
+  Dialog,
+  DialogActions,
+  DialogContent,
+  DialogTitle,
+  IconButton,
+  makeStyles,
+  TextField,
+  Typography,
+} from "@material-ui/core";
+import { Add as AddIcon, Delete as DeleteIcon } from "@material-ui/icons";
+import EditIcon from "@material-ui/icons/Edit";
+import Skeleton from "@material-ui/lab/Skeleton/Skeleton";
+import * as React from "react";
+import { FC, memo, useState } from "react";
+import { DEPLOY_WINDOWS_DESCRIPTION } from "~/constants/text";
+import { UPDATE_DEPLOY_WINDOWS_SUCCESS } from "~/constants/toast-text";
+import {
+  UI_TEXT_ADD,
+  UI_TEXT_CANCEL,
+  UI_TEXT_SAVE,
+} from "~/constants/ui-text";
+import { useAppDispatch, useAppSelector } from "~/hooks/redux";
+import {
+  DeployWindows,
+  fetchProject,
+  updateDeployWindows,
+} from "~/modules/project";
+import { addToast } from "~/modules/toasts";
+import { useProjectSettingStyles } from "~/styles/project-setting";
+import { ProjectSettingLabeledText } from "../project-setting-labeled-text";
+
+const useStyles = makeStyles((theme) => ({
+  window: {
+    display: "flex",
+    alignItems: "center",
+    "& > *": {
+      marginRight: theme.spacing(1),
+    },
+  },
+}));
+
+const SECTION_TITLE = "Deploy Windows";
+const DIALOG_TITLE = `Edit ${SECTION_TITLE}`;
+const LABELS = {
+  TIMEZONE: "Timezone",
+  ALLOW: "Allow Windows",
+  DENY: "Deny Windows",
+  SCHEDULE: "Schedule",
+  DURATION: "Duration",
+  DESCRIPTION: "Description",
+};
+
+type DeployWindow = DeployWindows["allowList"][number];
+
+const emptyWindow = (): DeployWindow => ({
+  schedule: "",
+  duration: "",
+  description: "",
+});
+
+const formatWindows = (windows: DeployWindow[]): string =>
+  windows.length === 0
+    ? "-"
+    : windows
+        .map(
+          (w) =>
+            `${w.schedule} for ${w.duration}` +
+            (w.description ? ` (${w.description})` : "")
+        )
+        .join(", ");
+
+const WindowListField: FC<{
+  label: string;
+  windows: DeployWindow[];
+  onChange: (windows: DeployWindow[]) => void;
+}> = ({ label, windows, onChange }) => {
+  const classes = useStyles();
+  const update = (index: number, value: Partial<DeployWindow>): void => {
+    onChange(windows.map((w, i) => (i === index ? { ...w, ...value } : w)));
+  };
+
+  return (
+    <>
+      <Typography variant="subtitle2">{label}</Typography>
+      {windows.map((w, i) => (
+        <div key={i} className={classes.window}>
+          <TextField
+            value={w.schedule}
+            variant="outlined"
+            margin="dense"
+            label={LABELS.SCHEDULE}
+            placeholder="0 18 * * 5"
+            required
+            onChange={(e) => update(i, { schedule: e.currentTarget.value })}
+          />
+          <TextField
+            value={w.duration}
+            variant="outlined"
+            margin="dense"
+            label={LABELS.DURATION}
+            placeholder="62h"
+            required
+            onChange={(e) => update(i, { duration: e.currentTarget.value })}
+          />
+          <TextField
+            value={w.description}
+            variant="outlined"
+            margin="dense"
+            label={LABELS.DESCRIPTION}
+            onChange={(e) => update(i, { description: e.currentTarget.value })}
+          />
+          <IconButton
+            aria-label="delete"
+            onClick={() => onChange(windows.filter((_, j) => j !== i))}
+          >
+            <DeleteIcon />
+          </IconButton>
+        </div>
+      ))}
+      <Button
+        color="primary"
+        startIcon={<AddIcon />}
+        onClick={() => onChange([...windows, emptyWindow()])}
+      >
+        {UI_TEXT_ADD}
+      </Button>
+    </>
+  );
+};
+
+export const DeployWindowsForm: FC = memo(function DeployWindowsForm() {
+  const projectSettingClasses = useProjectSettingStyles();
+  const deployWindows = useAppSelector<DeployWindows | null | undefined>(
+    (state) => state.project.deployWindows
+  );
+  const dispatch = useAppDispatch();
+  const [isEdit, setIsEdit] = useState(false);
+  const [timezone, setTimezone] = useState("");
+  const [allowList, setAllowList] = useState<DeployWindow[]>([]);
+  const [denyList, setDenyList] = useState<DeployWindow[]>([]);
+
+  const handleClose = (): void => {
+    setIsEdit(false);
+  };
+  const handleSave = (e: React.FormEvent<HTMLFormElement>): void => {
+    e.preventDefault();
+    // Removing all windows removes the deploy windows from the project.
+    const params =
+      allowList.length === 0 && denyList.length === 0
+        ? null
+        : { timezone, allowList, denyList };
+    dispatch(updateDeployWindows(params)).then((result) => {
+      if (updateDeployWindows.fulfilled.match(result)) {
+        dispatch(fetchProject());
+        dispatch(
+          addToast({
+            message: UPDATE_DEPLOY_WINDOWS_SUCCESS,
+            severity: "success",
+          })
+        );
+      }
+    });
+    setIsEdit(false);
+  };
+
+  return (
+    <>
+      <div className={projectSettingClasses.title}>
+        <Typography
+          variant="h5"
+          className={projectSettingClasses.titleWithIcon}
+        >
+          {SECTION_TITLE}
+        </Typography>
+      </div>
+
+      <Typography
+        variant="body1"
+        color="textSecondary"
+        className={projectSettingClasses.description}
+      >
+        {DEPLOY_WINDOWS_DESCRIPTION}
+      </Typography>
+
+      <div className={projectSettingClasses.valuesWrapper}>
+        {deployWindows ? (
+          <div className={projectSettingClasses.values}>
+            <ProjectSettingLabeledText
+              label={LABELS.TIMEZONE}
+              value={deployWindows.timezone || "UTC"}
+            />
+            <ProjectSettingLabeledText
+              label={LABELS.ALLOW}
+              value={formatWindows(deployWindows.allowList)}
+            />
+            <ProjectSettingLabeledText
+              label={LABELS.DENY}
+              value={formatWindows(deployWindows.denyList)}
+            />
+          </div>
+        ) : (
+          <div className={projectSettingClasses.values}>
+            {deployWindows === undefined ? (
+              <>
+                <Skeleton width={200} height={28} />
+                <Skeleton width={200} height={28} />
+                <Skeleton width={200} height={28} />
+              </>
+            ) : (
+              <Typography variant="body1" color="textSecondary">
+                Not Configured
+              </Typography>
+            )}
+          </div>
+        )}
+        <div>
+          <IconButton onClick={() => setIsEdit(true)}>
+            <EditIcon />
+          </IconButton>
+        </div>
+      </div>
+
+      <Dialog
+        open={isEdit}
+        onEnter={() => {
+          setTimezone(deployWindows?.timezone ?? "");
+          setAllowList(deployWindows?.allowList ?? []);
+          setDenyList(deployWindows?.denyList ?? []);
+        }}
+        onClose={handleClose}
+        maxWidth="md"
+      >
+        <form onSubmit={handleSave}>
+          <DialogTitle>{DIALOG_TITLE}</DialogTitle>
+          <DialogContent>
+            <TextField
+              value={timezone}
+              variant="outlined"
+              margin="dense"
+              label={LABELS.TIMEZONE}
+              placeholder="UTC"
+              fullWidth
+              autoFocus
+              onChange={(e) => setTimezone(e.currentTarget.value)}
+            />
+            <WindowListField
+              label={LABELS.ALLOW}
+              windows={allowList}
+              onChange={setAllowList}
+            />
+            <WindowListField
+              label={LABELS.DENY}
+              windows={denyList}
+              onChange={setDenyList}
+            />
+          </DialogContent>
+          <DialogActions>
+            <Button onClick={handleClose}>{UI_TEXT_CANCEL}</Button>
+            <Button type="submit" color="primary">
+              {UI_TEXT_SAVE}
+            </Button>
+          </DialogActions>
+        </form>
+      </Dialog>
+    </>
+  );
+});
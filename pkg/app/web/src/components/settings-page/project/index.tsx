@@ -2,6 +2,7 @@ import { makeStyles } from "@material-ui/core";
 import { FC, memo, useEffect } from "react";
 import { useAppDispatch } from "~/hooks/redux";
 import { fetchProject } from "~/modules/project";
+import { DeployWindowsForm } from "./components/deploy-windows-form";
 import { GithubSSOForm } from "./components/github-sso-form";
 import { RBACForm } from "./components/rbac-form";
 import { StaticAdminForm } from "./components/static-admin-form";
@@ -28,6 +29,7 @@ export const SettingsProjectPage: FC = memo(function SettingsProjectPage() {
       <StaticAdminForm />
       <GithubSSOForm />
       <RBACForm />
+      <DeployWindowsForm />
     </div>
   );
 });
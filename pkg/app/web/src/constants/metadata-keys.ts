@@ -1 +1,3 @@
 export const METADATA_APPROVED_BY = "ApprovedBy";
+export const METADATA_WAITING_APPROVAL = "WaitingApproval";
+export const METADATA_SKIPPABLE = "Skippable";
@@ -1,3 +1,4 @@
 export const STATIC_ADMIN_DESCRIPTION = `An admin account that was automatically generated while initializing the project. This admin account can be logged in by using the provided or configured username and password.`;
 export const SSO_DESCRIPTION = `Single sign-on (SSO) allows to user to log in to PipeCD by relying on a trusted third party service such as GitHub, GitHub Enterprise, Google Gmail, BitBucket...`;
+export const DEPLOY_WINDOWS_DESCRIPTION = `Deploy windows restrict the time windows in which deployments of all applications in the project are allowed to start. They are applied in addition to the ones configured in the piped and application configurations.`;
 export const RBAC_DESCRIPTION = `Role-based access control (RBAC) allows restricting the access on PipeCD web based on the roles of user groups within the project. Before using this feature, the SSO must be configured.`;
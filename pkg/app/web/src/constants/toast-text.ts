@@ -2,6 +2,8 @@ export const UPDATE_STATIC_ADMIN_INFO_SUCCESS =
   "Successfully updated Static Admin configurations.";
 export const UPDATE_RBAC_SUCCESS = "Successfully updated RBAC configurations.";
 export const UPDATE_SSO_SUCCESS = "Successfully updated SSO configurations.";
+export const UPDATE_DEPLOY_WINDOWS_SUCCESS =
+  "Successfully updated deploy windows.";
 
 // API Key
 export const GENERATE_API_KEY_SUCCESS = "Successfully generated API Key.";
@@ -84,5 +84,8 @@ export const applicationLiveStateSlice = createSlice({
 
 export {
   ApplicationLiveStateSnapshot,
+  CloudRunResourceState,
+  ECSResourceState,
   KubernetesResourceState,
+  LambdaResourceState,
 } from "pipe/pkg/app/web/model/application_live_state_pb";
@@ -20,6 +20,10 @@ export const COMMAND_TYPE_TEXT: Record<Command.Type, string> = {
   [Command.Type.UPDATE_APPLICATION_CONFIG]: "Update Application Config",
   [Command.Type.BUILD_PLAN_PREVIEW]: "Build Plan Preview",
   [Command.Type.CHAIN_SYNC_APPLICATION]: "Chain Sync Application",
+  [Command.Type.RETRY_STAGE]: "Retry Stage",
+  [Command.Type.SKIP_STAGE]: "Skip Stage",
+  [Command.Type.OVERRIDE_DEPLOY_WINDOW]: "Override Deploy Window",
+  [Command.Type.REJECT_STAGE]: "Reject Stage",
 };
 
 const commandsAdapter = createEntityAdapter<Command.AsObject>();
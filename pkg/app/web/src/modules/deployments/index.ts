@@ -64,6 +64,7 @@ export const isStageRunning = (status: StageStatus): boolean => {
     case StageStatus.STAGE_SUCCESS:
     case StageStatus.STAGE_FAILURE:
     case StageStatus.STAGE_CANCELLED:
+    case StageStatus.STAGE_SKIPPED:
       return false;
   }
 };
@@ -172,6 +173,38 @@ export const approveStage = createAsyncThunk<
   await thunkAPI.dispatch(fetchCommand(commandId));
 });
 
+export const rejectStage = createAsyncThunk<
+  void,
+  { deploymentId: string; stageId: string; reason: string }
+>("deployments/rejectStage", async (props, thunkAPI) => {
+  const { commandId } = await deploymentsApi.rejectStage(props);
+  await thunkAPI.dispatch(fetchCommand(commandId));
+});
+
+export const retryStage = createAsyncThunk<
+  void,
+  { deploymentId: string; stageId: string }
+>("deployments/retryStage", async (props, thunkAPI) => {
+  const { commandId } = await deploymentsApi.retryStage(props);
+  await thunkAPI.dispatch(fetchCommand(commandId));
+});
+
+export const skipStage = createAsyncThunk<
+  void,
+  { deploymentId: string; stageId: string }
+>("deployments/skipStage", async (props, thunkAPI) => {
+  const { commandId } = await deploymentsApi.skipStage(props);
+  await thunkAPI.dispatch(fetchCommand(commandId));
+});
+
+export const overrideDeployWindow = createAsyncThunk<
+  void,
+  { deploymentId: string; reason: string }
+>("deployments/overrideDeployWindow", async (props, thunkAPI) => {
+  const { commandId } = await deploymentsApi.overrideDeployWindow(props);
+  await thunkAPI.dispatch(fetchCommand(commandId));
+});
+
 export const cancelDeployment = createAsyncThunk<
   void,
   {
@@ -282,4 +315,5 @@ export {
   DeploymentStatus,
   StageStatus,
   PipelineStage,
+  TriggerKind,
 } from "pipe/pkg/app/web/model/deployment_pb";
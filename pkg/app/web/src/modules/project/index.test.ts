@@ -38,9 +38,23 @@ describe("projectSlice reducer", () => {
             baseUrl: "base-url",
             uploadUrl: "upload-url",
           },
+          deployWindows: {
+            timezone: "Asia/Tokyo",
+            allowList: [],
+            denyList: [
+              { schedule: "0 18 * * 5", duration: "62h", description: "" },
+            ],
+          },
         },
       })
     ).toEqual({
+      deployWindows: {
+        timezone: "Asia/Tokyo",
+        allowList: [],
+        denyList: [
+          { schedule: "0 18 * * 5", duration: "62h", description: "" },
+        ],
+      },
       desc: "desc",
       github: {
         baseUrl: "base-url",
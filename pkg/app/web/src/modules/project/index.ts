@@ -1,5 +1,6 @@
 import { createAsyncThunk, createSlice } from "@reduxjs/toolkit";
 import {
+  ProjectDeployWindows,
   ProjectRBACConfig,
   ProjectSSOConfig,
 } from "pipe/pkg/app/web/model/project_pb";
@@ -8,6 +9,7 @@ import type { AppState } from "~/store";
 
 export type GitHubSSO = ProjectSSOConfig.GitHub.AsObject;
 export type Teams = ProjectRBACConfig.AsObject;
+export type DeployWindows = ProjectDeployWindows.AsObject;
 
 export interface ProjectState {
   id: string | null;
@@ -19,6 +21,7 @@ export interface ProjectState {
   sharedSSO: string | null;
   teams?: Teams | null;
   github?: GitHubSSO | null;
+  deployWindows?: DeployWindows | null;
 }
 
 const initialState: ProjectState = {
@@ -39,6 +42,7 @@ export const fetchProject = createAsyncThunk<{
   sharedSSO: string | null;
   staticAdminDisabled: boolean;
   github: GitHubSSO | null;
+  deployWindows: DeployWindows | null;
 }>("project/fetchProject", async () => {
   const { project } = await projectAPI.getProject();
 
@@ -51,6 +55,7 @@ export const fetchProject = createAsyncThunk<{
       teams: null,
       github: null,
       sharedSSO: null,
+      deployWindows: null,
     };
   }
 
@@ -62,6 +67,7 @@ export const fetchProject = createAsyncThunk<{
     teams: project.rbac ?? null,
     github: project.sso?.github ?? null,
     sharedSSO: project.sharedSsoName,
+    deployWindows: project.deployWindows ?? null,
   };
 });
 
@@ -102,6 +108,13 @@ export const updateRBAC = createAsyncThunk<
   await projectAPI.updateRBAC(Object.assign({}, project.teams, params));
 });
 
+export const updateDeployWindows = createAsyncThunk<
+  void,
+  DeployWindows | null
+>("project/updateDeployWindows", async (params) => {
+  await projectAPI.updateDeployWindows(params);
+});
+
 export const projectSlice = createSlice({
   name: "project",
   initialState,
@@ -117,6 +130,7 @@ export const projectSlice = createSlice({
         state.teams = action.payload.teams;
         state.github = action.payload.github;
         state.sharedSSO = action.payload.sharedSSO;
+        state.deployWindows = action.payload.deployWindows;
       })
       // .addCase(fetchProject.rejected, (_, action) => {})
       .addCase(updateStaticAdmin.pending, (state) => {
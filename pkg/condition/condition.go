@@ -0,0 +1,637 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package condition provides a small expression language
+// used to decide whether a pipeline stage should be executed.
+//
+// An expression is evaluated to a boolean value, for example:
+//
+//	trigger.kind == "ON_COMMIT" && !(commit.message =~ "^\\[skip-approval\\]")
+//	changed("apps/web/**") || stages.canary.status == "SKIPPED"
+//
+// The supported variables are:
+//   - trigger.kind: ON_COMMIT, ON_COMMAND, ON_OUT_OF_SYNC or ON_CHAIN
+//   - trigger.commander
+//   - commit.hash, commit.message, commit.branch and commit.author
+//   - labels.<key>: the value of the deployment label, empty when it is not set
+//   - stages.<id>.status: the status of the stage such as SUCCESS, FAILURE or SKIPPED
+//
+// The supported functions are:
+//   - changed(patterns...): whether any files changed by the deployment match the patterns
+//   - contains(s, substr) and startsWith(s, prefix)
+package condition
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pipe-cd/pipecd/pkg/filematcher"
+)
+
+// Env provides the values used while evaluating an expression.
+type Env struct {
+	// Variables holds the values of the variables keyed by their names such as "commit.branch".
+	// The variables not contained are considered as empty strings.
+	Variables map[string]string
+	// ChangedFiles returns the list of files changed by the deployment.
+	// It is called only when the expression is using the changed function.
+	// A nil function means that all files should be considered as changed.
+	ChangedFiles func() ([]string, error)
+}
+
+// Expression represents a parsed condition expression.
+type Expression struct {
+	raw      string
+	root     node
+	stageIDs []string
+}
+
+// Parse parses the given string into an expression
+// and checks whether it can be evaluated to a boolean value.
+func Parse(s string) (*Expression, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", t, t.pos)
+	}
+	if root.valueType() != typeBool {
+		return nil, fmt.Errorf("expression must be evaluated to a boolean value")
+	}
+	return &Expression{
+		raw:      s,
+		root:     root,
+		stageIDs: p.stageIDs,
+	}, nil
+}
+
+// String returns the original string of the expression.
+func (e *Expression) String() string {
+	return e.raw
+}
+
+// StageIDs returns the IDs of the stages whose results are referenced by the expression.
+func (e *Expression) StageIDs() []string {
+	return e.stageIDs
+}
+
+// Evaluate evaluates the expression with the given environment.
+func (e *Expression) Evaluate(env Env) (bool, error) {
+	ev := &evaluator{env: env}
+	v, err := e.root.eval(ev)
+	if err != nil {
+		return false, err
+	}
+	return v.b, nil
+}
+
+type evaluator struct {
+	env          Env
+	changedFiles []string
+	loaded       bool
+}
+
+func (e *evaluator) lookup(name string) string {
+	return e.env.Variables[name]
+}
+
+func (e *evaluator) listChangedFiles() ([]string, bool, error) {
+	if e.env.ChangedFiles == nil {
+		return nil, false, nil
+	}
+	if !e.loaded {
+		files, err := e.env.ChangedFiles()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list the changed files: %w", err)
+		}
+		e.changedFiles = files
+		e.loaded = true
+	}
+	return e.changedFiles, true, nil
+}
+
+type valueType int
+
+const (
+	typeString valueType = iota
+	typeBool
+)
+
+func (t valueType) String() string {
+	if t == typeBool {
+		return "boolean"
+	}
+	return "string"
+}
+
+type value struct {
+	s string
+	b bool
+}
+
+type node interface {
+	valueType() valueType
+	eval(e *evaluator) (value, error)
+}
+
+type literalNode struct {
+	typ valueType
+	v   value
+}
+
+func (n *literalNode) valueType() valueType { return n.typ }
+
+func (n *literalNode) eval(_ *evaluator) (value, error) { return n.v, nil }
+
+type variableNode struct {
+	name string
+}
+
+func (n *variableNode) valueType() valueType { return typeString }
+
+func (n *variableNode) eval(e *evaluator) (value, error) {
+	return value{s: e.lookup(n.name)}, nil
+}
+
+type notNode struct {
+	x node
+}
+
+func (n *notNode) valueType() valueType { return typeBool }
+
+func (n *notNode) eval(e *evaluator) (value, error) {
+	v, err := n.x.eval(e)
+	if err != nil {
+		return value{}, err
+	}
+	return value{b: !v.b}, nil
+}
+
+type logicalNode struct {
+	and  bool
+	l, r node
+}
+
+func (n *logicalNode) valueType() valueType { return typeBool }
+
+func (n *logicalNode) eval(e *evaluator) (value, error) {
+	l, err := n.l.eval(e)
+	if err != nil {
+		return value{}, err
+	}
+	// Short-circuit evaluation to avoid listing the changed files when not needed.
+	if l.b != n.and {
+		return l, nil
+	}
+	return n.r.eval(e)
+}
+
+type compareNode struct {
+	equal bool
+	l, r  node
+}
+
+func (n *compareNode) valueType() valueType { return typeBool }
+
+func (n *compareNode) eval(e *evaluator) (value, error) {
+	l, err := n.l.eval(e)
+	if err != nil {
+		return value{}, err
+	}
+	r, err := n.r.eval(e)
+	if err != nil {
+		return value{}, err
+	}
+	return value{b: (l == r) == n.equal}, nil
+}
+
+type matchNode struct {
+	negate bool
+	x      node
+	re     *regexp.Regexp
+}
+
+func (n *matchNode) valueType() valueType { return typeBool }
+
+func (n *matchNode) eval(e *evaluator) (value, error) {
+	v, err := n.x.eval(e)
+	if err != nil {
+		return value{}, err
+	}
+	return value{b: n.re.MatchString(v.s) != n.negate}, nil
+}
+
+type changedNode struct {
+	matcher *filematcher.PatternMatcher
+}
+
+func (n *changedNode) valueType() valueType { return typeBool }
+
+func (n *changedNode) eval(e *evaluator) (value, error) {
+	files, ok, err := e.listChangedFiles()
+	if err != nil {
+		return value{}, err
+	}
+	if !ok {
+		return value{b: true}, nil
+	}
+	return value{b: n.matcher.MatchesAny(files)}, nil
+}
+
+type stringFuncNode struct {
+	fn   func(s, t string) bool
+	args [2]node
+}
+
+func (n *stringFuncNode) valueType() valueType { return typeBool }
+
+func (n *stringFuncNode) eval(e *evaluator) (value, error) {
+	s, err := n.args[0].eval(e)
+	if err != nil {
+		return value{}, err
+	}
+	t, err := n.args[1].eval(e)
+	if err != nil {
+		return value{}, err
+	}
+	return value{b: n.fn(s.s, t.s)}, nil
+}
+
+var stringFuncs = map[string]func(s, t string) bool{
+	"contains":   strings.Contains,
+	"startsWith": strings.HasPrefix,
+}
+
+type parser struct {
+	tokens   []token
+	pos      int
+	stageIDs []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, fmt.Errorf("expected %s but got %s at position %d", kind, t, t.pos)
+	}
+	return t, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseLogical(tokenOr, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseLogical(tokenAnd, p.parseUnary)
+}
+
+func (p *parser) parseLogical(op tokenKind, operand func() (node, error)) (node, error) {
+	l, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == op {
+		t := p.next()
+		r, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if l.valueType() != typeBool || r.valueType() != typeBool {
+			return nil, fmt.Errorf("operands of %s at position %d must be boolean values", t, t.pos)
+		}
+		l = &logicalNode{and: op == tokenAnd, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind != tokenNot {
+		return p.parseComparison()
+	}
+	t := p.next()
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if x.valueType() != typeBool {
+		return nil, fmt.Errorf("operand of %s at position %d must be a boolean value", t, t.pos)
+	}
+	return &notNode{x: x}, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	l, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	switch t.kind {
+	case tokenEqual, tokenNotEqual:
+		p.next()
+		r, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if l.valueType() != r.valueType() {
+			return nil, fmt.Errorf("unable to compare %s with %s at position %d", l.valueType(), r.valueType(), t.pos)
+		}
+		return &compareNode{equal: t.kind == tokenEqual, l: l, r: r}, nil
+
+	case tokenMatch, tokenNotMatch:
+		p.next()
+		r, err := p.expect(tokenString)
+		if err != nil {
+			return nil, fmt.Errorf("right operand of %s must be a string literal: %w", t, err)
+		}
+		if l.valueType() != typeString {
+			return nil, fmt.Errorf("left operand of %s at position %d must be a string value", t, t.pos)
+		}
+		re, err := regexp.Compile(r.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q at position %d: %w", r.text, r.pos, err)
+		}
+		return &matchNode{negate: t.kind == tokenNotMatch, x: l, re: re}, nil
+	}
+
+	return l, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen); err != nil {
+			return nil, err
+		}
+		return x, nil
+
+	case tokenString:
+		return &literalNode{typ: typeString, v: value{s: t.text}}, nil
+
+	case tokenIdent:
+		if p.peek().kind == tokenLParen {
+			return p.parseCall(t)
+		}
+		switch t.text {
+		case "true", "false":
+			return &literalNode{typ: typeBool, v: value{b: t.text == "true"}}, nil
+		}
+		if err := p.checkVariable(t.text); err != nil {
+			return nil, fmt.Errorf("%w at position %d", err, t.pos)
+		}
+		return &variableNode{name: t.text}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %s at position %d", t, t.pos)
+}
+
+func (p *parser) parseCall(fn token) (node, error) {
+	p.next()
+	var args []node
+	for p.peek().kind != tokenRParen {
+		if len(args) > 0 {
+			if _, err := p.expect(tokenComma); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if arg.valueType() != typeString {
+			return nil, fmt.Errorf("arguments of %s at position %d must be string values", fn.text, fn.pos)
+		}
+		args = append(args, arg)
+	}
+	p.next()
+
+	if fn.text == "changed" {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("changed at position %d requires at least one pattern", fn.pos)
+		}
+		patterns := make([]string, 0, len(args))
+		for _, arg := range args {
+			lit, ok := arg.(*literalNode)
+			if !ok {
+				return nil, fmt.Errorf("arguments of changed at position %d must be string literals", fn.pos)
+			}
+			patterns = append(patterns, lit.v.s)
+		}
+		matcher, err := filematcher.NewPatternMatcher(patterns)
+		if err != nil {
+			return nil, fmt.Errorf("invalid patterns of changed at position %d: %w", fn.pos, err)
+		}
+		return &changedNode{matcher: matcher}, nil
+	}
+
+	f, ok := stringFuncs[fn.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s at position %d", fn.text, fn.pos)
+	}
+	if len(args) != 2 {
+		return nil, fmt.Errorf("%s at position %d requires 2 arguments but got %d", fn.text, fn.pos, len(args))
+	}
+	return &stringFuncNode{fn: f, args: [2]node{args[0], args[1]}}, nil
+}
+
+func (p *parser) checkVariable(name string) error {
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) == 2 {
+		switch parts[0] {
+		case "trigger":
+			if parts[1] == "kind" || parts[1] == "commander" {
+				return nil
+			}
+		case "commit":
+			switch parts[1] {
+			case "hash", "message", "branch", "author":
+				return nil
+			}
+		case "labels":
+			if parts[1] != "" {
+				return nil
+			}
+		case "stages":
+			if id := strings.TrimSuffix(parts[1], ".status"); id != parts[1] && id != "" {
+				p.stageIDs = append(p.stageIDs, id)
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unknown variable %s", name)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenNot
+	tokenAnd
+	tokenOr
+	tokenEqual
+	tokenNotEqual
+	tokenMatch
+	tokenNotMatch
+)
+
+var tokenNames = map[tokenKind]string{
+	tokenEOF:      "end of expression",
+	tokenIdent:    "identifier",
+	tokenString:   "string",
+	tokenLParen:   "(",
+	tokenRParen:   ")",
+	tokenComma:    ",",
+	tokenNot:      "!",
+	tokenAnd:      "&&",
+	tokenOr:       "||",
+	tokenEqual:    "==",
+	tokenNotEqual: "!=",
+	tokenMatch:    "=~",
+	tokenNotMatch: "!~",
+}
+
+func (k tokenKind) String() string {
+	return tokenNames[k]
+}
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenIdent:
+		return t.text
+	case tokenString:
+		return fmt.Sprintf("%q", t.text)
+	}
+	return t.kind.String()
+}
+
+var operators = []struct {
+	text string
+	kind tokenKind
+}{
+	{"&&", tokenAnd},
+	{"||", tokenOr},
+	{"==", tokenEqual},
+	{"!=", tokenNotEqual},
+	{"=~", tokenMatch},
+	{"!~", tokenNotMatch},
+	{"!", tokenNot},
+	{"(", tokenLParen},
+	{")", tokenRParen},
+	{",", tokenComma},
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '"' || c == '\'':
+			text, n, err := readString(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at position %d", err, i)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: i})
+			i += n
+
+		case isIdentStart(c):
+			start := i
+			for i < len(s) && isIdentPart(s[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: s[start:i], pos: start})
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(s[i:], op.text) {
+					tokens = append(tokens, token{kind: op.kind, text: op.text, pos: i})
+					i += len(op.text)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(s)}), nil
+}
+
+// readString reads a quoted string at the beginning of the given string
+// and returns its unquoted value and the number of consumed bytes.
+// Only the quote and the backslash can be escaped by a backslash.
+func readString(s string) (string, int, error) {
+	var (
+		quote = s[0]
+		b     strings.Builder
+	)
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(s) && (s[i+1] == quote || s[i+1] == '\\'):
+			b.WriteByte(s[i+1])
+			i++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9') || c == '.' || c == '-' || c == '/'
+}
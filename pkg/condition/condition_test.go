@@ -0,0 +1,249 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package condition
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testcases := []struct {
+		name     string
+		expr     string
+		stageIDs []string
+		wantErr  bool
+	}{
+		{
+			name: "comparison",
+			expr: `trigger.kind == "ON_COMMIT"`,
+		},
+		{
+			name:     "complex expression",
+			expr:     `(commit.branch != 'main' || labels.env == "dev") && !changed("apps/**/*.yaml", "!apps/**/config.yaml") && stages.canary-rollout.status == "SUCCESS"`,
+			stageIDs: []string{"canary-rollout"},
+		},
+		{
+			name: "regular expression",
+			expr: `commit.message =~ "^\\[hotfix\\]"`,
+		},
+		{
+			name: "functions",
+			expr: `contains(commit.message, "config") || startsWith(labels.team, "platform")`,
+		},
+		{
+			name: "boolean comparison",
+			expr: `changed("README.md") == false`,
+		},
+		{
+			name:    "empty",
+			expr:    "",
+			wantErr: true,
+		},
+		{
+			name:    "not a boolean expression",
+			expr:    "commit.message",
+			wantErr: true,
+		},
+		{
+			name:    "unknown variable",
+			expr:    `commit.unknown == "value"`,
+			wantErr: true,
+		},
+		{
+			name:    "stage without status",
+			expr:    `stages.canary == "SUCCESS"`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown function",
+			expr:    `endsWith(commit.message, "value")`,
+			wantErr: true,
+		},
+		{
+			name:    "changed without patterns",
+			expr:    `changed()`,
+			wantErr: true,
+		},
+		{
+			name:    "changed with a variable",
+			expr:    `changed(commit.message)`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid regular expression",
+			expr:    `commit.message =~ "("`,
+			wantErr: true,
+		},
+		{
+			name:    "comparing different types",
+			expr:    `commit.message == true`,
+			wantErr: true,
+		},
+		{
+			name:    "logical operator with string",
+			expr:    `commit.message && true`,
+			wantErr: true,
+		},
+		{
+			name:    "unterminated string",
+			expr:    `commit.message == "value`,
+			wantErr: true,
+		},
+		{
+			name:    "unclosed parenthesis",
+			expr:    `(true || false`,
+			wantErr: true,
+		},
+		{
+			name:    "trailing token",
+			expr:    `true false`,
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := Parse(tc.expr)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expr, e.String())
+			assert.Equal(t, tc.stageIDs, e.StageIDs())
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	env := Env{
+		Variables: map[string]string{
+			"trigger.kind":         "ON_COMMIT",
+			"commit.branch":        "main",
+			"commit.message":       "[config] Update replicas",
+			"labels.env":           "prod",
+			"stages.canary.status": "SKIPPED",
+		},
+		ChangedFiles: func() ([]string, error) {
+			return []string{"apps/web/config.yaml", "apps/web/README.md"}, nil
+		},
+	}
+
+	testcases := []struct {
+		name     string
+		expr     string
+		env      Env
+		expected bool
+		wantErr  bool
+	}{
+		{
+			name:     "equal",
+			expr:     `trigger.kind == "ON_COMMIT"`,
+			env:      env,
+			expected: true,
+		},
+		{
+			name:     "not equal",
+			expr:     `commit.branch != "main"`,
+			env:      env,
+			expected: false,
+		},
+		{
+			name:     "missing label is empty",
+			expr:     `labels.team == ""`,
+			env:      env,
+			expected: true,
+		},
+		{
+			name:     "regular expression",
+			expr:     `commit.message =~ "^\\[config\\]"`,
+			env:      env,
+			expected: true,
+		},
+		{
+			name:     "negated regular expression",
+			expr:     `commit.message !~ "^\\[config\\]"`,
+			env:      env,
+			expected: false,
+		},
+		{
+			name:     "logical operators",
+			expr:     `!(labels.env == "dev" || stages.canary.status == "SUCCESS") && true`,
+			env:      env,
+			expected: true,
+		},
+		{
+			name:     "changed files matched",
+			expr:     `changed("apps/web/*.yaml")`,
+			env:      env,
+			expected: true,
+		},
+		{
+			name:     "changed files not matched",
+			expr:     `changed("apps/web/**/*.go", "apps/api")`,
+			env:      env,
+			expected: false,
+		},
+		{
+			name:     "all files are considered as changed without the list",
+			expr:     `changed("apps/web/**/*.go")`,
+			env:      Env{},
+			expected: true,
+		},
+		{
+			name:     "functions",
+			expr:     `contains(commit.message, "replicas") && startsWith(labels.env, "pro")`,
+			env:      env,
+			expected: true,
+		},
+		{
+			name: "failed to list changed files",
+			expr: `changed("apps/web")`,
+			env: Env{
+				ChangedFiles: func() ([]string, error) {
+					return nil, errors.New("failed")
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "changed files are not listed when not needed",
+			expr: `true || changed("apps/web")`,
+			env: Env{
+				ChangedFiles: func() ([]string, error) {
+					return nil, errors.New("failed")
+				},
+			},
+			expected: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			e, err := Parse(tc.expr)
+			require.NoError(t, err)
+
+			got, err := e.Evaluate(tc.env)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -16,6 +16,7 @@ package config
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -29,6 +30,10 @@ const (
 	AnalysisDeviationEither = "EITHER"
 	AnalysisDeviationHigh   = "HIGH"
 	AnalysisDeviationLow    = "LOW"
+
+	AnalysisAlgorithmMannWhitney         = "MANN_WHITNEY"
+	AnalysisAlgorithmKolmogorovSmirnov   = "KOLMOGOROV_SMIRNOV"
+	AnalysisAlgorithmPercentageDeviation = "PERCENTAGE_DEVIATION"
 )
 
 // AnalysisMetrics contains common configurable values for deployment analysis with metrics.
@@ -58,10 +63,24 @@ type AnalysisMetrics struct {
 	// How long after which the query times out.
 	// Default is 30s.
 	Timeout Duration `json:"timeout"`
+	// The weight of this analysis used to compute the overall score.
+	// This is used only when scoring is enabled in the ANALYSIS stage. Defaults to 1.
+	Weight float64 `json:"weight" default:"1"`
 
 	// The stage fails on deviation in the specified direction. One of LOW or HIGH or EITHER is available.
 	// This can be used only for PREVIOUS, CANARY_BASELINE or CANARY_PRIMARY. Defaults to EITHER.
 	Deviation string `json:"deviation" default:"EITHER"`
+	// The algorithm used to compare two time-series data.
+	// One of MANN_WHITNEY or KOLMOGOROV_SMIRNOV or PERCENTAGE_DEVIATION is available.
+	// This can be used only for PREVIOUS, CANARY_BASELINE or CANARY_PRIMARY. Defaults to MANN_WHITNEY.
+	Algorithm string `json:"algorithm" default:"MANN_WHITNEY"`
+	// The significance level used to reject the null hypothesis that two data are from the same distribution.
+	// This can be used only for MANN_WHITNEY and KOLMOGOROV_SMIRNOV. Defaults to 0.05.
+	SignificanceLevel float64 `json:"significanceLevel" default:"0.05"`
+	// The acceptable deviation of the mean in percentage. For instance, if 10 is set,
+	// it fails when the mean of the experiment is 10% higher or lower than the one of the control.
+	// Required field for PERCENTAGE_DEVIATION.
+	Tolerance float64 `json:"tolerance"`
 	// The custom arguments to be populated for the Canary query.
 	// They can be referred as {{ .VariantArgs.xxx }}.
 	CanaryArgs map[string]string `json:"canaryArgs"`
@@ -83,9 +102,24 @@ func (m *AnalysisMetrics) Validate() error {
 	if m.Interval == 0 {
 		return fmt.Errorf("missing \"interval\" field")
 	}
+	if m.Weight < 0 {
+		return fmt.Errorf("\"weight\" must not be negative")
+	}
 	if m.Deviation != AnalysisDeviationEither && m.Deviation != AnalysisDeviationHigh && m.Deviation != AnalysisDeviationLow {
 		return fmt.Errorf("\"deviation\" have to be one of %s, %s or %s", AnalysisDeviationEither, AnalysisDeviationHigh, AnalysisDeviationLow)
 	}
+	switch m.Algorithm {
+	case AnalysisAlgorithmMannWhitney, AnalysisAlgorithmKolmogorovSmirnov:
+		if m.SignificanceLevel <= 0 || m.SignificanceLevel >= 1 {
+			return fmt.Errorf("\"significanceLevel\" must be greater than 0 and less than 1")
+		}
+	case AnalysisAlgorithmPercentageDeviation:
+		if m.Tolerance <= 0 {
+			return fmt.Errorf("\"tolerance\" must be greater than 0 for %s", AnalysisAlgorithmPercentageDeviation)
+		}
+	default:
+		return fmt.Errorf("\"algorithm\" have to be one of %s, %s or %s", AnalysisAlgorithmMannWhitney, AnalysisAlgorithmKolmogorovSmirnov, AnalysisAlgorithmPercentageDeviation)
+	}
 	return nil
 }
 
@@ -145,9 +179,15 @@ type AnalysisLog struct {
 	// How long after which the query times out.
 	Timeout  Duration `json:"timeout"`
 	Provider string   `json:"provider"`
+	// The weight of this analysis used to compute the overall score.
+	// This is used only when scoring is enabled in the ANALYSIS stage. Defaults to 1.
+	Weight float64 `json:"weight" default:"1"`
 }
 
 func (a *AnalysisLog) Validate() error {
+	if a.Weight < 0 {
+		return fmt.Errorf("\"weight\" must not be negative")
+	}
 	return nil
 }
 
@@ -156,19 +196,96 @@ type AnalysisHTTP struct {
 	URL    string `json:"url"`
 	Method string `json:"method"`
 	// Custom headers to set in the request. HTTP allows repeated headers.
-	Headers          []AnalysisHTTPHeader `json:"headers"`
-	ExpectedCode     int                  `json:"expectedCode"`
-	ExpectedResponse string               `json:"expectedResponse"`
-	Interval         Duration             `json:"interval"`
+	Headers []AnalysisHTTPHeader `json:"headers"`
+	// The body to send in the request.
+	Body string `json:"body"`
+	// The basic authentication credentials to set in the request.
+	BasicAuth *AnalysisHTTPBasicAuth `json:"basicAuth"`
+	// The path to the file containing the bearer token to set in the request.
+	BearerTokenFile string `json:"bearerTokenFile"`
+	ExpectedCode    int    `json:"expectedCode"`
+	// The response body must be exactly equal to this.
+	ExpectedResponse string `json:"expectedResponse"`
+	// The response body must match this RE2 style regular expression.
+	ExpectedResponseRegex string `json:"expectedResponseRegex"`
+	// List of assertions against the JSON response body.
+	ExpectedJSON []AnalysisHTTPJSONAssertion `json:"expectedJSON"`
+	// The maximum time allowed to receive the whole response.
+	MaxLatency Duration `json:"maxLatency"`
+	Interval   Duration `json:"interval"`
 	// Maximum number of failed checks before the response is considered as failure.
 	FailureLimit int `json:"failureLimit"`
 	// If true, it considers as success when no data returned from the analysis provider.
 	// Default is false.
 	SkipOnNoData bool     `json:"skipOnNoData"`
 	Timeout      Duration `json:"timeout"`
+	// The weight of this analysis used to compute the overall score.
+	// This is used only when scoring is enabled in the ANALYSIS stage. Defaults to 1.
+	Weight float64 `json:"weight" default:"1"`
 }
 
 func (a *AnalysisHTTP) Validate() error {
+	if a.Weight < 0 {
+		return fmt.Errorf("\"weight\" must not be negative")
+	}
+	if a.ExpectedResponseRegex != "" {
+		if _, err := regexp.Compile(a.ExpectedResponseRegex); err != nil {
+			return fmt.Errorf("invalid expectedResponseRegex %q: %w", a.ExpectedResponseRegex, err)
+		}
+	}
+	if a.BasicAuth != nil {
+		if err := a.BasicAuth.Validate(); err != nil {
+			return err
+		}
+		if a.BearerTokenFile != "" {
+			return fmt.Errorf("only one of basicAuth or bearerTokenFile can be specified")
+		}
+	}
+	for _, j := range a.ExpectedJSON {
+		if err := j.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type AnalysisHTTPBasicAuth struct {
+	// The path to the username file.
+	UsernameFile string `json:"usernameFile"`
+	// The path to the password file.
+	PasswordFile string `json:"passwordFile"`
+}
+
+func (a *AnalysisHTTPBasicAuth) Validate() error {
+	if a.UsernameFile == "" || a.PasswordFile == "" {
+		return fmt.Errorf("basicAuth requires both usernameFile and passwordFile")
+	}
+	return nil
+}
+
+// AnalysisHTTPJSONAssertion represents an assertion against the value
+// found at the given JSONPath in the JSON response body.
+type AnalysisHTTPJSONAssertion struct {
+	// The JSONPath to the value to be checked, e.g. "$.status".
+	Path string `json:"path"`
+	// The value must be equal to this.
+	Equal interface{} `json:"equal"`
+	// The value must be a number greater than or equal to this.
+	Min *float64 `json:"min"`
+	// The value must be a number less than or equal to this.
+	Max *float64 `json:"max"`
+}
+
+func (a *AnalysisHTTPJSONAssertion) Validate() error {
+	if a.Path == "" {
+		return fmt.Errorf("expectedJSON requires path field")
+	}
+	if a.Equal == nil && a.Min == nil && a.Max == nil {
+		return fmt.Errorf("expectedJSON for %s requires at least one of equal, min or max", a.Path)
+	}
+	if a.Min != nil && a.Max != nil && *a.Min > *a.Max {
+		return fmt.Errorf("expectedJSON for %s has min greater than max", a.Path)
+	}
 	return nil
 }
 
@@ -16,6 +16,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -63,3 +64,138 @@ func TestAnalysisExpectedString(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateAnalysisHTTP(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     AnalysisHTTP
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			cfg: AnalysisHTTP{
+				ExpectedResponseRegex: `"status":\s*"ok"`,
+				BearerTokenFile:       "/etc/piped-secret/token",
+				ExpectedJSON: []AnalysisHTTPJSONAssertion{
+					{Path: "$.latency", Min: floatPointer(0), Max: floatPointer(100)},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid regex",
+			cfg: AnalysisHTTP{
+				ExpectedResponseRegex: "(",
+			},
+			wantErr: true,
+		},
+		{
+			name: "both basic auth and bearer token given",
+			cfg: AnalysisHTTP{
+				BasicAuth: &AnalysisHTTPBasicAuth{
+					UsernameFile: "/etc/piped-secret/username",
+					PasswordFile: "/etc/piped-secret/password",
+				},
+				BearerTokenFile: "/etc/piped-secret/token",
+			},
+			wantErr: true,
+		},
+		{
+			name: "json assertion without any expectation",
+			cfg: AnalysisHTTP{
+				ExpectedJSON: []AnalysisHTTPJSONAssertion{
+					{Path: "$.status"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "json assertion with invalid range",
+			cfg: AnalysisHTTP{
+				ExpectedJSON: []AnalysisHTTPJSONAssertion{
+					{Path: "$.latency", Min: floatPointer(10), Max: floatPointer(1)},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestValidateAnalysisMetrics(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     AnalysisMetrics
+		wantErr bool
+	}{
+		{
+			name: "valid with mann-whitney",
+			cfg: AnalysisMetrics{
+				Provider:          "prometheus-dev",
+				Query:             "foo",
+				Interval:          Duration(time.Minute),
+				Deviation:         AnalysisDeviationHigh,
+				Algorithm:         AnalysisAlgorithmMannWhitney,
+				SignificanceLevel: 0.05,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid significance level",
+			cfg: AnalysisMetrics{
+				Provider:          "prometheus-dev",
+				Query:             "foo",
+				Interval:          Duration(time.Minute),
+				Deviation:         AnalysisDeviationHigh,
+				Algorithm:         AnalysisAlgorithmKolmogorovSmirnov,
+				SignificanceLevel: 1.5,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid with percentage deviation",
+			cfg: AnalysisMetrics{
+				Provider:  "prometheus-dev",
+				Query:     "foo",
+				Interval:  Duration(time.Minute),
+				Deviation: AnalysisDeviationEither,
+				Algorithm: AnalysisAlgorithmPercentageDeviation,
+				Tolerance: 10,
+			},
+			wantErr: false,
+		},
+		{
+			name: "percentage deviation without tolerance",
+			cfg: AnalysisMetrics{
+				Provider:  "prometheus-dev",
+				Query:     "foo",
+				Interval:  Duration(time.Minute),
+				Deviation: AnalysisDeviationEither,
+				Algorithm: AnalysisAlgorithmPercentageDeviation,
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown algorithm",
+			cfg: AnalysisMetrics{
+				Provider:  "prometheus-dev",
+				Query:     "foo",
+				Interval:  Duration(time.Minute),
+				Deviation: AnalysisDeviationEither,
+				Algorithm: "UNKNOWN",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
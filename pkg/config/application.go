@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/pipe-cd/pipecd/pkg/condition"
 	"github.com/pipe-cd/pipecd/pkg/model"
 )
 
@@ -57,6 +58,13 @@ type GenericApplicationSpec struct {
 	// The maximum length of time to execute deployment before giving up.
 	// Default is 6h.
 	Timeout Duration `json:"timeout,omitempty" default:"6h"`
+	// How long to wait for a RETRY_STAGE or SKIP_STAGE command after a stage was failed
+	// before marking the deployment as failed and starting the rollback.
+	// Default is 0, which means the deployment is failed immediately.
+	RollbackGracePeriod Duration `json:"rollbackGracePeriod,omitempty"`
+	// The time windows in which deployments of this application are allowed to start.
+	// Deployments triggered outside of them stay PENDING until a window opens.
+	DeployWindows *DeployWindows `json:"deployWindows"`
 	// List of encrypted secrets and targets that should be decoded before using.
 	Encryption *SecretEncryption `json:"encryption"`
 	// Additional configuration used while sending notification to external services.
@@ -112,6 +120,51 @@ type OnOutOfSync struct {
 	// Minimum amount of time must be elapsed since the last deployment.
 	// This can be used to avoid triggering unnecessary continuous deployments based on OUT_OF_SYNC status.
 	MinWindow Duration `json:"minWindow,omitempty" default:"5m"`
+	// Configurable fields used while re-applying the drifted resources
+	// without triggering a new deployment.
+	SelfHeal SelfHeal `json:"selfHeal"`
+}
+
+// SelfHeal represents the policy to revert the configuration drift
+// caused by changes made directly to the running resources.
+// The drifted resources are re-applied only when the application is
+// running the latest commit, so the changes not yet deployed are never touched.
+// Currently, this is only available for KUBERNETES application
+// and enabling it for the other kinds is rejected while loading the configuration.
+type SelfHeal struct {
+	// Whether to re-apply the drifted resources automatically.
+	// Default is false.
+	Enabled bool `json:"enabled,omitempty"`
+	// Minimum amount of time must be elapsed since the last self-heal or deployment.
+	MinInterval Duration `json:"minInterval,omitempty" default:"10m"`
+	// List of resource kinds those are allowed to be re-applied.
+	// e.g. Deployment, ConfigMap
+	Kinds []string `json:"kinds,omitempty"`
+}
+
+func (s *SelfHeal) Validate() error {
+	if !s.Enabled {
+		return nil
+	}
+	if len(s.Kinds) == 0 {
+		return fmt.Errorf("kinds must be specified to enable selfHeal")
+	}
+	for _, k := range s.Kinds {
+		if k == "" {
+			return fmt.Errorf("kinds of selfHeal must not contain an empty value")
+		}
+	}
+	return nil
+}
+
+// IsAllowedKind checks whether the resources of the given kind can be re-applied or not.
+func (s *SelfHeal) IsAllowedKind(kind string) bool {
+	for _, k := range s.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
 }
 
 type OnChain struct {
@@ -123,17 +176,8 @@ type OnChain struct {
 
 func (s *GenericApplicationSpec) Validate() error {
 	if s.Pipeline != nil {
-		for _, stage := range s.Pipeline.Stages {
-			if stage.AnalysisStageOptions != nil {
-				if err := stage.AnalysisStageOptions.Validate(); err != nil {
-					return err
-				}
-			}
-			if stage.WaitApprovalStageOptions != nil {
-				if err := stage.WaitApprovalStageOptions.Validate(); err != nil {
-					return err
-				}
-			}
+		if err := s.Pipeline.Validate(); err != nil {
+			return err
 		}
 	}
 
@@ -149,6 +193,16 @@ func (s *GenericApplicationSpec) Validate() error {
 		}
 	}
 
+	if w := s.DeployWindows; w != nil {
+		if err := w.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.Trigger.OnOutOfSync.SelfHeal.Validate(); err != nil {
+		return err
+	}
+
 	if s.DeploymentNotification != nil {
 		for _, m := range s.DeploymentNotification.Mentions {
 			if err := m.Validate(); err != nil {
@@ -197,6 +251,143 @@ type DeploymentCommitMatcher struct {
 // - ConfigMaps, Secrets that are mounted as volumes or envs in the deployment.
 type DeploymentPipeline struct {
 	Stages []PipelineStage `json:"stages"`
+	// The name of the pipeline template defined in the .pipe directory
+	// to be used as the stages of this pipeline.
+	// This can not be specified together with stages.
+	UseTemplate string `json:"useTemplate"`
+	// The values of the arguments used to render the pipeline template.
+	TemplateArgs map[string]string `json:"templateArgs"`
+}
+
+// Validate checks the configured stages and ensures that all stages required
+// by each stage are defined in the pipeline.
+// Cyclic requirements are detected by the planner while building the stage graph.
+func (p *DeploymentPipeline) Validate() error {
+	if p.UseTemplate != "" && len(p.Stages) > 0 {
+		return fmt.Errorf("stages can not be specified together with useTemplate")
+	}
+	if p.UseTemplate == "" && len(p.TemplateArgs) > 0 {
+		return fmt.Errorf("templateArgs can be specified only when useTemplate is specified")
+	}
+	return p.validateStages()
+}
+
+// ExpandTemplate replaces the stages of this pipeline with the ones
+// rendered from the pipeline template specified by useTemplate.
+// Nothing will be changed if useTemplate was not specified.
+func (p *DeploymentPipeline) ExpandTemplate(repoRoot string) error {
+	if p.UseTemplate == "" {
+		return nil
+	}
+	spec, err := LoadPipelineTemplate(repoRoot)
+	if err == ErrNotFound {
+		return fmt.Errorf("pipeline template %s was not found in %s directory", p.UseTemplate, SharedConfigurationDirName)
+	}
+	if err != nil {
+		return err
+	}
+	tpl, ok := spec.Templates[p.UseTemplate]
+	if !ok {
+		return fmt.Errorf("pipeline template %s was not found in %s directory", p.UseTemplate, SharedConfigurationDirName)
+	}
+	stages, err := tpl.Render(p.TemplateArgs)
+	if err != nil {
+		return fmt.Errorf("failed to render pipeline template %s: %w", p.UseTemplate, err)
+	}
+	p.Stages = stages
+	if err := p.validateStages(); err != nil {
+		return fmt.Errorf("invalid stages in pipeline template %s: %w", p.UseTemplate, err)
+	}
+	return nil
+}
+
+func (p *DeploymentPipeline) validateStages() error {
+	for _, stage := range p.Stages {
+		if err := stage.validateOptions(); err != nil {
+			return err
+		}
+	}
+
+	// Map from stage ID to its index in the pipeline.
+	ids := make(map[string]int, len(p.Stages))
+	for i, s := range p.Stages {
+		if s.Id == "" {
+			continue
+		}
+		if _, ok := ids[s.Id]; ok {
+			return fmt.Errorf("stage id %s is duplicated", s.Id)
+		}
+		ids[s.Id] = i
+	}
+	for i, s := range p.Stages {
+		if err := s.validateRetry(); err != nil {
+			return err
+		}
+		for _, r := range s.Requires {
+			if r == s.Id {
+				return fmt.Errorf("stage %s must not require itself", s.Id)
+			}
+			if _, ok := ids[r]; !ok {
+				return fmt.Errorf("stage %s requires an undefined stage %s (the required stage must have an id)", s.Name, r)
+			}
+		}
+		cond, err := s.Condition()
+		if err != nil {
+			return err
+		}
+		if cond == nil {
+			continue
+		}
+		ancestors := p.stageAncestors(i, ids)
+		for _, id := range cond.StageIDs() {
+			if id == s.Id {
+				return fmt.Errorf("condition of stage %s must not refer to its own result", s.Id)
+			}
+			idx, ok := ids[id]
+			if !ok {
+				return fmt.Errorf("condition of stage %s refers to an undefined stage %s (the referred stage must have an id)", s.Name, id)
+			}
+			// The result of the referred stage is decided only when it is always completed
+			// before the stage starts.
+			if _, ok := ancestors[idx]; !ok {
+				return fmt.Errorf("condition of stage %s refers to stage %s which is not required by it directly or transitively", s.Name, id)
+			}
+		}
+	}
+	return nil
+}
+
+// stageAncestors returns the indexes of the stages that the stage at the given index
+// requires directly or transitively.
+// As same as while planning, a stage without requires implicitly requires its previous one.
+func (p *DeploymentPipeline) stageAncestors(index int, ids map[string]int) map[int]struct{} {
+	var (
+		ancestors = make(map[int]struct{})
+		queue     = []int{index}
+	)
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		var parents []int
+		if requires := p.Stages[cur].Requires; requires != nil {
+			for _, r := range requires {
+				if i, ok := ids[r]; ok {
+					parents = append(parents, i)
+				}
+			}
+		} else if cur > 0 {
+			parents = append(parents, cur-1)
+		}
+		for _, i := range parents {
+			if _, ok := ancestors[i]; ok {
+				continue
+			}
+			ancestors[i] = struct{}{}
+			queue = append(queue, i)
+		}
+	}
+	return ancestors
 }
 
 // PipelineStage represents a single stage of a pipeline.
@@ -206,10 +397,28 @@ type PipelineStage struct {
 	Name    model.Stage
 	Desc    string
 	Timeout Duration
+	// List of stage IDs that must be completed successfully before this stage.
+	// Nil means this stage requires the previous one in the pipeline.
+	// Stages whose requirements are satisfied at the same time are executed concurrently.
+	Requires []string
+	// The maximum number of times the stage is retried when its attempt failed.
+	Retries int
+	// How long to wait before retrying the stage.
+	RetryInterval Duration
+	// The conditions on which the stage is retried.
+	// Defaults to both FAILURE and TIMEOUT.
+	RetryOn []StageRetryCondition
+	// Whether the stage can be skipped by a SKIP_STAGE command after it was failed.
+	Skippable bool
+	// The condition expression to decide whether the stage should be executed.
+	// The stage is skipped when it is evaluated to false.
+	// Empty means the stage is always executed.
+	When string
 
 	WaitStageOptions         *WaitStageOptions
 	WaitApprovalStageOptions *WaitApprovalStageOptions
 	AnalysisStageOptions     *AnalysisStageOptions
+	ScriptRunStageOptions    *ScriptRunStageOptions
 
 	K8sPrimaryRolloutStageOptions  *K8sPrimaryRolloutStageOptions
 	K8sCanaryRolloutStageOptions   *K8sCanaryRolloutStageOptions
@@ -217,6 +426,7 @@ type PipelineStage struct {
 	K8sBaselineRolloutStageOptions *K8sBaselineRolloutStageOptions
 	K8sBaselineCleanStageOptions   *K8sBaselineCleanStageOptions
 	K8sTrafficRoutingStageOptions  *K8sTrafficRoutingStageOptions
+	K8sJobStageOptions             *K8sJobStageOptions
 
 	TerraformSyncStageOptions  *TerraformSyncStageOptions
 	TerraformPlanStageOptions  *TerraformPlanStageOptions
@@ -236,12 +446,124 @@ type PipelineStage struct {
 	ECSTrafficRoutingStageOptions *ECSTrafficRoutingStageOptions
 }
 
+// StageRetryCondition represents a condition on which a failed stage is retried.
+type StageRetryCondition string
+
+const (
+	// StageRetryOnFailure retries the stage when it was failed by itself.
+	StageRetryOnFailure StageRetryCondition = "FAILURE"
+	// StageRetryOnTimeout retries the stage when it was failed because of reaching its own timeout.
+	StageRetryOnTimeout StageRetryCondition = "TIMEOUT"
+)
+
+var defaultStageRetryConditions = []StageRetryCondition{StageRetryOnFailure, StageRetryOnTimeout}
+
+// ShouldRetry reports whether the stage should be executed again
+// after its attempt failed with the given number of retries made so far.
+func (s PipelineStage) ShouldRetry(retried int, timedOut bool) bool {
+	if retried >= s.Retries {
+		return false
+	}
+	cond := StageRetryOnFailure
+	if timedOut {
+		cond = StageRetryOnTimeout
+	}
+	conds := s.RetryOn
+	if len(conds) == 0 {
+		conds = defaultStageRetryConditions
+	}
+	for _, c := range conds {
+		if c == cond {
+			return true
+		}
+	}
+	return false
+}
+
+// Condition parses the when expression of the stage.
+// Nil is returned when the stage has no condition.
+func (s PipelineStage) Condition() (*condition.Expression, error) {
+	if s.When == "" {
+		return nil, nil
+	}
+	cond, err := condition.Parse(s.When)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition of stage %s: %w", s.Name, err)
+	}
+	return cond, nil
+}
+
+func (s PipelineStage) validateRetry() error {
+	if s.Retries < 0 {
+		return fmt.Errorf("retries of stage %s must be greater than or equal to 0", s.Name)
+	}
+	if s.RetryInterval < 0 {
+		return fmt.Errorf("retryInterval of stage %s must be greater than or equal to 0", s.Name)
+	}
+	for _, c := range s.RetryOn {
+		if c != StageRetryOnFailure && c != StageRetryOnTimeout {
+			return fmt.Errorf("retryOn of stage %s contains an invalid value %q (must be %s or %s)", s.Name, c, StageRetryOnFailure, StageRetryOnTimeout)
+		}
+	}
+	return nil
+}
+
+func (s PipelineStage) validateOptions() error {
+	if s.AnalysisStageOptions != nil {
+		if err := s.AnalysisStageOptions.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.WaitApprovalStageOptions != nil {
+		if err := s.WaitApprovalStageOptions.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.ScriptRunStageOptions != nil {
+		if err := s.ScriptRunStageOptions.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.K8sTrafficRoutingStageOptions != nil {
+		if err := s.K8sTrafficRoutingStageOptions.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.K8sJobStageOptions != nil {
+		if err := s.K8sJobStageOptions.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.ECSTrafficRoutingStageOptions != nil {
+		if err := s.ECSTrafficRoutingStageOptions.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.LambdaPromoteStageOptions != nil {
+		if err := s.LambdaPromoteStageOptions.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.CloudRunPromoteStageOptions != nil {
+		if err := s.CloudRunPromoteStageOptions.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type genericPipelineStage struct {
-	Id      string          `json:"id"`
-	Name    model.Stage     `json:"name"`
-	Desc    string          `json:"desc,omitempty"`
-	Timeout Duration        `json:"timeout"`
-	With    json.RawMessage `json:"with"`
+	Id            string                `json:"id"`
+	Name          model.Stage           `json:"name"`
+	Desc          string                `json:"desc,omitempty"`
+	Timeout       Duration              `json:"timeout"`
+	Requires      []string              `json:"requires"`
+	Retries       int                   `json:"retries"`
+	RetryInterval Duration              `json:"retryInterval"`
+	RetryOn       []StageRetryCondition `json:"retryOn"`
+	Skippable     bool                  `json:"skippable"`
+	When          string                `json:"when"`
+	With          json.RawMessage       `json:"with"`
 }
 
 func (s *PipelineStage) UnmarshalJSON(data []byte) error {
@@ -254,6 +576,12 @@ func (s *PipelineStage) UnmarshalJSON(data []byte) error {
 	s.Name = gs.Name
 	s.Desc = gs.Desc
 	s.Timeout = gs.Timeout
+	s.Requires = gs.Requires
+	s.Retries = gs.Retries
+	s.RetryInterval = gs.RetryInterval
+	s.RetryOn = gs.RetryOn
+	s.Skippable = gs.Skippable
+	s.When = gs.When
 
 	switch s.Name {
 	case model.StageWait:
@@ -279,6 +607,14 @@ func (s *PipelineStage) UnmarshalJSON(data []byte) error {
 				s.AnalysisStageOptions.Metrics[i].Timeout = defaultAnalysisQueryTimeout
 			}
 		}
+		if s.AnalysisStageOptions.Scoring != nil && s.AnalysisStageOptions.Scoring.Approval.Timeout <= 0 {
+			s.AnalysisStageOptions.Scoring.Approval.Timeout = defaultWaitApprovalTimeout
+		}
+	case model.StageScriptRun:
+		s.ScriptRunStageOptions = &ScriptRunStageOptions{}
+		if len(gs.With) > 0 {
+			err = json.Unmarshal(gs.With, s.ScriptRunStageOptions)
+		}
 	case model.StageK8sPrimaryRollout:
 		s.K8sPrimaryRolloutStageOptions = &K8sPrimaryRolloutStageOptions{}
 		if len(gs.With) > 0 {
@@ -309,6 +645,11 @@ func (s *PipelineStage) UnmarshalJSON(data []byte) error {
 		if len(gs.With) > 0 {
 			err = json.Unmarshal(gs.With, s.K8sTrafficRoutingStageOptions)
 		}
+	case model.StageK8sJob:
+		s.K8sJobStageOptions = &K8sJobStageOptions{}
+		if len(gs.With) > 0 {
+			err = json.Unmarshal(gs.With, s.K8sJobStageOptions)
+		}
 
 	case model.StageTerraformSync:
 		s.TerraformSyncStageOptions = &TerraformSyncStageOptions{}
@@ -397,12 +738,111 @@ type WaitApprovalStageOptions struct {
 	Timeout        Duration `json:"timeout"`
 	Approvers      []string `json:"approvers"`
 	MinApproverNum int      `json:"minApproverNum" default:"1"`
+	// The groups of users who can approve the stage.
+	// When specified, the stage is approved only after every group gave its required number of approvals,
+	// and minApproverNum is ignored. This cannot be used together with approvers.
+	ApproverGroups []ApproverGroup `json:"approverGroups"`
+	// Whether to prevent the author of the deploying commit and the user
+	// who triggered the deployment from approving the stage.
+	PreventSelfApproval bool `json:"preventSelfApproval"`
+	// Map from the git author name of the commits to the PipeCD username of that author.
+	// The author of the deploying commit can be recognized only when it is listed here,
+	// since the git author name is not guaranteed to be the same as the PipeCD username.
+	CommitAuthors map[string]string `json:"commitAuthors"`
+	// How long an approval remains valid. The approvals older than this are not counted,
+	// so all of the required approvals must be given within this duration.
+	// Empty means the approvals never expire.
+	ApprovalExpiry Duration `json:"approvalExpiry"`
 }
 
 func (w *WaitApprovalStageOptions) Validate() error {
 	if w.MinApproverNum < 1 {
 		return fmt.Errorf("minApproverNum %d should be greater than 0", w.MinApproverNum)
 	}
+	if w.ApprovalExpiry < 0 {
+		return fmt.Errorf("approvalExpiry must not be negative")
+	}
+	if len(w.ApproverGroups) == 0 {
+		return nil
+	}
+	if len(w.Approvers) > 0 {
+		return fmt.Errorf("approvers and approverGroups cannot be used together")
+	}
+	names := make(map[string]struct{}, len(w.ApproverGroups))
+	for _, g := range w.ApproverGroups {
+		if err := g.Validate(); err != nil {
+			return err
+		}
+		if _, ok := names[g.Name]; ok {
+			return fmt.Errorf("approver group name %s must be unique", g.Name)
+		}
+		names[g.Name] = struct{}{}
+	}
+	return nil
+}
+
+// ApproverGroup represents a group of users who can approve a stage.
+// A user belongs to the group when their username is listed in users
+// or they are a member of one of the listed SSO teams.
+type ApproverGroup struct {
+	// The name of the group.
+	// Required field.
+	Name string `json:"name"`
+	// The usernames of the group members.
+	Users []string `json:"users"`
+	// The SSO teams whose members belong to the group.
+	// For GitHub, each team is in the format of "org/team-slug".
+	Teams []string `json:"teams"`
+	// The minimum number of approvals required from this group.
+	MinApproverNum int `json:"minApproverNum" default:"1"`
+}
+
+func (g *ApproverGroup) Validate() error {
+	if g.Name == "" {
+		return fmt.Errorf("name of approver group must be specified")
+	}
+	if len(g.Users) == 0 && len(g.Teams) == 0 {
+		return fmt.Errorf("approver group %s must have at least one user or team", g.Name)
+	}
+	if g.MinApproverNum < 1 {
+		return fmt.Errorf("minApproverNum %d of approver group %s should be greater than 0", g.MinApproverNum, g.Name)
+	}
+	return nil
+}
+
+// HasMember checks whether the given user belongs to this group.
+func (g *ApproverGroup) HasMember(username string, teams []string) bool {
+	for _, u := range g.Users {
+		if u == username {
+			return true
+		}
+	}
+	for _, t := range g.Teams {
+		for _, ut := range teams {
+			if t == ut {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ScriptRunStageOptions contains all configurable values for a SCRIPT_RUN stage.
+type ScriptRunStageOptions struct {
+	// The script to be executed on the piped host.
+	// It is run by "sh -c" inside the application directory at the target commit.
+	Run string `json:"run"`
+	// Additional environment variables to be set while executing the scripts.
+	Env map[string]string `json:"env"`
+	// The script to be executed in the ROLLBACK stage
+	// to revert what was done by the run script.
+	OnRollback string `json:"onRollback"`
+}
+
+func (s *ScriptRunStageOptions) Validate() error {
+	if s.Run == "" {
+		return fmt.Errorf("run must be specified for %s stage", model.StageScriptRun)
+	}
 	return nil
 }
 
@@ -416,6 +856,10 @@ type AnalysisStageOptions struct {
 	Metrics          []TemplatableAnalysisMetrics `json:"metrics"`
 	Logs             []TemplatableAnalysisLog     `json:"logs"`
 	Https            []TemplatableAnalysisHTTP    `json:"https"`
+	// If specified, the stage no longer fails as soon as any analysis exceeds its failure limit.
+	// Instead, it keeps running all analyses until the end of its duration and
+	// decides the result by the overall score computed from them.
+	Scoring *AnalysisScoring `json:"scoring"`
 }
 
 func (a *AnalysisStageOptions) Validate() error {
@@ -457,6 +901,96 @@ func (a *AnalysisStageOptions) Validate() error {
 			return fmt.Errorf("one of http configurations of ANALYSIS stage is invalid: %w", err)
 		}
 	}
+	if a.Scoring != nil {
+		if err := a.Scoring.Validate(); err != nil {
+			return fmt.Errorf("scoring configuration of ANALYSIS stage is invalid: %w", err)
+		}
+		if a.hasNoWeight() {
+			return fmt.Errorf("scoring configuration of ANALYSIS stage requires at least one analysis with a positive weight")
+		}
+	}
+	return nil
+}
+
+// hasNoWeight reports whether the total weight of the analyses is zero.
+// The analyses using a template are considered to have weight
+// because their weights are not known until the template is loaded.
+func (a *AnalysisStageOptions) hasNoWeight() bool {
+	var total float64
+	for _, m := range a.Metrics {
+		if m.Template.Name != "" {
+			return false
+		}
+		total += m.Weight
+	}
+	for _, l := range a.Logs {
+		if l.Template.Name != "" {
+			return false
+		}
+		total += l.Weight
+	}
+	for _, h := range a.Https {
+		if h.Template.Name != "" {
+			return false
+		}
+		total += h.Weight
+	}
+	return total == 0
+}
+
+// TrafficRoutingStep represents a step of progressively shifting the traffic
+// to the new version inside a single traffic routing stage.
+type TrafficRoutingStep struct {
+	// The percentage of traffic should be routed to the new version at this step.
+	Weight Percentage `json:"weight"`
+	// How long the traffic should be kept at this step before moving to the next one.
+	Interval Duration `json:"interval"`
+	// The analysis to be executed while keeping the traffic at this step.
+	// Its duration is always the interval of this step.
+	// If it fails, the stage fails immediately without moving to the next step.
+	Analysis *AnalysisStageOptions `json:"analysis"`
+}
+
+// AnalysisOptions returns the analysis to be executed at this step with its duration
+// set to the interval of this step.
+func (s TrafficRoutingStep) AnalysisOptions() *AnalysisStageOptions {
+	if s.Analysis == nil {
+		return nil
+	}
+	opts := *s.Analysis
+	opts.Duration = s.Interval
+	if opts.Scoring != nil && opts.Scoring.Approval.Timeout <= 0 {
+		scoring := *opts.Scoring
+		scoring.Approval.Timeout = defaultWaitApprovalTimeout
+		opts.Scoring = &scoring
+	}
+	return &opts
+}
+
+func (s TrafficRoutingStep) Validate() error {
+	if w := s.Weight.Int(); w < 0 || w > 100 {
+		return fmt.Errorf("weight of traffic routing step must be between 0 and 100, got %d", w)
+	}
+	if s.Interval < 0 {
+		return fmt.Errorf("interval of traffic routing step must not be negative")
+	}
+	if s.Analysis != nil {
+		if s.Interval == 0 {
+			return fmt.Errorf("traffic routing step with analysis requires interval field")
+		}
+		if err := s.AnalysisOptions().Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateTrafficRoutingSteps(steps []TrafficRoutingStep) error {
+	for i, s := range steps {
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("step %d is invalid: %w", i+1, err)
+		}
+	}
 	return nil
 }
 
@@ -472,6 +1006,31 @@ func (a *AnalysisTemplateRef) Validate() error {
 	return nil
 }
 
+// AnalysisScoring contains the thresholds used to decide the result of the ANALYSIS stage
+// by the overall score. The score of each analysis is the percentage of its successful evaluations,
+// and the overall score is the weighted average of them.
+type AnalysisScoring struct {
+	// The minimum overall score to consider the stage as a success.
+	// Required field.
+	Pass float64 `json:"pass"`
+	// The minimum overall score to ask for a manual decision.
+	// The stage waits for an approval when the score is less than the pass score but not less than this.
+	// If not specified, the stage fails when the score is less than the pass score.
+	Marginal float64 `json:"marginal"`
+	// Configuration for the manual decision on the marginal score.
+	Approval WaitApprovalStageOptions `json:"approval"`
+}
+
+func (s *AnalysisScoring) Validate() error {
+	if s.Pass <= 0 || s.Pass > 100 {
+		return fmt.Errorf("pass score must be greater than 0 and less than or equal to 100")
+	}
+	if s.Marginal < 0 || s.Marginal > s.Pass {
+		return fmt.Errorf("marginal score must be between 0 and the pass score")
+	}
+	return s.Approval.Validate()
+}
+
 // TemplatableAnalysisMetrics wraps AnalysisMetrics to allow specify template to use.
 type TemplatableAnalysisMetrics struct {
 	AnalysisMetrics
@@ -544,6 +1103,24 @@ func (n *DeploymentNotification) FindSlackAccounts(event model.NotificationEvent
 	return approvers
 }
 
+func (n *DeploymentNotification) FindEmails(event model.NotificationEventType) []string {
+	es := make(map[string]struct{})
+	for _, m := range n.Mentions {
+		if m.Event != allEventsSymbol && "EVENT_"+m.Event != event.String() {
+			continue
+		}
+		for _, e := range m.Email {
+			es[e] = struct{}{}
+		}
+	}
+
+	emails := make([]string, 0, len(es))
+	for e := range es {
+		emails = append(emails, e)
+	}
+	return emails
+}
+
 type NotificationMention struct {
 	// The event to be notified to users.
 	Event string `json:"event"`
@@ -551,8 +1128,7 @@ type NotificationMention struct {
 	// See https://api.slack.com/reference/surfaces/formatting#mentioning-users
 	// for more information on how to check them.
 	Slack []string `json:"slack"`
-	// TODO: Support for email notification
-	// The email for notification.
+	// List of email addresses to be notified via the email receivers.
 	Email []string `json:"email"`
 }
 
@@ -48,4 +48,13 @@ type CloudRunSyncStageOptions struct {
 type CloudRunPromoteStageOptions struct {
 	// Percentage of traffic should be routed to the new version.
 	Percent Percentage `json:"percent"`
+	// List of steps to progressively route the traffic to the new version.
+	// The weight of each step is the percentage of traffic should be routed to the new version.
+	// When this is specified, percent field will be ignored.
+	Steps []TrafficRoutingStep `json:"steps"`
+}
+
+// Validate returns an error if any wrong configuration value was found.
+func (opts CloudRunPromoteStageOptions) Validate() error {
+	return validateTrafficRoutingSteps(opts.Steps)
 }
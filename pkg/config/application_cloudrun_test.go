@@ -47,6 +47,9 @@ func TestCloudRunApplicationConfig(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -76,6 +76,15 @@ type ECSTrafficRoutingStageOptions struct {
 	Canary Percentage `json:"canary"`
 	// Primary represents the amount of traffic that the rolled out CANARY variant will serve.
 	Primary Percentage `json:"primary"`
+	// List of steps to progressively route the traffic to the CANARY variant.
+	// The weight of each step is the amount of traffic that the CANARY variant will serve.
+	// When this is specified, canary and primary fields will be ignored.
+	Steps []TrafficRoutingStep `json:"steps"`
+}
+
+// Validate returns an error if any wrong configuration value was found.
+func (opts ECSTrafficRoutingStageOptions) Validate() error {
+	return validateTrafficRoutingSteps(opts.Steps)
 }
 
 func (opts ECSTrafficRoutingStageOptions) Percentage() (primary, canary int) {
@@ -48,6 +48,9 @@ func TestECSApplicationConfig(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -14,6 +14,11 @@
 
 package config
 
+import (
+	"fmt"
+	"regexp"
+)
+
 // KubernetesApplicationSpec represents an application configuration for Kubernetes application.
 type KubernetesApplicationSpec struct {
 	GenericApplicationSpec
@@ -106,11 +111,16 @@ const (
 	KubernetesTrafficRoutingMethodPodSelector KubernetesTrafficRoutingMethod = "podselector"
 	KubernetesTrafficRoutingMethodIstio       KubernetesTrafficRoutingMethod = "istio"
 	KubernetesTrafficRoutingMethodSMI         KubernetesTrafficRoutingMethod = "smi"
+	KubernetesTrafficRoutingMethodNginx       KubernetesTrafficRoutingMethod = "nginx"
+	KubernetesTrafficRoutingMethodGatewayAPI  KubernetesTrafficRoutingMethod = "gatewayapi"
 )
 
 type KubernetesTrafficRouting struct {
-	Method KubernetesTrafficRoutingMethod `json:"method"`
-	Istio  *IstioTrafficRouting           `json:"istio"`
+	Method     KubernetesTrafficRoutingMethod `json:"method"`
+	Istio      *IstioTrafficRouting           `json:"istio"`
+	SMI        *SMITrafficRouting             `json:"smi"`
+	Nginx      *NginxTrafficRouting           `json:"nginx"`
+	GatewayAPI *GatewayAPITrafficRouting      `json:"gatewayAPI"`
 }
 
 // DetermineKubernetesTrafficRoutingMethod determines the routing method should be used based on the TrafficRouting config.
@@ -137,6 +147,50 @@ type IstioTrafficRouting struct {
 	VirtualService K8sResourceReference `json:"virtualService"`
 }
 
+// SMITrafficRouting contains the configuration for routing traffic
+// by generating and updating an SMI TrafficSplit resource.
+// The TrafficSplit uses the application Service as its root service and
+// the backend of PRIMARY variant, while the CANARY and BASELINE variants
+// are routed through their "<name>-canary" and "<name>-baseline" Services.
+type SMITrafficRouting struct {
+	// The API version of the generated TrafficSplit.
+	// Default is "split.smi-spec.io/v1alpha2".
+	APIVersion string `json:"apiVersion"`
+	// The name of the generated TrafficSplit.
+	// Empty means the name of the application Service will be used.
+	TrafficSplitName string `json:"trafficSplitName"`
+}
+
+// TrafficSplitAPIVersion returns the API version should be used for the generated TrafficSplit.
+func (s *SMITrafficRouting) TrafficSplitAPIVersion() string {
+	if s == nil || s.APIVersion == "" {
+		return "split.smi-spec.io/v1alpha2"
+	}
+	return s.APIVersion
+}
+
+// NginxTrafficRouting contains the configuration for routing traffic
+// by generating a canary Ingress with the NGINX canary annotations.
+// The canary Ingress routes the specified weight of traffic to the "<name>-canary" Service,
+// so K8S_CANARY_ROLLOUT stage must be configured with createService enabled.
+// BASELINE variant is not supported by this method.
+type NginxTrafficRouting struct {
+	// The reference to Ingress manifest.
+	// Empty means the first Ingress resource will be used.
+	Ingress K8sResourceReference `json:"ingress"`
+}
+
+// GatewayAPITrafficRouting contains the configuration for routing traffic
+// by updating the weights of backendRefs in a Gateway API HTTPRoute.
+// The application Service is used as the backend of PRIMARY variant,
+// while the CANARY and BASELINE variants are routed through their
+// "<name>-canary" and "<name>-baseline" Services.
+type GatewayAPITrafficRouting struct {
+	// The reference to HTTPRoute manifest.
+	// Empty means the first HTTPRoute resource will be used.
+	HTTPRoute K8sResourceReference `json:"httpRoute"`
+}
+
 type K8sResourceReference struct {
 	Kind string `json:"kind"`
 	Name string `json:"name"`
@@ -244,6 +298,101 @@ type K8sTrafficRoutingStageOptions struct {
 	Canary Percentage `json:"canary"`
 	// The percentage of traffic should be routed to BASELINE variant.
 	Baseline Percentage `json:"baseline"`
+	// List of rules to match the requests those should be routed to CANARY variant
+	// regardless of the percentages. A request matching any of these rules will be routed to CANARY.
+	// This can be used to send internal testers to CANARY before sending any real traffic.
+	// Currently, this is supported only by istio method.
+	CanaryMatches []K8sTrafficRoutingMatch `json:"canaryMatches"`
+	// List of steps to progressively route the traffic to CANARY variant.
+	// The weight of each step is the percentage of traffic should be routed to CANARY variant,
+	// and the rest is routed to PRIMARY variant.
+	// When this is specified, all, primary, canary and baseline fields will be ignored.
+	Steps []TrafficRoutingStep `json:"steps"`
+}
+
+// Validate returns an error if any wrong configuration value was found.
+func (opts K8sTrafficRoutingStageOptions) Validate() error {
+	if err := validateTrafficRoutingSteps(opts.Steps); err != nil {
+		return err
+	}
+	for _, m := range opts.CanaryMatches {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// K8sJobStageOptions contains all configurable values for a K8S_JOB stage.
+type K8sJobStageOptions struct {
+	// The path to the file containing the Job manifest.
+	// The path is relative to the application directory.
+	Manifest string `json:"manifest"`
+	// The workload whose containers' image and env should be used
+	// for the Job's containers with the same names.
+	// Empty means the Job manifest will be applied as it is.
+	InheritFrom K8sResourceReference `json:"inheritFrom"`
+}
+
+// Validate returns an error if any wrong configuration value was found.
+func (opts K8sJobStageOptions) Validate() error {
+	if opts.Manifest == "" {
+		return fmt.Errorf("manifest must be specified for K8S_JOB stage")
+	}
+	return nil
+}
+
+// K8sTrafficRoutingMatch represents a rule to match the requests by an HTTP header or a cookie.
+type K8sTrafficRoutingMatch struct {
+	// The name of the HTTP header to match.
+	Header string `json:"header"`
+	// The name of the cookie to match.
+	// Only one of header or cookie can be specified.
+	Cookie string `json:"cookie"`
+	// The value must be exactly equal to this.
+	Exact string `json:"exact"`
+	// The value must start with this.
+	Prefix string `json:"prefix"`
+	// The value must match this RE2 style regular expression.
+	// Only one of exact, prefix or regex can be specified.
+	Regex string `json:"regex"`
+}
+
+func (m K8sTrafficRoutingMatch) String() string {
+	target := "header " + m.Header
+	if m.Cookie != "" {
+		target = "cookie " + m.Cookie
+	}
+	switch {
+	case m.Exact != "":
+		return fmt.Sprintf("%s equal to %q", target, m.Exact)
+	case m.Prefix != "":
+		return fmt.Sprintf("%s starting with %q", target, m.Prefix)
+	default:
+		return fmt.Sprintf("%s matching %q", target, m.Regex)
+	}
+}
+
+// Validate returns an error if any wrong configuration value was found.
+func (m K8sTrafficRoutingMatch) Validate() error {
+	if (m.Header == "") == (m.Cookie == "") {
+		return fmt.Errorf("one of header or cookie must be specified for traffic routing match")
+	}
+	var values int
+	for _, v := range []string{m.Exact, m.Prefix, m.Regex} {
+		if v != "" {
+			values++
+		}
+	}
+	if values != 1 {
+		return fmt.Errorf("one of exact, prefix or regex must be specified for traffic routing match")
+	}
+	if m.Regex != "" {
+		if _, err := regexp.Compile(m.Regex); err != nil {
+			return fmt.Errorf("invalid regex %q for traffic routing match: %w", m.Regex, err)
+		}
+	}
+	return nil
 }
 
 func (opts K8sTrafficRoutingStageOptions) Percentages() (primary, canary, baseline int) {
@@ -90,6 +90,9 @@ func TestKubernetesApplicationConfig(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -105,6 +108,84 @@ func TestKubernetesApplicationConfig(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			fileName:           "testdata/application/k8s-app-job.yaml",
+			expectedKind:       KindKubernetesApp,
+			expectedAPIVersion: "pipecd.dev/v1beta1",
+			expectedSpec: &KubernetesApplicationSpec{
+				GenericApplicationSpec: GenericApplicationSpec{
+					Pipeline: &DeploymentPipeline{
+						Stages: []PipelineStage{
+							{
+								Name:    model.StageK8sJob,
+								Timeout: Duration(15 * time.Minute),
+								K8sJobStageOptions: &K8sJobStageOptions{
+									Manifest: "jobs/migrate.yaml",
+									InheritFrom: K8sResourceReference{
+										Kind: "Deployment",
+										Name: "helloworld",
+									},
+								},
+							},
+							{
+								Name:                          model.StageK8sPrimaryRollout,
+								K8sPrimaryRolloutStageOptions: &K8sPrimaryRolloutStageOptions{},
+							},
+						},
+					},
+					Timeout: Duration(6 * time.Hour),
+					Trigger: Trigger{
+						OnOutOfSync: OnOutOfSync{
+							Disabled:  newBoolPointer(true),
+							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
+						},
+						OnChain: OnChain{
+							Disabled: newBoolPointer(true),
+						},
+					},
+				},
+				Input: KubernetesDeploymentInput{
+					AutoRollback: newBoolPointer(true),
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			fileName:           "testdata/application/k8s-app-use-pipeline-template.yaml",
+			expectedKind:       KindKubernetesApp,
+			expectedAPIVersion: "pipecd.dev/v1beta1",
+			expectedSpec: &KubernetesApplicationSpec{
+				GenericApplicationSpec: GenericApplicationSpec{
+					Pipeline: &DeploymentPipeline{
+						UseTemplate: "k8s-canary-with-analysis",
+						TemplateArgs: map[string]string{
+							"canaryReplicas": "20%",
+							"failureLimit":   "2",
+						},
+					},
+					Timeout: Duration(6 * time.Hour),
+					Trigger: Trigger{
+						OnOutOfSync: OnOutOfSync{
+							Disabled:  newBoolPointer(true),
+							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
+						},
+						OnChain: OnChain{
+							Disabled: newBoolPointer(true),
+						},
+					},
+				},
+				Input: KubernetesDeploymentInput{
+					AutoRollback: newBoolPointer(true),
+				},
+			},
+			expectedError: nil,
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.fileName, func(t *testing.T) {
@@ -118,3 +199,61 @@ func TestKubernetesApplicationConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateK8sTrafficRoutingStageOptions(t *testing.T) {
+	testcases := []struct {
+		name    string
+		matches []K8sTrafficRoutingMatch
+		wantErr bool
+	}{
+		{
+			name:    "no match",
+			wantErr: false,
+		},
+		{
+			name: "valid",
+			matches: []K8sTrafficRoutingMatch{
+				{Header: "x-canary", Exact: "true"},
+				{Cookie: "user", Regex: "^tester-.*"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "both header and cookie",
+			matches: []K8sTrafficRoutingMatch{
+				{Header: "x-canary", Cookie: "user", Exact: "true"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing header and cookie",
+			matches: []K8sTrafficRoutingMatch{
+				{Exact: "true"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "multiple values",
+			matches: []K8sTrafficRoutingMatch{
+				{Header: "x-canary", Exact: "true", Prefix: "t"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid regex",
+			matches: []K8sTrafficRoutingMatch{
+				{Header: "x-canary", Regex: "("},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := K8sTrafficRoutingStageOptions{
+				CanaryMatches: tc.matches,
+			}
+			err := opts.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -49,6 +49,9 @@ func TestLambdaApplicationConfig(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -99,6 +102,9 @@ func TestLambdaApplicationConfig(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -140,6 +146,9 @@ func TestLambdaApplicationConfig(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
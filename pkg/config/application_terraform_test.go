@@ -49,6 +49,9 @@ func TestTerraformApplicationtConfig(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -76,6 +79,9 @@ func TestTerraformApplicationtConfig(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -106,6 +112,9 @@ func TestTerraformApplicationtConfig(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(false),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -165,6 +174,9 @@ func TestTerraformApplicationtConfig(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -15,6 +15,7 @@
 package config
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -102,6 +103,105 @@ func TestValidateWaitApprovalStageOptions(t *testing.T) {
 	}
 }
 
+func TestValidateWaitApprovalStageOptionsWithApproverGroups(t *testing.T) {
+	testcases := []struct {
+		name    string
+		opts    WaitApprovalStageOptions
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			opts: WaitApprovalStageOptions{
+				MinApproverNum: 1,
+				ApproverGroups: []ApproverGroup{
+					{Name: "sre", Teams: []string{"org/sre"}, MinApproverNum: 1},
+					{Name: "product-owner", Users: []string{"user-1"}, MinApproverNum: 1},
+				},
+				ApprovalExpiry: Duration(time.Hour),
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid because of using together with approvers",
+			opts: WaitApprovalStageOptions{
+				MinApproverNum: 1,
+				Approvers:      []string{"user-1"},
+				ApproverGroups: []ApproverGroup{
+					{Name: "sre", Teams: []string{"org/sre"}, MinApproverNum: 1},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid because of missing group name",
+			opts: WaitApprovalStageOptions{
+				MinApproverNum: 1,
+				ApproverGroups: []ApproverGroup{
+					{Teams: []string{"org/sre"}, MinApproverNum: 1},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid because of duplicated group names",
+			opts: WaitApprovalStageOptions{
+				MinApproverNum: 1,
+				ApproverGroups: []ApproverGroup{
+					{Name: "sre", Teams: []string{"org/sre"}, MinApproverNum: 1},
+					{Name: "sre", Users: []string{"user-1"}, MinApproverNum: 1},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid because of a group without members",
+			opts: WaitApprovalStageOptions{
+				MinApproverNum: 1,
+				ApproverGroups: []ApproverGroup{
+					{Name: "sre", MinApproverNum: 1},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid because of group minApproverNum",
+			opts: WaitApprovalStageOptions{
+				MinApproverNum: 1,
+				ApproverGroups: []ApproverGroup{
+					{Name: "sre", Teams: []string{"org/sre"}, MinApproverNum: 0},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid because of negative approvalExpiry",
+			opts: WaitApprovalStageOptions{
+				MinApproverNum: 1,
+				ApprovalExpiry: Duration(-time.Hour),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opts.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestApproverGroupHasMember(t *testing.T) {
+	g := ApproverGroup{
+		Name:  "sre",
+		Users: []string{"user-1"},
+		Teams: []string{"org/sre"},
+	}
+	assert.True(t, g.HasMember("user-1", nil))
+	assert.True(t, g.HasMember("user-2", []string{"org/dev", "org/sre"}))
+	assert.False(t, g.HasMember("user-2", []string{"org/dev"}))
+	assert.False(t, g.HasMember("user-3", nil))
+}
+
 func TestFindSlackAccounts(t *testing.T) {
 	testcases := []struct {
 		name     string
@@ -177,6 +277,105 @@ func TestFindSlackAccounts(t *testing.T) {
 	}
 }
 
+func TestFindEmails(t *testing.T) {
+	testcases := []struct {
+		name     string
+		mentions []NotificationMention
+		event    model.NotificationEventType
+		want     []string
+	}{
+		{
+			name: "match with both event name and all-events mark",
+			mentions: []NotificationMention{
+				{
+					Event: "DEPLOYMENT_FAILED",
+					Slack: []string{"user-1"},
+					Email: []string{"foo@example.com", "bar@example.com"},
+				},
+				{
+					Event: "*",
+					Email: []string{"foo@example.com", "baz@example.com"},
+				},
+			},
+			event: model.NotificationEventType_EVENT_DEPLOYMENT_FAILED,
+			want:  []string{"foo@example.com", "bar@example.com", "baz@example.com"},
+		},
+		{
+			name: "does not match anything",
+			mentions: []NotificationMention{
+				{
+					Event: "DEPLOYMENT_FAILED",
+					Email: []string{"foo@example.com"},
+				},
+			},
+			event: model.NotificationEventType_EVENT_DEPLOYMENT_SUCCEEDED,
+			want:  []string{},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := &DeploymentNotification{
+				tc.mentions,
+			}
+			es := n.FindEmails(tc.event)
+			assert.ElementsMatch(t, tc.want, es)
+		})
+	}
+}
+
+func TestValidateTrafficRoutingStep(t *testing.T) {
+	testcases := []struct {
+		name    string
+		step    TrafficRoutingStep
+		wantErr bool
+	}{
+		{
+			name: "valid without analysis",
+			step: TrafficRoutingStep{
+				Weight:   Percentage{Number: 10},
+				Interval: Duration(time.Minute),
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid with analysis",
+			step: TrafficRoutingStep{
+				Weight:   Percentage{Number: 10},
+				Interval: Duration(time.Minute),
+				Analysis: &AnalysisStageOptions{
+					Metrics: []TemplatableAnalysisMetrics{
+						{
+							Template: AnalysisTemplateRef{Name: "error-rate"},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid due to weight over 100",
+			step: TrafficRoutingStep{
+				Weight: Percentage{Number: 120},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid due to analysis without interval",
+			step: TrafficRoutingStep{
+				Weight:   Percentage{Number: 10},
+				Analysis: &AnalysisStageOptions{},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.step.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
 func TestValidateAnalysisTemplateRef(t *testing.T) {
 	testcases := []struct {
 		name    string
@@ -307,6 +506,37 @@ func TestGenericTriggerConfiguration(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
+						},
+						OnChain: OnChain{
+							Disabled: newBoolPointer(true),
+						},
+					},
+				},
+				Input: KubernetesDeploymentInput{
+					AutoRollback: newBoolPointer(true),
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			fileName:           "testdata/application/generic-trigger-self-heal.yaml",
+			expectedKind:       KindKubernetesApp,
+			expectedAPIVersion: "pipecd.dev/v1beta1",
+			expectedSpec: &KubernetesApplicationSpec{
+				GenericApplicationSpec: GenericApplicationSpec{
+					Timeout: Duration(6 * time.Hour),
+					Trigger: Trigger{
+						OnOutOfSync: OnOutOfSync{
+							Disabled:  newBoolPointer(true),
+							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								Enabled:     true,
+								MinInterval: Duration(30 * time.Minute),
+								Kinds:       []string{"Deployment", "ConfigMap"},
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -319,6 +549,10 @@ func TestGenericTriggerConfiguration(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			fileName:      "testdata/application/generic-trigger-self-heal-unsupported-kind.yaml",
+			expectedError: fmt.Errorf("selfHeal is not supported for ECSApp application"),
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.fileName, func(t *testing.T) {
@@ -333,6 +567,49 @@ func TestGenericTriggerConfiguration(t *testing.T) {
 	}
 }
 
+func TestValidateSelfHeal(t *testing.T) {
+	testcases := []struct {
+		name     string
+		selfHeal SelfHeal
+		wantErr  bool
+	}{
+		{
+			name:     "disabled",
+			selfHeal: SelfHeal{},
+			wantErr:  false,
+		},
+		{
+			name: "valid",
+			selfHeal: SelfHeal{
+				Enabled: true,
+				Kinds:   []string{"Deployment"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid because kinds is missing",
+			selfHeal: SelfHeal{
+				Enabled: true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid because kinds contains an empty value",
+			selfHeal: SelfHeal{
+				Enabled: true,
+				Kinds:   []string{"Deployment", ""},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.selfHeal.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
 func TestTrueByDefaultBoolConfiguration(t *testing.T) {
 	testcases := []struct {
 		fileName           string
@@ -352,6 +629,9 @@ func TestTrueByDefaultBoolConfiguration(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -375,6 +655,9 @@ func TestTrueByDefaultBoolConfiguration(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(false),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -398,6 +681,9 @@ func TestTrueByDefaultBoolConfiguration(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -443,6 +729,9 @@ func TestGenericPostSyncConfiguration(t *testing.T) {
 						OnOutOfSync: OnOutOfSync{
 							Disabled:  newBoolPointer(true),
 							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
 						},
 						OnChain: OnChain{
 							Disabled: newBoolPointer(true),
@@ -486,3 +775,395 @@ func TestGenericPostSyncConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestGenericScriptRunConfiguration(t *testing.T) {
+	testcases := []struct {
+		fileName           string
+		expectedKind       Kind
+		expectedAPIVersion string
+		expectedSpec       interface{}
+		expectedError      error
+	}{
+		{
+			fileName:           "testdata/application/generic-script-run.yaml",
+			expectedKind:       KindKubernetesApp,
+			expectedAPIVersion: "pipecd.dev/v1beta1",
+			expectedSpec: &KubernetesApplicationSpec{
+				GenericApplicationSpec: GenericApplicationSpec{
+					Timeout: Duration(6 * time.Hour),
+					Trigger: Trigger{
+						OnOutOfSync: OnOutOfSync{
+							Disabled:  newBoolPointer(true),
+							MinWindow: Duration(5 * time.Minute),
+							SelfHeal: SelfHeal{
+								MinInterval: Duration(10 * time.Minute),
+							},
+						},
+						OnChain: OnChain{
+							Disabled: newBoolPointer(true),
+						},
+					},
+					Pipeline: &DeploymentPipeline{
+						Stages: []PipelineStage{
+							{
+								Name:                         model.StageK8sCanaryRollout,
+								K8sCanaryRolloutStageOptions: &K8sCanaryRolloutStageOptions{},
+							},
+							{
+								Name:    model.StageScriptRun,
+								Timeout: Duration(10 * time.Minute),
+								ScriptRunStageOptions: &ScriptRunStageOptions{
+									Run: "./scripts/migrate.sh up",
+									Env: map[string]string{
+										"DB_NAME": "app",
+									},
+									OnRollback: "./scripts/migrate.sh down",
+								},
+							},
+							{
+								Name:                          model.StageK8sPrimaryRollout,
+								K8sPrimaryRolloutStageOptions: &K8sPrimaryRolloutStageOptions{},
+							},
+						},
+					},
+				},
+				Input: KubernetesDeploymentInput{
+					AutoRollback: newBoolPointer(true),
+				},
+			},
+			expectedError: nil,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.fileName, func(t *testing.T) {
+			cfg, err := LoadFromYAML(tc.fileName)
+			require.Equal(t, tc.expectedError, err)
+			if err == nil {
+				assert.Equal(t, tc.expectedKind, cfg.Kind)
+				assert.Equal(t, tc.expectedAPIVersion, cfg.APIVersion)
+				assert.Equal(t, tc.expectedSpec, cfg.spec)
+			}
+		})
+	}
+}
+
+func TestValidateAnalysisScoring(t *testing.T) {
+	testcases := []struct {
+		name    string
+		scoring AnalysisScoring
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			scoring: AnalysisScoring{
+				Pass:     95,
+				Marginal: 75,
+				Approval: WaitApprovalStageOptions{MinApproverNum: 1},
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid without marginal",
+			scoring: AnalysisScoring{
+				Pass:     95,
+				Approval: WaitApprovalStageOptions{MinApproverNum: 1},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid due to missing pass",
+			scoring: AnalysisScoring{
+				Approval: WaitApprovalStageOptions{MinApproverNum: 1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid due to marginal greater than pass",
+			scoring: AnalysisScoring{
+				Pass:     75,
+				Marginal: 95,
+				Approval: WaitApprovalStageOptions{MinApproverNum: 1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid due to approver number",
+			scoring: AnalysisScoring{
+				Pass:     95,
+				Marginal: 75,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.scoring.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestValidateAnalysisStageOptionsWeight(t *testing.T) {
+	scoring := &AnalysisScoring{
+		Pass:     95,
+		Approval: WaitApprovalStageOptions{MinApproverNum: 1},
+	}
+	testcases := []struct {
+		name    string
+		opts    AnalysisStageOptions
+		wantErr bool
+	}{
+		{
+			name: "positive total weight",
+			opts: AnalysisStageOptions{
+				Duration: Duration(time.Minute),
+				Logs: []TemplatableAnalysisLog{
+					{AnalysisLog: AnalysisLog{Weight: 2}},
+				},
+				Https: []TemplatableAnalysisHTTP{
+					{AnalysisHTTP: AnalysisHTTP{Weight: 0}},
+				},
+				Scoring: scoring,
+			},
+			wantErr: false,
+		},
+		{
+			name: "zero total weight",
+			opts: AnalysisStageOptions{
+				Duration: Duration(time.Minute),
+				Logs: []TemplatableAnalysisLog{
+					{AnalysisLog: AnalysisLog{Weight: 0}},
+				},
+				Https: []TemplatableAnalysisHTTP{
+					{AnalysisHTTP: AnalysisHTTP{Weight: 0}},
+				},
+				Scoring: scoring,
+			},
+			wantErr: true,
+		},
+		{
+			name: "no analysis",
+			opts: AnalysisStageOptions{
+				Duration: Duration(time.Minute),
+				Scoring:  scoring,
+			},
+			wantErr: true,
+		},
+		{
+			name: "weight given by template",
+			opts: AnalysisStageOptions{
+				Duration: Duration(time.Minute),
+				Logs: []TemplatableAnalysisLog{
+					{AnalysisLog: AnalysisLog{Weight: 0}},
+				},
+				Https: []TemplatableAnalysisHTTP{
+					{Template: AnalysisTemplateRef{Name: "http-check"}},
+				},
+				Scoring: scoring,
+			},
+			wantErr: false,
+		},
+		{
+			name: "zero total weight without scoring",
+			opts: AnalysisStageOptions{
+				Duration: Duration(time.Minute),
+				Logs: []TemplatableAnalysisLog{
+					{AnalysisLog: AnalysisLog{Weight: 0}},
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opts.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestValidateDeploymentPipeline(t *testing.T) {
+	testcases := []struct {
+		name    string
+		stages  []PipelineStage
+		wantErr bool
+	}{
+		{
+			name: "valid retry configuration",
+			stages: []PipelineStage{
+				{
+					Id:            "sync",
+					Name:          model.StageK8sSync,
+					Retries:       2,
+					RetryInterval: Duration(time.Minute),
+					RetryOn:       []StageRetryCondition{StageRetryOnTimeout},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative retries",
+			stages: []PipelineStage{
+				{Name: model.StageK8sSync, Retries: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid retry condition",
+			stages: []PipelineStage{
+				{Name: model.StageK8sSync, Retries: 1, RetryOn: []StageRetryCondition{"UNKNOWN"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "require a defined stage",
+			stages: []PipelineStage{
+				{Id: "canary", Name: model.StageK8sCanaryRollout},
+				{Id: "analysis", Name: model.StageAnalysis, Requires: []string{"canary"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "require an undefined stage",
+			stages: []PipelineStage{
+				{Id: "analysis", Name: model.StageAnalysis, Requires: []string{"canary"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid condition",
+			stages: []PipelineStage{
+				{Id: "canary", Name: model.StageK8sCanaryRollout},
+				{Name: model.StageAnalysis, When: `changed("apps/web/**") && stages.canary.status == "SUCCESS"`},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid condition",
+			stages: []PipelineStage{
+				{Name: model.StageAnalysis, When: `commit.message ==`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "condition refers to an undefined stage",
+			stages: []PipelineStage{
+				{Id: "analysis", Name: model.StageAnalysis, When: `stages.canary.status == "SUCCESS"`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "condition refers to a transitively required stage",
+			stages: []PipelineStage{
+				{Id: "canary", Name: model.StageK8sCanaryRollout},
+				{Id: "analysis", Name: model.StageAnalysis, Requires: []string{"canary"}},
+				{Id: "approval", Name: model.StageWaitApproval, Requires: []string{"analysis"}},
+				{Name: model.StageK8sPrimaryRollout, Requires: []string{"approval"}, When: `stages.canary.status == "SUCCESS"`},
+			},
+			wantErr: false,
+		},
+		{
+			name: "condition refers to a stage implicitly required as the previous one",
+			stages: []PipelineStage{
+				{Id: "canary", Name: model.StageK8sCanaryRollout},
+				{Name: model.StageAnalysis},
+				{Name: model.StageK8sPrimaryRollout, When: `stages.canary.status == "SUCCESS"`},
+			},
+			wantErr: false,
+		},
+		{
+			name: "condition refers to a concurrent stage",
+			stages: []PipelineStage{
+				{Id: "canary", Name: model.StageK8sCanaryRollout},
+				{Id: "analysis", Name: model.StageAnalysis, Requires: []string{"canary"}},
+				{Id: "approval", Name: model.StageWaitApproval, Requires: []string{"canary"}, When: `stages.analysis.status == "SUCCESS"`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "condition refers to a later stage",
+			stages: []PipelineStage{
+				{Id: "canary", Name: model.StageK8sCanaryRollout, When: `stages.analysis.status == "SUCCESS"`},
+				{Id: "analysis", Name: model.StageAnalysis},
+			},
+			wantErr: true,
+		},
+		{
+			name: "condition of a stage requiring nothing refers to a previous stage",
+			stages: []PipelineStage{
+				{Id: "canary", Name: model.StageK8sCanaryRollout},
+				{Id: "analysis", Name: model.StageAnalysis, Requires: []string{}, When: `stages.canary.status == "SUCCESS"`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "condition refers to its own result",
+			stages: []PipelineStage{
+				{Id: "analysis", Name: model.StageAnalysis, When: `stages.analysis.status == "SUCCESS"`},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &DeploymentPipeline{Stages: tc.stages}
+			err := p.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestPipelineStageShouldRetry(t *testing.T) {
+	testcases := []struct {
+		name     string
+		stage    PipelineStage
+		retried  int
+		timedOut bool
+		want     bool
+	}{
+		{
+			name:    "no retry configured",
+			stage:   PipelineStage{},
+			retried: 0,
+			want:    false,
+		},
+		{
+			name:    "retry on failure by default",
+			stage:   PipelineStage{Retries: 2},
+			retried: 1,
+			want:    true,
+		},
+		{
+			name:     "retry on timeout by default",
+			stage:    PipelineStage{Retries: 2},
+			retried:  0,
+			timedOut: true,
+			want:     true,
+		},
+		{
+			name:    "all retries were used",
+			stage:   PipelineStage{Retries: 2},
+			retried: 2,
+			want:    false,
+		},
+		{
+			name:     "timeout is not a retry condition",
+			stage:    PipelineStage{Retries: 2, RetryOn: []StageRetryCondition{StageRetryOnFailure}},
+			retried:  0,
+			timedOut: true,
+			want:     false,
+		},
+		{
+			name:    "failure is not a retry condition",
+			stage:   PipelineStage{Retries: 2, RetryOn: []StageRetryCondition{StageRetryOnTimeout}},
+			retried: 0,
+			want:    false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.stage.ShouldRetry(tc.retried, tc.timedOut)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
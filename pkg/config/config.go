@@ -66,6 +66,10 @@ const (
 	KindAnalysisTemplate Kind = "AnalysisTemplate"
 	// KindEventWatcher represents configuration for Event Watcher.
 	KindEventWatcher Kind = "EventWatcher"
+	// KindPipelineTemplate represents shared pipeline templates for a repository.
+	// This configuration file should be placed in .pipe directory
+	// at the root of the repository.
+	KindPipelineTemplate Kind = "PipelineTemplate"
 )
 
 // ToApplicationKind converts itself into model.ApplicationKind if it's for an application config.
@@ -101,6 +105,7 @@ type Config struct {
 	ControlPlaneSpec     *ControlPlaneSpec
 	AnalysisTemplateSpec *AnalysisTemplateSpec
 	EventWatcherSpec     *EventWatcherSpec
+	PipelineTemplateSpec *PipelineTemplateSpec
 
 	SealedSecretSpec *SealedSecretSpec
 }
@@ -156,6 +161,10 @@ func (c *Config) init(kind Kind, apiVersion string) error {
 		c.EventWatcherSpec = &EventWatcherSpec{}
 		c.spec = c.EventWatcherSpec
 
+	case KindPipelineTemplate:
+		c.PipelineTemplateSpec = &PipelineTemplateSpec{}
+		c.spec = c.PipelineTemplateSpec
+
 	default:
 		return fmt.Errorf("unsupported kind: %s", c.Kind)
 	}
@@ -210,6 +219,12 @@ func (c *Config) Validate() error {
 	if err := spec.Validate(); err != nil {
 		return err
 	}
+
+	// Self-heal is currently available only for Kubernetes application,
+	// so enabling it for other kinds is rejected instead of being ignored silently.
+	if g, ok := c.GetGenericApplication(); ok && c.Kind != KindKubernetesApp && g.Trigger.OnOutOfSync.SelfHeal.Enabled {
+		return fmt.Errorf("selfHeal is not supported for %s application", c.Kind)
+	}
 	return nil
 }
 
@@ -0,0 +1,207 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+const deployWindowTimeFormat = "2006-01-02 15:04 MST"
+
+// DeployWindows configures the time windows in which deployments are allowed to start.
+type DeployWindows struct {
+	// The IANA name of the timezone used to evaluate the schedules.
+	// e.g. Asia/Tokyo
+	// Default is UTC.
+	Timezone string `json:"timezone"`
+	// List of windows in which deployments are allowed to start.
+	// Empty means deployments are allowed at any time except in the deny windows.
+	Allow []DeployWindow `json:"allow"`
+	// List of windows in which deployments are not allowed to start.
+	// The deny windows take precedence over the allow windows.
+	Deny []DeployWindow `json:"deny"`
+}
+
+// DeployWindow represents a recurring time window.
+type DeployWindow struct {
+	// The cron expression in the standard 5-field format specifying when the window opens.
+	// e.g. "0 18 * * 5" for every Friday at 18:00
+	Schedule string `json:"schedule"`
+	// How long the window stays open after each opening.
+	Duration Duration `json:"duration"`
+	// The human-readable description of the window.
+	// e.g. Weekend freeze
+	Description string `json:"description"`
+}
+
+// Validate returns an error if any wrong configuration value was found.
+func (w DeployWindows) Validate() error {
+	if _, err := time.LoadLocation(w.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q for deploy windows: %w", w.Timezone, err)
+	}
+	for _, dw := range append(append([]DeployWindow{}, w.Allow...), w.Deny...) {
+		if err := dw.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate returns an error if any wrong configuration value was found.
+func (w DeployWindow) Validate() error {
+	if _, err := cron.ParseStandard(w.Schedule); err != nil {
+		return fmt.Errorf("invalid schedule %q for deploy window: %w", w.Schedule, err)
+	}
+	if w.Duration <= 0 {
+		return fmt.Errorf("duration of deploy window %q must be greater than 0", w.Schedule)
+	}
+	return nil
+}
+
+// IsEmpty reports whether no window was configured.
+func (w DeployWindows) IsEmpty() bool {
+	return len(w.Allow) == 0 && len(w.Deny) == 0
+}
+
+// Check reports whether deployments are allowed to start at the given time.
+// When not allowed, a human-readable reason is returned as well.
+func (w DeployWindows) Check(t time.Time) (allowed bool, reason string, err error) {
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return false, "", err
+	}
+	t = t.In(loc)
+
+	for _, dw := range w.Deny {
+		open, closeAt, err := dw.openAt(t)
+		if err != nil {
+			return false, "", err
+		}
+		if open {
+			reason = fmt.Sprintf("Deployment is blocked by deny window %q until %s", dw.name(), closeAt.Format(deployWindowTimeFormat))
+			return false, reason, nil
+		}
+	}
+
+	if len(w.Allow) == 0 {
+		return true, "", nil
+	}
+
+	var next time.Time
+	for _, dw := range w.Allow {
+		open, _, err := dw.openAt(t)
+		if err != nil {
+			return false, "", err
+		}
+		if open {
+			return true, "", nil
+		}
+		sched, err := cron.ParseStandard(dw.Schedule)
+		if err != nil {
+			return false, "", err
+		}
+		if n := sched.Next(t); next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+
+	reason = fmt.Sprintf("Deployment is blocked because it is outside of all allow windows, the next one opens at %s", next.Format(deployWindowTimeFormat))
+	return false, reason, nil
+}
+
+// CheckDeployWindows reports whether deployments are allowed to start
+// at the given time by all of the given deploy windows.
+// Nil deploy windows are ignored.
+func CheckDeployWindows(t time.Time, windows ...*DeployWindows) (allowed bool, reason string, err error) {
+	for _, w := range windows {
+		if w == nil {
+			continue
+		}
+		if allowed, reason, err = w.Check(t); err != nil || !allowed {
+			return
+		}
+	}
+	return true, "", nil
+}
+
+// NewDeployWindowsFromProject converts the deploy windows configured at the project level
+// into the same form used by the piped and application configurations.
+// Nil is returned when no window was configured.
+func NewDeployWindowsFromProject(pw *model.ProjectDeployWindows) (*DeployWindows, error) {
+	if pw == nil {
+		return nil, nil
+	}
+	convert := func(windows []*model.ProjectDeployWindow) ([]DeployWindow, error) {
+		var out []DeployWindow
+		for _, w := range windows {
+			d, err := time.ParseDuration(w.Duration)
+			if err != nil {
+				return nil, fmt.Errorf("invalid duration %q for deploy window: %w", w.Duration, err)
+			}
+			out = append(out, DeployWindow{
+				Schedule:    w.Schedule,
+				Duration:    Duration(d),
+				Description: w.Description,
+			})
+		}
+		return out, nil
+	}
+
+	allow, err := convert(pw.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := convert(pw.Deny)
+	if err != nil {
+		return nil, err
+	}
+	w := &DeployWindows{
+		Timezone: pw.Timezone,
+		Allow:    allow,
+		Deny:     deny,
+	}
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// openAt reports whether the window is open at the given time.
+// When open, the time the window closes is returned as well.
+func (w DeployWindow) openAt(t time.Time) (bool, time.Time, error) {
+	sched, err := cron.ParseStandard(w.Schedule)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	// The most recent opening that is still in effect must be
+	// in the range of (t - duration, t].
+	start := sched.Next(t.Add(-w.Duration.Duration()))
+	if start.After(t) {
+		return false, time.Time{}, nil
+	}
+	return true, start.Add(w.Duration.Duration()), nil
+}
+
+func (w DeployWindow) name() string {
+	if w.Description != "" {
+		return w.Description
+	}
+	return w.Schedule
+}
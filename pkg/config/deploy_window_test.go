@@ -0,0 +1,230 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+func TestDeployWindowsCheck(t *testing.T) {
+	weekendFreeze := DeployWindow{
+		Schedule:    "0 18 * * 5",
+		Duration:    Duration(62 * time.Hour),
+		Description: "Weekend freeze",
+	}
+	businessHours := DeployWindow{
+		Schedule: "0 9 * * 1-5",
+		Duration: Duration(9 * time.Hour),
+	}
+
+	testcases := []struct {
+		name           string
+		windows        DeployWindows
+		time           time.Time
+		expectedAllow  bool
+		expectedReason string
+	}{
+		{
+			name:          "no window",
+			time:          time.Date(2022, 6, 10, 19, 0, 0, 0, time.UTC),
+			expectedAllow: true,
+		},
+		{
+			name: "outside of deny window",
+			windows: DeployWindows{
+				Deny: []DeployWindow{weekendFreeze},
+			},
+			time:          time.Date(2022, 6, 10, 17, 59, 0, 0, time.UTC),
+			expectedAllow: true,
+		},
+		{
+			name: "inside of deny window",
+			windows: DeployWindows{
+				Deny: []DeployWindow{weekendFreeze},
+			},
+			time:           time.Date(2022, 6, 12, 10, 0, 0, 0, time.UTC),
+			expectedAllow:  false,
+			expectedReason: "Deployment is blocked by deny window \"Weekend freeze\" until 2022-06-13 08:00 UTC",
+		},
+		{
+			name: "deny window is closed at the end of its duration",
+			windows: DeployWindows{
+				Deny: []DeployWindow{weekendFreeze},
+			},
+			time:          time.Date(2022, 6, 13, 8, 0, 0, 0, time.UTC),
+			expectedAllow: true,
+		},
+		{
+			name: "evaluated in the configured timezone",
+			windows: DeployWindows{
+				Timezone: "Asia/Tokyo",
+				Deny:     []DeployWindow{weekendFreeze},
+			},
+			time:           time.Date(2022, 6, 10, 10, 0, 0, 0, time.UTC),
+			expectedAllow:  false,
+			expectedReason: "Deployment is blocked by deny window \"Weekend freeze\" until 2022-06-13 08:00 JST",
+		},
+		{
+			name: "inside of allow window",
+			windows: DeployWindows{
+				Allow: []DeployWindow{businessHours},
+			},
+			time:          time.Date(2022, 6, 10, 9, 0, 0, 0, time.UTC),
+			expectedAllow: true,
+		},
+		{
+			name: "outside of all allow windows",
+			windows: DeployWindows{
+				Allow: []DeployWindow{businessHours},
+			},
+			time:           time.Date(2022, 6, 11, 9, 0, 0, 0, time.UTC),
+			expectedAllow:  false,
+			expectedReason: "Deployment is blocked because it is outside of all allow windows, the next one opens at 2022-06-13 09:00 UTC",
+		},
+		{
+			name: "deny window takes precedence over allow window",
+			windows: DeployWindows{
+				Allow: []DeployWindow{businessHours},
+				Deny:  []DeployWindow{weekendFreeze},
+			},
+			time:           time.Date(2022, 6, 10, 18, 0, 0, 0, time.UTC),
+			expectedAllow:  false,
+			expectedReason: "Deployment is blocked by deny window \"Weekend freeze\" until 2022-06-13 08:00 UTC",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			allowed, reason, err := tc.windows.Check(tc.time)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedAllow, allowed)
+			assert.Equal(t, tc.expectedReason, reason)
+		})
+	}
+}
+
+func TestDeployWindowsValidate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		windows DeployWindows
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			windows: DeployWindows{
+				Timezone: "Asia/Tokyo",
+				Deny: []DeployWindow{
+					{Schedule: "0 18 * * 5", Duration: Duration(62 * time.Hour)},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid timezone",
+			windows: DeployWindows{
+				Timezone: "Mars/Olympus",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid schedule",
+			windows: DeployWindows{
+				Allow: []DeployWindow{
+					{Schedule: "every friday", Duration: Duration(time.Hour)},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing duration",
+			windows: DeployWindows{
+				Deny: []DeployWindow{
+					{Schedule: "0 18 * * 5"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.windows.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestNewDeployWindowsFromProject(t *testing.T) {
+	testcases := []struct {
+		name     string
+		windows  *model.ProjectDeployWindows
+		expected *DeployWindows
+		wantErr  bool
+	}{
+		{
+			name: "not configured",
+		},
+		{
+			name: "valid windows",
+			windows: &model.ProjectDeployWindows{
+				Timezone: "Asia/Tokyo",
+				Allow: []*model.ProjectDeployWindow{
+					{Schedule: "0 9 * * 1-5", Duration: "9h"},
+				},
+				Deny: []*model.ProjectDeployWindow{
+					{Schedule: "0 18 * * 5", Duration: "62h", Description: "Weekend freeze"},
+				},
+			},
+			expected: &DeployWindows{
+				Timezone: "Asia/Tokyo",
+				Allow: []DeployWindow{
+					{Schedule: "0 9 * * 1-5", Duration: Duration(9 * time.Hour)},
+				},
+				Deny: []DeployWindow{
+					{Schedule: "0 18 * * 5", Duration: Duration(62 * time.Hour), Description: "Weekend freeze"},
+				},
+			},
+		},
+		{
+			name: "invalid duration",
+			windows: &model.ProjectDeployWindows{
+				Deny: []*model.ProjectDeployWindow{
+					{Schedule: "0 18 * * 5", Duration: "two days"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid schedule",
+			windows: &model.ProjectDeployWindows{
+				Allow: []*model.ProjectDeployWindow{
+					{Schedule: "every friday", Duration: "1h"},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := NewDeployWindowsFromProject(tc.windows)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.expected, w)
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/pipe-cd/pipecd/pkg/model"
 )
@@ -68,6 +69,14 @@ type PipedSpec struct {
 	SecretManagement *SecretManagement `json:"secretManagement"`
 	// Optional settings for event watcher.
 	EventWatcher PipedEventWatcher `json:"eventWatcher"`
+	// The time windows in which deployments of all applications handled by this piped are allowed to start.
+	// These are applied together with the ones configured in each application configuration.
+	DeployWindows *DeployWindows `json:"deployWindows"`
+	// The maximum numbers of deployments those can be handled concurrently by this piped.
+	// The deployments exceeding the limits are queued and started in the order of their triggered time.
+	DeploymentConcurrency *PipedDeploymentConcurrency `json:"deploymentConcurrency"`
+	// Optional settings for the SCRIPT_RUN stage.
+	ScriptRun PipedScriptRun `json:"scriptRun"`
 }
 
 // Validate validates configured data of all fields.
@@ -108,6 +117,30 @@ func (s *PipedSpec) Validate() error {
 			return err
 		}
 	}
+	if err := s.Notifications.Validate(); err != nil {
+		return err
+	}
+	if s.DeployWindows != nil {
+		if err := s.DeployWindows.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.DeploymentConcurrency != nil {
+		if err := s.DeploymentConcurrency.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := s.ScriptRun.Validate(); err != nil {
+		return err
+	}
+	for _, cp := range s.CloudProviders {
+		if cp.TerraformConfig == nil {
+			continue
+		}
+		if err := cp.TerraformConfig.DriftDetection.Validate(); err != nil {
+			return fmt.Errorf("invalid config of cloud provider %s: %w", cp.Name, err)
+		}
+	}
 	return nil
 }
 
@@ -425,6 +458,31 @@ type CloudProviderTerraformConfig struct {
 	// 'image_id_list=["ami-abc123","ami-def456"]'
 	// 'image_id_map={"us-east-1":"ami-abc123","us-east-2":"ami-def456"}'
 	Vars []string `json:"vars"`
+	// Configuration for detecting the configuration drift of the applications
+	// by periodically running "terraform plan" at the latest commit.
+	DriftDetection TerraformDriftDetection `json:"driftDetection"`
+}
+
+type TerraformDriftDetection struct {
+	// Whether to stop detecting the drift of the terraform applications.
+	// Default is false.
+	Disabled bool `json:"disabled"`
+	// How often to check the drift of all applications.
+	// Default is 10m.
+	Interval Duration `json:"interval" default:"10m"`
+	// The maximum number of applications being planned at the same time.
+	// Default is 1.
+	Concurrency int `json:"concurrency" default:"1"`
+}
+
+func (d *TerraformDriftDetection) Validate() error {
+	if d.Interval <= 0 {
+		return errors.New("driftDetection.interval must be greater than 0")
+	}
+	if d.Concurrency <= 0 {
+		return errors.New("driftDetection.concurrency must be greater than 0")
+	}
+	return nil
 }
 
 type CloudProviderCloudRunConfig struct {
@@ -474,9 +532,13 @@ type PipedAnalysisProvider struct {
 	Name string                     `json:"name"`
 	Type model.AnalysisProviderType `json:"type"`
 
-	PrometheusConfig  *AnalysisProviderPrometheusConfig  `json:"prometheus"`
-	DatadogConfig     *AnalysisProviderDatadogConfig     `json:"datadog"`
-	StackdriverConfig *AnalysisProviderStackdriverConfig `json:"stackdriver"`
+	PrometheusConfig    *AnalysisProviderPrometheusConfig    `json:"prometheus"`
+	DatadogConfig       *AnalysisProviderDatadogConfig       `json:"datadog"`
+	StackdriverConfig   *AnalysisProviderStackdriverConfig   `json:"stackdriver"`
+	LokiConfig          *AnalysisProviderLokiConfig          `json:"loki"`
+	ElasticsearchConfig *AnalysisProviderElasticsearchConfig `json:"elasticsearch"`
+	CloudWatchConfig    *AnalysisProviderCloudWatchConfig    `json:"cloudWatch"`
+	NewRelicConfig      *AnalysisProviderNewRelicConfig      `json:"newRelic"`
 }
 
 type genericPipedAnalysisProvider struct {
@@ -510,6 +572,26 @@ func (p *PipedAnalysisProvider) UnmarshalJSON(data []byte) error {
 		if len(gp.Config) > 0 {
 			err = json.Unmarshal(gp.Config, p.StackdriverConfig)
 		}
+	case model.AnalysisProviderLoki:
+		p.LokiConfig = &AnalysisProviderLokiConfig{}
+		if len(gp.Config) > 0 {
+			err = json.Unmarshal(gp.Config, p.LokiConfig)
+		}
+	case model.AnalysisProviderElasticsearch:
+		p.ElasticsearchConfig = &AnalysisProviderElasticsearchConfig{}
+		if len(gp.Config) > 0 {
+			err = json.Unmarshal(gp.Config, p.ElasticsearchConfig)
+		}
+	case model.AnalysisProviderCloudWatch:
+		p.CloudWatchConfig = &AnalysisProviderCloudWatchConfig{}
+		if len(gp.Config) > 0 {
+			err = json.Unmarshal(gp.Config, p.CloudWatchConfig)
+		}
+	case model.AnalysisProviderNewRelic:
+		p.NewRelicConfig = &AnalysisProviderNewRelicConfig{}
+		if len(gp.Config) > 0 {
+			err = json.Unmarshal(gp.Config, p.NewRelicConfig)
+		}
 	default:
 		err = fmt.Errorf("unsupported analysis provider type: %s", p.Name)
 	}
@@ -524,6 +606,14 @@ func (p *PipedAnalysisProvider) Validate() error {
 		return p.DatadogConfig.Validate()
 	case model.AnalysisProviderStackdriver:
 		return p.StackdriverConfig.Validate()
+	case model.AnalysisProviderLoki:
+		return p.LokiConfig.Validate()
+	case model.AnalysisProviderElasticsearch:
+		return p.ElasticsearchConfig.Validate()
+	case model.AnalysisProviderCloudWatch:
+		return p.CloudWatchConfig.Validate()
+	case model.AnalysisProviderNewRelic:
+		return p.NewRelicConfig.Validate()
 	default:
 		return fmt.Errorf("unknow provider type: %s", p.Type)
 	}
@@ -574,6 +664,85 @@ func (a *AnalysisProviderStackdriverConfig) Validate() error {
 	return nil
 }
 
+type AnalysisProviderLokiConfig struct {
+	// The address of Loki server.
+	Address string `json:"address"`
+	// The tenant ID sent as X-Scope-OrgID header.
+	// Required only when Loki is running in multi-tenant mode.
+	TenantID string `json:"tenantID"`
+	// The path to the username file.
+	UsernameFile string `json:"usernameFile"`
+	// The path to the password file.
+	PasswordFile string `json:"passwordFile"`
+}
+
+func (a *AnalysisProviderLokiConfig) Validate() error {
+	if a.Address == "" {
+		return fmt.Errorf("loki analysis provider requires the address")
+	}
+	return nil
+}
+
+type AnalysisProviderElasticsearchConfig struct {
+	// The address of Elasticsearch or OpenSearch server.
+	Address string `json:"address"`
+	// The index or index pattern to search the logs in.
+	Index string `json:"index"`
+	// The name of the field used to filter the logs by time.
+	// Defaults to "@timestamp".
+	TimestampField string `json:"timestampField"`
+	// The path to the username file.
+	UsernameFile string `json:"usernameFile"`
+	// The path to the password file.
+	PasswordFile string `json:"passwordFile"`
+	// The path to the api key file.
+	// This takes precedence over the username and password.
+	APIKeyFile string `json:"apiKeyFile"`
+}
+
+func (a *AnalysisProviderElasticsearchConfig) Validate() error {
+	if a.Address == "" {
+		return fmt.Errorf("elasticsearch analysis provider requires the address")
+	}
+	if a.Index == "" {
+		return fmt.Errorf("elasticsearch analysis provider requires the index")
+	}
+	return nil
+}
+
+// AnalysisProviderCloudWatchConfig uses the same credential options as the ECS cloud provider.
+type AnalysisProviderCloudWatchConfig struct {
+	CloudProviderECSConfig
+}
+
+func (a *AnalysisProviderCloudWatchConfig) Validate() error {
+	if a.Region == "" {
+		return fmt.Errorf("cloudwatch analysis provider requires the region")
+	}
+	return nil
+}
+
+type AnalysisProviderNewRelicConfig struct {
+	// The address of New Relic NerdGraph API.
+	// Use "https://api.eu.newrelic.com/graphql" for accounts in the EU region.
+	// Defaults to "https://api.newrelic.com/graphql"
+	Address string `json:"address"`
+	// Required: The ID of the account to run NRQL queries against.
+	AccountID int `json:"accountID"`
+	// Required: The path to the user api key file.
+	APIKeyFile string `json:"apiKeyFile"`
+}
+
+func (a *AnalysisProviderNewRelicConfig) Validate() error {
+	if a.AccountID <= 0 {
+		return fmt.Errorf("newrelic analysis provider requires the account id")
+	}
+	if a.APIKeyFile == "" {
+		return fmt.Errorf("newrelic analysis provider requires the api key file")
+	}
+	return nil
+}
+
 type Notifications struct {
 	// List of notification routes.
 	Routes []NotificationRoute `json:"routes"`
@@ -594,10 +763,36 @@ type NotificationRoute struct {
 	IgnoreEnvs   []string `json:"ignoreEnvs"`
 }
 
+func (n *Notifications) Validate() error {
+	for _, r := range n.Receivers {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type NotificationReceiver struct {
-	Name    string                       `json:"name"`
-	Slack   *NotificationReceiverSlack   `json:"slack"`
-	Webhook *NotificationReceiverWebhook `json:"webhook"`
+	Name      string                         `json:"name"`
+	Slack     *NotificationReceiverSlack     `json:"slack"`
+	Webhook   *NotificationReceiverWebhook   `json:"webhook"`
+	Teams     *NotificationReceiverTeams     `json:"teams"`
+	Email     *NotificationReceiverEmail     `json:"email"`
+	PagerDuty *NotificationReceiverPagerDuty `json:"pagerDuty"`
+}
+
+func (n *NotificationReceiver) Validate() error {
+	if n.Email != nil {
+		if err := n.Email.Validate(); err != nil {
+			return fmt.Errorf("receiver %s: %w", n.Name, err)
+		}
+	}
+	if n.PagerDuty != nil {
+		if err := n.PagerDuty.Validate(); err != nil {
+			return fmt.Errorf("receiver %s: %w", n.Name, err)
+		}
+	}
+	return nil
 }
 
 type NotificationReceiverSlack struct {
@@ -610,6 +805,62 @@ type NotificationReceiverWebhook struct {
 	SignatureValue string `json:"signatureValue"`
 }
 
+type NotificationReceiverTeams struct {
+	// The URL of the Incoming Webhook connector configured in the channel.
+	HookURL string `json:"hookURL"`
+}
+
+type NotificationReceiverEmail struct {
+	// The host name of the SMTP server.
+	Host string `json:"host"`
+	// The port number of the SMTP server.
+	// Default is 587.
+	Port int `json:"port" default:"587"`
+	// Path to the file containing the username used to authenticate with the SMTP server.
+	// Leave empty when the server does not require authentication.
+	UsernameFile string `json:"usernameFile"`
+	// Path to the file containing the password used to authenticate with the SMTP server.
+	PasswordFile string `json:"passwordFile"`
+	// The address used as the sender of the emails.
+	From string `json:"from"`
+	// List of addresses that always receive the emails.
+	// The addresses specified in the mentions of the application
+	// configuration are added to this list.
+	To []string `json:"to"`
+}
+
+func (e *NotificationReceiverEmail) Validate() error {
+	if e.Host == "" {
+		return errors.New("host must be set for email receiver")
+	}
+	if e.Port <= 0 {
+		return errors.New("port must be greater than 0 for email receiver")
+	}
+	if e.From == "" {
+		return errors.New("from must be set for email receiver")
+	}
+	if e.UsernameFile != "" && e.PasswordFile == "" {
+		return errors.New("passwordFile must be set when usernameFile is specified for email receiver")
+	}
+	return nil
+}
+
+type NotificationReceiverPagerDuty struct {
+	// Path to the file containing the integration key (routing key)
+	// of the PagerDuty Events API v2 integration.
+	RoutingKeyFile string `json:"routingKeyFile"`
+	// The URL of the PagerDuty Events API v2.
+	// Default is https://events.pagerduty.com/v2/enqueue.
+	EventsURL string `json:"eventsURL" default:"https://events.pagerduty.com/v2/enqueue"`
+}
+
+func (p *NotificationReceiverPagerDuty) Validate() error {
+	if p.RoutingKeyFile == "" {
+		return errors.New("routingKeyFile must be set for pagerDuty receiver")
+	}
+	return nil
+}
+
 type SecretManagement struct {
 	// Which management service should be used.
 	// Available values: KEY_PAIR, SEALING_KEY, GCP_KMS, AWS_KMS
@@ -773,3 +1024,87 @@ type PipedEventWatcherGitRepo struct {
 	// This is prioritized if both includes and this one are given.
 	Excludes []string `json:"excludes"`
 }
+
+type PipedDeploymentConcurrency struct {
+	// The maximum number of concurrent deployments of all applications handled by piped.
+	// Zero means no limit.
+	MaxDeployments int `json:"maxDeployments"`
+	// The maximum numbers of concurrent deployments to specific cloud providers.
+	CloudProviders []CloudProviderDeploymentConcurrency `json:"cloudProviders"`
+	// The maximum numbers of concurrent deployments of the applications matching label selectors.
+	Labels []LabelDeploymentConcurrency `json:"labels"`
+}
+
+func (p *PipedDeploymentConcurrency) Validate() error {
+	if p.MaxDeployments < 0 {
+		return errors.New("deploymentConcurrency.maxDeployments must be greater than or equal to 0")
+	}
+	seen := make(map[string]struct{}, len(p.CloudProviders))
+	for i, cp := range p.CloudProviders {
+		if cp.Name == "" {
+			return fmt.Errorf("missing cloud provider name at index %d of deploymentConcurrency.cloudProviders", i)
+		}
+		if _, ok := seen[cp.Name]; ok {
+			return fmt.Errorf("duplicated cloud provider (%s) found in deploymentConcurrency.cloudProviders", cp.Name)
+		}
+		seen[cp.Name] = struct{}{}
+		if cp.MaxDeployments <= 0 {
+			return fmt.Errorf("maxDeployments for cloud provider %s must be greater than 0", cp.Name)
+		}
+	}
+	for i, l := range p.Labels {
+		if len(l.Selector) == 0 {
+			return fmt.Errorf("missing selector at index %d of deploymentConcurrency.labels", i)
+		}
+		if l.MaxDeployments <= 0 {
+			return fmt.Errorf("maxDeployments at index %d of deploymentConcurrency.labels must be greater than 0", i)
+		}
+	}
+	return nil
+}
+
+type CloudProviderDeploymentConcurrency struct {
+	// The name of cloud provider.
+	Name string `json:"name"`
+	// The maximum number of concurrent deployments to this cloud provider.
+	MaxDeployments int `json:"maxDeployments"`
+}
+
+type LabelDeploymentConcurrency struct {
+	// The labels an application must have to be counted in this limit.
+	Selector map[string]string `json:"selector"`
+	// The maximum number of concurrent deployments of the applications matching the selector.
+	MaxDeployments int `json:"maxDeployments"`
+}
+
+// Matches returns true when the given labels contain all labels of the selector.
+func (l LabelDeploymentConcurrency) Matches(labels map[string]string) bool {
+	for k, v := range l.Selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// PipedScriptRun contains the settings for the SCRIPT_RUN stage.
+// Since the stage executes the commands written in the application configuration
+// on the piped host, it is disabled unless it is explicitly enabled here.
+type PipedScriptRun struct {
+	// Whether the SCRIPT_RUN stage can be executed by this piped.
+	// Default is false.
+	Enabled bool `json:"enabled"`
+	// The names of the environment variables of the piped process passed to the scripts.
+	// Only PATH, HOME and TMPDIR are passed by default
+	// to avoid leaking the credentials given to the piped process.
+	AllowedEnv []string `json:"allowedEnv"`
+}
+
+func (p *PipedScriptRun) Validate() error {
+	for _, e := range p.AllowedEnv {
+		if e == "" || strings.Contains(e, "=") {
+			return fmt.Errorf("invalid environment variable name %q in scriptRun.allowedEnv", e)
+		}
+	}
+	return nil
+}
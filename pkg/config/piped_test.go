@@ -113,6 +113,10 @@ func TestPipedConfig(t *testing.T) {
 								"project=gcp-project",
 								"region=us-centra1",
 							},
+							DriftDetection: TerraformDriftDetection{
+								Interval:    Duration(10 * time.Minute),
+								Concurrency: 1,
+							},
 						},
 					},
 					{
@@ -156,6 +160,41 @@ func TestPipedConfig(t *testing.T) {
 							ServiceAccountFile: "/etc/piped-secret/gcp-service-account.json",
 						},
 					},
+					{
+						Name: "loki-dev",
+						Type: model.AnalysisProviderLoki,
+						LokiConfig: &AnalysisProviderLokiConfig{
+							Address:  "https://your-loki.dev",
+							TenantID: "dev",
+						},
+					},
+					{
+						Name: "elasticsearch-dev",
+						Type: model.AnalysisProviderElasticsearch,
+						ElasticsearchConfig: &AnalysisProviderElasticsearchConfig{
+							Address:    "https://your-elasticsearch.dev",
+							Index:      "logs-*",
+							APIKeyFile: "/etc/piped-secret/elasticsearch-api-key",
+						},
+					},
+					{
+						Name: "cloudwatch-dev",
+						Type: model.AnalysisProviderCloudWatch,
+						CloudWatchConfig: &AnalysisProviderCloudWatchConfig{
+							CloudProviderECSConfig: CloudProviderECSConfig{
+								Region:  "us-west-2",
+								Profile: "default",
+							},
+						},
+					},
+					{
+						Name: "newrelic-dev",
+						Type: model.AnalysisProviderNewRelic,
+						NewRelicConfig: &AnalysisProviderNewRelicConfig{
+							AccountID:  1234567,
+							APIKeyFile: "/etc/piped-secret/newrelic-api-key",
+						},
+					},
 				},
 				Notifications: Notifications{
 					Routes: []NotificationRoute{
@@ -196,6 +235,30 @@ func TestPipedConfig(t *testing.T) {
 								SignatureValue: "random-signature-string",
 							},
 						},
+						{
+							Name: "dev-teams-channel",
+							Teams: &NotificationReceiverTeams{
+								HookURL: "https://outlook.office.com/webhook/dev",
+							},
+						},
+						{
+							Name: "oncall-email",
+							Email: &NotificationReceiverEmail{
+								Host:         "smtp.example.com",
+								Port:         587,
+								UsernameFile: "/etc/piped-secret/smtp-username",
+								PasswordFile: "/etc/piped-secret/smtp-password",
+								From:         "pipecd@example.com",
+								To:           []string{"oncall@example.com"},
+							},
+						},
+						{
+							Name: "oncall-pagerduty",
+							PagerDuty: &NotificationReceiverPagerDuty{
+								RoutingKeyFile: "/etc/piped-secret/pagerduty-routing-key",
+								EventsURL:      "https://events.pagerduty.com/v2/enqueue",
+							},
+						},
 					},
 				},
 				SecretManagement: &SecretManagement{
@@ -314,3 +377,140 @@ func TestPipedEventWatcherValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestPipedDeploymentConcurrencyValidate(t *testing.T) {
+	testcases := []struct {
+		name        string
+		concurrency PipedDeploymentConcurrency
+		wantErr     bool
+	}{
+		{
+			name:        "empty",
+			concurrency: PipedDeploymentConcurrency{},
+			wantErr:     false,
+		},
+		{
+			name: "negative max deployments",
+			concurrency: PipedDeploymentConcurrency{
+				MaxDeployments: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing cloud provider name",
+			concurrency: PipedDeploymentConcurrency{
+				CloudProviders: []CloudProviderDeploymentConcurrency{
+					{
+						MaxDeployments: 1,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicated cloud provider",
+			concurrency: PipedDeploymentConcurrency{
+				CloudProviders: []CloudProviderDeploymentConcurrency{
+					{
+						Name:           "kubernetes-default",
+						MaxDeployments: 1,
+					},
+					{
+						Name:           "kubernetes-default",
+						MaxDeployments: 2,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing label selector",
+			concurrency: PipedDeploymentConcurrency{
+				Labels: []LabelDeploymentConcurrency{
+					{
+						MaxDeployments: 1,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero max deployments for label selector",
+			concurrency: PipedDeploymentConcurrency{
+				Labels: []LabelDeploymentConcurrency{
+					{
+						Selector: map[string]string{"team": "payment"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid",
+			concurrency: PipedDeploymentConcurrency{
+				MaxDeployments: 10,
+				CloudProviders: []CloudProviderDeploymentConcurrency{
+					{
+						Name:           "kubernetes-default",
+						MaxDeployments: 3,
+					},
+				},
+				Labels: []LabelDeploymentConcurrency{
+					{
+						Selector:       map[string]string{"team": "payment"},
+						MaxDeployments: 2,
+					},
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.concurrency.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestPipedScriptRunValidate(t *testing.T) {
+	testcases := []struct {
+		name      string
+		scriptRun PipedScriptRun
+		wantErr   bool
+	}{
+		{
+			name:      "empty",
+			scriptRun: PipedScriptRun{},
+			wantErr:   false,
+		},
+		{
+			name: "valid",
+			scriptRun: PipedScriptRun{
+				Enabled:    true,
+				AllowedEnv: []string{"KUBECONFIG", "AWS_PROFILE"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty name",
+			scriptRun: PipedScriptRun{
+				AllowedEnv: []string{""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "name with value",
+			scriptRun: PipedScriptRun{
+				AllowedEnv: []string{"KUBECONFIG=/etc/kubeconfig"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.scriptRun.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/creasty/defaults"
+)
+
+var templateArgPattern = regexp.MustCompile(`{{\s*\.Args\.([A-Za-z0-9_]+)\s*}}`)
+
+type PipelineTemplateSpec struct {
+	// Map from template name to its definition.
+	Templates map[string]PipelineTemplate `json:"templates"`
+}
+
+// PipelineTemplate represents a reusable list of pipeline stages.
+// The stages can contain placeholders like "{{ .Args.canaryReplicas }}"
+// that will be rendered with the arguments given by each application.
+type PipelineTemplate struct {
+	// The default values of the arguments.
+	Args map[string]string `json:"args"`
+	// The stages to be used as the pipeline of the applications using this template.
+	Stages []json.RawMessage `json:"stages"`
+}
+
+func (s *PipelineTemplateSpec) Validate() error {
+	for name, t := range s.Templates {
+		if len(t.Stages) == 0 {
+			return fmt.Errorf("pipeline template %s must have at least one stage", name)
+		}
+	}
+	return nil
+}
+
+// LoadPipelineTemplate finds all config files for the pipeline templates in the .pipe
+// directory and returns the merged config, ErrNotFound is returned if not found.
+func LoadPipelineTemplate(repoRoot string) (*PipelineTemplateSpec, error) {
+	dir := filepath.Join(repoRoot, SharedConfigurationDirName)
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var spec *PipelineTemplateSpec
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		if ext := filepath.Ext(f.Name()); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		path := filepath.Join(dir, f.Name())
+		cfg, err := LoadFromYAML(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", path, err)
+		}
+		if cfg.Kind != KindPipelineTemplate {
+			continue
+		}
+		if spec == nil {
+			spec = &PipelineTemplateSpec{
+				Templates: make(map[string]PipelineTemplate),
+			}
+		}
+		for name, t := range cfg.PipelineTemplateSpec.Templates {
+			if _, ok := spec.Templates[name]; ok {
+				return nil, fmt.Errorf("pipeline template %s is defined more than once", name)
+			}
+			spec.Templates[name] = t
+		}
+	}
+	if spec == nil {
+		return nil, ErrNotFound
+	}
+	return spec, nil
+}
+
+// Render builds the pipeline stages by replacing the placeholders in the template with the given arguments.
+// The default values defined in the template are used for the arguments not given.
+// Only the placeholders referring to the arguments are replaced, others like "{{ .App.Name }}"
+// used by the ANALYSIS stage are kept as they are.
+// When the whole value is a single placeholder and it is replaced by a number or a boolean,
+// that value is used as is instead of a string.
+func (t PipelineTemplate) Render(args map[string]string) ([]PipelineStage, error) {
+	values := make(map[string]string, len(t.Args)+len(args))
+	for k, v := range t.Args {
+		values[k] = v
+	}
+	for k, v := range args {
+		values[k] = v
+	}
+
+	stages := make([]PipelineStage, 0, len(t.Stages))
+	for i, raw := range t.Stages {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("invalid stage at index %d: %w", i, err)
+		}
+		rendered, err := renderTemplateValue(v, values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render stage at index %d: %w", i, err)
+		}
+		js, err := json.Marshal(rendered)
+		if err != nil {
+			return nil, err
+		}
+
+		var stage PipelineStage
+		if err := json.Unmarshal(js, &stage); err != nil {
+			return nil, fmt.Errorf("invalid stage at index %d: %w", i, err)
+		}
+		if err := defaults.Set(&stage); err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+func renderTemplateValue(v interface{}, args map[string]string) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			r, err := renderTemplateValue(e, args)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			r, err := renderTemplateValue(e, args)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, r)
+		}
+		return out, nil
+
+	case string:
+		var missing []string
+		rendered := templateArgPattern.ReplaceAllStringFunc(v, func(m string) string {
+			name := templateArgPattern.FindStringSubmatch(m)[1]
+			value, ok := args[name]
+			if !ok {
+				missing = append(missing, name)
+			}
+			return value
+		})
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("missing value for argument %s", strings.Join(missing, ", "))
+		}
+
+		if m := templateArgPattern.FindString(v); m != "" && m == strings.TrimSpace(v) {
+			var typed interface{}
+			if err := json.Unmarshal([]byte(rendered), &typed); err == nil {
+				switch typed.(type) {
+				case float64, bool:
+					return typed, nil
+				}
+			}
+		}
+		return rendered, nil
+
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2022 The PipeCD Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pipe-cd/pipecd/pkg/model"
+)
+
+func TestLoadPipelineTemplate(t *testing.T) {
+	spec, err := LoadPipelineTemplate("testdata")
+	require.NoError(t, err)
+	require.Len(t, spec.Templates, 1)
+
+	tpl, ok := spec.Templates["k8s-canary-with-analysis"]
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{
+		"canaryReplicas":   "10%",
+		"analysisDuration": "10m",
+		"failureLimit":     "1",
+	}, tpl.Args)
+	assert.Len(t, tpl.Stages, 4)
+
+	_, err = LoadPipelineTemplate("not-found")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestPipelineTemplateRender(t *testing.T) {
+	testcases := []struct {
+		name     string
+		template PipelineTemplate
+		args     map[string]string
+		expected []PipelineStage
+		wantErr  bool
+	}{
+		{
+			name: "use default values",
+			template: PipelineTemplate{
+				Args: map[string]string{
+					"replicas": "3",
+				},
+				Stages: []json.RawMessage{
+					[]byte(`{"name": "K8S_CANARY_ROLLOUT", "with": {"replicas": "{{ .Args.replicas }}"}}`),
+				},
+			},
+			expected: []PipelineStage{
+				{
+					Name: model.StageK8sCanaryRollout,
+					K8sCanaryRolloutStageOptions: &K8sCanaryRolloutStageOptions{
+						Replicas: Replicas{Number: 3},
+					},
+				},
+			},
+		},
+		{
+			name: "given values take precedence over default values",
+			template: PipelineTemplate{
+				Args: map[string]string{
+					"replicas": "3",
+				},
+				Stages: []json.RawMessage{
+					[]byte(`{"name": "K8S_CANARY_ROLLOUT", "with": {"replicas": "{{ .Args.replicas }}", "createService": "{{.Args.createService}}", "suffix": "{{ .Args.suffix }}-canary"}}`),
+				},
+			},
+			args: map[string]string{
+				"replicas":      "20%",
+				"createService": "true",
+				"suffix":        "v2",
+			},
+			expected: []PipelineStage{
+				{
+					Name: model.StageK8sCanaryRollout,
+					K8sCanaryRolloutStageOptions: &K8sCanaryRolloutStageOptions{
+						Replicas:      Replicas{Number: 20, IsPercentage: true},
+						CreateService: true,
+						Suffix:        "v2-canary",
+					},
+				},
+			},
+		},
+		{
+			name: "keep placeholders not referring to arguments",
+			template: PipelineTemplate{
+				Stages: []json.RawMessage{
+					[]byte(`{"name": "SCRIPT_RUN", "with": {"run": "echo {{ .App.Name }}"}}`),
+				},
+			},
+			expected: []PipelineStage{
+				{
+					Name: model.StageScriptRun,
+					ScriptRunStageOptions: &ScriptRunStageOptions{
+						Run: "echo {{ .App.Name }}",
+					},
+				},
+			},
+		},
+		{
+			name: "missing argument",
+			template: PipelineTemplate{
+				Stages: []json.RawMessage{
+					[]byte(`{"name": "K8S_CANARY_ROLLOUT", "with": {"replicas": "{{ .Args.replicas }}"}}`),
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			stages, err := tc.template.Render(tc.args)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.expected, stages)
+		})
+	}
+}
+
+func TestDeploymentPipelineExpandTemplate(t *testing.T) {
+	cfg, err := LoadFromYAML("testdata/application/k8s-app-use-pipeline-template.yaml")
+	require.NoError(t, err)
+
+	p := cfg.KubernetesApplicationSpec.Pipeline
+	require.NoError(t, p.ExpandTemplate("testdata"))
+	require.Len(t, p.Stages, 4)
+
+	assert.Equal(t, model.StageK8sCanaryRollout, p.Stages[0].Name)
+	assert.Equal(t, Replicas{Number: 20, IsPercentage: true}, p.Stages[0].K8sCanaryRolloutStageOptions.Replicas)
+
+	assert.Equal(t, model.StageAnalysis, p.Stages[1].Name)
+	assert.Equal(t, Duration(10*time.Minute), p.Stages[1].AnalysisStageOptions.Duration)
+	require.Len(t, p.Stages[1].AnalysisStageOptions.Metrics, 1)
+	assert.Equal(t, 2, p.Stages[1].AnalysisStageOptions.Metrics[0].FailureLimit)
+	assert.Equal(t, `http_error_rate{app="{{ .App.Name }}", variant="canary"}`, p.Stages[1].AnalysisStageOptions.Metrics[0].Query)
+
+	assert.Equal(t, model.StageK8sPrimaryRollout, p.Stages[2].Name)
+	assert.Equal(t, model.StageK8sCanaryClean, p.Stages[3].Name)
+
+	p = &DeploymentPipeline{UseTemplate: "not-found"}
+	assert.Error(t, p.ExpandTemplate("testdata"))
+}
+
+func TestDeploymentPipelineValidateTemplate(t *testing.T) {
+	testcases := []struct {
+		name     string
+		pipeline DeploymentPipeline
+		wantErr  bool
+	}{
+		{
+			name: "use template",
+			pipeline: DeploymentPipeline{
+				UseTemplate:  "foo",
+				TemplateArgs: map[string]string{"foo": "bar"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "both template and stages are specified",
+			pipeline: DeploymentPipeline{
+				UseTemplate: "foo",
+				Stages: []PipelineStage{
+					{Name: model.StageWait},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "template args without template",
+			pipeline: DeploymentPipeline{
+				TemplateArgs: map[string]string{"foo": "bar"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.pipeline.Validate()
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
@@ -37,6 +37,7 @@ var (
 			d.RunningCommitHash = runningCommitHash
 			d.Version = version
 			d.Stages = stages
+			d.QueuePosition = 0
 			return nil
 		}
 	}
@@ -45,6 +46,18 @@ var (
 		return func(d *model.Deployment) error {
 			d.Status = status
 			d.StatusReason = statusReason
+			d.QueuePosition = 0
+			return nil
+		}
+	}
+
+	DeploymentToQueuedUpdater = func(statusReason string, queuePosition uint32) func(*model.Deployment) error {
+		return func(d *model.Deployment) error {
+			if d.Status != model.DeploymentStatus_DEPLOYMENT_PENDING {
+				return fmt.Errorf("deployment status %s can not be queued: %w", d.Status, ErrInvalidArgument)
+			}
+			d.StatusReason = statusReason
+			d.QueuePosition = queuePosition
 			return nil
 		}
 	}
@@ -58,6 +71,7 @@ var (
 			d.Status = status
 			d.StatusReason = statusReason
 			d.CompletedAt = completedAt
+			d.QueuePosition = 0
 			for i := range d.Stages {
 				stageID := d.Stages[i].Id
 				if status, ok := statuses[stageID]; ok {
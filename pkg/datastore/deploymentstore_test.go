@@ -93,6 +93,57 @@ func TestDeploymentStatusUpdater(t *testing.T) {
 	assert.Equal(t, expectedStatusDesc, d.StatusReason)
 }
 
+func TestDeploymentToQueuedUpdater(t *testing.T) {
+	testcases := []struct {
+		name          string
+		deployment    model.Deployment
+		statusReason  string
+		queuePosition uint32
+
+		expectedDeployment model.Deployment
+		expectedErr        error
+	}{
+		{
+			name: "not pending deployment",
+			deployment: model.Deployment{
+				Id:     "deployment-id",
+				Status: model.DeploymentStatus_DEPLOYMENT_RUNNING,
+			},
+			statusReason:  "queued",
+			queuePosition: 1,
+			expectedDeployment: model.Deployment{
+				Id:     "deployment-id",
+				Status: model.DeploymentStatus_DEPLOYMENT_RUNNING,
+			},
+			expectedErr: ErrInvalidArgument,
+		},
+		{
+			name: "pending deployment",
+			deployment: model.Deployment{
+				Id:     "deployment-id",
+				Status: model.DeploymentStatus_DEPLOYMENT_PENDING,
+			},
+			statusReason:  "queued",
+			queuePosition: 2,
+			expectedDeployment: model.Deployment{
+				Id:            "deployment-id",
+				Status:        model.DeploymentStatus_DEPLOYMENT_PENDING,
+				StatusReason:  "queued",
+				QueuePosition: 2,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			updater := DeploymentToQueuedUpdater(tc.statusReason, tc.queuePosition)
+			err := updater(&tc.deployment)
+			assert.True(t, errors.Is(err, tc.expectedErr))
+			assert.Equal(t, tc.expectedDeployment, tc.deployment)
+		})
+	}
+}
+
 func TestDeploymentToCompletedUpdater(t *testing.T) {
 	now := time.Now()
 	testcases := []struct {
@@ -35,6 +35,7 @@ type ProjectStore interface {
 	DisableStaticAdmin(ctx context.Context, id string) error
 	UpdateProjectSSOConfig(ctx context.Context, id string, sso *model.ProjectSSOConfig) error
 	UpdateProjectRBACConfig(ctx context.Context, id string, sso *model.ProjectRBACConfig) error
+	UpdateProjectDeployWindows(ctx context.Context, id string, windows *model.ProjectDeployWindows) error
 	GetProject(ctx context.Context, id string) (*model.Project, error)
 	ListProjects(ctx context.Context, opts ListOptions) ([]model.Project, error)
 }
@@ -124,6 +125,14 @@ func (s *projectStore) UpdateProjectRBACConfig(ctx context.Context, id string, r
 	})
 }
 
+// UpdateProjectDeployWindows updates the deploy windows applied to all applications of the project.
+func (s *projectStore) UpdateProjectDeployWindows(ctx context.Context, id string, windows *model.ProjectDeployWindows) error {
+	return s.UpdateProject(ctx, id, func(p *model.Project) error {
+		p.DeployWindows = windows
+		return nil
+	})
+}
+
 func (s *projectStore) GetProject(ctx context.Context, id string) (*model.Project, error) {
 	var entity model.Project
 	if err := s.ds.Get(ctx, ProjectModelKind, id, &entity); err != nil {
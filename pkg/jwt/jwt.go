@@ -36,6 +36,7 @@ type Claims struct {
 	jwtgo.StandardClaims
 	AvatarURL string     `json:"avatarUrl,omitempty"`
 	Role      model.Role `json:"role,omitempty"`
+	Teams     []string   `json:"teams,omitempty"`
 }
 
 // NewClaims creates a new claims for a given github user.
@@ -75,8 +75,47 @@ func (s *ApplicationLiveStateSnapshot) DetermineAppHealthStatus() {
 			}
 		}
 		s.HealthStatus = status
+	case ApplicationKind_CLOUDRUN:
+		c := s.Cloudrun
+		if c == nil {
+			return
+		}
+		status := ApplicationLiveStateSnapshot_HEALTHY
+		for _, r := range c.Resources {
+			if r.HealthStatus == CloudRunResourceState_OTHER {
+				status = ApplicationLiveStateSnapshot_OTHER
+				break
+			}
+		}
+		s.HealthStatus = status
+	case ApplicationKind_LAMBDA:
+		l := s.Lambda
+		if l == nil {
+			return
+		}
+		status := ApplicationLiveStateSnapshot_HEALTHY
+		for _, r := range l.Resources {
+			if r.HealthStatus == LambdaResourceState_OTHER {
+				status = ApplicationLiveStateSnapshot_OTHER
+				break
+			}
+		}
+		s.HealthStatus = status
+	case ApplicationKind_ECS:
+		e := s.Ecs
+		if e == nil {
+			return
+		}
+		status := ApplicationLiveStateSnapshot_HEALTHY
+		for _, r := range e.Resources {
+			if r.HealthStatus == ECSResourceState_OTHER {
+				status = ApplicationLiveStateSnapshot_OTHER
+				break
+			}
+		}
+		s.HealthStatus = status
 	default:
-		// TODO: Determine health state of other than k8s app
+		// TODO: Determine health state of terraform app
 		return
 	}
 }
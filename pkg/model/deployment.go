@@ -22,6 +22,12 @@ import (
 
 const (
 	MetadataKeyDeploymentNotification = "DeploymentNotification"
+	MetadataKeyDeployWindows          = "DeployWindows"
+	MetadataKeyDeployWindowOverride   = "DeployWindowOverride"
+
+	// MetadataKeyStageSkippable is the key of stage metadata
+	// telling that the stage can be skipped by a SKIP_STAGE command.
+	MetadataKeyStageSkippable = "Skippable"
 )
 
 var notCompletedDeploymentStatuses = []DeploymentStatus{
@@ -66,6 +72,8 @@ func IsCompletedStage(status StageStatus) bool {
 		return true
 	case StageStatus_STAGE_CANCELLED:
 		return true
+	case StageStatus_STAGE_SKIPPED:
+		return true
 	}
 	return false
 }
@@ -109,6 +117,9 @@ func CanUpdateStageStatus(cur, next StageStatus) bool {
 		return cur <= StageStatus_STAGE_RUNNING
 	case StageStatus_STAGE_CANCELLED:
 		return cur <= StageStatus_STAGE_RUNNING
+	case StageStatus_STAGE_SKIPPED:
+		// A failed stage can be skipped by a SKIP_STAGE command.
+		return cur <= StageStatus_STAGE_RUNNING || cur == StageStatus_STAGE_FAILURE
 	}
 	return false
 }
@@ -69,3 +69,49 @@ func TestDeployment_ContainTags(t *testing.T) {
 		})
 	}
 }
+
+func TestCanUpdateStageStatus(t *testing.T) {
+	testcases := []struct {
+		name string
+		cur  StageStatus
+		next StageStatus
+		want bool
+	}{
+		{
+			name: "running stage can be succeeded",
+			cur:  StageStatus_STAGE_RUNNING,
+			next: StageStatus_STAGE_SUCCESS,
+			want: true,
+		},
+		{
+			name: "succeeded stage can not be failed",
+			cur:  StageStatus_STAGE_SUCCESS,
+			next: StageStatus_STAGE_FAILURE,
+			want: false,
+		},
+		{
+			name: "failed stage can be skipped",
+			cur:  StageStatus_STAGE_FAILURE,
+			next: StageStatus_STAGE_SKIPPED,
+			want: true,
+		},
+		{
+			name: "cancelled stage can not be skipped",
+			cur:  StageStatus_STAGE_CANCELLED,
+			next: StageStatus_STAGE_SKIPPED,
+			want: false,
+		},
+		{
+			name: "skipped stage can not be running again",
+			cur:  StageStatus_STAGE_SKIPPED,
+			next: StageStatus_STAGE_RUNNING,
+			want: false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := CanUpdateStageStatus(tc.cur, tc.next)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
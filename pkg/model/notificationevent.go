@@ -58,6 +58,14 @@ func (e *NotificationEventDeploymentFailed) GetAppName() string {
 	return e.Deployment.ApplicationName
 }
 
+func (e *NotificationEventDeploymentBlocked) GetAppName() string {
+	return e.Deployment.ApplicationName
+}
+
+func (e *NotificationEventDeploymentDeployWindowOverridden) GetAppName() string {
+	return e.Deployment.ApplicationName
+}
+
 func (e *NotificationEventApplicationSynced) GetAppName() string {
 	return e.Application.Name
 }
@@ -27,6 +27,9 @@ const (
 	// StageAnalysis represents the waiting state for analysing
 	// the application status based on metrics, log, http request...
 	StageAnalysis Stage = "ANALYSIS"
+	// StageScriptRun represents the state where
+	// the specified script has been executed on the piped host.
+	StageScriptRun Stage = "SCRIPT_RUN"
 
 	// StageK8sSync represents the state where
 	// all resources should be synced with the Git state.
@@ -49,6 +52,9 @@ const (
 	// StageK8sTrafficRouting represents the state where the traffic to application
 	// should be splitted as the specified percentage to PRIMARY, CANARY, BASELINE variants.
 	StageK8sTrafficRouting Stage = "K8S_TRAFFIC_ROUTING"
+	// StageK8sJob represents the state where
+	// a Kubernetes Job has been run to completion and then removed.
+	StageK8sJob Stage = "K8S_JOB"
 
 	// StageTerraformSync synced infrastructure with all the tf defined in Git.
 	// Firstly, it does plan and if there are any changes detected it applies those changes automatically.
@@ -120,9 +120,24 @@ func (c *OAuthClient) GetUser(ctx context.Context) (*model.User, error) {
 			ProjectId:   c.project.Id,
 			ProjectRole: role,
 		},
+		Teams: teamNames(teams),
 	}, nil
 }
 
+// teamNames returns the names of the given teams in the format of "org/team-slug".
+func teamNames(teams []*github.Team) []string {
+	names := make([]string, 0, len(teams))
+	for _, team := range teams {
+		slug := team.GetSlug()
+		org := team.Organization.GetLogin()
+		if org == "" || slug == "" {
+			continue
+		}
+		names = append(names, fmt.Sprintf("%s/%s", org, slug))
+	}
+	return names
+}
+
 func (c *OAuthClient) decideRole(user string, teams []*github.Team) (role model.Role_ProjectRole, err error) {
 	var found bool
 
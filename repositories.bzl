@@ -82,6 +82,13 @@ def go_repositories():
         version = "v1.0.0",
     )
 
+    go_repository(
+        name = "com_github_aws_aws_sdk_go_v2_service_cloudwatch",
+        build_file_proto_mode = "disable",
+        importpath = "github.com/aws/aws-sdk-go-v2/service/cloudwatch",
+        sum = "h1:Mt2+LnGKQQyncULtRrx+oJkIwnrfy5XKb96Rvsml30U=",
+        version = "v1.4.1",
+    )
     go_repository(
         name = "com_github_aws_aws_sdk_go_v2_service_ecs",
         build_file_proto_mode = "disable",